@@ -7,7 +7,7 @@ version_gt() {
 scriptpath="$(cd "$(dirname "$0")"; pwd -P)"
 go=$(command -v go)
 
-command -v go >/dev/null 2>&1 || { echo >&2 "Go 1.8+ needs to be available for compilation."; exit 1; }
+command -v go >/dev/null 2>&1 || { echo >&2 "Go 1.24+ needs to be available for compilation."; exit 1; }
 command -v git >/dev/null 2>&1 || { echo >&2 "Git needs to be available for compilation."; exit 1; }
 
 bos=$($go run $scriptpath/build/build.go -o)
@@ -29,6 +29,7 @@ for arg in "$@"; do
             echo -e "-a|--arch\033[3m[=]target_arch\033[0m\t\t\t\tSelect target architecture (amd64, arm)"
             echo -e "-o|--os\033[3m[=]target_os\033[0m\t\t\t\tSelect the target operating system (linux, darwin, windows, freebsd)"
             echo -e "-v|--verbose\t\t\t\tEnable verbose compilation mode"
+            echo -e "--fips\t\t\t\tEnforce the fips crypto policy and build with the Go FIPS 140-3 module"
             echo -e "--ogo\033[3m[=]path_to go_binary\033[0m\t\t\t\tSelect a different Go binary for compilation"
             exit 0
             ;;
@@ -38,6 +39,10 @@ for arg in "$@"; do
         --verbose|-v )
             cmd="-v $cmd"
             ;;
+        --fips )
+            cmd="-tags fips $cmd"
+            export GOFIPS140=latest
+            ;;
         --arch=*|-a=* )
             arch=`echo $arg | sed 's/[-a-zA-Z0-9]*=//'`
             ;;
@@ -68,8 +73,8 @@ done
 
 bversion=$($go run $scriptpath/build/build.go -v)
 
-if version_gt "1.8.0" ${bversion}; then
-    echo "Go version 1.8 or later is required. Found Go version: $bversion"
+if version_gt "1.24.0" ${bversion}; then
+    echo "Go version 1.24 or later is required. Found Go version: $bversion"
     exit 1
 fi
 
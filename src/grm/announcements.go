@@ -0,0 +1,165 @@
+package main
+
+import (
+	"github.com/google/go-github/github"
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"sort"
+	"strings"
+	"time"
+	"grm/config"
+	"grm/i18n"
+)
+
+// Discussion category searched without announcement-category property
+const defaultAnnouncementCategory = "Announcements"
+
+// Kinds of announcements
+const (
+	announcementDiscussion  = "discussion"
+	announcementPinnedIssue = "pinned-issue"
+)
+
+// Discussions and pinned issues are only available with the GraphQL API
+const announcementsQuery = `query($owner: String!, $name: String!) {
+  repository(owner: $owner, name: $name) {
+    discussions(first: 25, orderBy: {field: CREATED_AT, direction: DESC}) {
+      nodes { title url createdAt category { name } }
+    }
+    pinnedIssues(first: 3) {
+      nodes { issue { title url createdAt } }
+    }
+  }
+}`
+
+type announcementPost struct {
+	Title     string    `json:"title"`
+	Url       string    `json:"url"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+type announcementsResponse struct {
+	Data struct {
+		Repository struct {
+			Discussions struct {
+				Nodes []struct {
+					announcementPost
+					Category struct {
+						Name string `json:"name"`
+					} `json:"category"`
+				} `json:"nodes"`
+			} `json:"discussions"`
+			PinnedIssues struct {
+				Nodes []struct {
+					Issue announcementPost `json:"issue"`
+				} `json:"nodes"`
+			} `json:"pinnedIssues"`
+		} `json:"repository"`
+	} `json:"data"`
+	Errors []struct {
+		Message string `json:"message"`
+	} `json:"errors"`
+}
+
+// announcement is a post of the announcement Discussions category or a pinned issue, some projects
+// announce releases there before tagging them
+type announcement struct {
+	repository string
+	kind       string
+	title      string
+	url        string
+	created    time.Time
+}
+
+func (a announcement) message(l *i18n.Locale) string {
+	if a.kind == announcementPinnedIssue {
+		return l.T("pinned issue \"%s\" (%s): %s", a.title, l.Date(a.created), a.url)
+	}
+	return l.T("discussion \"%s\" (%s): %s", a.title, l.Date(a.created), a.url)
+}
+
+// detectAnnouncements searches the repositories with the announcement-pattern property for
+// announcement discussions and pinned issues created since the report's start date whose title
+// matches the pattern
+func detectAnnouncements(ctx context.Context, name, account string, repositories []*github.Repository, since time.Time, client *github.Client) []announcement {
+	announcements := make([]announcement, 0)
+	for _, repo := range repositories {
+		value, ok := namedSectionGet(name, config.Remote, config.AnnouncementPattern, repo.GetName())
+		if !ok || value == "" {
+			continue
+		}
+		pattern, err := compileNamePattern(name, value)
+		if err != nil {
+			log.Fatal(fmt.Sprintf("Cannot compile %s regex: %s: ", config.AnnouncementPattern.Name(), value), err)
+		}
+		category := defaultAnnouncementCategory
+		if c, ok := namedSectionGet(name, config.Remote, config.AnnouncementCategory, repo.GetName()); ok && c != "" {
+			category = c
+		}
+
+		found := readAnnouncements(ctx, account, repo.GetName(), category, client)
+		for _, a := range found {
+			if a.created.After(since) && pattern.MatchString(a.title) {
+				announcements = append(announcements, a)
+			}
+		}
+	}
+	sort.SliceStable(announcements, func(i, j int) bool {
+		return announcements[i].created.Before(announcements[j].created)
+	})
+	return announcements
+}
+
+// readAnnouncements returns the recent discussions of the category, matched case-insensitive anywhere
+// in the category name to allow for emojis, and the pinned issues of the repository
+func readAnnouncements(ctx context.Context, account, repository, category string, client *github.Client) []announcement {
+	body := map[string]interface{}{
+		"query":     announcementsQuery,
+		"variables": map[string]string{"owner": account, "name": repository},
+	}
+	for {
+		request, err := client.NewRequest("POST", "graphql", body)
+		if err != nil {
+			log.Fatal(fmt.Sprintf("Could not create announcements request for repository %s: ", repository), err)
+		}
+		result := announcementsResponse{}
+		response, err := client.Do(ctx, request, &result)
+		if rateLimit(response) {
+			continue
+		}
+
+		if err != nil {
+			log.Fatal(fmt.Sprintf("Could not retrieve announcements for repository %s: ", repository), err)
+		}
+		if len(result.Errors) > 0 {
+			log.Fatal(fmt.Sprintf("Could not retrieve announcements for repository %s: %s", repository, result.Errors[0].Message))
+		}
+
+		announcements := make([]announcement, 0)
+		for _, discussion := range result.Data.Repository.Discussions.Nodes {
+			if strings.Contains(strings.ToLower(discussion.Category.Name), strings.ToLower(category)) {
+				announcements = append(announcements, announcement{repository, announcementDiscussion,
+					discussion.Title, discussion.Url, discussion.CreatedAt})
+			}
+		}
+		for _, pinned := range result.Data.Repository.PinnedIssues.Nodes {
+			announcements = append(announcements, announcement{repository, announcementPinnedIssue,
+				pinned.Issue.Title, pinned.Issue.Url, pinned.Issue.CreatedAt})
+		}
+		return announcements
+	}
+}
+
+func printAnnouncements(writer io.Writer, announcements []announcement) {
+	if len(announcements) == 0 {
+		return
+	}
+
+	fmt.Fprintln(writer, locale.T("Announcements:"))
+	for _, a := range announcements {
+		fmt.Fprintln(writer, fmt.Sprintf(" * %s: %s", a.repository, a.message(locale)))
+	}
+	fmt.Fprintln(writer, "")
+}
@@ -0,0 +1,382 @@
+package main
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+	"grm/config"
+	"grm/state"
+)
+
+// acknowledgement marks a release as handled, e.g. by an internal deployment tool
+type acknowledgement struct {
+	Acknowledged time.Time `json:"acknowledged"`
+	Comment      string    `json:"comment,omitempty"`
+	// By is the API user who acknowledged the release
+	By string `json:"by,omitempty"`
+}
+
+func acknowledgementKey(name, repository, tag string) string {
+	return state.Key("ack", name, repository, tag)
+}
+
+func readAcknowledgement(name, repository, tag string) (acknowledgement, bool) {
+	ack := acknowledgement{}
+	ok := openState().Get(acknowledgementKey(name, repository, tag), &ack)
+	return ack, ok
+}
+
+// Roles of API users: viewers only read, admins also trigger refreshes, acknowledge releases and
+// change remote definitions
+const (
+	roleViewer = "viewer"
+	roleAdmin  = "admin"
+)
+
+// apiUser is a token of the REST API with its role
+type apiUser struct {
+	name  string
+	token string
+	role  string
+}
+
+type apiUserContextKey struct{}
+
+// readApiToken reads the admin token of the REST API from GRM_API_TOKEN or the global api-token
+// property
+func readApiToken() string {
+	if token := os.Getenv("GRM_API_TOKEN"); token != "" {
+		return token
+	}
+	token, _ := sectionGet(config.Core, config.ApiToken, "")
+	return token
+}
+
+// readApiUsers reads the admin token and the tokens of the ApiUser sections, without any token the
+// API is disabled
+func readApiUsers() ([]apiUser, error) {
+	users := make([]apiUser, 0)
+	if token := readApiToken(); token != "" {
+		users = append(users, apiUser{"admin", token, roleAdmin})
+	}
+
+	sections := configuration.NamedSections(config.ApiUser)
+	sort.Strings(sections)
+	for _, section := range sections {
+		name := config.ExtractSpecifier(section)
+		token, _ := namedSectionGet(name, config.ApiUser, config.ApiToken, "")
+		if token == "" {
+			return nil, fmt.Errorf("no %s defined for the API user %s", config.ApiToken.Name(), name)
+		}
+		role, _ := namedSectionGet(name, config.ApiUser, config.Role, "")
+		switch role {
+		case "":
+			role = roleViewer
+		case roleViewer, roleAdmin:
+		default:
+			return nil, fmt.Errorf("unknown role '%s' of the API user %s, supported roles: %s, %s", role, name, roleViewer, roleAdmin)
+		}
+		users = append(users, apiUser{name, token, role})
+	}
+	return users, nil
+}
+
+type apiRemote struct {
+	Name         string    `json:"name"`
+	Account      string    `json:"account"`
+	Repositories int       `json:"repositories"`
+	Updated      time.Time `json:"updated"`
+}
+
+type apiError struct {
+	Error string `json:"error"`
+}
+
+// authenticate returns the API user of the bearer token of the request, nil without a valid token.
+// Without any API user the API is disabled.
+func (s *releaseServer) authenticate(request *http.Request) (user *apiUser, enabled bool) {
+	s.mutex.RLock()
+	users := s.apiUsers
+	s.mutex.RUnlock()
+
+	token := strings.TrimPrefix(request.Header.Get("Authorization"), "Bearer ")
+	// All tokens are compared, the time doesn't tell which one matched
+	for i := range users {
+		if subtle.ConstantTimeCompare([]byte(token), []byte(users[i].token)) == 1 && user == nil {
+			user = &users[i]
+		}
+	}
+	return user, len(users) > 0
+}
+
+// authorize requires the token of an API user as bearer token, viewers may only read
+func (s *releaseServer) authorize(handler http.Handler) http.Handler {
+	return http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		user, enabled := s.authenticate(request)
+		if !enabled {
+			writeApi(writer, http.StatusNotFound, apiError{"API disabled, no API token configured"})
+			return
+		}
+		if user == nil {
+			writer.Header().Set("WWW-Authenticate", "Bearer")
+			writeApi(writer, http.StatusUnauthorized, apiError{"invalid or missing API token"})
+			return
+		}
+		if user.role != roleAdmin && request.Method != http.MethodGet {
+			writeApi(writer, http.StatusForbidden, apiError{"the API user is a viewer, only admins may change anything"})
+			return
+		}
+		handler.ServeHTTP(writer, request.WithContext(context.WithValue(request.Context(), apiUserContextKey{}, user)))
+	})
+}
+
+// requestUser returns the API user of an authorized request
+func requestUser(request *http.Request) *apiUser {
+	return contextUser(request.Context())
+}
+
+func contextUser(ctx context.Context) *apiUser {
+	user, _ := ctx.Value(apiUserContextKey{}).(*apiUser)
+	return user
+}
+
+// handleApi serves the REST API:
+//
+//	GET    /api/remotes
+//	PATCH  /api/remotes/<name>
+//	POST   /api/refresh
+//	GET    /api/releases/<account>/<repository>
+//	POST   /api/releases/<account>/<repository>/<tag>/ack
+//	DELETE /api/releases/<account>/<repository>/<tag>/ack
+func (s *releaseServer) handleApi(writer http.ResponseWriter, request *http.Request) {
+	tokens := strings.Split(strings.Trim(strings.TrimPrefix(request.URL.Path, "/api/"), "/"), "/")
+	switch {
+	case len(tokens) == 1 && tokens[0] == "remotes" && request.Method == http.MethodGet:
+		s.handleRemotes(writer)
+	case len(tokens) == 2 && tokens[0] == "remotes" && request.Method == http.MethodPatch:
+		s.handleRemoteChanges(writer, request, tokens[1])
+	case len(tokens) == 1 && tokens[0] == "refresh" && request.Method == http.MethodPost:
+		s.handleRefresh(writer)
+	case len(tokens) == 3 && tokens[0] == "releases" && request.Method == http.MethodGet:
+		s.handleReleases(writer, tokens[1], tokens[2])
+	case len(tokens) == 5 && tokens[0] == "releases" && tokens[4] == "ack":
+		s.handleAcknowledge(writer, request, tokens[1], tokens[2], tokens[3])
+	default:
+		writeApi(writer, http.StatusNotFound, apiError{"unknown API endpoint"})
+	}
+}
+
+func (s *releaseServer) handleRemotes(writer http.ResponseWriter) {
+	writeApi(writer, http.StatusOK, s.remotes())
+}
+
+// remotes lists the remote definitions with a report
+func (s *releaseServer) remotes() []apiRemote {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	remotes := make([]apiRemote, 0, len(s.names))
+	for _, name := range s.names {
+		if report, ok := s.reports[name]; ok {
+			remotes = append(remotes, apiRemote{name, report.account, len(report.repositories), locale.Time(s.updated)})
+		}
+	}
+	return remotes
+}
+
+// Properties of remote definitions the API may change. They only select and classify releases, properties
+// running commands, reading files or fetching URLs on the server, credentials and sinks are left to the
+// configuration file.
+var apiRemoteKeys = map[config.Key]bool{
+	config.RepositoryPattern:     true,
+	config.PatternFlags:          true,
+	config.Query:                 true,
+	config.Team:                  true,
+	config.ReleasePattern:        true,
+	config.MilestonePattern:      true,
+	config.RepositoryBlacklisted: true,
+	config.StaleAfter:            true,
+	config.Highlight:             true,
+	config.HighlightEscalate:     true,
+	config.Owner:                 true,
+	config.PinnedVersion:         true,
+	config.MaintainerAlerts:      true,
+	config.Subprojects:           true,
+	config.BetaPattern:           true,
+	config.NightlyPattern:        true,
+	config.AnnouncementPattern:   true,
+	config.AnnouncementCategory:  true,
+	config.ScrapePattern:         true,
+	config.ScrapeSelector:        true,
+}
+
+// Specifiers of repository overrides set through the API: repository names or globs, optionally with
+// a sub-project
+var apiSpecifierPattern = regexp.MustCompile(`^[A-Za-z0-9._*?\[\]-]+(/[A-Za-z0-9._*?\[\]-]+)?$`)
+
+// handleRemoteChanges sets the properties of a remote definition given as JSON object, an empty value
+// removes the property. With ?repository= the properties are set as repository override. Credentials
+// can't be changed through the API, neither can values reference environment variables, files or
+// secret stores, they would be resolved on the server and returned in the reports.
+func (s *releaseServer) handleRemoteChanges(writer http.ResponseWriter, request *http.Request, name string) {
+	if len(configuration.NamedSection(name, config.Remote)) == 0 {
+		writeApi(writer, http.StatusNotFound, apiError{"unknown remote definition"})
+		return
+	}
+	values := make(map[string]string)
+	if err := json.NewDecoder(request.Body).Decode(&values); err != nil {
+		writeApi(writer, http.StatusBadRequest, apiError{"the body has to be a JSON object of properties"})
+		return
+	}
+
+	repository := request.URL.Query().Get("repository")
+	if repository != "" && !apiSpecifierPattern.MatchString(repository) {
+		writeApi(writer, http.StatusBadRequest, apiError{fmt.Sprintf("invalid repository %q", repository)})
+		return
+	}
+	types := make(map[config.Key]string)
+	for _, description := range config.Keys() {
+		if description.Section == config.Remote {
+			types[description.Key] = description.Type
+		}
+	}
+	for property, value := range values {
+		key := config.KeyLookup(property)
+		valueType, ok := types[key]
+		switch {
+		case key == nil || !ok || key.Name() != property:
+			writeApi(writer, http.StatusBadRequest, apiError{fmt.Sprintf("unknown property %s", property)})
+			return
+		case strings.ContainsAny(value, "\r\n"):
+			writeApi(writer, http.StatusBadRequest, apiError{fmt.Sprintf("the value of %s has to be a single line", property)})
+			return
+		case config.IsReference(value):
+			writeApi(writer, http.StatusBadRequest, apiError{fmt.Sprintf("the value of %s can't be a reference", property)})
+			return
+		case valueType == config.TypeCommand || valueType == config.TypePath || !apiRemoteKeys[key]:
+			writeApi(writer, http.StatusForbidden, apiError{fmt.Sprintf("%s can't be changed through the API", property)})
+			return
+		case repository != "" && !key.Overloadable():
+			writeApi(writer, http.StatusBadRequest, apiError{fmt.Sprintf("%s can't be overridden per repository", property)})
+			return
+		}
+	}
+
+	err := configuration.ApplyChanges(func(mutator config.Mutator) {
+		for property, value := range values {
+			if value == "" {
+				mutator.NamedSectionDelete(name, config.Remote, config.KeyLookup(property), repository)
+			} else {
+				mutator.NamedSectionSet(name, config.Remote, config.KeyLookup(property), repository, value)
+			}
+		}
+	})
+	if err != nil {
+		writeApi(writer, http.StatusInternalServerError, apiError{err.Error()})
+		return
+	}
+	s.handleRefresh(writer)
+}
+
+func (s *releaseServer) handleRefresh(writer http.ResponseWriter) {
+	s.scheduleRefresh()
+	writeApi(writer, http.StatusAccepted, map[string]string{"status": "refresh scheduled"})
+}
+
+// scheduleRefresh triggers a refresh of all reports, a refresh already waiting to run is not queued twice
+func (s *releaseServer) scheduleRefresh() {
+	select {
+	case s.trigger <- true:
+	default:
+	}
+}
+
+func (s *releaseServer) handleReleases(writer http.ResponseWriter, account, name string) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	report, rep := s.findRepository(account, name)
+	if rep == nil {
+		writeApi(writer, http.StatusNotFound, apiError{"unknown repository"})
+		return
+	}
+
+	releases := make([]jsonRelease, 0, len(rep.releases))
+	for _, rel := range rep.releases {
+		releases = append(releases, newJsonRelease(report, rep, rel))
+	}
+	writeApi(writer, http.StatusOK, releases)
+}
+
+func (s *releaseServer) handleAcknowledge(writer http.ResponseWriter, request *http.Request, account, name, tag string) {
+	var ack *acknowledgement
+	switch request.Method {
+	case http.MethodPost:
+		ack = &acknowledgement{}
+		// The body is optional, it may only carry a comment
+		if err := json.NewDecoder(request.Body).Decode(ack); err != nil && err != io.EOF {
+			writeApi(writer, http.StatusBadRequest, apiError{"the body has to be a JSON object with an optional comment"})
+			return
+		}
+		ack.By = requestUser(request).name
+	case http.MethodDelete:
+	default:
+		writeApi(writer, http.StatusMethodNotAllowed, apiError{"use POST or DELETE"})
+		return
+	}
+
+	rel, found, err := s.acknowledge(account, name, tag, ack)
+	switch {
+	case !found:
+		writeApi(writer, http.StatusNotFound, apiError{"unknown release"})
+	case err != nil:
+		writeApi(writer, http.StatusInternalServerError, apiError{err.Error()})
+	default:
+		writeApi(writer, http.StatusOK, rel)
+	}
+}
+
+// acknowledge records the acknowledgement of a release, without acknowledgement it is removed. It
+// returns the release with the changed acknowledgement, found is false for unknown releases.
+func (s *releaseServer) acknowledge(account, name, tag string, ack *acknowledgement) (rel jsonRelease, found bool, err error) {
+	// Acknowledgements are changed and saved one at a time
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	report, rep := s.findRepository(account, name)
+	var acknowledged *release
+	if rep != nil {
+		for _, r := range rep.releases {
+			if r.name == tag {
+				acknowledged = r
+			}
+		}
+	}
+	if acknowledged == nil {
+		return jsonRelease{}, false, nil
+	}
+
+	key := acknowledgementKey(report.name, rep.stateName(), acknowledged.name)
+	if ack != nil {
+		ack.Acknowledged = time.Now().UTC()
+		openState().Set(key, *ack)
+	} else {
+		openState().Delete(key)
+	}
+	if err := openState().Save(); err != nil {
+		return jsonRelease{}, true, err
+	}
+	return newJsonRelease(report, rep, acknowledged), true, nil
+}
+
+func writeApi(writer http.ResponseWriter, status int, value interface{}) {
+	writer.Header().Set("Content-Type", "application/json")
+	writer.WriteHeader(status)
+	json.NewEncoder(writer).Encode(value)
+}
@@ -0,0 +1,158 @@
+package main
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+	"grm/config"
+	"grm/state"
+)
+
+// useConfigurationFile makes the given content the configuration file of the test, changes are
+// written to it
+func useConfigurationFile(t *testing.T, content string) string {
+	home := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(home, "github-release-monitor"), 0700); err != nil {
+		t.Fatal(err)
+	}
+	configPath := filepath.Join(home, "github-release-monitor", "config")
+	if err := ioutil.WriteFile(configPath, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+	c, err := config.LoadConfiguration(home)
+	if err != nil {
+		t.Fatal(err)
+	}
+	previous := configuration
+	configuration = c
+	t.Cleanup(func() { configuration = previous })
+	return configPath
+}
+
+func TestHandleRemoteChanges(t *testing.T) {
+	tests := []struct {
+		name   string
+		query  string
+		body   string
+		status int
+	}{
+		{"release pattern", "", `{"release-pattern":"^v1\\."}`, http.StatusAccepted},
+		{"repository override", "?repository=terraform", `{"owner":"ops"}`, http.StatusAccepted},
+		{"wildcard override", "?repository=terraform-provider-*", `{"owner":"ops"}`, http.StatusAccepted},
+		{"sub-project override", "?repository=consul/api", `{"owner":"ops"}`, http.StatusAccepted},
+		{"removal", "", `{"stale-after":""}`, http.StatusAccepted},
+		{"not an object", "", `["owner"]`, http.StatusBadRequest},
+		{"unknown property", "", `{"colour":"red"}`, http.StatusBadRequest},
+		{"property with specifier", "", `{"owner:terraform":"ops"}`, http.StatusBadRequest},
+		{"command", "", `{"summary-cmd":"id"}`, http.StatusForbidden},
+		{"credential", "", `{"token":"secret"}`, http.StatusForbidden},
+		{"line feed", "", `{"owner":"ops\nsummary-cmd = id"}`, http.StatusBadRequest},
+		{"carriage return", "", `{"owner":"ops\rsummary-cmd = id"}`, http.StatusBadRequest},
+		{"file reference", "", `{"owner":"file:/root/.ssh/id_rsa"}`, http.StatusBadRequest},
+		{"environment reference", "", `{"owner":"${GRM_API_TOKEN}"}`, http.StatusBadRequest},
+		{"vault reference", "", `{"owner":"vault:secret/grm#token"}`, http.StatusBadRequest},
+		{"specifier with line feed", "?repository=terraform%0Asummary-cmd", `{"owner":"ops"}`, http.StatusBadRequest},
+		{"specifier with key separator", "?repository=terraform%3Dx", `{"owner":"ops"}`, http.StatusBadRequest},
+		{"specifier with quote", "?repository=terraform%22", `{"owner":"ops"}`, http.StatusBadRequest},
+		{"not overloadable", "?repository=terraform", `{"repository-pattern":"^terraform$"}`, http.StatusBadRequest},
+	}
+	for _, test := range tests {
+		configPath := useConfigurationFile(t, "[Core]\nconfig-version = 1\n[Remote \"hashicorp\"]\nuser = hashicorp\n")
+		server := newReleaseServer(nil, reportOptions{}, 0, 0, 0, nil, false)
+
+		request := httptest.NewRequest(http.MethodPatch, "/api/remotes/hashicorp"+test.query, strings.NewReader(test.body))
+		response := httptest.NewRecorder()
+		server.handleApi(response, request)
+		if response.Code != test.status {
+			t.Errorf("%s: status %d, expected %d, %s", test.name, response.Code, test.status, response.Body)
+		}
+
+		content, _ := ioutil.ReadFile(configPath)
+		if strings.Contains(string(content), "summary-cmd") || strings.Contains(string(content), "token") {
+			t.Errorf("%s: configuration written %q", test.name, content)
+		}
+		if test.status != http.StatusAccepted && strings.Contains(string(content), "owner") {
+			t.Errorf("%s: rejected change written %q", test.name, content)
+		}
+	}
+}
+
+func TestHandleRemoteChangesUnknownRemote(t *testing.T) {
+	useConfigurationFile(t, "[Core]\nconfig-version = 1\n")
+	server := newReleaseServer(nil, reportOptions{}, 0, 0, 0, nil, false)
+
+	response := httptest.NewRecorder()
+	server.handleApi(response, httptest.NewRequest(http.MethodPatch, "/api/remotes/hashicorp", strings.NewReader(`{"owner":"ops"}`)))
+	if response.Code != http.StatusNotFound {
+		t.Errorf("status %d", response.Code)
+	}
+}
+
+func TestHandleAcknowledgeBody(t *testing.T) {
+	server := newReleaseServer(nil, reportOptions{}, 0, 0, 0, nil, false)
+	tests := []struct {
+		body   string
+		status int
+	}{
+		{`{"comment":`, http.StatusBadRequest},
+		{`"deployed"`, http.StatusBadRequest},
+		// Well-formed bodies get to the lookup of the release
+		{``, http.StatusNotFound},
+		{`{"comment":"deployed"}`, http.StatusNotFound},
+	}
+	for _, test := range tests {
+		request := httptest.NewRequest(http.MethodPost, "/api/releases/hashicorp/terraform/v0.11.8/ack", strings.NewReader(test.body))
+		request = request.WithContext(context.WithValue(request.Context(), apiUserContextKey{}, &apiUser{name: "ops", role: roleAdmin}))
+		response := httptest.NewRecorder()
+		server.handleApi(response, request)
+		if response.Code != test.status {
+			t.Errorf("%q: status %d, expected %d", test.body, response.Code, test.status)
+		}
+	}
+}
+
+func TestHandleAcknowledgeConcurrently(t *testing.T) {
+	useConfiguration(t, "[Core]\nconfig-version = 1\n[Remote \"hashicorp\"]\nuser = hashicorp\n")
+	path := useState(t)
+	tags := []string{"v0.11.5", "v0.11.6", "v0.11.7", "v0.11.8"}
+	rep := &repository{name: "terraform"}
+	for _, tag := range tags {
+		rep.releases = append(rep.releases, &release{name: tag, created: time.Now()})
+	}
+	server := newReleaseServer([]string{"hashicorp"}, reportOptions{}, 0, 0, 0, nil, false)
+	server.reports["hashicorp"] = &reportModel{name: "hashicorp", account: "hashicorp", repositories: []*repository{rep}}
+
+	var wait sync.WaitGroup
+	for _, tag := range tags {
+		wait.Add(1)
+		go func(tag string) {
+			defer wait.Done()
+			request := httptest.NewRequest(http.MethodPost, "/api/releases/hashicorp/terraform/"+tag+"/ack", strings.NewReader(`{"comment":"deployed"}`))
+			request = request.WithContext(context.WithValue(request.Context(), apiUserContextKey{}, &apiUser{name: "ops", role: roleAdmin}))
+			response := httptest.NewRecorder()
+			server.handleApi(response, request)
+			if response.Code != http.StatusOK {
+				t.Errorf("%s: status %d, %s", tag, response.Code, response.Body)
+			}
+		}(tag)
+	}
+	wait.Wait()
+
+	saved, err := state.NewStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tag := range tags {
+		ack := acknowledgement{}
+		if !saved.Get(acknowledgementKey("hashicorp", "terraform", tag), &ack) || ack.Comment != "deployed" || ack.By != "ops" {
+			t.Errorf("%s: saved %+v", tag, ack)
+		}
+	}
+}
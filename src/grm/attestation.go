@@ -0,0 +1,278 @@
+package main
+
+import (
+	"github.com/google/go-github/github"
+	"context"
+	"fmt"
+	"net/http"
+	"log"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/asn1"
+	"crypto/x509"
+	"strings"
+	"os/exec"
+	"bytes"
+)
+
+// Fulcio certificate extension containing the source repository of the signing workflow
+var oidSourceRepositoryUri = asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 57264, 1, 12}
+
+const (
+	attestationProvenance = "provenance"
+	attestationSbom       = "sbom"
+)
+
+type attestationResult struct {
+	Asset         string `json:"asset"`
+	Kind          string `json:"kind"`
+	PredicateType string `json:"predicate-type"`
+	Verified      bool   `json:"verified"`
+	Method        string `json:"method"`
+	Message       string `json:"message,omitempty"`
+}
+
+func (a attestationResult) String() string {
+	verdict := "verified"
+	if !a.Verified {
+		verdict = "NOT verified"
+	}
+	result := fmt.Sprintf("%s %s (%s): %s", a.Kind, verdict, a.Method, a.Asset)
+	if a.Message != "" {
+		result = fmt.Sprintf("%s, %s", result, a.Message)
+	}
+	return result
+}
+
+type attestationsResponse struct {
+	Attestations []struct {
+		Bundle sigstoreBundle `json:"bundle"`
+	} `json:"attestations"`
+}
+
+type sigstoreBundle struct {
+	MediaType            string `json:"mediaType"`
+	VerificationMaterial struct {
+		Certificate *struct {
+			RawBytes string `json:"rawBytes"`
+		} `json:"certificate"`
+		X509CertificateChain *struct {
+			Certificates []struct {
+				RawBytes string `json:"rawBytes"`
+			} `json:"certificates"`
+		} `json:"x509CertificateChain"`
+	} `json:"verificationMaterial"`
+	DsseEnvelope struct {
+		Payload     string `json:"payload"`
+		PayloadType string `json:"payloadType"`
+		Signatures  []struct {
+			Sig string `json:"sig"`
+		} `json:"signatures"`
+	} `json:"dsseEnvelope"`
+}
+
+type inTotoStatement struct {
+	Subject []struct {
+		Name   string            `json:"name"`
+		Digest map[string]string `json:"digest"`
+	} `json:"subject"`
+	PredicateType string `json:"predicateType"`
+}
+
+// verifyAttestations fetches the Github artifact attestations for every downloaded asset and verifies
+// them. If the Github CLI is installed it is used for full Sigstore verification, otherwise the DSSE
+// signature, the attested digest and the signing repository are verified, but not the certificate chain.
+func verifyAttestations(account, repository string, assets []downloadedAsset, client *github.Client) []attestationResult {
+	results := make([]attestationResult, 0)
+	for _, asset := range assets {
+		for _, bundle := range readAttestations(account, repository, asset.Sha256, client) {
+			results = append(results, verifyAttestation(account, repository, asset, bundle))
+		}
+	}
+	return results
+}
+
+// attestationsVerified reports whether every asset has at least one verified attestation
+func attestationsVerified(assets []downloadedAsset, attestations []attestationResult) bool {
+	for _, asset := range assets {
+		verified := false
+		for _, attestation := range attestations {
+			if attestation.Asset == asset.Name && attestation.Verified {
+				verified = true
+			}
+		}
+		if !verified {
+			return false
+		}
+	}
+	return true
+}
+
+func readAttestations(account, repository, sha256 string, client *github.Client) []sigstoreBundle {
+	ctx := context.Background()
+
+	url := fmt.Sprintf("repos/%s/%s/attestations/sha256:%s", account, repository, sha256)
+	for {
+		req, err := client.NewRequest("GET", url, nil)
+		if err != nil {
+			log.Fatal("Could not create attestation request: ", err)
+		}
+
+		attestations := &attestationsResponse{}
+		response, err := client.Do(ctx, req, attestations)
+		if response != nil && response.StatusCode == http.StatusNotFound {
+			return nil
+		}
+
+		if rateLimit(response) {
+			continue
+		}
+
+		if err != nil {
+			log.Fatal(fmt.Sprintf("Could not retrieve attestations for repository %s: ", repository), err)
+		}
+
+		bundles := make([]sigstoreBundle, 0, len(attestations.Attestations))
+		for _, attestation := range attestations.Attestations {
+			bundles = append(bundles, attestation.Bundle)
+		}
+		return bundles
+	}
+}
+
+func verifyAttestation(account, repository string, asset downloadedAsset, bundle sigstoreBundle) attestationResult {
+	result := attestationResult{
+		Asset:  asset.Name,
+		Kind:   "unknown",
+		Method: "signature",
+	}
+
+	payload, err := base64.StdEncoding.DecodeString(bundle.DsseEnvelope.Payload)
+	if err != nil {
+		result.Message = "invalid attestation payload"
+		return result
+	}
+
+	statement := inTotoStatement{}
+	if err := json.Unmarshal(payload, &statement); err != nil {
+		result.Message = "invalid in-toto statement"
+		return result
+	}
+	result.PredicateType = statement.PredicateType
+	result.Kind = attestationKind(statement.PredicateType)
+
+	if _, err := exec.LookPath("gh"); err == nil {
+		result.Method = "gh"
+		var stderr bytes.Buffer
+		verify := exec.Command("gh", "attestation", "verify", asset.Path,
+			"--repo", fmt.Sprintf("%s/%s", account, repository), "--predicate-type", statement.PredicateType)
+		verify.Stderr = &stderr
+		if err := verify.Run(); err != nil {
+			result.Message = strings.TrimSpace(stderr.String())
+			return result
+		}
+		result.Verified = true
+		return result
+	}
+
+	subjectMatches := false
+	for _, subject := range statement.Subject {
+		if strings.EqualFold(subject.Digest["sha256"], asset.Sha256) {
+			subjectMatches = true
+		}
+	}
+	if !subjectMatches {
+		result.Message = "attestation subject does not match the downloaded asset"
+		return result
+	}
+
+	certificate, err := bundleCertificate(bundle)
+	if err != nil {
+		result.Message = err.Error()
+		return result
+	}
+
+	if len(bundle.DsseEnvelope.Signatures) == 0 {
+		result.Message = "attestation is not signed"
+		return result
+	}
+	signature, err := base64.StdEncoding.DecodeString(bundle.DsseEnvelope.Signatures[0].Sig)
+	if err != nil {
+		result.Message = "invalid attestation signature"
+		return result
+	}
+
+	signed := dssePreAuthEncoding(bundle.DsseEnvelope.PayloadType, payload)
+	if err := certificate.CheckSignature(x509.ECDSAWithSHA256, signed, signature); err != nil {
+		result.Message = "attestation signature is invalid"
+		return result
+	}
+
+	if !certificateMatchesRepository(certificate, account, repository) {
+		result.Message = "attestation was not signed by a workflow of this repository"
+		return result
+	}
+
+	// Anyone can sign with a self-issued certificate naming the repository, without the chain to the
+	// Sigstore root the attestation proves nothing and never satisfies --require-attestation
+	result.Message = "signature matches, but the certificate chain can't be checked, install the Github CLI to verify"
+	return result
+}
+
+func attestationKind(predicateType string) string {
+	switch {
+	case strings.HasPrefix(predicateType, "https://slsa.dev/provenance/"):
+		return attestationProvenance
+	case strings.HasPrefix(predicateType, "https://spdx.dev/Document"),
+		strings.HasPrefix(predicateType, "https://cyclonedx.org/bom"):
+		return attestationSbom
+	}
+	return predicateType
+}
+
+func bundleCertificate(bundle sigstoreBundle) (*x509.Certificate, error) {
+	raw := ""
+	material := bundle.VerificationMaterial
+	if material.Certificate != nil {
+		raw = material.Certificate.RawBytes
+	} else if material.X509CertificateChain != nil && len(material.X509CertificateChain.Certificates) > 0 {
+		raw = material.X509CertificateChain.Certificates[0].RawBytes
+	}
+	if raw == "" {
+		return nil, fmt.Errorf("attestation contains no signing certificate")
+	}
+
+	der, err := base64.StdEncoding.DecodeString(raw)
+	if err != nil {
+		return nil, fmt.Errorf("invalid signing certificate")
+	}
+	return x509.ParseCertificate(der)
+}
+
+// dssePreAuthEncoding builds the byte sequence actually signed in a DSSE envelope
+func dssePreAuthEncoding(payloadType string, payload []byte) []byte {
+	var buffer bytes.Buffer
+	buffer.WriteString(fmt.Sprintf("DSSEv1 %d %s %d ", len(payloadType), payloadType, len(payload)))
+	buffer.Write(payload)
+	return buffer.Bytes()
+}
+
+func certificateMatchesRepository(certificate *x509.Certificate, account, repository string) bool {
+	expected := strings.ToLower(fmt.Sprintf("https://github.com/%s/%s", account, repository))
+
+	for _, extension := range certificate.Extensions {
+		if extension.Id.Equal(oidSourceRepositoryUri) {
+			var value string
+			if _, err := asn1.Unmarshal(extension.Value, &value); err == nil {
+				return strings.ToLower(value) == expected
+			}
+		}
+	}
+
+	for _, uri := range certificate.URIs {
+		if strings.HasPrefix(strings.ToLower(uri.String()), expected+"/") {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,168 @@
+package main
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+const testPayloadType = "application/vnd.in-toto+json"
+
+func TestDssePreAuthEncoding(t *testing.T) {
+	tests := []struct {
+		payloadType string
+		payload     string
+		expected    string
+	}{
+		// Test vector of the DSSE protocol specification
+		{"http://example.com/HelloWorld", "hello world", "DSSEv1 29 http://example.com/HelloWorld 11 hello world"},
+		{"", "", "DSSEv1 0  0 "},
+		{testPayloadType, "{}", "DSSEv1 28 application/vnd.in-toto+json 2 {}"},
+		// Lengths count bytes, not characters
+		{"ü", "✓", "DSSEv1 2 ü 3 ✓"},
+	}
+	for _, test := range tests {
+		if encoded := string(dssePreAuthEncoding(test.payloadType, []byte(test.payload))); encoded != test.expected {
+			t.Errorf("%q %q: encoded %q, expected %q", test.payloadType, test.payload, encoded, test.expected)
+		}
+	}
+}
+
+func TestAttestationKind(t *testing.T) {
+	tests := map[string]string{
+		"https://slsa.dev/provenance/v1":     attestationProvenance,
+		"https://spdx.dev/Document/v2.3":     attestationSbom,
+		"https://cyclonedx.org/bom":          attestationSbom,
+		"https://in-toto.io/attestation/vsa": "https://in-toto.io/attestation/vsa",
+	}
+	for predicateType, expected := range tests {
+		if kind := attestationKind(predicateType); kind != expected {
+			t.Errorf("%s: kind %s, expected %s", predicateType, kind, expected)
+		}
+	}
+}
+
+// newSigningCertificate creates a self-signed certificate like Fulcio issues for the workflows of a
+// repository, either with the source repository extension or as URI
+func newSigningCertificate(t *testing.T, repositoryUri string, extension bool) (*ecdsa.PrivateKey, []byte) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "sigstore-intermediate"},
+		NotBefore:    time.Now().Add(-time.Minute),
+		NotAfter:     time.Now().Add(10 * time.Minute),
+	}
+	if extension {
+		value, err := asn1.Marshal(repositoryUri)
+		if err != nil {
+			t.Fatal(err)
+		}
+		template.ExtraExtensions = []pkix.Extension{{Id: oidSourceRepositoryUri, Value: value}}
+	} else {
+		uri, err := url.Parse(repositoryUri + "/.github/workflows/release.yml@refs/tags/v1.0.0")
+		if err != nil {
+			t.Fatal(err)
+		}
+		template.URIs = []*url.URL{uri}
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return key, der
+}
+
+func signDsse(t *testing.T, key *ecdsa.PrivateKey, payloadType string, payload []byte) []byte {
+	digest := sha256.Sum256(dssePreAuthEncoding(payloadType, payload))
+	signature, err := ecdsa.SignASN1(rand.Reader, key, digest[:])
+	if err != nil {
+		t.Fatal(err)
+	}
+	return signature
+}
+
+func newBundle(t *testing.T, certificate []byte, payloadType string, payload []byte, signatures ...[]byte) sigstoreBundle {
+	material := map[string]interface{}{}
+	if certificate != nil {
+		material["certificate"] = map[string]string{"rawBytes": base64.StdEncoding.EncodeToString(certificate)}
+	}
+	sigs := []map[string]string{}
+	for _, signature := range signatures {
+		sigs = append(sigs, map[string]string{"sig": base64.StdEncoding.EncodeToString(signature)})
+	}
+	data, err := json.Marshal(map[string]interface{}{
+		"verificationMaterial": material,
+		"dsseEnvelope": map[string]interface{}{
+			"payload":     base64.StdEncoding.EncodeToString(payload),
+			"payloadType": payloadType,
+			"signatures":  sigs,
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	bundle := sigstoreBundle{}
+	if err := json.Unmarshal(data, &bundle); err != nil {
+		t.Fatal(err)
+	}
+	return bundle
+}
+
+func TestVerifyAttestationSignature(t *testing.T) {
+	// Without the Github CLI only the signature of the DSSE envelope is verified
+	t.Setenv("PATH", t.TempDir())
+
+	asset := downloadedAsset{Name: "terraform_0.11.8_linux_amd64.zip", Sha256: strings.Repeat("ab", 32)}
+	statement := []byte(fmt.Sprintf(`{"predicateType":"https://slsa.dev/provenance/v1","subject":[{"name":"%s","digest":{"sha256":"%s"}}]}`,
+		asset.Name, strings.ToUpper(asset.Sha256)))
+	otherStatement := []byte(`{"predicateType":"https://slsa.dev/provenance/v1","subject":[{"name":"other","digest":{"sha256":"00"}}]}`)
+
+	key, certificate := newSigningCertificate(t, "https://github.com/HashiCorp/Terraform", true)
+	uriKey, uriCertificate := newSigningCertificate(t, "https://github.com/hashicorp/terraform", false)
+	otherKey, otherCertificate := newSigningCertificate(t, "https://github.com/hashicorp/vault", true)
+	signature := signDsse(t, key, testPayloadType, statement)
+
+	unverifiable := "signature matches, but the certificate chain can't be checked"
+	tests := []struct {
+		name    string
+		bundle  sigstoreBundle
+		message string
+	}{
+		{"repository extension", newBundle(t, certificate, testPayloadType, statement, signature), unverifiable},
+		{"repository URI", newBundle(t, uriCertificate, testPayloadType, statement, signDsse(t, uriKey, testPayloadType, statement)), unverifiable},
+		{"other repository", newBundle(t, otherCertificate, testPayloadType, statement, signDsse(t, otherKey, testPayloadType, statement)),
+			"attestation was not signed by a workflow of this repository"},
+		{"signed by another key", newBundle(t, certificate, testPayloadType, statement, signDsse(t, otherKey, testPayloadType, statement)),
+			"attestation signature is invalid"},
+		{"payload type changed", newBundle(t, certificate, "application/json", statement, signature), "attestation signature is invalid"},
+		{"other subject", newBundle(t, certificate, testPayloadType, otherStatement, signDsse(t, key, testPayloadType, otherStatement)),
+			"attestation subject does not match the downloaded asset"},
+		{"unsigned", newBundle(t, certificate, testPayloadType, statement), "attestation is not signed"},
+		{"no certificate", newBundle(t, nil, testPayloadType, statement, signature), "attestation contains no signing certificate"},
+		{"invalid statement", newBundle(t, certificate, testPayloadType, []byte("{"), signature), "invalid in-toto statement"},
+	}
+	for _, test := range tests {
+		result := verifyAttestation("hashicorp", "terraform", asset, test.bundle)
+		if result.Verified {
+			t.Errorf("%s: verified without the certificate chain", test.name)
+		}
+		if !strings.HasPrefix(result.Message, test.message) {
+			t.Errorf("%s: message %q, expected %q", test.name, result.Message, test.message)
+		}
+	}
+}
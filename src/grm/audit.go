@@ -0,0 +1,165 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"sync"
+	"text/tabwriter"
+	"time"
+	"grm/config"
+)
+
+// Kinds of audit log entries
+const (
+	auditNotify   = "notification"
+	auditDownload = "download"
+	auditInstall  = "install"
+	auditMirror   = "mirror"
+)
+
+// auditEntry is a line of the audit log, the evidence of what the upgrade pipeline sent, fetched and
+// installed
+type auditEntry struct {
+	Time       time.Time `json:"time"`
+	Kind       string    `json:"kind"`
+	Remote     string    `json:"remote"`
+	Repository string    `json:"repository"`
+	Tag        string    `json:"tag"`
+	// Url is the notification sink without credentials or the origin of the asset
+	Url    string `json:"url"`
+	Sha256 string `json:"sha256,omitempty"`
+	Path   string `json:"path,omitempty"`
+	Status string `json:"status,omitempty"`
+	Error  string `json:"error,omitempty"`
+}
+
+// Serializes the appends of concurrent notifications
+var auditMutex sync.Mutex
+
+// auditLogPath returns the audit log file, headless runs only keep one if the audit-log property is set
+func auditLogPath() string {
+	if path, ok := sectionGet(config.Core, config.AuditLog, ""); ok && path != "" {
+		return path
+	}
+	if headlessRun {
+		return ""
+	}
+	return grmPath("audit.log")
+}
+
+// writeAudit appends the entry to the audit log, entries are never changed once written
+func writeAudit(entry auditEntry) error {
+	path := auditLogPath()
+	if path == "" {
+		return nil
+	}
+	entry.Time = time.Now().UTC()
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+
+	auditMutex.Lock()
+	defer auditMutex.Unlock()
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0600)
+	if err != nil {
+		return err
+	}
+	if _, err := file.Write(append(line, '\n')); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+// auditNotification records a notification sent to a sink, a failing audit log doesn't stop the
+// notifications
+func auditNotification(s *sink, event releaseEvent, err error) {
+	entry := auditEntry{Kind: auditNotify, Remote: event.Remote, Repository: event.Repository, Tag: event.Tag,
+		Url: urlCredentialsPattern.ReplaceAllString(s.String(), "://<redacted>@"), Status: deliverySent}
+	if err != nil {
+		entry.Status, entry.Error = deliveryFailed, err.Error()
+	}
+	if err := writeAudit(entry); err != nil {
+		log.Println(fmt.Sprintf("Could not write audit log: %s", err))
+	}
+}
+
+// auditAsset records a downloaded or installed asset, nothing is installed without evidence
+func auditAsset(kind, name, repository, tag string, asset downloadedAsset, target string) {
+	path := asset.Path
+	if target != "" {
+		path = filepath.Join(target, asset.Name)
+	}
+	err := writeAudit(auditEntry{Kind: kind, Remote: name, Repository: repository, Tag: tag,
+		Url: urlCredentialsPattern.ReplaceAllString(asset.Url, "://<redacted>@"), Sha256: asset.Sha256, Path: path})
+	if err != nil {
+		log.Fatal("Could not write audit log: ", err)
+	}
+}
+
+// auditUpload records an asset uploaded to a mirror target
+func auditUpload(name, repository, tag string, asset downloadedAsset, location string) {
+	err := writeAudit(auditEntry{Kind: auditMirror, Remote: name, Repository: repository, Tag: tag,
+		Url: urlCredentialsPattern.ReplaceAllString(asset.Url, "://<redacted>@"), Sha256: asset.Sha256,
+		Path: urlCredentialsPattern.ReplaceAllString(location, "://<redacted>@")})
+	if err != nil {
+		log.Fatal("Could not write audit log: ", err)
+	}
+}
+
+// readAudit returns the entries of the audit log, the oldest first
+func readAudit() []auditEntry {
+	entries := make([]auditEntry, 0)
+	path := auditLogPath()
+	if path == "" {
+		return entries
+	}
+	file, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return entries
+	}
+	if err != nil {
+		log.Fatal(fmt.Sprintf("Could not read audit log '%s': ", path), err)
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		entry := auditEntry{}
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			log.Fatal(fmt.Sprintf("Could not parse line %d of audit log '%s': ", line, path), err)
+		}
+		entries = append(entries, entry)
+	}
+	if err := scanner.Err(); err != nil {
+		log.Fatal(fmt.Sprintf("Could not read audit log '%s': ", path), err)
+	}
+	return entries
+}
+
+func formatAuditText(writer io.Writer, entries []auditEntry) error {
+	table := tabwriter.NewWriter(writer, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(table, "Time\tKind\tRemote\tRepository\tTag\tUrl\tSha256\tStatus")
+	for _, entry := range entries {
+		fmt.Fprintln(table, fmt.Sprintf("%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s", locale.Time(entry.Time).Format("2006-01-02 15:04:05"),
+			entry.Kind, entry.Remote, entry.Repository, entry.Tag, entry.Url, orDash(entry.Sha256), orDash(entry.Status)))
+	}
+	return table.Flush()
+}
+
+func formatAuditJson(writer io.Writer, entries []auditEntry) error {
+	encoder := json.NewEncoder(writer)
+	encoder.SetIndent("", "  ")
+	encoder.SetEscapeHTML(false)
+	return encoder.Encode(entries)
+}
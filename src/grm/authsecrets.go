@@ -0,0 +1,78 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"text/tabwriter"
+	"grm/config"
+)
+
+// remoteSecret is a secret held by a remote definition and how it is protected
+type remoteSecret struct {
+	Remote string `json:"remote"`
+	Secret string `json:"secret,omitempty"`
+	Scheme string `json:"scheme,omitempty"`
+}
+
+// readRemoteSecrets lists the secrets of all remote definitions without decrypting or resolving them,
+// remote definitions without secrets are listed without secret
+func readRemoteSecrets() []remoteSecret {
+	names := make([]string, 0)
+	for _, section := range configuration.NamedSections(config.Remote) {
+		names = append(names, config.ExtractSpecifier(section))
+	}
+	sort.Strings(names)
+
+	secrets := make([]remoteSecret, 0)
+	for _, name := range names {
+		values := configuration.NamedSection(name, config.Remote)
+		found := false
+		for _, s := range storedSecrets {
+			if password := values[s.password.Name()]; password != "" {
+				secrets = append(secrets, remoteSecret{Remote: name, Secret: s.password.Name(), Scheme: secretScheme(password)})
+				found = true
+			}
+		}
+		if token := values[config.Token.Name()]; token != "" {
+			scheme := "plain text"
+			if config.IsReference(token) {
+				scheme = "reference"
+			}
+			secrets = append(secrets, remoteSecret{Remote: name, Secret: config.Token.Name(), Scheme: scheme})
+			found = true
+		}
+		if !found {
+			secrets = append(secrets, remoteSecret{Remote: name})
+		}
+	}
+	return secrets
+}
+
+// deleteRemoteSecrets removes the stored passwords and the token of the remote definition, other
+// remote definitions keep theirs
+func deleteRemoteSecrets(name string) {
+	applyChanges(func(mutator config.Mutator) {
+		for _, s := range storedSecrets {
+			mutator.NamedSectionDelete(name, config.Remote, s.password, "")
+			mutator.NamedSectionDelete(name, config.Remote, s.salt, "")
+		}
+		mutator.NamedSectionDelete(name, config.Remote, config.Token, "")
+	})
+}
+
+func formatRemoteSecretsText(writer io.Writer, secrets []remoteSecret) error {
+	table := tabwriter.NewWriter(writer, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(table, "Remote\tSecret\tScheme")
+	for _, secret := range secrets {
+		fmt.Fprintln(table, fmt.Sprintf("%s\t%s\t%s", secret.Remote, orDash(secret.Secret), orDash(secret.Scheme)))
+	}
+	return table.Flush()
+}
+
+func formatRemoteSecretsJson(writer io.Writer, secrets []remoteSecret) error {
+	encoder := json.NewEncoder(writer)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(secrets)
+}
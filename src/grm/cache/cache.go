@@ -0,0 +1,396 @@
+package cache
+
+import (
+	"net/http"
+	"path/filepath"
+	"os"
+	"sync"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"sort"
+	"strings"
+	"strconv"
+	"fmt"
+	"bytes"
+	"time"
+)
+
+const DefaultMaxSize int64 = 100 * 1024 * 1024
+
+const (
+	metaSuffix = ".meta"
+	bodySuffix = ".body"
+)
+
+// Cache is an on-disk, ETag aware HTTP cache implementing http.RoundTripper.
+// Responses carrying an ETag or Last-Modified header are stored and revalidated
+// using conditional requests. The total size is bounded by evicting the least
+// recently used entries. A cache without a directory stores nothing and passes
+// all requests through.
+type Cache struct {
+	dir       string
+	maxSize   int64
+	transport http.RoundTripper
+	mutex     sync.Mutex
+}
+
+type Stats struct {
+	Entries int
+	Size    int64
+	MaxSize int64
+	Oldest  time.Time
+	Newest  time.Time
+}
+
+type entry struct {
+	Url          string      `json:"url"`
+	StatusCode   int         `json:"status"`
+	Header       http.Header `json:"header"`
+	ETag         string      `json:"etag,omitempty"`
+	LastModified string      `json:"last-modified,omitempty"`
+}
+
+type file struct {
+	key     string
+	size    int64
+	modTime time.Time
+}
+
+func NewCache(dir string, maxSize int64) *Cache {
+	if maxSize <= 0 {
+		maxSize = DefaultMaxSize
+	}
+	return &Cache{
+		dir:       dir,
+		maxSize:   maxSize,
+		transport: http.DefaultTransport,
+	}
+}
+
+// ParseSize parses human readable sizes like 512K, 100MB or 1G into bytes.
+func ParseSize(value string) (int64, error) {
+	value = strings.ToUpper(strings.TrimSpace(value))
+	value = strings.TrimSuffix(value, "B")
+
+	multiplier := int64(1)
+	switch {
+	case strings.HasSuffix(value, "K"):
+		multiplier = 1024
+	case strings.HasSuffix(value, "M"):
+		multiplier = 1024 * 1024
+	case strings.HasSuffix(value, "G"):
+		multiplier = 1024 * 1024 * 1024
+	}
+	if multiplier > 1 {
+		value = value[:len(value)-1]
+	}
+
+	size, err := strconv.ParseInt(strings.TrimSpace(value), 10, 64)
+	if err != nil {
+		return 0, err
+	}
+	if size <= 0 {
+		return 0, fmt.Errorf("size must be positive: %d", size)
+	}
+	return size * multiplier, nil
+}
+
+// FormatSize renders a byte count in a human readable way.
+func FormatSize(size int64) string {
+	switch {
+	case size >= 1024*1024*1024:
+		return fmt.Sprintf("%.1fGB", float64(size)/(1024*1024*1024))
+	case size >= 1024*1024:
+		return fmt.Sprintf("%.1fMB", float64(size)/(1024*1024))
+	case size >= 1024:
+		return fmt.Sprintf("%.1fKB", float64(size)/1024)
+	}
+	return fmt.Sprintf("%dB", size)
+}
+
+func (c *Cache) Client() *http.Client {
+	return &http.Client{Transport: c}
+}
+
+func (c *Cache) RoundTrip(req *http.Request) (*http.Response, error) {
+	if c.dir == "" || req.Method != http.MethodGet || req.Header.Get("Range") != "" {
+		return c.transport.RoundTrip(req)
+	}
+
+	key := cacheKey(req)
+	cached := c.readEntry(key)
+
+	if cached != nil {
+		// Don't modify the caller's request
+		conditional := new(http.Request)
+		*conditional = *req
+		conditional.Header = make(http.Header, len(req.Header))
+		for k, v := range req.Header {
+			conditional.Header[k] = v
+		}
+		if cached.ETag != "" {
+			conditional.Header.Set("If-None-Match", cached.ETag)
+		}
+		if cached.LastModified != "" {
+			conditional.Header.Set("If-Modified-Since", cached.LastModified)
+		}
+		req = conditional
+	}
+
+	response, err := c.transport.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if cached != nil && response.StatusCode == http.StatusNotModified {
+		if r := c.cachedResponse(key, cached, req, response); r != nil {
+			return r, nil
+		}
+		// Cache entry vanished in between, retry without conditions
+		response.Body.Close()
+		req.Header.Del("If-None-Match")
+		req.Header.Del("If-Modified-Since")
+		return c.transport.RoundTrip(req)
+	}
+
+	if response.StatusCode != http.StatusOK {
+		return response, nil
+	}
+
+	etag := response.Header.Get("ETag")
+	lastModified := response.Header.Get("Last-Modified")
+	if etag == "" && lastModified == "" {
+		return response, nil
+	}
+
+	if response.ContentLength > c.maxSize {
+		return response, nil
+	}
+
+	if err := os.MkdirAll(c.dir, os.ModePerm); err != nil {
+		return response, nil
+	}
+
+	temp, err := ioutil.TempFile(c.dir, "tmp-")
+	if err != nil {
+		return response, nil
+	}
+
+	response.Body = &cachingReader{
+		cache: c,
+		key:   key,
+		body:  response.Body,
+		temp:  temp,
+		entry: &entry{
+			Url:          req.URL.String(),
+			StatusCode:   response.StatusCode,
+			Header:       response.Header,
+			ETag:         etag,
+			LastModified: lastModified,
+		},
+	}
+	return response, nil
+}
+
+func (c *Cache) Stats() Stats {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	stats := Stats{MaxSize: c.maxSize}
+	for _, f := range c.files() {
+		stats.Entries++
+		stats.Size += f.size
+		if stats.Oldest.IsZero() || f.modTime.Before(stats.Oldest) {
+			stats.Oldest = f.modTime
+		}
+		if f.modTime.After(stats.Newest) {
+			stats.Newest = f.modTime
+		}
+	}
+	return stats
+}
+
+func (c *Cache) Clear() error {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return os.RemoveAll(c.dir)
+}
+
+func (c *Cache) cachedResponse(key string, cached *entry, req *http.Request, notModified *http.Response) *http.Response {
+	body, err := os.Open(c.path(key, bodySuffix))
+	if err != nil {
+		return nil
+	}
+	notModified.Body.Close()
+
+	now := time.Now()
+	os.Chtimes(c.path(key, bodySuffix), now, now)
+
+	header := make(http.Header, len(cached.Header))
+	for k, v := range cached.Header {
+		header[k] = v
+	}
+	// Keep fresh values like rate limit information from the revalidation
+	for k, v := range notModified.Header {
+		header[k] = v
+	}
+
+	size := int64(-1)
+	if info, err := body.Stat(); err == nil {
+		size = info.Size()
+	}
+
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", cached.StatusCode, http.StatusText(cached.StatusCode)),
+		StatusCode:    cached.StatusCode,
+		Proto:         notModified.Proto,
+		ProtoMajor:    notModified.ProtoMajor,
+		ProtoMinor:    notModified.ProtoMinor,
+		Header:        header,
+		Body:          body,
+		ContentLength: size,
+		Request:       req,
+	}
+}
+
+func (c *Cache) readEntry(key string) *entry {
+	data, err := ioutil.ReadFile(c.path(key, metaSuffix))
+	if err != nil {
+		return nil
+	}
+	if _, err := os.Stat(c.path(key, bodySuffix)); err != nil {
+		return nil
+	}
+	e := &entry{}
+	if err := json.Unmarshal(data, e); err != nil {
+		return nil
+	}
+	return e
+}
+
+func (c *Cache) store(key string, e *entry, temp string) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	data, err := json.Marshal(e)
+	if err != nil {
+		os.Remove(temp)
+		return
+	}
+	if err := ioutil.WriteFile(c.path(key, metaSuffix), data, 0600); err != nil {
+		os.Remove(temp)
+		return
+	}
+	if err := os.Rename(temp, c.path(key, bodySuffix)); err != nil {
+		os.Remove(temp)
+		os.Remove(c.path(key, metaSuffix))
+		return
+	}
+	c.evict()
+}
+
+// evict removes least recently used entries until the cache fits into maxSize,
+// must be called with the mutex held
+func (c *Cache) evict() {
+	files := c.files()
+
+	var size int64
+	for _, f := range files {
+		size += f.size
+	}
+	if size <= c.maxSize {
+		return
+	}
+
+	sort.Slice(files, func(i, j int) bool {
+		return files[i].modTime.Before(files[j].modTime)
+	})
+
+	for _, f := range files {
+		if size <= c.maxSize {
+			return
+		}
+		os.Remove(c.path(f.key, bodySuffix))
+		os.Remove(c.path(f.key, metaSuffix))
+		size -= f.size
+	}
+}
+
+func (c *Cache) files() []file {
+	infos, err := ioutil.ReadDir(c.dir)
+	if err != nil {
+		return nil
+	}
+
+	files := make([]file, 0, len(infos))
+	for _, info := range infos {
+		if !strings.HasSuffix(info.Name(), bodySuffix) {
+			continue
+		}
+		files = append(files, file{
+			key:     strings.TrimSuffix(info.Name(), bodySuffix),
+			size:    info.Size(),
+			modTime: info.ModTime(),
+		})
+	}
+	return files
+}
+
+func (c *Cache) path(key, suffix string) string {
+	return filepath.Join(c.dir, key+suffix)
+}
+
+func cacheKey(req *http.Request) string {
+	var buffer bytes.Buffer
+	buffer.WriteString(req.URL.String())
+	buffer.WriteString("\n")
+	// Responses depend on the credentials used, never share them
+	buffer.WriteString(req.Header.Get("Authorization"))
+	buffer.WriteString("\n")
+	buffer.WriteString(req.Header.Get("Accept"))
+	hash := sha256.Sum256(buffer.Bytes())
+	return hex.EncodeToString(hash[:])
+}
+
+// cachingReader copies the response body into a temporary file while it is
+// read and commits it to the cache once the body was fully consumed.
+type cachingReader struct {
+	cache    *Cache
+	key      string
+	body     io.ReadCloser
+	temp     *os.File
+	entry    *entry
+	size     int64
+	complete bool
+	failed   bool
+}
+
+func (r *cachingReader) Read(p []byte) (int, error) {
+	n, err := r.body.Read(p)
+	if n > 0 && !r.failed {
+		r.size += int64(n)
+		if r.size > r.cache.maxSize {
+			r.failed = true
+		} else if _, werr := r.temp.Write(p[:n]); werr != nil {
+			r.failed = true
+		}
+	}
+	if err == io.EOF {
+		r.complete = true
+	}
+	return n, err
+}
+
+func (r *cachingReader) Close() error {
+	err := r.body.Close()
+	r.temp.Close()
+	if r.complete && !r.failed {
+		r.cache.store(r.key, r.entry, r.temp.Name())
+	} else {
+		os.Remove(r.temp.Name())
+	}
+	return err
+}
@@ -0,0 +1,142 @@
+package cache
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestEvict(t *testing.T) {
+	type cached struct {
+		key  string
+		size int
+		age  time.Duration
+	}
+	tests := []struct {
+		name    string
+		maxSize int64
+		entries []cached
+		kept    string
+	}{
+		{"fits", 300, []cached{{"a", 100, time.Hour}, {"b", 200, time.Minute}}, "a b"},
+		{"least recently used first", 250, []cached{{"a", 100, time.Minute}, {"b", 100, time.Hour}, {"c", 100, time.Second}}, "a c"},
+		{"until it fits", 100, []cached{{"a", 60, 3 * time.Hour}, {"b", 60, 2 * time.Hour}, {"c", 60, time.Hour}}, "c"},
+		{"larger than the cache", 50, []cached{{"a", 100, time.Hour}}, ""},
+		{"empty entries", 10, []cached{{"a", 0, time.Hour}, {"b", 10, time.Minute}}, "a b"},
+	}
+	for _, test := range tests {
+		c := NewCache(t.TempDir(), test.maxSize)
+		for _, e := range test.entries {
+			if err := ioutil.WriteFile(c.path(e.key, metaSuffix), []byte("{}"), 0600); err != nil {
+				t.Fatal(err)
+			}
+			if err := ioutil.WriteFile(c.path(e.key, bodySuffix), make([]byte, e.size), 0600); err != nil {
+				t.Fatal(err)
+			}
+			modTime := time.Now().Add(-e.age)
+			os.Chtimes(c.path(e.key, bodySuffix), modTime, modTime)
+		}
+		c.evict()
+
+		kept := []string{}
+		for _, f := range c.files() {
+			kept = append(kept, f.key)
+			if _, err := os.Stat(c.path(f.key, metaSuffix)); err != nil {
+				t.Errorf("%s: meta data of %s missing", test.name, f.key)
+			}
+		}
+		sort.Strings(kept)
+		if strings.Join(kept, " ") != test.kept {
+			t.Errorf("%s: kept %v, expected %s", test.name, kept, test.kept)
+		}
+		if metas, _ := ioutil.ReadDir(c.dir); len(metas) != 2*len(kept) {
+			t.Errorf("%s: %d files left for %d entries", test.name, len(metas), len(kept))
+		}
+	}
+}
+
+func TestRoundTrip(t *testing.T) {
+	requests := make(map[string]int)
+	server := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		requests[request.URL.Path]++
+		etag := `"` + request.URL.Path + `"`
+		if request.URL.Path != "/untagged" {
+			writer.Header().Set("ETag", etag)
+		}
+		if request.Header.Get("If-None-Match") == etag {
+			writer.WriteHeader(http.StatusNotModified)
+			return
+		}
+		fmt.Fprint(writer, strings.Repeat("x", len(request.URL.Path)*10))
+	}))
+	defer server.Close()
+
+	c := NewCache(t.TempDir(), 250)
+	client := c.Client()
+	get := func(path string) string {
+		response, err := client.Get(server.URL + path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer response.Body.Close()
+		body, _ := ioutil.ReadAll(response.Body)
+		if response.StatusCode != http.StatusOK {
+			t.Errorf("%s: status %d", path, response.StatusCode)
+		}
+		return string(body)
+	}
+
+	tests := []struct {
+		path    string
+		entries int
+	}{
+		{"/releases", 1},
+		{"/releases", 1},
+		{"/untagged", 1},
+		{"/tags", 2},
+		// The least recently used entry makes room for the 150 bytes of this one
+		{"/milestones-abc", 2},
+		{"/releases", 2},
+		// Responses larger than the cache are passed through
+		{"/a-path-too-large-for-the-cache", 2},
+	}
+	for i, test := range tests {
+		if body := get(test.path); len(body) != len(test.path)*10 {
+			t.Errorf("%d %s: body of %d bytes", i, test.path, len(body))
+		}
+		if stats := c.Stats(); stats.Entries != test.entries || stats.Size > stats.MaxSize {
+			t.Errorf("%d %s: %d entries of %d bytes, expected %d", i, test.path, stats.Entries, stats.Size, test.entries)
+		}
+	}
+	if requests["/releases"] != 3 || requests["/untagged"] != 1 || requests["/tags"] != 1 {
+		t.Errorf("requests %v", requests)
+	}
+}
+
+func TestParseSize(t *testing.T) {
+	tests := []struct {
+		value string
+		size  int64
+		err   bool
+	}{
+		{"512", 512, false},
+		{"512K", 512 * 1024, false},
+		{"100MB", 100 * 1024 * 1024, false},
+		{" 1g ", 1024 * 1024 * 1024, false},
+		{"0", 0, true},
+		{"-1M", 0, true},
+		{"much", 0, true},
+	}
+	for _, test := range tests {
+		size, err := ParseSize(test.value)
+		if (err != nil) != test.err || size != test.size {
+			t.Errorf("%q: size %d (%v), expected %d", test.value, size, err, test.size)
+		}
+	}
+}
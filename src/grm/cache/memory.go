@@ -0,0 +1,158 @@
+package cache
+
+import (
+	"net/http"
+	"sync"
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+)
+
+const (
+	DefaultMemoryMaxSize int64 = 64 * 1024 * 1024
+	// Larger responses, e.g. downloads, are passed through
+	memoryMaxEntrySize int64 = 1024 * 1024
+)
+
+// Memory keeps the API responses of one invocation in memory, so consumers reading the same data,
+// like the report, the notifications and the checks of a report, share a single request. Concurrent
+// requests of the same resource wait for the first one. Only successful and not found responses of
+// GET requests are kept, responses are never shared between credentials.
+type Memory struct {
+	maxSize int64
+	mutex   sync.Mutex
+	entries map[string]*memoryEntry
+	size    int64
+}
+
+type memoryEntry struct {
+	// closed once the first request completed
+	done       chan struct{}
+	stored     bool
+	statusCode int
+	header     http.Header
+	body       []byte
+}
+
+type memoryTransport struct {
+	memory    *Memory
+	transport http.RoundTripper
+}
+
+func NewMemory(maxSize int64) *Memory {
+	if maxSize <= 0 {
+		maxSize = DefaultMemoryMaxSize
+	}
+	return &Memory{
+		maxSize: maxSize,
+		entries: make(map[string]*memoryEntry),
+	}
+}
+
+// Transport returns a round tripper answering from the shared responses, other requests are sent
+// with the given transport
+func (m *Memory) Transport(transport http.RoundTripper) http.RoundTripper {
+	return &memoryTransport{m, transport}
+}
+
+// Reset drops all responses, e.g. before the next refresh of a long running command
+func (m *Memory) Reset() {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	m.entries = make(map[string]*memoryEntry)
+	m.size = 0
+}
+
+func (t *memoryTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Method != http.MethodGet || req.Header.Get("Range") != "" {
+		return t.transport.RoundTrip(req)
+	}
+
+	key := cacheKey(req)
+	t.memory.mutex.Lock()
+	e, ok := t.memory.entries[key]
+	if !ok {
+		e = &memoryEntry{done: make(chan struct{})}
+		t.memory.entries[key] = e
+	}
+	t.memory.mutex.Unlock()
+
+	if ok {
+		select {
+		case <-e.done:
+		case <-req.Context().Done():
+			return nil, req.Context().Err()
+		}
+		if e.stored {
+			return e.response(req), nil
+		}
+		return t.transport.RoundTrip(req)
+	}
+
+	response, err := t.transport.RoundTrip(req)
+	if err != nil || (response.StatusCode != http.StatusOK && response.StatusCode != http.StatusNotFound) ||
+		response.ContentLength > memoryMaxEntrySize {
+		t.memory.forget(key, e)
+		return response, err
+	}
+
+	body, err := ioutil.ReadAll(io.LimitReader(response.Body, memoryMaxEntrySize+1))
+	if err != nil || int64(len(body)) > memoryMaxEntrySize {
+		t.memory.forget(key, e)
+		// Hand out what was read so far followed by the rest of the body
+		response.Body = &prefixedBody{io.MultiReader(bytes.NewReader(body), response.Body), response.Body}
+		return response, nil
+	}
+	response.Body.Close()
+
+	e.statusCode, e.header, e.body = response.StatusCode, response.Header, body
+	t.memory.store(key, e)
+	return e.response(req), nil
+}
+
+// store keeps the response unless the memory is full or was reset in the meantime
+func (m *Memory) store(key string, e *memoryEntry) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	if m.entries[key] == e && m.size+int64(len(e.body)) <= m.maxSize {
+		e.stored = true
+		m.size += int64(len(e.body))
+	} else if m.entries[key] == e {
+		delete(m.entries, key)
+	}
+	close(e.done)
+}
+
+// forget drops a response which can't be shared, waiting requests send their own
+func (m *Memory) forget(key string, e *memoryEntry) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	if m.entries[key] == e {
+		delete(m.entries, key)
+	}
+	close(e.done)
+}
+
+func (e *memoryEntry) response(req *http.Request) *http.Response {
+	header := make(http.Header, len(e.header))
+	for k, v := range e.header {
+		header[k] = v
+	}
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", e.statusCode, http.StatusText(e.statusCode)),
+		StatusCode:    e.statusCode,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          ioutil.NopCloser(bytes.NewReader(e.body)),
+		ContentLength: int64(len(e.body)),
+		Request:       req,
+	}
+}
+
+type prefixedBody struct {
+	io.Reader
+	io.Closer
+}
@@ -0,0 +1,274 @@
+package main
+
+import (
+	"github.com/google/go-github/github"
+	"io"
+	"fmt"
+	"sort"
+	"time"
+	"grm/state"
+	"grm/config"
+	"grm/i18n"
+	"log"
+	"strconv"
+	"strings"
+)
+
+const (
+	// Minimum number of known releases before a cadence is considered established
+	cadenceMinimumReleases = 4
+	// A repository is silent if its last release is older than this multiple of the usual interval
+	cadenceSilenceFactor = 3
+	// Shorter silences are never flagged, even for projects releasing weekly
+	cadenceMinimumSilence = 30 * 24 * time.Hour
+	// Number of new releases on a single day considered a burst
+	cadenceBurstReleases = 5
+)
+
+type historyEntry struct {
+	Tag      string    `json:"tag"`
+	Released time.Time `json:"released"`
+	// Seen is when the release was found first, missing in histories recorded by older versions
+	Seen time.Time `json:"seen,omitempty"`
+}
+
+// firstSeen returns when the release was found first, or its release date for older histories
+func (e historyEntry) firstSeen() time.Time {
+	if e.Seen.IsZero() {
+		return e.Released
+	}
+	return e.Seen
+}
+
+type staleRepository struct {
+	repository string
+	latest     historyEntry
+	threshold  time.Duration
+}
+
+type cadenceAnomaly struct {
+	repository string
+	kind       string
+	interval   time.Duration
+	silence    time.Duration
+	day        time.Time
+	count      int
+}
+
+func (a cadenceAnomaly) message(l *i18n.Locale) string {
+	if a.kind == "burst" {
+		return l.T("%s releases on %s, usually releases every %s", l.Number(a.count), l.Date(a.day), l.Days(a.interval))
+	}
+	return l.T("no release for %s, usually releases every %s", l.Days(a.silence), l.Days(a.interval))
+}
+
+func historyKey(name, repository string) string {
+	return state.Key("history", name, repository)
+}
+
+func readHistory(name, repository string) []historyEntry {
+	history := make([]historyEntry, 0)
+	openState().Get(historyKey(name, repository), &history)
+	return history
+}
+
+// recordHistory merges the releases found by the report into the stored release history of the
+// repository and returns the history together with the releases not seen before
+func recordHistory(name string, rep *repository) ([]historyEntry, []historyEntry) {
+	history := readHistory(name, rep.name)
+	known := make(map[string]bool, len(history))
+	for _, entry := range history {
+		known[entry.Tag] = true
+	}
+
+	added := make([]historyEntry, 0)
+	now := time.Now().UTC()
+	for _, rel := range rep.releases {
+		if !known[rel.name] {
+			entry := historyEntry{Tag: rel.name, Released: rel.created.UTC(), Seen: now}
+			history = append(history, entry)
+			added = append(added, entry)
+		}
+	}
+
+	sort.Slice(history, func(i, j int) bool {
+		return history[i].Released.Before(history[j].Released)
+	})
+
+	if len(added) > 0 {
+		openState().Set(historyKey(name, rep.name), history)
+	}
+	return history, added
+}
+
+// detectAnomalies tracks the release history of all matched repositories and flags repositories which
+// stopped releasing compared to their usual cadence, or suddenly publish a burst of releases. Releases
+// not seen before are collected in the report, except for repositories without previous history.
+// Sub-projects and release channels are release lines of their own, with their own history baseline.
+func detectAnomalies(name string, repositories []*github.Repository, report *reportModel) []cadenceAnomaly {
+	reported := make(map[string]*repository, len(report.repositories))
+	for _, rep := range report.repositories {
+		reported[rep.name] = rep
+	}
+
+	report.added = make(map[string][]historyEntry)
+	anomalies := make([]cadenceAnomaly, 0)
+	for _, repo := range repositories {
+		previous := readHistory(name, repo.GetName())
+		history, added := previous, make([]historyEntry, 0)
+		if rep, ok := reported[repo.GetName()]; ok {
+			history, added = recordHistory(name, rep)
+		}
+
+		prefixes, classifier := readSubprojects(name, repo.GetName()), readChannelClassifier(name, repo.GetName())
+		group := func(entries []historyEntry) map[string][]historyEntry {
+			return groupHistory(prefixes, classifier, repo.GetName(), entries)
+		}
+		known, lines, addedLines := group(previous), group(history), group(added)
+		for _, line := range historyLines(lines) {
+			history, added := lines[line], addedLines[line]
+			firstRun := len(known[line]) == 0
+			if !firstRun && len(added) > 0 {
+				report.added[repo.GetName()] = append(report.added[repo.GetName()], added...)
+			}
+
+			if len(history) < cadenceMinimumReleases {
+				continue
+			}
+
+			interval := medianInterval(history)
+			silence := time.Since(history[len(history)-1].Released)
+			threshold := time.Duration(cadenceSilenceFactor) * interval
+			if threshold < cadenceMinimumSilence {
+				threshold = cadenceMinimumSilence
+			}
+			if silence > threshold {
+				anomalies = append(anomalies, cadenceAnomaly{
+					repository: line,
+					kind:       "silent",
+					interval:   interval,
+					silence:    silence,
+				})
+			}
+
+			// Without previous history all releases are new, a burst can't be told apart from the past
+			if firstRun {
+				continue
+			}
+			if day, count := busiestDay(added); count >= cadenceBurstReleases && interval > 24*time.Hour {
+				anomalies = append(anomalies, cadenceAnomaly{
+					repository: line,
+					kind:       "burst",
+					interval:   interval,
+					day:        day,
+					count:      count,
+				})
+			}
+		}
+	}
+	return anomalies
+}
+
+func medianInterval(history []historyEntry) time.Duration {
+	intervals := make([]time.Duration, 0, len(history)-1)
+	for i := 1; i < len(history); i++ {
+		intervals = append(intervals, history[i].Released.Sub(history[i-1].Released))
+	}
+	sort.Slice(intervals, func(i, j int) bool {
+		return intervals[i] < intervals[j]
+	})
+	return intervals[len(intervals)/2]
+}
+
+func busiestDay(entries []historyEntry) (time.Time, int) {
+	days := make(map[time.Time]int)
+	busiest, count := time.Time{}, 0
+	for _, entry := range entries {
+		t := locale.Time(entry.Released)
+		day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+		days[day]++
+		if days[day] > count {
+			busiest, count = day, days[day]
+		}
+	}
+	return busiest, count
+}
+
+// detectStale reports repositories whose latest known release is older than their stale-after threshold
+func detectStale(name string, repositories []*github.Repository) []staleRepository {
+	stale := make([]staleRepository, 0)
+	for _, repo := range repositories {
+		value, ok := namedSectionGet(name, config.Remote, config.StaleAfter, repo.GetName())
+		if !ok || value == "" {
+			continue
+		}
+		threshold, err := parseAge(value)
+		if err != nil {
+			log.Fatal(fmt.Sprintf("Could not parse %s '%s': ", config.StaleAfter.Name(), value), err)
+		}
+
+		history := readHistory(name, repo.GetName())
+		if len(history) == 0 {
+			continue
+		}
+		// Nightly builds and betas don't keep a repository alive once it had stable releases
+		latest := history[len(history)-1]
+		classifier := readChannelClassifier(name, repo.GetName())
+		for i := len(history) - 1; i >= 0; i-- {
+			if classifier.channel(history[i].Tag) == channelStable {
+				latest = history[i]
+				break
+			}
+		}
+		if time.Since(latest.Released) > threshold {
+			stale = append(stale, staleRepository{repo.GetName(), latest, threshold})
+		}
+	}
+	return stale
+}
+
+// parseAge parses ages like 90d, 12w, 6m or 1y, as well as Go durations like 72h
+func parseAge(value string) (time.Duration, error) {
+	units := map[string]time.Duration{
+		"d": 24 * time.Hour,
+		"w": 7 * 24 * time.Hour,
+		"m": 30 * 24 * time.Hour,
+		"y": 365 * 24 * time.Hour,
+	}
+	value = strings.TrimSpace(value)
+	if len(value) > 1 {
+		if unit, ok := units[value[len(value)-1:]]; ok {
+			count, err := strconv.Atoi(value[:len(value)-1])
+			if err == nil {
+				return time.Duration(count) * unit, nil
+			}
+		}
+	}
+	return time.ParseDuration(value)
+}
+
+func printStale(writer io.Writer, stale []staleRepository) {
+	if len(stale) == 0 {
+		return
+	}
+
+	fmt.Fprintln(writer, locale.T("Stale repositories:"))
+	for _, s := range stale {
+		fmt.Fprintln(writer, locale.T(" * %s: latest release %s (%s) is %s old, threshold %s", s.repository,
+			s.latest.Tag, locale.Date(s.latest.Released), locale.Days(time.Since(s.latest.Released)),
+			locale.Days(s.threshold)))
+	}
+	fmt.Fprintln(writer, "")
+}
+
+func printAnomalies(writer io.Writer, anomalies []cadenceAnomaly) {
+	if len(anomalies) == 0 {
+		return
+	}
+
+	fmt.Fprintln(writer, locale.T("Release cadence anomalies:"))
+	for _, anomaly := range anomalies {
+		fmt.Fprintln(writer, fmt.Sprintf(" * %s: %s", anomaly.repository, anomaly.message(locale)))
+	}
+	fmt.Fprintln(writer, "")
+}
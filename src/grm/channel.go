@@ -0,0 +1,91 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"grm/config"
+)
+
+// Release channels, tags matching neither the beta nor the nightly pattern are stable releases
+const (
+	channelStable  = "stable"
+	channelBeta    = "beta"
+	channelNightly = "nightly"
+)
+
+var channels = []string{channelStable, channelBeta, channelNightly}
+
+// Channel patterns used without beta-pattern and nightly-pattern properties
+const (
+	defaultBetaPattern    = `(?i)[-.+_](alpha|beta|rc|pre|preview)|\d(a|b|rc)\d+$`
+	defaultNightlyPattern = `(?i)nightly|snapshot|[-.+_](dev|canary)\b`
+)
+
+// channelClassifier assigns tags of a repository to release channels
+type channelClassifier struct {
+	beta    *namePattern
+	nightly *namePattern
+}
+
+func readChannelClassifier(name, repository string) channelClassifier {
+	return channelClassifier{
+		beta:    readChannelPattern(name, config.BetaPattern, repository, defaultBetaPattern),
+		nightly: readChannelPattern(name, config.NightlyPattern, repository, defaultNightlyPattern),
+	}
+}
+
+func readChannelPattern(name string, key config.Key, repository, fallback string) *namePattern {
+	value, ok := namedSectionGet(name, config.Remote, key, repository)
+	if !ok || value == "" {
+		value = fallback
+	}
+	pattern, err := compileNamePattern(name, value)
+	if err != nil {
+		log.Fatal(fmt.Sprintf("Cannot compile %s regex: %s: ", key.Name(), value), err)
+	}
+	return pattern
+}
+
+// channel returns the release channel of the tag, nightly builds win over tags looking like betas
+func (c channelClassifier) channel(tag string) string {
+	switch {
+	case c.nightly.MatchString(tag):
+		return channelNightly
+	case c.beta.MatchString(tag):
+		return channelBeta
+	}
+	return channelStable
+}
+
+func knownChannel(channel string) bool {
+	for _, c := range channels {
+		if c == channel {
+			return true
+		}
+	}
+	return false
+}
+
+// filterChannel drops all releases of other channels from the report, the history of the report
+// already recorded them
+func filterChannel(report *reportModel, channel string) {
+	for _, rep := range report.repositories {
+		releases := make([]*release, 0, len(rep.releases))
+		for _, rel := range rep.releases {
+			if rel.channel == channel {
+				releases = append(releases, rel)
+			}
+		}
+		rep.releases = releases
+	}
+}
+
+// releaseLineName names a release line of a repository, releases of the stable channel go without
+// the channel, like repository, repository/sub-project or repository/sub-project (nightly)
+func releaseLineName(repository, subproject, channel string) string {
+	name := subprojectName(repository, subproject)
+	if channel == "" || channel == channelStable {
+		return name
+	}
+	return fmt.Sprintf("%s (%s)", name, channel)
+}
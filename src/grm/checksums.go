@@ -0,0 +1,112 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"net/url"
+	"path"
+	"time"
+	"grm/i18n"
+	"grm/state"
+)
+
+// checksumRecord pins the checksum of a release asset observed first, later observations of other
+// checksums are kept as evidence of a re-uploaded artifact
+type checksumRecord struct {
+	Sha256    string                `json:"sha256"`
+	Url       string                `json:"url"`
+	FirstSeen time.Time             `json:"first_seen"`
+	LastSeen  time.Time             `json:"last_seen"`
+	Changes   []checksumObservation `json:"changes,omitempty"`
+}
+
+type checksumObservation struct {
+	Sha256 string    `json:"sha256"`
+	Seen   time.Time `json:"seen"`
+}
+
+// checksumChange is an asset whose checksum differs from the pinned one, a classic sign of a
+// compromised release
+type checksumChange struct {
+	repository string
+	tag        string
+	asset      string
+	pinned     string
+	observed   string
+	detected   time.Time
+}
+
+func (c checksumChange) message(l *i18n.Locale) string {
+	return l.T("checksum of %s %s changed upstream from %s to %s", c.tag, c.asset, c.pinned, c.observed)
+}
+
+func checksumKey(name, repository, tag, asset string) string {
+	return state.Key("checksums", name, repository, tag, asset)
+}
+
+// observeChecksum records the checksum of a release asset. It returns the change if the checksum
+// differs from the pinned one, a new checksum is only returned the first time it is seen.
+func observeChecksum(name, repository, tag, asset, location, sha256 string) *checksumChange {
+	now := time.Now().UTC()
+	key := checksumKey(name, repository, tag, asset)
+	record := checksumRecord{}
+	if !openState().Get(key, &record) {
+		openState().Set(key, checksumRecord{Sha256: sha256, Url: location, FirstSeen: now, LastSeen: now})
+		return nil
+	}
+
+	record.LastSeen = now
+	if record.Sha256 == sha256 {
+		openState().Set(key, record)
+		return nil
+	}
+	for _, observation := range record.Changes {
+		if observation.Sha256 == sha256 {
+			openState().Set(key, record)
+			return nil
+		}
+	}
+	record.Changes = append(record.Changes, checksumObservation{sha256, now})
+	openState().Set(key, record)
+	return &checksumChange{repository, tag, asset, record.Sha256, sha256, now}
+}
+
+// pinnedChecksum returns the pinned checksum of a release asset
+func pinnedChecksum(name, repository, tag, asset string) (string, bool) {
+	record := checksumRecord{}
+	if !openState().Get(checksumKey(name, repository, tag, asset), &record) {
+		return "", false
+	}
+	return record.Sha256, true
+}
+
+// repinChecksum accepts the current checksum of a release asset after it was verified, the changes
+// stay recorded
+func repinChecksum(name, repository, tag, asset, sha256 string) {
+	key := checksumKey(name, repository, tag, asset)
+	record := checksumRecord{}
+	if openState().Get(key, &record) {
+		record.Sha256 = sha256
+		openState().Set(key, record)
+	}
+}
+
+// assetName names the asset of a download url
+func assetName(location string) string {
+	if u, err := url.Parse(location); err == nil {
+		return path.Base(u.Path)
+	}
+	return path.Base(location)
+}
+
+func printChecksumChanges(writer io.Writer, changes []checksumChange) {
+	if len(changes) == 0 {
+		return
+	}
+
+	fmt.Fprintln(writer, colorize(writer, locale.T("Changed checksums:"), ansiBoldRed))
+	for _, change := range changes {
+		fmt.Fprintln(writer, fmt.Sprintf(" * %s: %s", change.repository, change.message(locale)))
+	}
+	fmt.Fprintln(writer, "")
+}
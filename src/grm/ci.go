@@ -0,0 +1,155 @@
+package main
+
+import (
+	"github.com/google/go-github/github"
+	"context"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+)
+
+// CI states of a release commit, combined from the commit statuses and the check runs
+const (
+	ciSuccess = "success"
+	ciPending = "pending"
+	ciFailure = "failure"
+)
+
+var ciColors = map[string]string{
+	ciSuccess: ansiGreen,
+	ciPending: ansiYellow,
+	ciFailure: ansiBoldRed,
+}
+
+// Check run conclusions counting as red CI, neutral and skipped runs don't count at all
+var failedConclusions = map[string]bool{
+	"failure":         true,
+	"timed_out":       true,
+	"cancelled":       true,
+	"action_required": true,
+}
+
+type checkRun struct {
+	Name       string `json:"name"`
+	Status     string `json:"status"`
+	Conclusion string `json:"conclusion"`
+}
+
+type checkRuns struct {
+	CheckRuns []checkRun `json:"check_runs"`
+}
+
+// resolveCiStatus reads the CI state of the tagged commits of all reported releases
+func resolveCiStatus(ctx context.Context, report *reportModel, client *github.Client) {
+	for _, rep := range report.repositories {
+		for _, rel := range rep.releases {
+			if !rel.published || rel.commit == "" {
+				continue
+			}
+			rel.ciStatus, rel.ciFailures = readCiStatus(ctx, report.account, rep.name, rel.commit, client)
+		}
+	}
+}
+
+// readCiStatus combines the commit statuses and the check runs of the commit. Any failure makes the
+// commit fail, otherwise any pending status or run keeps it pending. Commits without any CI have no
+// state. The names of the failed statuses and runs are returned as well.
+func readCiStatus(ctx context.Context, account, repository, sha string, client *github.Client) (string, []string) {
+	states := make(map[string]bool)
+	failures := make([]string, 0)
+
+	status := readCombinedStatus(ctx, account, repository, sha, client)
+	for _, s := range status.Statuses {
+		switch s.GetState() {
+		case "failure", "error":
+			states[ciFailure] = true
+			failures = append(failures, s.GetContext())
+		case "pending":
+			states[ciPending] = true
+		case "success":
+			states[ciSuccess] = true
+		}
+	}
+
+	for _, run := range readCheckRuns(ctx, account, repository, sha, client) {
+		switch {
+		case run.Status != "completed":
+			states[ciPending] = true
+		case failedConclusions[run.Conclusion]:
+			states[ciFailure] = true
+			failures = append(failures, run.Name)
+		case run.Conclusion == "success":
+			states[ciSuccess] = true
+		}
+	}
+
+	sort.Strings(failures)
+	switch {
+	case states[ciFailure]:
+		return ciFailure, failures
+	case states[ciPending]:
+		return ciPending, nil
+	case states[ciSuccess]:
+		return ciSuccess, nil
+	}
+	return "", nil
+}
+
+func readCombinedStatus(ctx context.Context, account, repository, sha string, client *github.Client) *github.CombinedStatus {
+	for {
+		status, response, err := client.Repositories.GetCombinedStatus(ctx, account, repository, sha, &github.ListOptions{PerPage: 100})
+		if rateLimit(response) {
+			continue
+		}
+
+		if err != nil {
+			log.Fatal(fmt.Sprintf("Could not retrieve commit status of commitId %s: ", sha), err)
+		}
+
+		return status
+	}
+}
+
+// readCheckRuns lists the check runs of the commit, e.g. of Github Actions workflows. The vendored
+// Github client predates the checks API, so the request is built by hand.
+func readCheckRuns(ctx context.Context, account, repository, sha string, client *github.Client) []checkRun {
+	runs := make([]checkRun, 0)
+
+	page := 1
+	for {
+		request, err := client.NewRequest("GET", fmt.Sprintf("repos/%s/%s/commits/%s/check-runs?per_page=100&page=%d",
+			account, repository, sha, page), nil)
+		if err != nil {
+			log.Fatal(fmt.Sprintf("Could not create check runs request for commitId %s: ", sha), err)
+		}
+		request.Header.Set("Accept", "application/vnd.github.antiope-preview+json")
+
+		result := checkRuns{}
+		response, err := client.Do(ctx, request, &result)
+		if rateLimit(response) {
+			continue
+		}
+
+		if err != nil {
+			log.Fatal(fmt.Sprintf("Could not retrieve check runs of commitId %s: ", sha), err)
+		}
+
+		runs = append(runs, result.CheckRuns...)
+
+		if hasMorePages(response) {
+			page++
+			continue
+		}
+
+		return runs
+	}
+}
+
+// ciText formats the CI state for the text report, failed runs are listed by name
+func ciText(rel *release) string {
+	if len(rel.ciFailures) == 0 {
+		return rel.ciStatus
+	}
+	return fmt.Sprintf("%s (%s)", rel.ciStatus, strings.Join(rel.ciFailures, ", "))
+}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"github.com/google/go-github/github"
+	"log"
+	"fmt"
+	"net/http"
+	"grm/config"
+)
+
+// newGithubClient creates an authenticated Github client for the remote definition, backed by the
+// shared HTTP cache, and returns it together with the authenticated username. Each client tracks the
+// rate limit of its credential, clients of different credentials don't wait for each other.
+func newGithubClient(name string) (*github.Client, string) {
+	// A plain access token is portable, e.g. for headless runs in containers without a stable machine id
+	if token, ok := namedSectionGet(name, config.Remote, config.Token, ""); ok && token != "" {
+		username, _ := namedSectionGet(name, config.Remote, config.Username, "")
+		basicAuth := github.BasicAuthTransport{
+			Username:  username,
+			Password:  token,
+			Transport: githubTransport(name, credentialId(username, token)),
+		}
+		return github.NewClient(basicAuth.Client()), username
+	}
+
+	username, ok := namedSectionGet(name, config.Remote, config.Username, "")
+	if !ok {
+		log.Fatal(fmt.Sprintf("Could not retrieve username from config, please run 'grm auth %s'", name))
+	}
+	pass, ok := namedSectionGet(name, config.Remote, config.Password, "")
+	if !ok {
+		log.Fatal(fmt.Sprintf("Could not retrieve password from config, please run 'grm auth %s'", name))
+	}
+
+	salt, ok := namedSectionGet(name, config.Remote, config.Salt, "")
+	if !ok && !isEncryptedWithoutSalt(pass) {
+		log.Fatal(fmt.Sprintf("Could not retrieve salt from config, please run 'grm auth %s'", name))
+	}
+
+	password := decryptSecret(name, pass, salt)
+	basicAuth := github.BasicAuthTransport{
+		Username:  username,
+		Password:  password,
+		Transport: githubTransport(name, credentialId(username, password)),
+	}
+
+	return github.NewClient(basicAuth.Client()), username
+}
+
+func githubTransport(name, credential string) http.RoundTripper {
+	// Responses shared within the invocation count neither as API request nor against the rate limit
+	return responses.Transport(&apiMetricsTransport{name, &rateLimitTransport{credential, withHttpDebugging(tracer.Transport(httpCache))}})
+}
+
+// readRemoteAccount returns the Github account to scan, defaults to the authenticated user
+func readRemoteAccount(name, username string) string {
+	if u, ok := namedSectionGet(name, config.Remote, config.RemoteUser, ""); ok {
+		return u
+	}
+	return username
+}
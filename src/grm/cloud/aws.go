@@ -0,0 +1,275 @@
+package cloud
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// AwsCredentials are resolved like the AWS SDKs do, see ReadAwsCredentials
+type AwsCredentials struct {
+	AccessKeyId     string `json:"AccessKeyId"`
+	SecretAccessKey string `json:"SecretAccessKey"`
+	SessionToken    string `json:"Token"`
+	Region          string `json:"-"`
+}
+
+const (
+	ecsCredentialsHost = "http://169.254.170.2"
+	imdsHost           = "http://169.254.169.254"
+)
+
+// ReadAwsCredentials follows the default credential chain of the AWS SDKs: the environment variables
+// AWS_ACCESS_KEY_ID, AWS_SECRET_ACCESS_KEY and AWS_SESSION_TOKEN, the shared credentials file with the
+// AWS_PROFILE or default profile, the ECS container credentials and finally the EC2 instance role.
+// The region is read from AWS_REGION, AWS_DEFAULT_REGION or the shared config file, default: us-east-1.
+func ReadAwsCredentials() (AwsCredentials, error) {
+	credentials := AwsCredentials{
+		AccessKeyId:     os.Getenv("AWS_ACCESS_KEY_ID"),
+		SecretAccessKey: os.Getenv("AWS_SECRET_ACCESS_KEY"),
+		SessionToken:    os.Getenv("AWS_SESSION_TOKEN"),
+	}
+
+	profile := os.Getenv("AWS_PROFILE")
+	if profile == "" {
+		profile = "default"
+	}
+
+	if credentials.AccessKeyId == "" {
+		file := awsSharedFile("AWS_SHARED_CREDENTIALS_FILE", "credentials")
+		values := readAwsProfile(file, profile)
+		credentials.AccessKeyId = values["aws_access_key_id"]
+		credentials.SecretAccessKey = values["aws_secret_access_key"]
+		credentials.SessionToken = values["aws_session_token"]
+	}
+	if credentials.AccessKeyId == "" {
+		c, err := readRemoteAwsCredentials()
+		if err != nil {
+			return credentials, fmt.Errorf("no AWS credentials found in the environment, the shared credentials file, the container or the instance: %s", err)
+		}
+		credentials = c
+	}
+
+	credentials.Region = os.Getenv("AWS_REGION")
+	if credentials.Region == "" {
+		credentials.Region = os.Getenv("AWS_DEFAULT_REGION")
+	}
+	if credentials.Region == "" {
+		section := "profile " + profile
+		if profile == "default" {
+			section = profile
+		}
+		credentials.Region = readAwsProfile(awsSharedFile("AWS_CONFIG_FILE", "config"), section)["region"]
+	}
+	if credentials.Region == "" {
+		credentials.Region = "us-east-1"
+	}
+	return credentials, nil
+}
+
+func awsSharedFile(variable, name string) string {
+	if file := os.Getenv(variable); file != "" {
+		return file
+	}
+	home, _ := os.UserHomeDir()
+	return filepath.Join(home, ".aws", name)
+}
+
+// readAwsProfile reads the key value pairs of one section of an AWS shared INI file
+func readAwsProfile(file, profile string) map[string]string {
+	values := make(map[string]string)
+	data, err := ioutil.ReadFile(file)
+	if err != nil {
+		return values
+	}
+
+	current := ""
+	for _, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		if strings.HasPrefix(line, "[") && strings.HasSuffix(line, "]") {
+			current = strings.TrimSpace(line[1 : len(line)-1])
+			continue
+		}
+		if current != profile {
+			continue
+		}
+		if tokens := strings.SplitN(line, "=", 2); len(tokens) == 2 {
+			values[strings.TrimSpace(tokens[0])] = strings.TrimSpace(tokens[1])
+		}
+	}
+	return values
+}
+
+// readRemoteAwsCredentials requests credentials from the ECS container endpoint or the EC2 instance metadata
+func readRemoteAwsCredentials() (AwsCredentials, error) {
+	credentials := AwsCredentials{}
+	client := &http.Client{Timeout: 2 * time.Second}
+
+	if path := os.Getenv("AWS_CONTAINER_CREDENTIALS_RELATIVE_URI"); path != "" {
+		req, _ := http.NewRequest(http.MethodGet, ecsCredentialsHost+path, nil)
+		err := getJson(client, req, &credentials)
+		return credentials, err
+	}
+	if u := os.Getenv("AWS_CONTAINER_CREDENTIALS_FULL_URI"); u != "" {
+		req, err := http.NewRequest(http.MethodGet, u, nil)
+		if err != nil {
+			return credentials, err
+		}
+		if token := os.Getenv("AWS_CONTAINER_AUTHORIZATION_TOKEN"); token != "" {
+			req.Header.Set("Authorization", token)
+		}
+		err = getJson(client, req, &credentials)
+		return credentials, err
+	}
+
+	// IMDSv2 requires a session token first
+	req, _ := http.NewRequest(http.MethodPut, imdsHost+"/latest/api/token", nil)
+	req.Header.Set("X-aws-ec2-metadata-token-ttl-seconds", "300")
+	response, err := client.Do(req)
+	if err != nil {
+		return credentials, err
+	}
+	token, _ := ioutil.ReadAll(response.Body)
+	response.Body.Close()
+
+	roles := imdsHost + "/latest/meta-data/iam/security-credentials/"
+	req, _ = http.NewRequest(http.MethodGet, roles, nil)
+	req.Header.Set("X-aws-ec2-metadata-token", string(token))
+	response, err = client.Do(req)
+	if err != nil {
+		return credentials, err
+	}
+	role, _ := ioutil.ReadAll(response.Body)
+	response.Body.Close()
+	if response.StatusCode != http.StatusOK {
+		return credentials, fmt.Errorf("no instance role: %s", response.Status)
+	}
+
+	req, _ = http.NewRequest(http.MethodGet, roles+strings.TrimSpace(string(role)), nil)
+	req.Header.Set("X-aws-ec2-metadata-token", string(token))
+	err = getJson(client, req, &credentials)
+	return credentials, err
+}
+
+func getJson(client *http.Client, req *http.Request, value interface{}) error {
+	response, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode != http.StatusOK {
+		return fmt.Errorf("%s responded with %s", req.URL, response.Status)
+	}
+	return json.NewDecoder(response.Body).Decode(value)
+}
+
+// SignV4 signs the request with AWS Signature Version 4, the payload must be the request body
+func (c AwsCredentials) SignV4(req *http.Request, payload []byte, service string) {
+	c.signV4(req, hashHex(payload), service, time.Now().UTC())
+}
+
+// SignV4Sha256 signs a request with a streamed body, payloadHash is the hex SHA-256 of the body
+func (c AwsCredentials) SignV4Sha256(req *http.Request, payloadHash string, service string) {
+	c.signV4(req, payloadHash, service, time.Now().UTC())
+}
+
+func (c AwsCredentials) signV4(req *http.Request, payloadHash string, service string, now time.Time) {
+	date := now.Format("20060102")
+	timestamp := now.Format("20060102T150405Z")
+
+	req.Header.Set("X-Amz-Date", timestamp)
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+	if c.SessionToken != "" {
+		req.Header.Set("X-Amz-Security-Token", c.SessionToken)
+	}
+
+	headers := map[string]string{"host": req.URL.Host}
+	for name, values := range req.Header {
+		headers[strings.ToLower(name)] = strings.TrimSpace(strings.Join(values, ","))
+	}
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	canonicalHeaders := ""
+	for _, name := range names {
+		canonicalHeaders += name + ":" + headers[name] + "\n"
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		canonicalPath(req.URL),
+		canonicalQuery(req.URL.Query()),
+		canonicalHeaders,
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+
+	scope := fmt.Sprintf("%s/%s/%s/aws4_request", date, c.Region, service)
+	stringToSign := strings.Join([]string{"AWS4-HMAC-SHA256", timestamp, scope, hashHex([]byte(canonicalRequest))}, "\n")
+
+	key := hmacSha256([]byte("AWS4"+c.SecretAccessKey), date)
+	key = hmacSha256(key, c.Region)
+	key = hmacSha256(key, service)
+	key = hmacSha256(key, "aws4_request")
+	signature := hex.EncodeToString(hmacSha256(key, stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		c.AccessKeyId, scope, signedHeaders, signature))
+}
+
+func canonicalPath(u *url.URL) string {
+	path := u.EscapedPath()
+	if path == "" {
+		return "/"
+	}
+	return path
+}
+
+func canonicalQuery(values url.Values) string {
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	pairs := make([]string, 0, len(values))
+	for _, key := range keys {
+		sorted := append([]string{}, values[key]...)
+		sort.Strings(sorted)
+		for _, value := range sorted {
+			pairs = append(pairs, awsEscape(key)+"="+awsEscape(value))
+		}
+	}
+	return strings.Join(pairs, "&")
+}
+
+// awsEscape escapes everything except the unreserved characters, as required by SigV4
+func awsEscape(value string) string {
+	return strings.Replace(url.QueryEscape(value), "+", "%20", -1)
+}
+
+func hashHex(data []byte) string {
+	hash := sha256.Sum256(data)
+	return hex.EncodeToString(hash[:])
+}
+
+func hmacSha256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
@@ -0,0 +1,154 @@
+package cloud
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// Token endpoint of the metadata server available on GCE, GKE and Cloud Run
+	gcpMetadataToken = "http://metadata.google.internal/computeMetadata/v1/instance/service-accounts/default/token"
+	gcpScope         = "https://www.googleapis.com/auth/cloud-platform"
+)
+
+// HttpClient exchanges service account keys for access tokens, grm replaces it with its client logging
+// the requests with --debug-http. The metadata servers are asked with short timeouts of their own.
+var HttpClient = &http.Client{Timeout: 30 * time.Second}
+
+var googleToken = struct {
+	sync.Mutex
+	token   string
+	expires time.Time
+}{}
+
+type googleTokenResponse struct {
+	AccessToken string `json:"access_token"`
+	ExpiresIn   int    `json:"expires_in"`
+}
+
+// GoogleAccessToken follows the application default credentials of the Google SDKs: a token from
+// GOOGLE_OAUTH_ACCESS_TOKEN, the service account key file in GOOGLE_APPLICATION_CREDENTIALS or the
+// default service account of the metadata server. Tokens are reused until shortly before they expire.
+func GoogleAccessToken() (string, error) {
+	if token := os.Getenv("GOOGLE_OAUTH_ACCESS_TOKEN"); token != "" {
+		return token, nil
+	}
+
+	googleToken.Lock()
+	defer googleToken.Unlock()
+	if googleToken.token != "" && time.Now().Before(googleToken.expires) {
+		return googleToken.token, nil
+	}
+
+	var (
+		response googleTokenResponse
+		err      error
+	)
+	if file := os.Getenv("GOOGLE_APPLICATION_CREDENTIALS"); file != "" {
+		response, err = serviceAccountToken(file)
+	} else {
+		response, err = metadataToken()
+	}
+	if err != nil {
+		return "", err
+	}
+
+	googleToken.token = response.AccessToken
+	googleToken.expires = time.Now().Add(time.Duration(response.ExpiresIn)*time.Second - time.Minute)
+	return response.AccessToken, nil
+}
+
+func metadataToken() (googleTokenResponse, error) {
+	token := googleTokenResponse{}
+	req, err := http.NewRequest(http.MethodGet, gcpMetadataToken, nil)
+	if err != nil {
+		return token, err
+	}
+	req.Header.Set("Metadata-Flavor", "Google")
+
+	client := &http.Client{Timeout: 5 * time.Second}
+	if err := getJson(client, req, &token); err != nil {
+		return token, fmt.Errorf("no Google credentials configured and metadata server not available: %s", err)
+	}
+	return token, nil
+}
+
+// serviceAccountToken exchanges a JWT signed with the service account key for an access token
+func serviceAccountToken(file string) (googleTokenResponse, error) {
+	token := googleTokenResponse{}
+	data, err := ioutil.ReadFile(file)
+	if err != nil {
+		return token, err
+	}
+
+	account := struct {
+		Type         string `json:"type"`
+		ClientEmail  string `json:"client_email"`
+		PrivateKey   string `json:"private_key"`
+		PrivateKeyId string `json:"private_key_id"`
+		TokenUri     string `json:"token_uri"`
+	}{}
+	if err := json.Unmarshal(data, &account); err != nil {
+		return token, err
+	}
+	if account.Type != "service_account" {
+		return token, fmt.Errorf("credentials '%s' are no service account key", file)
+	}
+
+	block, _ := pem.Decode([]byte(account.PrivateKey))
+	if block == nil {
+		return token, errors.New("invalid service account private key")
+	}
+	key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return token, err
+	}
+	rsaKey, ok := key.(*rsa.PrivateKey)
+	if !ok {
+		return token, errors.New("service account private key is no RSA key")
+	}
+
+	now := time.Now().Unix()
+	header, _ := json.Marshal(map[string]string{"alg": "RS256", "typ": "JWT", "kid": account.PrivateKeyId})
+	claims, _ := json.Marshal(map[string]interface{}{
+		"iss":   account.ClientEmail,
+		"scope": gcpScope,
+		"aud":   account.TokenUri,
+		"iat":   now,
+		"exp":   now + 3600,
+	})
+	unsigned := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(claims)
+	hash := sha256.Sum256([]byte(unsigned))
+	signature, err := rsa.SignPKCS1v15(rand.Reader, rsaKey, crypto.SHA256, hash[:])
+	if err != nil {
+		return token, err
+	}
+	assertion := unsigned + "." + base64.RawURLEncoding.EncodeToString(signature)
+
+	form := url.Values{
+		"grant_type": {"urn:ietf:params:oauth:grant-type:jwt-bearer"},
+		"assertion":  {assertion},
+	}
+	req, err := http.NewRequest(http.MethodPost, account.TokenUri, strings.NewReader(form.Encode()))
+	if err != nil {
+		return token, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	err = getJson(HttpClient, req, &token)
+	return token, err
+}
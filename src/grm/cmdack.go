@@ -0,0 +1,41 @@
+package main
+
+import (
+	"github.com/jawher/mow.cli"
+	"fmt"
+	"log"
+	"time"
+)
+
+func cmdAck(cmd *cli.Cmd) {
+	cmd.Spec = "REMOTE_REPOSITORY TAG [ --comment=<comment> ] [ --remove ]"
+
+	var (
+		target  = cmd.StringArg("REMOTE_REPOSITORY", "", "The remote definition and repository, e.g. remote/repository")
+		tag     = cmd.StringArg("TAG", "", "The release tag to acknowledge")
+		comment = cmd.StringOpt("comment", "", "A comment, e.g. how the release was adopted")
+		remove  = cmd.BoolOpt("remove", false, "Remove the acknowledgement")
+	)
+
+	cmd.Action = func() {
+		if *target == "" {
+			log.Fatal("No remote repository specified")
+		}
+		if *tag == "" {
+			log.Fatal("No release tag specified")
+		}
+
+		name, repository := splitRemoteRepository(*target)
+		key := acknowledgementKey(name, repository, *tag)
+		if *remove {
+			openState().Delete(key)
+			saveState()
+			fmt.Println(fmt.Sprintf("Removed acknowledgement of %s %s", repository, *tag))
+			return
+		}
+
+		openState().Set(key, acknowledgement{Acknowledged: time.Now().UTC(), Comment: *comment})
+		saveState()
+		fmt.Println(fmt.Sprintf("Acknowledged %s %s", repository, *tag))
+	}
+}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"github.com/jawher/mow.cli"
+	"fmt"
+	"log"
+	"os"
+	"time"
+)
+
+func cmdAudit(cmd *cli.Cmd) {
+	cmd.Spec = "[ NAME ] [ --kind=<kind> ] [ --repository=<repository> ] [ --since=<date> ] [ --format=<format> ]"
+
+	var (
+		name       = cmd.StringArg("NAME", "", "The name of the remote definition, default: all remote definitions")
+		kind       = cmd.StringOpt("kind", "", "Only entries of this kind: notification, download, install or mirror")
+		repository = cmd.StringOpt("repository", "", "Only entries of this repository")
+		since      = cmd.StringOpt("since", "", "Only entries recorded after this date")
+		format     = cmd.StringOpt("format", "text", "Output format: text or json")
+	)
+
+	cmd.Action = func() {
+		if *format == "json" {
+			enableJsonErrors()
+		}
+		if *format != "text" && *format != "json" {
+			log.Fatal(fmt.Sprintf("Unknown audit format '%s', supported formats: text, json", *format))
+		}
+		switch *kind {
+		case "", auditNotify, auditDownload, auditInstall, auditMirror:
+		default:
+			log.Fatal(fmt.Sprintf("Unknown kind '%s', supported kinds: %s, %s, %s, %s", *kind, auditNotify, auditDownload,
+				auditInstall, auditMirror))
+		}
+		locale = readLocale("").In(readTimezone(""))
+
+		after := time.Time{}
+		if *since != "" {
+			after = parseSnapshotTime(*since)
+		}
+
+		entries := make([]auditEntry, 0)
+		for _, entry := range readAudit() {
+			if (*name != "" && entry.Remote != *name) || (*kind != "" && entry.Kind != *kind) ||
+				(*repository != "" && entry.Repository != *repository) || entry.Time.Before(after) {
+				continue
+			}
+			entries = append(entries, entry)
+		}
+
+		var err error
+		if *format == "json" {
+			err = formatAuditJson(os.Stdout, entries)
+		} else {
+			err = formatAuditText(os.Stdout, entries)
+		}
+		if err != nil {
+			log.Fatal("Could not write audit log: ", err)
+		}
+	}
+}
@@ -5,17 +5,23 @@ import (
 	"log"
 	"grm/config"
 	"fmt"
+	"os"
 )
 
 func cmdAuth(cmd *cli.Cmd) {
-	cmd.Spec = "NAME|--all [ -u=<username> ] [ -p=<password> ] [ --yes ]"
+	cmd.Command("list", "Lists the secrets of all remote definitions and how they are encrypted", cmdAuthList)
+	cmd.Command("delete", "Deletes the secrets of a remote definition", cmdAuthDelete)
+
+	cmd.Spec = "[ NAME | --all ] [ -u=<username> ] [ -p=<password> ] [ --yes ] [ --xmpp ] [ --reencrypt ]"
 
 	var (
 		name     = cmd.StringArg("NAME", "", "The name of the remote definition")
-		username = cmd.StringOpt("u username", "", "The username to access Github")
-		password = cmd.StringOpt("p password", "", "The password to access Github")
+		username = cmd.StringOpt("u username", "", "The username, or the key of an app key, to access the provider")
+		password = cmd.StringOpt("p password", "", "The password, token or secret to access the provider")
 		yes      = cmd.BoolOpt("y yes", false, "Accept all questions with yes")
 		all      = cmd.BoolOpt("all", false, "Re-authorize all remote definitions")
+		xmpp     = cmd.BoolOpt("xmpp", false, "Store the password of the XMPP notification sink instead")
+		again    = cmd.BoolOpt("reencrypt", false, "Encrypt the stored passwords again with the configured encryption and new keys")
 	)
 
 	cmd.Action = func() {
@@ -33,17 +39,36 @@ func cmdAuth(cmd *cli.Cmd) {
 
 		definitions := []string{*name}
 		if *all {
-			definitions = configuration.NamedSections(config.Remote)
+			definitions = make([]string, 0)
+			for _, section := range configuration.NamedSections(config.Remote) {
+				definitions = append(definitions, config.ExtractSpecifier(section))
+			}
 		}
 
-		for _, definition := range definitions {
-			specifier := config.ExtractSpecifier(definition)
+		for _, specifier := range definitions {
+
+			if *again {
+				reencryptSecrets(specifier)
+				continue
+			}
+
+			if *xmpp {
+				authXmpp(specifier, *password)
+				continue
+			}
+
+			// The provider decides about the shape of the credentials
+			provider := readProviderName(specifier)
+			shape := releaseProviders[provider].credentials
+			if shape == credentialAnonymous {
+				fmt.Println(fmt.Sprintf("The %s provider of remote definition %s needs no credentials", provider, specifier))
+				continue
+			}
 
 			if configuration != nil {
-				_, oku := configuration.NamedSectionGet(specifier, config.Remote, config.Username, "")
-				_, okp := configuration.NamedSectionGet(specifier, config.Remote, config.Password, "")
+				_, okp := namedSectionGet(specifier, config.Remote, config.Password, "")
 
-				if oku && okp {
+				if okp {
 					if !readOverride(specifier) {
 						// Stop execution
 						fmt.Println("Configuration not changed")
@@ -52,24 +77,92 @@ func cmdAuth(cmd *cli.Cmd) {
 				}
 			}
 
-			fmt.Println(fmt.Sprintf("Configure authorization information for remote definition: %s", specifier))
-			realUsername := *username
-			if realUsername == "" {
-				realUsername = readLine("Username:", false, "")
+			fmt.Println(fmt.Sprintf("Configure the %s credentials of the %s provider for remote definition: %s", shape, provider, specifier))
+			prompts := credentialPrompts[shape]
+			realUsername := ""
+			if prompts[0] != "" {
+				realUsername = *username
+				if realUsername == "" {
+					realUsername = readLine(prompts[0], false, "")
+				}
 			}
 
 			realPassword := *password
 			if realPassword == "" {
-				realPassword = readLine("Password:", true, "")
+				realPassword = readLine(prompts[1], true, "")
 			}
 
-			encryptedPassword, salt := encrypt(realPassword, machineKey)
+			if err := validateCredentials(provider, shape, realUsername, realPassword); err != nil {
+				log.Fatal(fmt.Sprintf("Invalid credentials for remote definition %s: ", specifier), err)
+			}
 
-			configuration.ApplyChanges(func(mutator config.Mutator) {
-				mutator.NamedSectionSet(specifier, config.Remote, config.Username, "", realUsername)
-				mutator.NamedSectionSet(specifier, config.Remote, config.Password, "", encryptedPassword)
-				mutator.NamedSectionSet(specifier, config.Remote, config.Salt, "", salt)
+			applyChanges(func(mutator config.Mutator) {
+				if realUsername != "" {
+					mutator.NamedSectionSet(specifier, config.Remote, config.Username, "", realUsername)
+				} else {
+					mutator.NamedSectionDelete(specifier, config.Remote, config.Username, "")
+				}
+				setEncryptedSecret(mutator, specifier, config.Password, config.Salt, realPassword)
 			})
 		}
 	}
 }
+
+// authXmpp stores the encrypted password of the XMPP account used by xmpp:// notification sinks
+func authXmpp(name, password string) {
+	fmt.Println(fmt.Sprintf("Configure the XMPP password for remote definition: %s", name))
+	if password == "" {
+		password = readLine("XMPP password:", true, "")
+	}
+
+	applyChanges(func(mutator config.Mutator) {
+		setEncryptedSecret(mutator, name, config.XmppPassword, config.XmppSalt, password)
+	})
+}
+
+func cmdAuthList(cmd *cli.Cmd) {
+	cmd.Spec = "[ --format=<format> ]"
+
+	var (
+		format = cmd.StringOpt("format", "text", "Output format: text or json")
+	)
+
+	cmd.Action = func() {
+		if *format == "json" {
+			enableJsonErrors()
+		}
+		if *format != "text" && *format != "json" {
+			log.Fatal(fmt.Sprintf("Unknown secrets format '%s', supported formats: text, json", *format))
+		}
+
+		var err error
+		if *format == "json" {
+			err = formatRemoteSecretsJson(os.Stdout, readRemoteSecrets())
+		} else {
+			err = formatRemoteSecretsText(os.Stdout, readRemoteSecrets())
+		}
+		if err != nil {
+			log.Fatal("Could not write secrets: ", err)
+		}
+	}
+}
+
+func cmdAuthDelete(cmd *cli.Cmd) {
+	cmd.Spec = "NAME [ --yes ]"
+
+	var (
+		name = cmd.StringArg("NAME", "", "The name of the remote definition")
+		yes  = cmd.BoolOpt("y yes", false, "Accept all questions with yes")
+	)
+
+	cmd.Action = func() {
+		if len(configuration.NamedSection(*name, config.Remote)) == 0 {
+			log.Fatal(fmt.Sprintf("Unknown remote definition '%s'", *name))
+		}
+		if !*yes && !readYesNoQuestion(fmt.Sprintf("Delete all passwords and tokens of remote definition '%s'?", *name), false) {
+			fmt.Println("Configuration not changed")
+			return
+		}
+		deleteRemoteSecrets(*name)
+	}
+}
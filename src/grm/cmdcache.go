@@ -0,0 +1,56 @@
+package main
+
+import (
+	"github.com/jawher/mow.cli"
+	"log"
+	"fmt"
+	"grm/cache"
+)
+
+func cmdCache(cmd *cli.Cmd) {
+	cmd.Command("stats", "Shows size and usage of the HTTP cache", cmdCacheStats)
+	cmd.Command("clear", "Removes all entries from the HTTP cache", cmdCacheClear)
+}
+
+func cmdCacheStats(cmd *cli.Cmd) {
+	cmd.Spec = ""
+
+	cmd.Action = func() {
+		stats := httpCache.Stats()
+		fmt.Println(fmt.Sprintf("Location: %s", grmPath("cache")))
+		fmt.Println(fmt.Sprintf("Entries: %d", stats.Entries))
+		fmt.Println(fmt.Sprintf("Size: %s of %s (%.1f%%)", cache.FormatSize(stats.Size), cache.FormatSize(stats.MaxSize),
+			float64(stats.Size)*100/float64(stats.MaxSize)))
+		if stats.Entries > 0 {
+			fmt.Println(fmt.Sprintf("Least recently used: %s", stats.Oldest.Format("2006-01-02 15:04:05")))
+			fmt.Println(fmt.Sprintf("Most recently used: %s", stats.Newest.Format("2006-01-02 15:04:05")))
+		}
+	}
+}
+
+func cmdCacheClear(cmd *cli.Cmd) {
+	cmd.Spec = "[ --yes ]"
+
+	var (
+		yes = cmd.BoolOpt("y yes", false, "Accept all questions with yes")
+	)
+
+	cmd.Action = func() {
+		stats := httpCache.Stats()
+		if stats.Entries == 0 {
+			fmt.Println("Cache is already empty")
+			return
+		}
+
+		if !*yes && !readYesNoQuestion(fmt.Sprintf("The cache contains %d entries (%s), do you really want to "+
+			"clear it?", stats.Entries, cache.FormatSize(stats.Size)), false) {
+			fmt.Println("Cache not changed")
+			return
+		}
+
+		if err := httpCache.Clear(); err != nil {
+			log.Fatal("Could not clear the cache: ", err)
+		}
+		fmt.Println("Cache cleared")
+	}
+}
@@ -5,6 +5,11 @@ import (
 	"log"
 	"grm/config"
 	"fmt"
+	"encoding/json"
+	"io"
+	"os"
+	"sort"
+	"text/tabwriter"
 )
 
 func cmdConfig(cmd *cli.Cmd) {
@@ -12,12 +17,15 @@ func cmdConfig(cmd *cli.Cmd) {
 	cmd.Command("get", "Gets a configuration parameter", cmdConfigGet)
 	cmd.Command("remove", "Removes a configuration parameter", cmdConfigRemove)
 	cmd.Command("list", "Lists all configuration parameters", cmdConfigList)
+	cmd.Command("keys", "Lists and describes all known configuration keys", cmdConfigKeys)
+	cmd.Command("overrides", "Lists or copies repository specific overrides", cmdConfigOverrides)
 }
 
 func cmdConfigSet(cmd *cli.Cmd) {
-	cmd.Spec = "NAME KEY VALUE [ --repository=<repository> ]"
+	cmd.Spec = "(--global | NAME) KEY VALUE [ --repository=<repository> ]"
 
 	var (
+		global     = cmd.BoolOpt("g global", false, "Set a global property instead of a remote definition property")
 		name       = cmd.StringArg("NAME", "", "The name of the remote definition")
 		key        = cmd.StringArg("KEY", "", "The property key to configure")
 		value      = cmd.StringArg("VALUE", "", "The property's new value")
@@ -25,7 +33,7 @@ func cmdConfigSet(cmd *cli.Cmd) {
 	)
 
 	cmd.Action = func() {
-		if *name == "" {
+		if *name == "" && !*global {
 			log.Fatal("No name specified")
 		}
 
@@ -38,23 +46,28 @@ func cmdConfigSet(cmd *cli.Cmd) {
 			log.Fatal(fmt.Sprintf("Unknown key specified: %s", *key))
 		}
 
-		configuration.ApplyChanges(func(mutator config.Mutator) {
-			mutator.NamedSectionSet(*name, config.Remote, realKey, *repository, *value)
+		applyChanges(func(mutator config.Mutator) {
+			if *global {
+				mutator.SectionSet(config.Core, realKey, *repository, *value)
+			} else {
+				mutator.NamedSectionSet(*name, config.Remote, realKey, *repository, *value)
+			}
 		})
 	}
 }
 
 func cmdConfigGet(cmd *cli.Cmd) {
-	cmd.Spec = "NAME KEY [ --repository=<repository> ]"
+	cmd.Spec = "(--global | NAME) KEY [ --repository=<repository> ]"
 
 	var (
+		global     = cmd.BoolOpt("g global", false, "Get a global property instead of a remote definition property")
 		name       = cmd.StringArg("NAME", "", "The name of the remote definition")
 		key        = cmd.StringArg("KEY", "", "The property key to configure")
 		repository = cmd.StringOpt("repository", "", "Set as repository specific override")
 	)
 
 	cmd.Action = func() {
-		if *name == "" {
+		if *name == "" && !*global {
 			log.Fatal("No name specified")
 		}
 
@@ -67,18 +80,34 @@ func cmdConfigGet(cmd *cli.Cmd) {
 			log.Fatal(fmt.Sprintf("Unknown key specified: %s", *key))
 		}
 
+		get := func(specifier string) (string, bool) {
+			if *global {
+				return sectionGet(config.Core, realKey, specifier)
+			}
+			return namedSectionGet(*name, config.Remote, realKey, specifier)
+		}
+
 		if *repository != "" {
-			if v, ok := configuration.NamedSectionGet(*name, config.Remote, realKey, *repository); ok {
+			if v, ok := get(*repository); ok {
 				fmt.Println(fmt.Sprintf("Configured value for key '%s' => %s", *key, v))
 			}
 
 		} else {
-			if v, ok := configuration.NamedSectionGet(*name, config.Remote, realKey, ""); ok {
+			if v, ok := get(""); ok {
 				fmt.Println(fmt.Sprintf("Default value for key '%s' => %s", *key, v))
 			}
 
 			fmt.Println("Existing overrides:")
-			values := configuration.NamedSectionGetOverrides(*name, config.Remote, realKey)
+			var values map[string]string
+			var err error
+			if *global {
+				values, err = configuration.SectionGetOverrides(config.Core, realKey)
+			} else {
+				values, err = configuration.NamedSectionGetOverrides(*name, config.Remote, realKey)
+			}
+			if err != nil {
+				log.Fatal("Could not read the overrides: ", err)
+			}
 			for k, v := range values {
 				fmt.Println(fmt.Sprintf("\t%s => %s", k, v))
 			}
@@ -87,16 +116,17 @@ func cmdConfigGet(cmd *cli.Cmd) {
 }
 
 func cmdConfigRemove(cmd *cli.Cmd) {
-	cmd.Spec = "NAME KEY [ --repository=<repository> ]"
+	cmd.Spec = "(--global | NAME) KEY [ --repository=<repository> ]"
 
 	var (
+		global     = cmd.BoolOpt("g global", false, "Remove a global property instead of a remote definition property")
 		name       = cmd.StringArg("NAME", "", "The name of the remote definition")
 		key        = cmd.StringArg("KEY", "", "The property key to configure")
 		repository = cmd.StringOpt("repository", "", "Set as repository specific override")
 	)
 
 	cmd.Action = func() {
-		if *name == "" {
+		if *name == "" && !*global {
 			log.Fatal("No name specified")
 		}
 
@@ -109,28 +139,195 @@ func cmdConfigRemove(cmd *cli.Cmd) {
 			log.Fatal(fmt.Sprintf("Unknown key specified: %s", *key))
 		}
 
-		configuration.ApplyChanges(func(mutator config.Mutator) {
-			mutator.NamedSectionDelete(*name, config.Remote, realKey, *repository)
+		applyChanges(func(mutator config.Mutator) {
+			if *global {
+				mutator.SectionDelete(config.Core, realKey, *repository)
+			} else {
+				mutator.NamedSectionDelete(*name, config.Remote, realKey, *repository)
+			}
 		})
 	}
 }
 
 func cmdConfigList(cmd *cli.Cmd) {
-	cmd.Spec = "NAME"
+	cmd.Spec = "(--global | NAME)"
 
 	var (
-		name = cmd.StringArg("NAME", "", "The name of the remote definition")
+		global = cmd.BoolOpt("g global", false, "List global properties instead of remote definition properties")
+		name   = cmd.StringArg("NAME", "", "The name of the remote definition")
 	)
 
 	cmd.Action = func() {
-		if *name == "" {
+		if *name == "" && !*global {
 			log.Fatal("No name specified")
 		}
 
 		fmt.Println("Available configuration properties:")
-		values := configuration.NamedSection(*name, config.Remote)
+		values := configuration.Section(config.Core)
+		if !*global {
+			values = configuration.NamedSection(*name, config.Remote)
+		}
 		for k, v := range values {
 			fmt.Println(fmt.Sprintf("%s => %s", k, v))
 		}
 	}
 }
+
+func cmdConfigKeys(cmd *cli.Cmd) {
+	cmd.Spec = "[ --format=<format> ]"
+
+	var (
+		format = cmd.StringOpt("format", "text", "Output format: text or json")
+	)
+
+	cmd.Action = func() {
+		if *format == "json" {
+			enableJsonErrors()
+		}
+		if *format != "text" && *format != "json" {
+			log.Fatal(fmt.Sprintf("Unknown keys format '%s', supported formats: text, json", *format))
+		}
+
+		var err error
+		if *format == "json" {
+			err = formatConfigKeysJson(os.Stdout, config.Keys())
+		} else {
+			err = formatConfigKeysText(os.Stdout, config.Keys())
+		}
+		if err != nil {
+			log.Fatal("Could not write configuration keys: ", err)
+		}
+	}
+}
+
+func formatConfigKeysText(writer io.Writer, keys []config.KeyDescription) error {
+	table := tabwriter.NewWriter(writer, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(table, "Key\tSection\tType\tOverridable\tExportable\tDescription")
+	for _, key := range keys {
+		fmt.Fprintln(table, fmt.Sprintf("%s\t%s\t%s\t%t\t%t\t%s", key.Key.Name(), config.SectionName(key.Section),
+			key.Type, key.Key.Overloadable(), key.Key.Exportable(), key.Description))
+	}
+	return table.Flush()
+}
+
+// jsonConfigKey is a key in the json format of grm config keys
+type jsonConfigKey struct {
+	Name        string `json:"name"`
+	Section     string `json:"section"`
+	Type        string `json:"type"`
+	Overridable bool   `json:"overridable"`
+	Exportable  bool   `json:"exportable"`
+	Description string `json:"description"`
+}
+
+func formatConfigKeysJson(writer io.Writer, keys []config.KeyDescription) error {
+	entries := make([]jsonConfigKey, 0, len(keys))
+	for _, key := range keys {
+		entries = append(entries, jsonConfigKey{
+			Name:        key.Key.Name(),
+			Section:     config.SectionName(key.Section),
+			Type:        key.Type,
+			Overridable: key.Key.Overloadable(),
+			Exportable:  key.Key.Exportable(),
+			Description: key.Description,
+		})
+	}
+	encoder := json.NewEncoder(writer)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(entries)
+}
+
+func cmdConfigOverrides(cmd *cli.Cmd) {
+	cmd.Spec = "(--global | NAME) [ --copy-from=<repository> --to=<repository> ]"
+
+	var (
+		global   = cmd.BoolOpt("g global", false, "Use global properties instead of remote definition properties")
+		name     = cmd.StringArg("NAME", "", "The name of the remote definition")
+		copyFrom = cmd.StringOpt("copy-from", "", "Copy the overrides of this repository")
+		to       = cmd.StringOpt("to", "", "The repository receiving the copied overrides")
+	)
+
+	cmd.Action = func() {
+		if *name == "" && !*global {
+			log.Fatal("No name specified")
+		}
+
+		values := configuration.Section(config.Core)
+		if !*global {
+			values = configuration.NamedSection(*name, config.Remote)
+		}
+		overrides := readOverrides(values)
+
+		if *copyFrom == "" {
+			if len(overrides) == 0 {
+				fmt.Println("No repository specific overrides")
+				return
+			}
+			if err := formatOverridesText(os.Stdout, overrides); err != nil {
+				log.Fatal("Could not write overrides: ", err)
+			}
+			return
+		}
+
+		if *copyFrom == *to {
+			log.Fatal("Cannot copy the overrides of a repository to itself")
+		}
+		copied := make([]configOverride, 0)
+		for _, override := range overrides {
+			if override.repository == *copyFrom {
+				copied = append(copied, override)
+			}
+		}
+		if len(copied) == 0 {
+			log.Fatal(fmt.Sprintf("No overrides found for repository %s", *copyFrom))
+		}
+
+		applyChanges(func(mutator config.Mutator) {
+			for _, override := range copied {
+				if *global {
+					mutator.SectionSet(config.Core, override.key, *to, override.value)
+				} else {
+					mutator.NamedSectionSet(*name, config.Remote, override.key, *to, override.value)
+				}
+				fmt.Println(fmt.Sprintf("Copied %s => %s to %s", override.key.Name(), override.value, *to))
+			}
+		})
+	}
+}
+
+// configOverride is a repository specific override, stored as key:repository
+type configOverride struct {
+	repository string
+	key        config.Key
+	value      string
+}
+
+// readOverrides returns the overrides of known overloadable keys in a section, ordered by repository
+// and key
+func readOverrides(values map[string]string) []configOverride {
+	overrides := make([]configOverride, 0)
+	for k, v := range values {
+		repository := config.ExtractSpecifier(k)
+		key := config.KeyLookup(k)
+		if repository == "" || key == nil || !key.Overloadable() {
+			continue
+		}
+		overrides = append(overrides, configOverride{repository: repository, key: key, value: v})
+	}
+	sort.Slice(overrides, func(i, j int) bool {
+		if overrides[i].repository != overrides[j].repository {
+			return overrides[i].repository < overrides[j].repository
+		}
+		return overrides[i].key.Name() < overrides[j].key.Name()
+	})
+	return overrides
+}
+
+func formatOverridesText(writer io.Writer, overrides []configOverride) error {
+	table := tabwriter.NewWriter(writer, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(table, "Repository\tKey\tValue")
+	for _, override := range overrides {
+		fmt.Fprintln(table, fmt.Sprintf("%s\t%s\t%s", override.repository, override.key.Name(), override.value))
+	}
+	return table.Flush()
+}
@@ -0,0 +1,126 @@
+package main
+
+import (
+	"github.com/jawher/mow.cli"
+	"log"
+	"fmt"
+	"time"
+	"os"
+)
+
+func cmdDownload(cmd *cli.Cmd) {
+	cmd.Spec = "NAME REPOSITORY [ --tag=<tag> ] [ --out=<directory> ] [ --install=<directory> ] " +
+		"[ --require-attestation ] [ --accept-checksum ]"
+
+	var (
+		name       = cmd.StringArg("NAME", "", "The name of the remote definition")
+		repository = cmd.StringArg("REPOSITORY", "", "The repository to download the release from")
+		tag        = cmd.StringOpt("tag", "", "The release tag to download, default: latest release")
+		out        = cmd.StringOpt("out", "", "The download directory, default: inside the grm home directory")
+		install    = cmd.StringOpt("install", "", "Install the assets into this directory, if all scanners passed")
+		attested   = cmd.BoolOpt("require-attestation", false, "Block the installation if an asset has no verified attestation")
+		accept     = cmd.BoolOpt("accept-checksum", false, "Pin the checksums of the downloaded assets if they changed since they were first seen")
+	)
+
+	cmd.Action = func() {
+		if *name == "" {
+			log.Fatal("No remote name specified")
+		}
+
+		if *repository == "" {
+			log.Fatal("No repository specified")
+		}
+
+		client, username := newGithubClient(*name)
+		account := readRemoteAccount(*name, username)
+
+		release := readRelease(account, *repository, *tag, client)
+		releaseTag := *tag
+		if release != nil {
+			releaseTag = release.GetTagName()
+		}
+		if releaseTag == "" {
+			log.Fatal(fmt.Sprintf("No release found for repository %s", *repository))
+		}
+
+		target := *out
+		if target == "" {
+			target = grmPath("downloads", *name, *repository, releaseTag)
+		}
+
+		assets := downloadAssets(*name, account, *repository, releaseTag, release, client, target)
+		if len(assets) == 0 {
+			log.Fatal(fmt.Sprintf("Release %s of %s has no assets and no download url is configured",
+				releaseTag, *repository))
+		}
+
+		for _, asset := range assets {
+			fmt.Println(fmt.Sprintf("Downloaded %s (sha256: %s)", asset.Path, asset.Sha256))
+			auditAsset(auditDownload, *name, *repository, releaseTag, asset, "")
+		}
+
+		// An asset re-uploaded with the same name is a sign of a compromised release
+		checksumsPinned := true
+		for _, asset := range assets {
+			pinned, ok := pinnedChecksum(*name, *repository, releaseTag, asset.Name)
+			observeChecksum(*name, *repository, releaseTag, asset.Name, asset.Url, asset.Sha256)
+			if !ok || pinned == asset.Sha256 {
+				continue
+			}
+			if *accept {
+				repinChecksum(*name, *repository, releaseTag, asset.Name, asset.Sha256)
+				fmt.Println(fmt.Sprintf("Pinned the new checksum of %s (sha256: %s)", asset.Name, asset.Sha256))
+				continue
+			}
+			fmt.Println(fmt.Sprintf("Checksum of %s changed upstream, first seen sha256: %s", asset.Name, pinned))
+			checksumsPinned = false
+		}
+
+		verdicts := scanAssets(*name, *repository, assets)
+		for _, verdict := range verdicts {
+			fmt.Println(verdict.String())
+		}
+
+		attestations := verifyAttestations(account, *repository, assets, client)
+		if len(attestations) == 0 {
+			fmt.Println("No attestations published for the downloaded assets")
+		}
+		for _, attestation := range attestations {
+			fmt.Println(attestation.String())
+		}
+
+		record := downloadRecord{
+			Tag:        releaseTag,
+			Downloaded: time.Now().UTC(),
+			Assets:     assets,
+			Verdicts:   verdicts,
+			Attested:   attestations,
+		}
+
+		passed := scansPassed(verdicts) && checksumsPinned
+		if *attested && !attestationsVerified(assets, attestations) {
+			fmt.Println("Not every asset has a verified attestation")
+			passed = false
+		}
+		if *install != "" && passed {
+			installAssets(assets, *install)
+			for _, asset := range assets {
+				auditAsset(auditInstall, *name, *repository, releaseTag, asset, *install)
+			}
+			record.Installed = *install
+			fmt.Println(fmt.Sprintf("Installed %s %s into '%s'", *repository, releaseTag, *install))
+		}
+
+		openState().Set(downloadRecordKey(*name, *repository, releaseTag), record)
+		saveState()
+
+		if !passed {
+			if *install != "" {
+				fmt.Println("Installation blocked, at least one check failed")
+			} else {
+				fmt.Println("At least one check failed")
+			}
+			os.Exit(1)
+		}
+	}
+}
@@ -10,11 +10,13 @@ import (
 )
 
 func cmdExport(cmd *cli.Cmd) {
-	cmd.Spec = "NAME [ --out=<outfile> ]"
+	cmd.Spec = "NAME [ --out=<outfile> ] [ --sign=<method> [ --key=<key> ] ]"
 
 	var (
 		name = cmd.StringArg("NAME", "", "The name of the remote definition")
 		out  = cmd.StringOpt("out", "", "The export path and filename, default: {NAME}.config")
+		sign = cmd.StringOpt("sign", "", "Sign the export using one of: ssh, minisign, gpg")
+		key  = cmd.StringOpt("key", "", "The signing key (ssh private key, minisign secret key or gpg key id)")
 	)
 
 	cmd.Action = func() {
@@ -43,6 +45,15 @@ func cmdExport(cmd *cli.Cmd) {
 		}
 
 		export.Write(file)
+		file.Close()
 		fmt.Println("Export successful")
+
+		if *sign != "" {
+			signature, err := signFile(*sign, *key, outFile)
+			if err != nil {
+				log.Fatal("Could not sign export file: ", err)
+			}
+			fmt.Println(fmt.Sprintf("Signature written to '%s'", signature))
+		}
 	}
 }
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"github.com/jawher/mow.cli"
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"grm/config"
+)
+
+func cmdForks(cmd *cli.Cmd) {
+	cmd.Spec = "NAME... [ -p=<private_repos> ] [ --repository-pattern=<repository-pattern> ] [ --format=<format> ]"
+
+	var (
+		names             = cmd.StringsArg("NAME", nil, "The names of the remote definitions")
+		private           = cmd.BoolOpt("p private", false, "Analyze private repositories, default: false")
+		repositoryPattern = cmd.StringOpt("repository-pattern", "", "A pattern to match repository names")
+		format            = cmd.StringOpt("format", "text", "Output format: text or json")
+	)
+
+	cmd.Action = func() {
+		if *format == "json" {
+			enableJsonErrors()
+		}
+		if *format != "text" && *format != "json" {
+			log.Fatal(fmt.Sprintf("Unknown forks format '%s', supported formats: text, json", *format))
+		}
+		progressOutput = os.Stderr
+
+		forks := make([]forkDivergence, 0)
+		for _, name := range *names {
+			ctx, span := tracer.Start(context.Background(), "forks "+name)
+			client, username := newGithubClient(name)
+			account := readRemoteAccount(name, username)
+			pattern := *repositoryPattern
+			if r, ok := namedSectionGet(name, config.Remote, config.RepositoryPattern, ""); ok {
+				pattern = r
+			}
+
+			repos := readRepositories(ctx, name, account, readVisibility(name, *private), pattern, client)
+			forks = append(forks, readForks(ctx, name, repos, account, client)...)
+			span.End()
+		}
+		flushTraces()
+
+		if len(forks) == 0 && *format == "text" {
+			fmt.Println("No forks found")
+			return
+		}
+
+		var err error
+		if *format == "json" {
+			err = formatForksJson(os.Stdout, forks)
+		} else {
+			err = formatForksText(os.Stdout, forks, len(*names) > 1)
+		}
+		if err != nil {
+			log.Fatal("Could not write forks: ", err)
+		}
+	}
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"github.com/jawher/mow.cli"
+	"bytes"
+	"log"
+	"fmt"
+	"io"
+	"io/ioutil"
+)
+
+func cmdGenerate(cmd *cli.Cmd) {
+	cmd.Command("brew-formula", "Generates a Homebrew formula from the latest release", cmdGenerateBrewFormula)
+	cmd.Command("scoop-manifest", "Generates a Scoop manifest from the latest release", cmdGenerateScoopManifest)
+}
+
+func cmdGenerateBrewFormula(cmd *cli.Cmd) {
+	cmd.Spec = "REMOTE_REPOSITORY [ --out=<outfile> ] [ --binary=<binary> ]"
+
+	var (
+		target = cmd.StringArg("REMOTE_REPOSITORY", "", "The remote definition and repository, e.g. remote/repository")
+		out    = cmd.StringOpt("out", "", "The formula path and filename, default: {repository}.rb")
+		binary = cmd.StringOpt("binary", "", "The name of the installed binary, default: {repository}")
+	)
+
+	cmd.Action = func() {
+		generatePackageManifest(*target, *out, *binary, ".rb", renderBrewFormula)
+	}
+}
+
+func cmdGenerateScoopManifest(cmd *cli.Cmd) {
+	cmd.Spec = "REMOTE_REPOSITORY [ --out=<outfile> ] [ --binary=<binary> ]"
+
+	var (
+		target = cmd.StringArg("REMOTE_REPOSITORY", "", "The remote definition and repository, e.g. remote/repository")
+		out    = cmd.StringOpt("out", "", "The manifest path and filename, default: {repository}.json")
+		binary = cmd.StringOpt("binary", "", "The name of the installed binary, default: {repository}")
+	)
+
+	cmd.Action = func() {
+		generatePackageManifest(*target, *out, *binary, ".json", renderScoopManifest)
+	}
+}
+
+func generatePackageManifest(target, out, binary, extension string, render func(io.Writer, packageManifest) error) {
+	if target == "" {
+		log.Fatal("No remote repository specified")
+	}
+
+	name, repository := splitRemoteRepository(target)
+	manifest := buildPackageManifest(name, repository)
+	if binary != "" {
+		manifest.Binary = binary
+	}
+
+	outFile := out
+	if outFile == "" {
+		outFile = repository + extension
+	}
+
+	// Rendered first, a release without usable asset leaves no file behind
+	var buffer bytes.Buffer
+	if err := render(&buffer, manifest); err != nil {
+		log.Fatal("Could not render manifest: ", err)
+	}
+	if err := ioutil.WriteFile(outFile, buffer.Bytes(), 0644); err != nil {
+		log.Fatal(fmt.Sprintf("Could not write file '%s': ", outFile), err)
+	}
+	fmt.Println(fmt.Sprintf("Generated %s for %s %s", outFile, repository, manifest.Version))
+}
@@ -0,0 +1,54 @@
+package main
+
+import (
+	"github.com/jawher/mow.cli"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+)
+
+func cmdHealth(cmd *cli.Cmd) {
+	cmd.Spec = "[ --url=<url> ] [ --ready ] [ --timeout=<timeout> ]"
+
+	var (
+		url     = cmd.StringOpt("url", "http://localhost:8080", "The address of serve or watch --health-listen")
+		ready   = cmd.BoolOpt("ready", false, "Check readiness instead of liveness")
+		timeout = cmd.StringOpt("timeout", "10s", "Time to wait for the answer")
+	)
+
+	cmd.Action = func() {
+		wait, err := time.ParseDuration(*timeout)
+		if err != nil {
+			log.Fatal(fmt.Sprintf("Could not parse timeout '%s': ", *timeout), err)
+		}
+		endpoint := strings.TrimSuffix(*url, "/") + "/healthz"
+		if *ready {
+			endpoint = strings.TrimSuffix(*url, "/") + "/readyz"
+		}
+
+		client := &http.Client{Timeout: wait}
+		response, err := client.Get(endpoint)
+		if err != nil {
+			log.Fatal(fmt.Sprintf("Could not reach %s: ", endpoint), err)
+		}
+		defer response.Body.Close()
+
+		report := healthReport{}
+		if err := json.NewDecoder(response.Body).Decode(&report); err != nil {
+			log.Fatal(fmt.Sprintf("Could not parse the answer of %s: ", endpoint), err)
+		}
+
+		lastPoll := "no poll yet"
+		if report.LastPoll != nil {
+			lastPoll = fmt.Sprintf("last poll %s ago", time.Since(*report.LastPoll).Round(time.Second))
+		}
+		summary := fmt.Sprintf("%s, %s, %d polls, %d API errors", report.Status, lastPoll, report.Polls, report.ApiErrors)
+		if response.StatusCode != http.StatusOK {
+			log.Fatal(summary)
+		}
+		fmt.Println(summary)
+	}
+}
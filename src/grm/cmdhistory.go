@@ -0,0 +1,89 @@
+package main
+
+import (
+	"github.com/jawher/mow.cli"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"time"
+	"grm/dataset"
+)
+
+func cmdHistory(cmd *cli.Cmd) {
+	cmd.Command("export", "Exports the releases observed so far for analytics tools", cmdHistoryExport)
+
+	cmd.Spec = "[ TARGET ] [ --new-between=<from> UNTIL ] [ --tz=<timezone> ]"
+
+	var (
+		target = cmd.StringArg("TARGET", "", "The remote definition or remote/repository, default: all remote definitions")
+		from   = cmd.StringOpt("new-between", "", "Only releases seen first between this date and UNTIL, in ISO format YYYY-MM-DD")
+		until  = cmd.StringArg("UNTIL", "", "The end of the --new-between range, exclusive")
+		tz     = cmd.StringOpt("tz", "", "Time zone of dates, e.g. Europe/Madrid, default: UTC")
+	)
+
+	cmd.Action = func() {
+		locale = readLocale("").In(readTimezone(*tz))
+
+		records := readHistories(*target)
+		if *from != "" {
+			records = seenBetween(records, parseHistoryDate(*from), parseHistoryDate(*until))
+		}
+		if len(records) == 0 {
+			fmt.Println("No releases observed")
+			return
+		}
+		if err := printHistory(os.Stdout, records); err != nil {
+			log.Fatal("Could not write history: ", err)
+		}
+	}
+}
+
+func cmdHistoryExport(cmd *cli.Cmd) {
+	cmd.Spec = "[ TARGET ] [ --format=<format> ] [ --out=<outfile> ]"
+
+	var (
+		target = cmd.StringArg("TARGET", "", "The remote definition or remote/repository, default: all remote definitions")
+		format = cmd.StringOpt("format", "parquet", "Output format: parquet or sqlite")
+		out    = cmd.StringOpt("out", "", "The export path and filename, default: releases.parquet or releases.db")
+	)
+
+	cmd.Action = func() {
+		var write func(io.Writer, dataset.Table) error
+		outFile := *out
+		switch *format {
+		case "parquet":
+			write = dataset.WriteParquet
+			if outFile == "" {
+				outFile = "releases.parquet"
+			}
+		case "sqlite":
+			write = dataset.WriteSqlite
+			if outFile == "" {
+				outFile = "releases.db"
+			}
+		default:
+			log.Fatal(fmt.Sprintf("Unknown format '%s', supported: parquet, sqlite", *format))
+		}
+
+		records := readHistories(*target)
+		file, err := os.Create(outFile)
+		if err != nil {
+			log.Fatal(fmt.Sprintf("Could not create export file '%s': ", outFile), err)
+		}
+		defer file.Close()
+
+		if err := write(file, historyTable(records)); err != nil {
+			log.Fatal("Could not write history: ", err)
+		}
+		fmt.Println(fmt.Sprintf("Exported %d releases to %s", len(records), outFile))
+	}
+}
+
+func parseHistoryDate(value string) time.Time {
+	date, err := time.ParseInLocation("2006-01-02", value, locale.Time(time.Now()).Location())
+	if err != nil {
+		log.Fatal(fmt.Sprintf("Could not parse date '%s', expected YYYY-MM-DD: ", value), err)
+	}
+	return date
+}
@@ -9,12 +9,15 @@ import (
 )
 
 func cmdImport(cmd *cli.Cmd) {
-	cmd.Spec = "NAME IMPORTFILE"
+	cmd.Spec = "NAME IMPORTFILE [ --yes ] [ --verify=<method> [ --key=<key> ] [ --signature=<signature> ] ]"
 
 	var (
 		name       = cmd.StringArg("NAME", "", "The name of the remote definition")
 		importFile = cmd.StringArg("IMPORTFILE", "", "The path and filename of the config to import")
 		yes        = cmd.BoolOpt("y yes", false, "Accept all questions with yes")
+		verify     = cmd.StringOpt("verify", "", "Verify the import file's signature using one of: ssh, minisign, gpg")
+		key        = cmd.StringOpt("key", "", "The verification key (ssh allowed signers, minisign public key or gpg keyring)")
+		signature  = cmd.StringOpt("signature", "", "The signature file, default: next to the import file")
 	)
 
 	cmd.Action = func() {
@@ -26,6 +29,13 @@ func cmdImport(cmd *cli.Cmd) {
 			log.Fatal("No import file specified")
 		}
 
+		if *verify != "" {
+			if err := verifyFile(*verify, *key, *importFile, *signature); err != nil {
+				log.Fatal("Signature verification failed, refusing to import: ", err)
+			}
+			fmt.Println("Signature verified")
+		}
+
 		readOverride := func() bool {
 			if *yes {
 				return true
@@ -48,7 +58,7 @@ func cmdImport(cmd *cli.Cmd) {
 		}
 
 		if values, ok := importer.GetKvmap(goini.DefaultSection); ok {
-			configuration.ApplyChanges(func(mutator config.Mutator) {
+			applyChanges(func(mutator config.Mutator) {
 				for k, v := range values {
 					realKey := config.KeyLookup(k)
 					specifier := config.ExtractSpecifier(k)
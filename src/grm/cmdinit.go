@@ -0,0 +1,208 @@
+package main
+
+import (
+	"github.com/google/go-github/github"
+	"github.com/jawher/mow.cli"
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+	"grm/config"
+	"grm/i18n"
+	"grm/notify"
+)
+
+// Releases of the last days shown by the trial report of grm init
+const initTrialDays = 30
+
+func cmdInit(cmd *cli.Cmd) {
+	cmd.Action = func() {
+		fmt.Println("Welcome to the Github Release Monitor, this wizard sets up your first remote definition.")
+		fmt.Println("Press enter to accept the default values in brackets.")
+		fmt.Println("")
+
+		initDirectory()
+		name := initRemote()
+		if name == "" {
+			return
+		}
+		initCredentials(name)
+		initDefaults(name)
+
+		fmt.Println("")
+		if readYesNoQuestion(fmt.Sprintf("Run a trial report of the releases of the last %d days?", initTrialDays), true) {
+			initTrialReport(name)
+		}
+
+		fmt.Println("")
+		fmt.Println("Setup complete, next steps:")
+		fmt.Println(fmt.Sprintf(" * 'grm report %s' for the full release report", name))
+		fmt.Println(fmt.Sprintf(" * 'grm watch %s' for an event stream of new releases", name))
+		fmt.Println(" * 'grm config keys' for all properties to fine-tune the remote definition")
+	}
+}
+
+// initDirectory creates the configuration directory, readable only by the user
+func initDirectory() {
+	directory := grmPath()
+	if _, err := os.Stat(directory); err == nil {
+		fmt.Println(fmt.Sprintf("Using the configuration directory %s", directory))
+		return
+	}
+	if err := os.MkdirAll(directory, 0700); err != nil {
+		log.Fatal(fmt.Sprintf("Could not create the configuration directory %s: ", directory), err)
+	}
+	fmt.Println(fmt.Sprintf("Created the configuration directory %s", directory))
+}
+
+// initRemote adds the remote definition, returns its name or nothing if the user stopped
+func initRemote() string {
+	existing := configuration.NamedSections(config.Remote)
+	if len(existing) > 0 {
+		names := make([]string, 0, len(existing))
+		for _, section := range existing {
+			names = append(names, config.ExtractSpecifier(section))
+		}
+		fmt.Println(fmt.Sprintf("Remote definitions already configured: %s", strings.Join(names, ", ")))
+		if !readYesNoQuestion("Add another remote definition?", false) {
+			fmt.Println("Configuration not changed")
+			return ""
+		}
+	}
+
+	fmt.Println("")
+	name := ""
+	for name == "" {
+		name = readLine("Name of the remote definition: [github]", false, "github")
+		if len(configuration.NamedSection(name, config.Remote)) > 0 {
+			fmt.Println(fmt.Sprintf("Remote definition %s already exists", name))
+			name = ""
+		}
+	}
+	account := ""
+	for account == "" {
+		account = readLine("Github user or organization whose releases are monitored:", false, "")
+	}
+	private := readYesNoQuestion("Include private repositories?", false)
+	repositoryPattern := readLine("Pattern to match repository names: [.*]", false, ".*")
+	if _, err := compileNamePattern(name, repositoryPattern); err != nil {
+		log.Fatal(fmt.Sprintf("Could not compile repository pattern '%s': ", repositoryPattern), err)
+	}
+
+	applyChanges(func(mutator config.Mutator) {
+		mutator.NamedSectionSet(name, config.Remote, config.RemoteUser, "", account)
+		mutator.NamedSectionSet(name, config.Remote, config.ShowPrivate, "", strconv.FormatBool(private))
+		mutator.NamedSectionSet(name, config.Remote, config.RepositoryPattern, "", repositoryPattern)
+	})
+	return name
+}
+
+// initCredentials authenticates with the device flow if an OAuth app is known, or with a personal
+// access token
+func initCredentials(name string) {
+	fmt.Println("")
+	fmt.Println("Github allows 60 requests per hour without authentication, a report of more than a few")
+	fmt.Println("repositories needs credentials.")
+	if !readYesNoQuestion("Authenticate with Github?", true) {
+		fmt.Println(fmt.Sprintf("Skipped, authenticate later with 'grm auth %s'", name))
+		return
+	}
+
+	token := ""
+	if clientId := readOAuthClientId(); clientId != "" && readYesNoQuestion("Authorize GRM in the browser?", true) {
+		client := &http.Client{Timeout: 30 * time.Second, Transport: withHttpDebugging(http.DefaultTransport)}
+		code, err := requestDeviceCode(client, clientId)
+		if err != nil {
+			log.Fatal("Could not start the device authorization: ", err)
+		}
+		fmt.Println(fmt.Sprintf("Open %s and enter the code %s", code.VerificationUri, code.UserCode))
+		fmt.Println("Waiting for the authorization...")
+		if token, err = pollDeviceToken(client, clientId, code); err != nil {
+			log.Fatal("Could not complete the device authorization: ", err)
+		}
+	} else {
+		fmt.Println("Create a personal access token at https://github.com/settings/tokens, the repo scope is")
+		fmt.Println("needed for private repositories only.")
+		for token == "" {
+			token = readLine("Token:", true, "")
+		}
+	}
+
+	username := readAuthenticatedUser(token)
+	applyChanges(func(mutator config.Mutator) {
+		mutator.NamedSectionSet(name, config.Remote, config.Username, "", username)
+		setEncryptedSecret(mutator, name, config.Password, config.Salt, token)
+	})
+	fmt.Println(fmt.Sprintf("Authenticated as %s", username))
+}
+
+// readAuthenticatedUser verifies the token and returns the login of its user
+func readAuthenticatedUser(token string) string {
+	transport := github.BasicAuthTransport{
+		Username:  "x-access-token",
+		Password:  token,
+		Transport: withHttpDebugging(http.DefaultTransport),
+	}
+	user, _, err := github.NewClient(transport.Client()).Users.Get(context.Background(), "")
+	if err != nil {
+		log.Fatal("Could not verify the token: ", err)
+	}
+	return user.GetLogin()
+}
+
+// initDefaults sets the language and time zone of reports and the notification sink of the remote
+func initDefaults(name string) {
+	fmt.Println("")
+	language := ""
+	for language == "" {
+		language = readLine(fmt.Sprintf("Language of reports, one of %s: [en]", strings.Join(i18n.Tags(), ", ")), false, "en")
+		if _, ok := i18n.Lookup(language); !ok {
+			fmt.Println(fmt.Sprintf("Unsupported language '%s'", language))
+			language = ""
+		}
+	}
+	timezone := ""
+	for timezone == "" {
+		timezone = readLine("Time zone of dates in reports, like Europe/Madrid: [UTC]", false, "UTC")
+		if _, err := time.LoadLocation(timezone); err != nil {
+			fmt.Println(fmt.Sprintf("Unknown time zone '%s'", timezone))
+			timezone = ""
+		}
+	}
+
+	fmt.Println("New releases can be published to a notification sink, like")
+	fmt.Println("webhook+https://example.com/hooks/grm or mattermost://mattermost.example.com/hooks/<key>")
+	sink := "-"
+	for sink == "-" {
+		sink = readLine("Notification sink URL, empty for none: []", false, "")
+		if _, err := notify.Parse(sink); sink != "" && err != nil {
+			fmt.Println(fmt.Sprintf("Invalid notification sink '%s': %s", sink, err))
+			sink = "-"
+		}
+	}
+
+	applyChanges(func(mutator config.Mutator) {
+		if language != "en" {
+			mutator.SectionSet(config.Core, config.Language, "", language)
+		}
+		if timezone != "UTC" {
+			mutator.SectionSet(config.Core, config.Timezone, "", timezone)
+		}
+		if sink != "" {
+			mutator.NamedSectionSet(name, config.Remote, config.Publish, "", sink)
+		}
+	})
+}
+
+func initTrialReport(name string) {
+	locale = readLocale("").In(readTimezone(""))
+	since := time.Now().AddDate(0, 0, -initTrialDays).Format("2006-01-02")
+	report := buildReport(context.Background(), name, reportOptions{since: since})
+	if err := formatText(os.Stdout, report); err != nil {
+		log.Fatal("Could not write report: ", err)
+	}
+}
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"github.com/jawher/mow.cli"
+	"context"
+	"fmt"
+	"log"
+	"os"
+)
+
+func cmdMatrix(cmd *cli.Cmd) {
+	cmd.Spec = "NAME... [ -p=<private_repos> ] [ --repository-pattern=<repository-pattern> ] [ --format=<format> ]"
+
+	var (
+		names             = cmd.StringsArg("NAME", nil, "The names of the remote definitions")
+		private           = cmd.BoolOpt("p private", false, "Analyze private repositories, default: false")
+		repositoryPattern = cmd.StringOpt("repository-pattern", "", "A pattern to match repository names")
+		format            = cmd.StringOpt("format", "text", "Output format: text or json")
+	)
+
+	cmd.Action = func() {
+		if *format == "json" {
+			enableJsonErrors()
+		}
+		if *format != "text" && *format != "json" {
+			log.Fatal(fmt.Sprintf("Unknown matrix format '%s', supported formats: text, json", *format))
+		}
+		progressOutput = os.Stderr
+		locale = readLocale("").In(readTimezone(""))
+
+		rows := make([]matrixRow, 0)
+		for _, name := range *names {
+			ctx, span := tracer.Start(context.Background(), "matrix")
+			report := buildReport(ctx, name, reportOptions{
+				private:           *private,
+				repositoryPattern: *repositoryPattern,
+			})
+			span.End()
+			rows = append(rows, buildMatrix(report)...)
+		}
+		flushTraces()
+
+		var err error
+		if *format == "json" {
+			err = formatMatrixJson(os.Stdout, rows)
+		} else {
+			err = formatMatrixText(os.Stdout, rows, len(*names) > 1)
+		}
+		if err != nil {
+			log.Fatal("Could not write matrix: ", err)
+		}
+	}
+}
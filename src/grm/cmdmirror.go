@@ -0,0 +1,150 @@
+package main
+
+import (
+	"github.com/jawher/mow.cli"
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"time"
+	"grm/config"
+)
+
+func cmdMirror(cmd *cli.Cmd) {
+	cmd.Spec = "NAME [ REPOSITORY... ] [ --tag=<tag> ] [ --accept-checksum ] [ --dry-run ]"
+
+	var (
+		name         = cmd.StringArg("NAME", "", "The name of the remote definition")
+		repositories = cmd.StringsArg("REPOSITORY", nil, "The repositories to mirror, default: all repositories of the remote definition")
+		tag          = cmd.StringOpt("tag", "", "The release tag to mirror, default: latest release")
+		accept       = cmd.BoolOpt("accept-checksum", false, "Pin the checksums of the downloaded assets if they changed since they were first seen")
+		dryRun       = cmd.BoolOpt("dry-run", false, "Only list the releases which would be mirrored")
+	)
+
+	cmd.Action = func() {
+		if *name == "" {
+			log.Fatal("No remote name specified")
+		}
+
+		client, username := newGithubClient(*name)
+		account := readRemoteAccount(*name, username)
+
+		// Repositories without a release or mirror target are only reported if they were asked for
+		names := *repositories
+		explicit := len(names) > 0
+		if !explicit {
+			pattern, _ := namedSectionGet(*name, config.Remote, config.RepositoryPattern, "")
+			for _, repository := range readRepositories(context.Background(), *name, account, readVisibility(*name, false), pattern, client) {
+				names = append(names, repository.GetName())
+			}
+		}
+
+		token, _ := namedSectionGet(*name, config.Remote, config.MirrorToken, "")
+		targets := make(map[string]mirrorTarget)
+		mirrored, blocked := 0, 0
+		for _, repository := range names {
+			targetUrl, layout := readMirrorLayout(*name, repository)
+			if targetUrl == "" {
+				if explicit {
+					log.Fatal(fmt.Sprintf("No %s configured for repository %s", config.MirrorTarget.Name(), repository))
+				}
+				continue
+			}
+			target, ok := targets[targetUrl]
+			if !ok {
+				t, err := parseMirrorTarget(targetUrl, token)
+				if err != nil {
+					log.Fatal(fmt.Sprintf("Could not configure %s of %s: ", config.MirrorTarget.Name(), repository), err)
+				}
+				target = t
+				targets[targetUrl] = target
+			}
+
+			release := readRelease(account, repository, *tag, client)
+			if release == nil {
+				if explicit {
+					fmt.Println(fmt.Sprintf("No release found for repository %s", repository))
+				}
+				continue
+			}
+			releaseTag := release.GetTagName()
+			if _, ok := readMirrorRecord(*name, repository, releaseTag); ok {
+				continue
+			}
+			if *dryRun {
+				fmt.Println(fmt.Sprintf("Would mirror %s %s to %s", repository, releaseTag, target))
+				continue
+			}
+
+			directory := grmPath("mirror", *name, repository, releaseTag)
+			assets := downloadAssets(*name, account, repository, releaseTag, release, client, directory)
+			if len(assets) == 0 {
+				fmt.Println(fmt.Sprintf("Release %s of %s has no assets and no download url is configured", releaseTag, repository))
+				continue
+			}
+			for _, asset := range assets {
+				auditAsset(auditDownload, *name, repository, releaseTag, asset, "")
+			}
+
+			// Nothing enters the internal store without passing the checks of grm download
+			checksumsPinned := true
+			for _, asset := range assets {
+				pinned, ok := pinnedChecksum(*name, repository, releaseTag, asset.Name)
+				observeChecksum(*name, repository, releaseTag, asset.Name, asset.Url, asset.Sha256)
+				if !ok || pinned == asset.Sha256 {
+					continue
+				}
+				if *accept {
+					repinChecksum(*name, repository, releaseTag, asset.Name, asset.Sha256)
+					fmt.Println(fmt.Sprintf("Pinned the new checksum of %s (sha256: %s)", asset.Name, asset.Sha256))
+					continue
+				}
+				fmt.Println(fmt.Sprintf("Checksum of %s changed upstream, first seen sha256: %s", asset.Name, pinned))
+				checksumsPinned = false
+			}
+
+			verdicts := scanAssets(*name, repository, assets)
+			for _, verdict := range verdicts {
+				fmt.Println(verdict.String())
+			}
+			if !scansPassed(verdicts) || !checksumsPinned {
+				fmt.Println(fmt.Sprintf("Not mirroring %s %s, at least one check failed", repository, releaseTag))
+				blocked++
+				saveState()
+				continue
+			}
+
+			paths := make([]string, 0, len(assets))
+			for _, asset := range assets {
+				paths = append(paths, mirrorPath(layout, *name, account, repository, releaseTag, asset.Name))
+			}
+			uploaded, err := target.mirror(mirroredRelease{repository, release, assets, paths})
+			for i, asset := range uploaded {
+				auditUpload(*name, repository, releaseTag, assets[i], asset.Location)
+				fmt.Println(fmt.Sprintf("Mirrored %s to %s", asset.Name, asset.Location))
+			}
+			if err != nil {
+				saveState()
+				log.Fatal(fmt.Sprintf("Could not mirror %s %s: ", repository, releaseTag), err)
+			}
+			record := mirrorRecord{Tag: releaseTag, Target: target.String(), Assets: uploaded, Mirrored: time.Now().UTC()}
+			openState().Set(mirrorRecordKey(*name, repository, releaseTag), record)
+			saveState()
+			mirrored++
+
+			if err := os.RemoveAll(directory); err != nil {
+				log.Println(fmt.Sprintf("Could not remove '%s': %s", directory, err))
+			}
+		}
+
+		if *dryRun {
+			return
+		}
+		if mirrored == 0 && blocked == 0 {
+			fmt.Println("No new releases to mirror")
+		}
+		if blocked > 0 {
+			log.Fatal(fmt.Sprintf("%d releases were not mirrored, at least one check failed", blocked))
+		}
+	}
+}
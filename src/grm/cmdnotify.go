@@ -0,0 +1,88 @@
+package main
+
+import (
+	"github.com/jawher/mow.cli"
+	"fmt"
+	"log"
+	"os"
+	"sort"
+	"time"
+	"grm/config"
+)
+
+func cmdNotify(cmd *cli.Cmd) {
+	cmd.Command("replay", "Sends recorded notifications again, e.g. after an outage of a sink", cmdNotifyReplay)
+}
+
+func cmdNotifyReplay(cmd *cli.Cmd) {
+	cmd.Spec = "[ NAME... ] [ --failed ] [ --since=<date> ] [ --dry-run ]"
+
+	var (
+		names  = cmd.StringsArg("NAME", nil, "The names of the remote definitions, default: all remote definitions")
+		failed = cmd.BoolOpt("failed", false, "Only notifications which could not be delivered")
+		since  = cmd.StringOpt("since", "", "Only notifications generated after this date")
+		dryRun = cmd.BoolOpt("dry-run", false, "Only list the notifications which would be sent")
+	)
+
+	cmd.Action = func() {
+		locale = readLocale("").In(readTimezone(""))
+
+		after := time.Time{}
+		if *since != "" {
+			after = parseSnapshotTime(*since)
+		}
+		remotes := *names
+		if len(remotes) == 0 {
+			for _, section := range configuration.NamedSections(config.Remote) {
+				remotes = append(remotes, config.ExtractSpecifier(section))
+			}
+			sort.Strings(remotes)
+		}
+
+		replayed, failures := 0, 0
+		for _, name := range remotes {
+			sinks := make(map[string]*sink)
+			for _, s := range readSinks(name) {
+				sinks[s.String()] = s
+			}
+
+			for _, d := range readDeliveries(name) {
+				if (*failed && d.Status != deliveryFailed) || d.Generated.Before(after) {
+					continue
+				}
+				description := fmt.Sprintf("%s %s %s %s to %s (%s)", name, d.Event.Type, d.Event.Repository, d.Event.Tag,
+					d.Sink, locale.Time(d.Generated).Format("2006-01-02 15:04"))
+				s, ok := sinks[d.Sink]
+				if !ok {
+					fmt.Fprintln(os.Stderr, fmt.Sprintf("Skipped %s, the sink is no longer configured", description))
+					continue
+				}
+				if *dryRun {
+					fmt.Println(fmt.Sprintf("Would send %s", description))
+					continue
+				}
+
+				replayed++
+				if err := redeliver(d, s); err != nil {
+					failures++
+					fmt.Fprintln(os.Stderr, fmt.Sprintf("Could not send %s: %s", description, err))
+					continue
+				}
+				fmt.Println(fmt.Sprintf("Sent %s", description))
+			}
+		}
+		if *dryRun {
+			return
+		}
+		saveState()
+
+		if replayed == 0 {
+			fmt.Println("No notifications to replay")
+			return
+		}
+		if failures > 0 {
+			log.Fatal(fmt.Sprintf("%d of %d notifications could not be delivered, run 'grm notify replay --failed' again later",
+				failures, replayed))
+		}
+	}
+}
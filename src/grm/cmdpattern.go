@@ -0,0 +1,58 @@
+package main
+
+import (
+	"github.com/google/go-github/github"
+	"github.com/jawher/mow.cli"
+	"context"
+	"fmt"
+	"log"
+	"os"
+)
+
+func cmdPattern(cmd *cli.Cmd) {
+	cmd.Command("test", "Shows which configured patterns match repository and tag names", cmdPatternTest)
+}
+
+func cmdPatternTest(cmd *cli.Cmd) {
+	cmd.Spec = "NAME REPOSITORY [ SAMPLE... ] [ --live ]"
+
+	var (
+		name       = cmd.StringArg("NAME", "", "The name of the remote definition")
+		repository = cmd.StringArg("REPOSITORY", "", "The name of the repository")
+		samples    = cmd.StringsArg("SAMPLE", nil, "Sample tag names to test")
+		live       = cmd.BoolOpt("live", false, "Test the tags and milestones read from the Github API")
+	)
+
+	cmd.Action = func() {
+		if len(*samples) == 0 && !*live {
+			log.Fatal("No sample tag names given, please add tag names or use --live")
+		}
+
+		expression, result := matchRepositoryPattern(*name, *repository)
+		fmt.Println(fmt.Sprintf("Repository pattern: %s", orDash(expression)))
+		fmt.Println(fmt.Sprintf("Repository %s: %s", *repository, result))
+		fmt.Println("")
+
+		tags := *samples
+		var milestones []*github.Milestone
+		if *live {
+			provider := readProviderName(*name)
+			if provider != providerGithub {
+				log.Fatal(fmt.Sprintf("--live requires the %s provider, %s uses %s", providerGithub, *name, provider))
+			}
+			ctx := context.Background()
+			client, username := newGithubClient(*name)
+			account := readRemoteAccount(*name, username)
+			tags = append(tags, readTagNames(ctx, account, *repository, client)...)
+			milestones = readMilestones(ctx, account, *repository, client)
+		}
+
+		if len(tags) == 0 {
+			fmt.Println("No tags found")
+			return
+		}
+		if err := formatPatternMatchesText(os.Stdout, matchPatterns(*name, *repository, tags, milestones)); err != nil {
+			log.Fatal("Could not write pattern matches: ", err)
+		}
+	}
+}
@@ -0,0 +1,49 @@
+package main
+
+import (
+	"github.com/jawher/mow.cli"
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"sort"
+	"grm/config"
+)
+
+func cmdPlan(cmd *cli.Cmd) {
+	cmd.Spec = "[ NAME... ] [ --format=<format> ]"
+
+	var (
+		names  = cmd.StringsArg("NAME", nil, "The names of the remote definitions in the order of the run, default: all remote definitions")
+		format = cmd.StringOpt("format", "text", "Output format: text or json")
+	)
+
+	cmd.Action = func() {
+		if *format == "json" {
+			enableJsonErrors()
+		}
+		if *format != "text" && *format != "json" {
+			log.Fatal(fmt.Sprintf("Unknown plan format '%s', supported formats: text, json", *format))
+		}
+		locale = readLocale("").In(readTimezone(""))
+
+		remotes := *names
+		if len(remotes) == 0 {
+			for _, section := range configuration.NamedSections(config.Remote) {
+				remotes = append(remotes, config.ExtractSpecifier(section))
+			}
+			sort.Strings(remotes)
+		}
+
+		plan := buildPlan(context.Background(), remotes)
+		var err error
+		if *format == "json" {
+			err = formatPlanJson(os.Stdout, plan)
+		} else {
+			err = formatPlanText(os.Stdout, plan)
+		}
+		if err != nil {
+			log.Fatal("Could not write plan: ", err)
+		}
+	}
+}
@@ -1,8 +1,11 @@
 package main
 
 import (
+	"github.com/google/go-github/github"
 	"github.com/jawher/mow.cli"
+	"context"
 	"log"
+	"os"
 	"strconv"
 	"grm/config"
 	"fmt"
@@ -11,10 +14,11 @@ import (
 func cmdRemote(cmd *cli.Cmd) {
 	cmd.Command("add", "Adds a remote Github user", cmdRemoteAdd)
 	cmd.Command("remove", "Removes a remote Github user", cmdRemoteRemove)
+	cmd.Command("prune", "Removes overrides of repositories which no longer exist", cmdRemotePrune)
 }
 
 func cmdRemoteAdd(cmd *cli.Cmd) {
-	cmd.Spec = "NAME USER [ -p=<private> ] [ --release-pattern=<release-pattern> ] [ --repository-pattern=<repository-pattern> ] [ --milestone-pattern=<milestone-pattern> ] [ --download-url=<download-url> ]"
+	cmd.Spec = "NAME USER [ -p=<private> ] [ --release-pattern=<release-pattern> ] [ --repository-pattern=<repository-pattern> ] [ --milestone-pattern=<milestone-pattern> ] [ --download-url=<download-url> ] [ --yes ] [ --offline ]"
 
 	var (
 		name              = cmd.StringArg("NAME", "", "The name of the remote definition")
@@ -24,6 +28,8 @@ func cmdRemoteAdd(cmd *cli.Cmd) {
 		repositoryPattern = cmd.StringOpt("repository-pattern", "", "The default pattern to match repository names")
 		milestonePattern  = cmd.StringOpt("milestone-pattern", "", "The default pattern to match milestone names")
 		downloadUrl       = cmd.StringOpt("download-url", "", "The default download url pattern")
+		yes               = cmd.BoolOpt("y yes", false, "Save the remote definition without asking for confirmation")
+		offline           = cmd.BoolOpt("offline", false, "Skip checking the user and previewing the matching repositories")
 	)
 
 	cmd.Action = func() {
@@ -31,7 +37,7 @@ func cmdRemoteAdd(cmd *cli.Cmd) {
 			log.Fatal("No name specified")
 		}
 
-		if *name == "" {
+		if *user == "" {
 			log.Fatal("No remote user specified")
 		}
 
@@ -67,7 +73,27 @@ func cmdRemoteAdd(cmd *cli.Cmd) {
 				false, "http://download.example.com/{account}/{repository}/{version}")
 		}
 
-		configuration.ApplyChanges(func(mutator config.Mutator) {
+		// Misconfigurations like a typo in the user or a pattern matching nothing would only show up
+		// as empty reports
+		confirmByDefault := true
+		if !*offline {
+			client := github.NewClient(newHttpClient())
+			preview, err := readRemotePreview(context.Background(), *name, *user, realRepositoryPattern, client)
+			if err != nil {
+				log.Fatal(fmt.Sprintf("Could not validate remote user %s: ", *user), err)
+			}
+			printRemotePreview(os.Stdout, preview, realRepositoryPattern)
+			if len(preview.matches) == 0 {
+				fmt.Println("No public repository matches the pattern, private repositories are only visible after 'grm auth'")
+				confirmByDefault = false
+			}
+		}
+		if !*yes && !readYesNoQuestion(fmt.Sprintf("Save the remote definition %s?", *name), confirmByDefault) {
+			fmt.Println("Configuration not changed")
+			return
+		}
+
+		applyChanges(func(mutator config.Mutator) {
 			mutator.NamedSectionSet(*name, config.Remote, config.RemoteUser, "", *user)
 			mutator.NamedSectionSet(*name, config.Remote, config.ShowPrivate, "", strconv.FormatBool(showPrivate))
 			mutator.NamedSectionSet(*name, config.Remote, config.ReleasePattern, "", realReleasePattern)
@@ -79,7 +105,7 @@ func cmdRemoteAdd(cmd *cli.Cmd) {
 }
 
 func cmdRemoteRemove(cmd *cli.Cmd) {
-	cmd.Spec = "NAME"
+	cmd.Spec = "NAME [ --yes ]"
 
 	var (
 		name = cmd.StringArg("NAME", "", "The name of the remote definition")
@@ -107,8 +133,54 @@ func cmdRemoteRemove(cmd *cli.Cmd) {
 			}
 		}
 
-		configuration.ApplyChanges(func(mutator config.Mutator) {
+		applyChanges(func(mutator config.Mutator) {
 			mutator.NamedDelete(*name, config.Remote)
 		})
 	}
 }
+
+func cmdRemotePrune(cmd *cli.Cmd) {
+	cmd.Spec = "NAME [ --dry-run ] [ --yes ]"
+
+	var (
+		name   = cmd.StringArg("NAME", "", "The name of the remote definition")
+		dryRun = cmd.BoolOpt("n dry-run", false, "Only lists the overrides of repositories which no longer exist")
+		yes    = cmd.BoolOpt("y yes", false, "Accept all questions with yes")
+	)
+
+	cmd.Action = func() {
+		if len(configuration.NamedSection(*name, config.Remote)) == 0 {
+			log.Fatal(fmt.Sprintf("Remote definition %s does not exist", *name))
+		}
+		if provider := readProviderName(*name); provider != providerGithub {
+			log.Fatal(fmt.Sprintf("Remote definition %s uses the %s provider, only Github remote definitions can be pruned", *name, provider))
+		}
+		progressOutput = os.Stderr
+
+		ctx, span := tracer.Start(context.Background(), "prune "+*name)
+		client, username := newGithubClient(*name)
+		dead := readDeadRepositories(ctx, *name, readRemoteAccount(*name, username), client)
+		span.End()
+		flushTraces()
+
+		if len(dead) == 0 {
+			fmt.Println("All overridden repositories exist")
+			return
+		}
+
+		fmt.Println(fmt.Sprintf("%d overridden repositories no longer exist:", len(dead)))
+		printDeadRepositories(os.Stdout, dead)
+		if *dryRun {
+			return
+		}
+		if !*yes && !readYesNoQuestion("Do you want to clean up the overrides?", false) {
+			fmt.Println("Configuration not changed")
+			return
+		}
+
+		applyChanges(func(mutator config.Mutator) {
+			pruneDeadRepositories(mutator, *name, dead)
+		})
+		fmt.Println("Run 'grm undo' to restore the overrides")
+	}
+}
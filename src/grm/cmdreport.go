@@ -7,7 +7,6 @@ import (
 	"context"
 	"fmt"
 	"strconv"
-	"regexp"
 	"time"
 	"github.com/araddon/dateparse"
 	"sync"
@@ -16,101 +15,338 @@ import (
 	"strings"
 	"net/http"
 	"grm/config"
+	"os"
+	"sort"
+	"bytes"
+	"io"
 )
 
 func cmdReport(cmd *cli.Cmd) {
-	cmd.Spec = "NAME  [ -p=<private_repos> ] [ --repository-pattern=<repository-pattern> ] [ --since=<since> ]"
+	cmd.Spec = "[ NAME ] [ -p=<private_repos> ] [ --repository-pattern=<repository-pattern> ] [ --since=<since> ] " +
+		"[ --check-licenses ] [ --format=<format> ] [ --checksums ] [ --update-nix=<directory> ] [ --min-severity=<severity> ] " +
+		"[ --with-metadata ] [ --channel=<channel> ] [ --with-ci-status ] [ --lang=<language> ] [ --tz=<timezone> ] " +
+		"[ --cached ] [ --max-age=<age> ] [ --validate-output ] " +
+		"[ --filter=<expression> ] [ --page=<page> ] [ --page-size=<size> ] [ --redact-private ]"
 
 	var (
 		name              = cmd.StringArg("NAME", "", "The name of the remote definition")
 		private           = cmd.BoolOpt("p private", false, "Analyze private repositories, default: false")
 		repositoryPattern = cmd.StringOpt("repository-pattern", "", "A pattern to match repository names")
 		since             = cmd.StringOpt("since", "", "Date of search begin in ISO format YYYY-MM-DD")
+		licenses          = cmd.BoolOpt("check-licenses", false, "Check repository licenses for changes and against the license policy")
+		format            = cmd.StringOpt("format", "text", "Output format: text, html, json, terraform, terraform-json, ansible, nvchecker or ics")
+		checksums         = cmd.BoolOpt("checksums", false, "Download releases not downloaded before to calculate their checksums")
+		updateNix         = cmd.StringOpt("update-nix", "", "Update versions and hashes of the nix expressions in this directory")
+		lang              = cmd.StringOpt("lang", "", "Language of the report, e.g. de, es or fr, default: en")
+		tz                = cmd.StringOpt("tz", "", "Time zone of dates in the report, e.g. Europe/Madrid, default: UTC")
+		minSeverity       = cmd.StringOpt("min-severity", "", "Only report releases of at least this severity: patch, minor, major or breaking")
+		withMetadata      = cmd.BoolOpt("with-metadata", false, "Show description, stars, open issues, license and topics of the repositories")
+		channel           = cmd.StringOpt("channel", "", "Only report releases of this channel: stable, beta or nightly")
+		withCiStatus      = cmd.BoolOpt("with-ci-status", false, "Show the combined CI status of the tagged commit of each release")
+		cached            = cmd.BoolOpt("cached", false, "Reuse the last report generated with the same options if it is fresh")
+		maxAge            = cmd.StringOpt("max-age", "", "Maximum age of a reused report, e.g. 15m or 1h, implies --cached, default: 1h")
+		validate          = cmd.BoolOpt("validate-output", false, "Validate the json report against its schema, see grm schema report")
+		filter            = cmd.StringOpt("filter", "", "Only report releases matching the expression, e.g. 'repo=~terraform && prerelease==false'")
+		page              = cmd.IntOpt("page", 0, "Only report this page of the releases, starting with 1")
+		pageSize          = cmd.IntOpt("page-size", 0, fmt.Sprintf("Releases per page, implies --page=1, default: %d with --page", defaultReportPageSize))
+		redact            = cmd.BoolOpt("redact-private", false, "Replace the names, notes and links of private repositories with hashes, e.g. to share the report")
 	)
 
+	cmd.Command("diff", "Compares two json reports and prints the changes", cmdReportDiff)
+
 	cmd.Action = func() {
+		if *format == "json" || *format == "terraform-json" {
+			enableJsonErrors()
+		}
 		if *name == "" {
 			log.Fatal("No remote name specified")
 		}
 
-		username, ok := configuration.NamedSectionGet(*name, config.Remote, config.Username, "")
+		formatter, ok := reportFormats[*format]
 		if !ok {
-			log.Fatal(fmt.Sprintf("Could not retrieve username from config, please run 'grm auth %s'", *name))
+			log.Fatal(fmt.Sprintf("Unknown report format '%s', supported formats: %s", *format, reportFormatNames()))
 		}
-		pass, ok := configuration.NamedSectionGet(*name, config.Remote, config.Password, "")
-		if !ok {
-			log.Fatal(fmt.Sprintf("Could not retrieve password from config, please run 'grm auth %s'", *name))
+		if *format != "text" || *updateNix != "" {
+			progressOutput = os.Stderr
+		}
+		locale = readLocale(*lang).In(readTimezone(*tz))
+		if *minSeverity != "" && severityRank(*minSeverity) < 0 {
+			log.Fatal(fmt.Sprintf("Unknown severity '%s', supported severities: %s", *minSeverity, strings.Join(severities, ", ")))
+		}
+		if *channel != "" && !knownChannel(*channel) {
+			log.Fatal(fmt.Sprintf("Unknown channel '%s', supported channels: %s", *channel, strings.Join(channels, ", ")))
 		}
 
-		salt, ok := configuration.NamedSectionGet(*name, config.Remote, config.Salt, "")
-		if !ok {
-			log.Fatal(fmt.Sprintf("Could not retrieve salt from config, please run 'grm auth %s'", *name))
+		age := defaultReportMaxAge
+		if *maxAge != "" {
+			a, err := parseAge(*maxAge)
+			if err != nil {
+				log.Fatal(fmt.Sprintf("Could not parse max age '%s': ", *maxAge), err)
+			}
+			age, *cached = a, true
+		}
+		if *cached && *updateNix != "" {
+			log.Fatal("Cached reports cannot update nix expressions, please remove --update-nix")
+		}
+		if *validate && *format != "json" {
+			log.Fatal("Only json reports can be validated, please add --format json")
+		}
+		var releaseFilter releaseFilter
+		if *filter != "" {
+			f, err := parseReleaseFilter(*filter)
+			if err != nil {
+				log.Fatal(fmt.Sprintf("Could not parse filter '%s': ", *filter), err)
+			}
+			releaseFilter = f
+		}
+		if *page < 0 || *pageSize < 0 {
+			log.Fatal("The page and the page size have to be positive")
+		}
+		if *page > 0 && *pageSize == 0 {
+			*pageSize = defaultReportPageSize
+		}
+		if *pageSize > 0 && *page == 0 {
+			*page = 1
 		}
 
-		basicAuth := github.BasicAuthTransport{
-			Username: username,
-			Password: decrypt(pass, salt, machineKey),
+		output := &recordingWriter{file: os.Stdout}
+		cacheKey := reportCacheKey([]string{*name, strconv.FormatBool(*private), *repositoryPattern, *since,
+			strconv.FormatBool(*licenses), *format, strconv.FormatBool(*checksums), *minSeverity,
+			strconv.FormatBool(*withMetadata), *channel, strconv.FormatBool(*withCiStatus), *lang, *tz, *filter,
+			strconv.Itoa(*page), strconv.Itoa(*pageSize), strconv.FormatBool(*redact)}, output.Terminal())
+		if *cached {
+			if data, ok := readCachedReport(cacheKey, age); ok {
+				if *validate {
+					validateOutput("report", data)
+				}
+				os.Stdout.Write(data)
+				return
+			}
 		}
 
-		client := github.NewClient(basicAuth.Client())
+		started := time.Now()
+		ctx, span := tracer.Start(context.Background(), "report")
+		report := buildReport(ctx, *name, reportOptions{
+			private:           *private,
+			repositoryPattern: *repositoryPattern,
+			since:             *since,
+			licenses:          *licenses,
+			checksums:         *format != "text" || *updateNix != "",
+			download:          *checksums || *updateNix != "",
+			metadata:          *withMetadata,
+			ciStatus:          *withCiStatus,
+		})
+		report.minSeverity = *minSeverity
+		if *channel != "" {
+			filterChannel(report, *channel)
+		}
 
-		remoteAccount := username
-		showPrivate := *private
-		repositoryPattern := *repositoryPattern
-		if r, ok := configuration.NamedSectionGet(*name, config.Remote, config.RepositoryPattern, ""); ok {
-			repositoryPattern = r
+		if *updateNix != "" {
+			updateNixExpressions(*updateNix, report)
 		}
+		// Recorded before filtering and paging, report diff compares complete reports
+		recordReportSnapshot(report)
 
-		date := time.Date(1970, 1, 1, 0, 0, 0, 0, time.UTC)
-		if *since != "" {
-			d, err := dateparse.ParseIn(*since, time.UTC)
-			if err != nil {
-				log.Fatal("Could not parse since data", err)
-			}
-			date = d
+		if releaseFilter != nil {
+			filterReport(report, releaseFilter)
+		}
+		total := 0
+		if *page > 0 {
+			total = paginateReport(report, *page, *pageSize)
+		}
+		if *redact {
+			redactPrivate(report)
 		}
 
-		if u, ok := configuration.NamedSectionGet(*name, config.Remote, config.RemoteUser, ""); ok {
-			remoteAccount = u
+		writer := io.Writer(output)
+		validated := &bytes.Buffer{}
+		if *validate {
+			writer = validated
 		}
-		if p, ok := configuration.NamedSectionGet(*name, config.Remote, config.ShowPrivate, ""); ok {
-			sp, err := strconv.ParseBool(p)
-			if err != nil {
-				showPrivate = false
-			} else {
-				showPrivate = sp
+		if err := formatter(writer, report); err != nil {
+			log.Fatal("Could not write report: ", err)
+		}
+		if *validate {
+			validateOutput("report", validated.Bytes())
+			output.Write(validated.Bytes())
+		}
+		writeCachedReport(cacheKey, output.buffer.Bytes())
+		if *page > 0 {
+			pages := (total + *pageSize - 1) / *pageSize
+			fmt.Fprintln(progressOutput, fmt.Sprintf("Page %d of %d, %d releases", *page, pages, total))
+		}
+
+		span.End()
+
+		recordReportMetrics(report, started)
+		pushMetrics(*name)
+		flushTraces()
+	}
+}
+
+func cmdReportDiff(cmd *cli.Cmd) {
+	cmd.Spec = "REPORT... [ --against-state=<timestamp> ] [ --format=<format> ]"
+
+	var (
+		reports      = cmd.StringsArg("REPORT", nil, "The old and the new json report, only the new one with --against-state, - reads from stdin")
+		againstState = cmd.StringOpt("against-state", "", "Compare the report with the one recorded at or before this time, e.g. 2024-03-01 or 2024-03-01T08:00:00Z")
+		format       = cmd.StringOpt("format", "text", "Output format: text or json")
+	)
+
+	cmd.Action = func() {
+		if *format == "json" {
+			enableJsonErrors()
+		}
+		if *format != "text" && *format != "json" {
+			log.Fatal(fmt.Sprintf("Unknown diff format '%s', supported formats: text, json", *format))
+		}
+		locale = readLocale("").In(readTimezone(""))
+
+		var old, current jsonReport
+		switch {
+		case *againstState != "" && len(*reports) == 1:
+			current = readJsonReport((*reports)[0])
+			at := parseSnapshotTime(*againstState)
+			snapshot, ok := readReportSnapshot(current.Remote, at)
+			if !ok {
+				log.Fatal(fmt.Sprintf("No report of %s recorded at or before %s", current.Remote, at.Format(time.RFC3339)))
 			}
+			old = snapshot
+		case *againstState == "" && len(*reports) == 2:
+			old, current = readJsonReport((*reports)[0]), readJsonReport((*reports)[1])
+		case *againstState != "":
+			log.Fatal("Only the new report can be compared with --against-state")
+		default:
+			log.Fatal("Two reports are required, the old and the new one")
+		}
+		if old.Remote != current.Remote {
+			log.Fatal(fmt.Sprintf("Cannot compare reports of different remote definitions: %s and %s", old.Remote, current.Remote))
 		}
 
-		visibility := "public"
-		if showPrivate {
-			visibility = "all"
+		diff := diffReports(old, current)
+		var err error
+		if *format == "json" {
+			err = formatReportDiffJson(os.Stdout, diff)
+		} else {
+			err = formatReportDiffText(os.Stdout, diff)
+		}
+		if err != nil {
+			log.Fatal("Could not write diff: ", err)
 		}
+	}
+}
 
-		fmt.Print("Reading repositories... ")
-		repos := readRepositories(*name, remoteAccount, visibility, repositoryPattern, client)
-		fmt.Println("done.")
+type reportOptions struct {
+	private           bool
+	repositoryPattern string
+	since             string
+	licenses          bool
+	checksums         bool
+	download          bool
+	metadata          bool
+	ciStatus          bool
+}
 
-		repositories := selectRepositories(repos, *name, remoteAccount, date, client)
-		fmt.Println(fmt.Sprintf("Found %d repositories", len(repositories)))
-		for _, rep := range repositories {
-			for _, rel := range rep.releases {
-				if rel.milestone != nil {
-					fmt.Println(fmt.Sprintf("New %s release: %s (%s)", rep.name, rel.name, rel.created.Format("2006-01-02")))
-					fmt.Println("Release Notes: " + rel.milestoneUrl)
-					if rel.downloadUrl != "" {
-						fmt.Println("Download: " + rel.downloadUrl)
-					}
-					fmt.Println("")
-				}
-			}
+// buildReport scans the remote account and collects the releases of all matching repositories
+func buildReport(ctx context.Context, name string, options reportOptions) *reportModel {
+	ctx, span := tracer.Start(ctx, "remote "+name)
+	defer span.End()
+
+	repositoryPattern := options.repositoryPattern
+	if r, ok := namedSectionGet(name, config.Remote, config.RepositoryPattern, ""); ok {
+		repositoryPattern = r
+	}
+
+	date := time.Date(1970, 1, 1, 0, 0, 0, 0, time.UTC)
+	if options.since != "" {
+		d, err := dateparse.ParseIn(options.since, locale.Time(time.Now()).Location())
+		if err != nil {
+			log.Fatal("Could not parse since data", err)
 		}
+		date = d
 	}
+
+	provider := readProviderName(name)
+	releaseProvider := releaseProviders[provider]
+	span.SetAttribute("remote", name)
+	span.SetAttribute("provider", provider)
+	skipUnsupportedOptions(provider, releaseProvider.capabilities, &options)
+
+	requests := readApiRequests(name)
+	report := releaseProvider.build(ctx, name, options, repositoryPattern, date)
+	if provider == providerGithub {
+		recordPlan(name, len(report.repositories), readApiRequests(name)-requests)
+	}
+	if options.checksums {
+		resolveChecksums(report, options.download)
+		// Keeps the observed checksums
+		saveState()
+	}
+	span.SetAttribute("repositories", len(report.repositories))
+	return report
 }
 
-func readMilestones(account, repository string, client *github.Client) []*github.Milestone {
-	ctx := context.Background()
+// buildGithubReport reads the repositories of the remote account with the Github API
+func buildGithubReport(ctx context.Context, name string, options reportOptions, repositoryPattern string, date time.Time) *reportModel {
+	ctx, span := tracer.Start(ctx, "github "+name)
+	defer span.End()
+
+	client, username := newGithubClient(name)
+
+	remoteAccount := readRemoteAccount(name, username)
+	span.SetAttribute("account", remoteAccount)
+
+	fmt.Fprint(progressOutput, "Reading repositories... ")
+	repos := readRepositories(ctx, name, remoteAccount, readVisibility(name, options.private), repositoryPattern, client)
+	fmt.Fprintln(progressOutput, "done.")
 
+	report := &reportModel{
+		name:         name,
+		account:      remoteAccount,
+		repositories: selectRepositories(ctx, repos, name, remoteAccount, date, client),
+	}
+
+	if options.metadata {
+		enrichRepositories(ctx, report, client)
+	}
+	if options.ciStatus {
+		resolveCiStatus(ctx, report, client)
+	}
+
+	report.anomalies = detectAnomalies(name, repos, report)
+	report.stale = detectStale(name, repos)
+	report.maintainers = detectMaintainerChanges(ctx, name, remoteAccount, repos, client)
+	report.announcements = detectAnnouncements(ctx, name, remoteAccount, repos, date, client)
+
+	_, hasPolicy := namedSectionGet(name, config.Remote, config.LicensePolicy, "")
+	if options.licenses || hasPolicy {
+		report.licenses = checkLicenses(name, remoteAccount, repos, client)
+		report.checked = true
+	}
+	saveState()
+	return report
+}
+
+// readVisibility returns the visibility of the repositories to read, the show-private property of the
+// remote definition takes precedence over the private option
+func readVisibility(name string, private bool) string {
+	showPrivate := private
+	if p, ok := namedSectionGet(name, config.Remote, config.ShowPrivate, ""); ok {
+		sp, err := strconv.ParseBool(p)
+		if err != nil {
+			showPrivate = false
+		} else {
+			showPrivate = sp
+		}
+	}
+
+	if showPrivate {
+		return "all"
+	}
+	return "public"
+}
+
+func readMilestones(ctx context.Context, account, repository string, client *github.Client) []*github.Milestone {
 	milestones := make([]*github.Milestone, 0)
 
 	page := 1
@@ -144,11 +380,11 @@ func readMilestones(account, repository string, client *github.Client) []*github
 	}
 }
 
-func selectRepositories(repositories []*github.Repository, name, account string, since time.Time, client *github.Client) []*repository {
+func selectRepositories(ctx context.Context, repositories []*github.Repository, name, account string, since time.Time, client *github.Client) []*repository {
 	tasks := new(sync.WaitGroup)
 	tasks.Add(len(repositories))
 
-	p := mpb.New(mpb.WithWaitGroup(tasks))
+	p := mpb.New(mpb.WithWaitGroup(tasks), mpb.WithOutput(progressOutput))
 	bar := p.AddBar(int64(len(repositories)),
 		mpb.PrependDecorators(
 			decor.Name("Filtering repositories", decor.WCSyncSpaceR),
@@ -164,6 +400,7 @@ func selectRepositories(repositories []*github.Repository, name, account string,
 
 	for i := 0; i < 8; i++ {
 		go func() {
+			defer recoverCrash()
 			for job := range jobs {
 				job(collector)
 			}
@@ -171,39 +408,66 @@ func selectRepositories(repositories []*github.Repository, name, account string,
 	}
 
 	for _, repo := range repositories {
-		repoName := repo.GetName()
+		repoName, repoPrivate := repo.GetName(), repo.GetPrivate()
 		jobs <- func(collector chan<- *repository) {
-			milestones := readMilestones(account, repoName, client)
-			tags := readTags(name, account, repoName, client)
-			releases := filterTags(tags, account, repoName, since, client)
-
-			var pattern *regexp.Regexp = nil
-			milestonePattern, ok := configuration.NamedSectionGet(name, config.Remote, config.MilestonePattern, repoName)
-			if !ok {
-				log.Fatal("No milestone pattern defined to extract milestone naming scheme")
-			}
-			pattern, err := regexp.Compile(milestonePattern)
-			if err != nil {
-				log.Fatal(fmt.Sprintf("Cannot compile regex: %s", milestonePattern))
+			ctx, span := tracer.Start(ctx, "repository "+repoName)
+			span.SetAttribute("repository", repoName)
+			defer span.End()
+
+			milestones := readMilestones(ctx, account, repoName, client)
+			tags := readTags(ctx, name, account, repoName, client)
+			releases := filterTags(ctx, tags, account, repoName, since, client)
+			span.SetAttribute("releases", len(releases))
+
+			// Sub-projects may name their milestones differently
+			prefixes := readSubprojects(name, repoName)
+			classifier := readChannelClassifier(name, repoName)
+			patterns := make(map[string]*namePattern)
+			for _, release := range releases {
+				release.subproject, _ = subprojectOf(prefixes, release.name)
+				release.channel = classifier.channel(release.name)
+				if _, ok := patterns[release.subproject]; ok {
+					continue
+				}
+				milestonePattern, ok := subprojectGet(name, config.MilestonePattern, repoName, release.subproject)
+				if !ok {
+					log.Fatal("No milestone pattern defined to extract milestone naming scheme")
+				}
+				patterns[release.subproject] = mustCompileNamePattern(name, milestonePattern)
 			}
+			sort.SliceStable(releases, func(i, j int) bool {
+				return releases[i].subproject < releases[j].subproject
+			})
 
-			downloadUrl, _ := configuration.NamedSectionGet(name, config.Remote, config.DownloadUrl, repoName)
+			downloadUrl, _ := namedSectionGet(name, config.Remote, config.DownloadUrl, repoName)
 
 			for _, release := range releases {
-				milestone := findMatchingMilestone(release, milestones, pattern)
+				milestone := findMatchingMilestone(release, milestones, patterns[release.subproject])
 				if milestone != nil {
+					release.published = true
 					release.milestone = milestone
 					release.milestoneUrl = fmt.Sprintf("%s?closed=1", milestone.GetHTMLURL())
 					release.milestoneState = milestone.GetState()
 					release.downloadUrl = buildDownloadUrl(account, repoName, downloadUrl, milestone)
+					release.notes = readReleaseNotes(account, repoName, release, client)
+					release.references = extractReferences(account, repoName, release.notes)
 				}
 			}
 
-			if len(releases) > 0 {
+			upcoming := upcomingMilestones(milestones)
+			if len(releases) > 0 || len(upcoming) > 0 {
+				owner, _ := namedSectionGet(name, config.Remote, config.Owner, repoName)
 				rep := &repository{
-					name:     repoName,
-					releases: releases,
+					name:       repoName,
+					owner:      owner,
+					releases:   releases,
+					milestones: upcoming,
+					private:    repoPrivate,
 				}
+				classifyReleases(name, rep)
+				highlightReleases(name, rep)
+				summarizeReleases(name, rep)
+				snoozeReleases(name, rep)
 
 				collector <- rep
 			}
@@ -230,20 +494,32 @@ func buildDownloadUrl(account, repository, downloadUrl string, milestone *github
 	downloadUrl = strings.Replace(downloadUrl, "{name}", account, -1)
 	downloadUrl = strings.Replace(downloadUrl, "{repository}", repository, -1)
 	downloadUrl = strings.Replace(downloadUrl, "{version}", milestone.GetTitle(), -1)
-	response, err := http.Get(downloadUrl)
+	response, err := newHttpClient().Get(downloadUrl)
 	if err != nil {
 		log.Fatal("Cannot test download url")
 	}
+	response.Body.Close()
 	if response.StatusCode == http.StatusOK {
 		return downloadUrl
 	}
 	return ""
 }
 
-func findMatchingMilestone(release *release, milestones []*github.Milestone, pattern *regexp.Regexp) *github.Milestone {
-	substrings := pattern.FindAllStringSubmatch(release.name, 1)
-	if len(substrings) > 0 && len(substrings[0]) > 1 {
-		milestoneName := substrings[0][1]
+// upcomingMilestones returns the open milestones with a due date in the future
+func upcomingMilestones(milestones []*github.Milestone) []*github.Milestone {
+	upcoming := make([]*github.Milestone, 0)
+	for _, milestone := range milestones {
+		if milestone.GetState() == "open" && milestone.GetDueOn().After(time.Now()) {
+			upcoming = append(upcoming, milestone)
+		}
+	}
+	return upcoming
+}
+
+func findMatchingMilestone(release *release, milestones []*github.Milestone, pattern *namePattern) *github.Milestone {
+	substrings := pattern.FindStringSubmatch(release.name)
+	if len(substrings) > 1 {
+		milestoneName := substrings[1]
 		for _, milestone := range milestones {
 			if milestone.GetTitle() == milestoneName {
 				return milestone
@@ -254,14 +530,16 @@ func findMatchingMilestone(release *release, milestones []*github.Milestone, pat
 	return nil
 }
 
-func filterTags(tags []*github.RepositoryTag, account, repository string, since time.Time, client *github.Client) []*release {
+func filterTags(ctx context.Context, tags []*github.RepositoryTag, account, repository string, since time.Time, client *github.Client) []*release {
 	filteredTags := make([]*release, 0)
 	for _, tag := range tags {
-		commit := readCommit(account, repository, tag.GetCommit().GetSHA(), client)
+		commit := readCommit(ctx, account, repository, tag.GetCommit().GetSHA(), client)
 		if since.Before(commit.GetCommit().GetCommitter().GetDate()) {
 			filteredTags = append(filteredTags, &release{
-				created: commit.GetCommit().GetCommitter().GetDate(),
-				name:    tag.GetName(),
+				created:  commit.GetCommit().GetCommitter().GetDate(),
+				name:     tag.GetName(),
+				commit:   tag.GetCommit().GetSHA(),
+				provider: providerGithub,
 			})
 		}
 	}
@@ -269,9 +547,7 @@ func filterTags(tags []*github.RepositoryTag, account, repository string, since
 	return filteredTags
 }
 
-func readCommit(account, repository, sha string, client *github.Client) *github.RepositoryCommit {
-	ctx := context.Background()
-
+func readCommit(ctx context.Context, account, repository, sha string, client *github.Client) *github.RepositoryCommit {
 	commit, response, err := client.Repositories.GetCommit(ctx, account, repository, sha)
 	for {
 		if rateLimit(response) {
@@ -286,19 +562,28 @@ func readCommit(account, repository, sha string, client *github.Client) *github.
 	}
 }
 
-func readTags(name, account, repository string, client *github.Client) []*github.RepositoryTag {
-	ctx := context.Background()
+// readReleasePatterns returns a lookup of the release pattern of a tag by its sub-project, the pattern
+// is nil if the tags of the sub-project aren't filtered
+func readReleasePatterns(name, repository string) func(tag string) *namePattern {
+	prefixes := readSubprojects(name, repository)
+	patterns := make(map[string]*namePattern)
+	return func(tag string) *namePattern {
+		subproject, _ := subprojectOf(prefixes, tag)
+		if pattern, ok := patterns[subproject]; ok {
+			return pattern
+		}
+		var pattern *namePattern = nil
+		if r, ok := subprojectGet(name, config.ReleasePattern, repository, subproject); ok {
+			pattern = mustCompileNamePattern(name, r)
+		}
+		patterns[subproject] = pattern
+		return pattern
+	}
+}
 
+func readTags(ctx context.Context, name, account, repository string, client *github.Client) []*github.RepositoryTag {
 	releases := make([]*github.RepositoryTag, 0)
-
-	var pattern *regexp.Regexp = nil
-	if r, ok := configuration.NamedSectionGet(name, config.Remote, config.ReleasePattern, repository); ok {
-		p, err := regexp.Compile(r)
-		if err != nil {
-			log.Fatal(fmt.Sprintf("Cannot compile regex: %s", r))
-		}
-		pattern = p
-	}
+	releasePattern := readReleasePatterns(name, repository)
 
 	page := 1
 	for {
@@ -316,7 +601,7 @@ func readTags(name, account, repository string, client *github.Client) []*github
 		}
 
 		for _, release := range r {
-			if pattern != nil && !pattern.MatchString(release.GetName()) {
+			if pattern := releasePattern(release.GetName()); pattern != nil && !pattern.MatchString(release.GetName()) {
 				continue
 			}
 			releases = append(releases, release)
@@ -331,18 +616,32 @@ func readTags(name, account, repository string, client *github.Client) []*github
 	}
 }
 
-func readRepositories(name, account, visibility, repositoryPattern string, client *github.Client) []*github.Repository {
-	ctx := context.Background()
-
+func readRepositories(ctx context.Context, name, account, visibility, repositoryPattern string, client *github.Client) []*github.Repository {
 	repositories := make([]*github.Repository, 0)
 
-	var pattern *regexp.Regexp = nil
+	var pattern *namePattern = nil
 	if repositoryPattern != "" {
-		p, err := regexp.Compile(repositoryPattern)
-		if err != nil {
-			log.Fatal(fmt.Sprintf("Cannot compile regex: %s", repositoryPattern))
+		pattern = mustCompileNamePattern(name, repositoryPattern)
+	}
+	team, hasTeam := readTeamRepositories(ctx, name, account, client)
+
+	tracked := func(repository *github.Repository) bool {
+		if hasTeam && !team[repository.GetName()] {
+			return false
+		}
+		if pattern != nil && !pattern.MatchString(repository.GetName()) {
+			return false
 		}
-		pattern = p
+		return !isBlacklisted(name, repository.GetName())
+	}
+
+	if query, ok := readRepositoryQuery(name, account); ok {
+		for _, repository := range searchRepositories(ctx, query, account, visibility, client) {
+			if tracked(repository) {
+				repositories = append(repositories, repository)
+			}
+		}
+		return repositories
 	}
 
 	page := 1
@@ -366,10 +665,8 @@ func readRepositories(name, account, visibility, repositoryPattern string, clien
 		}
 
 		for _, repository := range r {
-			if pattern == nil || pattern.MatchString(repository.GetName()) {
-				if !isBlacklisted(name, repository.GetName()) {
-					repositories = append(repositories, repository)
-				}
+			if tracked(repository) {
+				repositories = append(repositories, repository)
 			}
 		}
 
@@ -383,7 +680,7 @@ func readRepositories(name, account, visibility, repositoryPattern string, clien
 }
 
 func isBlacklisted(name, repository string) bool {
-	if r, ok := configuration.NamedSectionGet(name, config.Remote, config.RepositoryBlacklisted, repository); ok {
+	if r, ok := namedSectionGet(name, config.Remote, config.RepositoryBlacklisted, repository); ok {
 		b, err := strconv.ParseBool(r)
 		if err != nil {
 			log.Fatal("Could not parse boolean: ", err)
@@ -394,9 +691,15 @@ func isBlacklisted(name, repository string) bool {
 }
 
 type repository struct {
-	name     string
-	releases []*release
-	url      string
+	name       string
+	owner      string
+	releases   []*release
+	milestones []*github.Milestone
+	url        string
+	metadata   *repositoryMetadata
+	private    bool
+	// realName is the name of a private repository whose name the report redacts
+	realName string
 }
 
 type release struct {
@@ -405,5 +708,29 @@ type release struct {
 	milestoneUrl   string
 	milestoneState string
 	downloadUrl    string
+	sha256         string
+	notes          string
+	severity       string
+	breakingMarker string
+	highlights     []string
+	escalated      bool
+	snoozed        bool
+	references     []reference
+	summary        string
 	milestone      *github.Milestone
+	// subproject is the sub-project of a monorepo the release belongs to, empty for the repository itself
+	subproject string
+	// channel is the release channel of the tag: stable, beta or nightly
+	channel string
+	// commit is the SHA of the tagged commit, ciStatus and ciFailures its CI state with --with-ci-status
+	commit     string
+	ciStatus   string
+	ciFailures []string
+	// appVersion is the version of the application packaged by a Helm chart release
+	appVersion string
+	// published releases are completed, Github releases by their closed milestone
+	published bool
+	// provider the release was read from, firstSeen if it has no date but the first report seeing it
+	provider  string
+	firstSeen bool
 }
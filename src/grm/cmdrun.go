@@ -0,0 +1,201 @@
+package main
+
+import (
+	"github.com/jawher/mow.cli"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"log"
+	"os"
+	"runtime"
+	"strings"
+	"time"
+	"grm/cache"
+	"grm/config"
+	"grm/state"
+)
+
+func cmdRun(cmd *cli.Cmd) {
+	cmd.Spec = "NAME... --config-from-secret=<source> [ --format=<format> ] [ --validate-output ] [ --parallel=<remotes> ] [ --quota=<strategy> ] [ --redact-private ]"
+
+	var (
+		names    = cmd.StringsArg("NAME", nil, "The names of the remote definitions")
+		secret   = cmd.StringOpt("config-from-secret", "", "The complete configuration, a mounted file or env:<VARIABLE>")
+		format   = cmd.StringOpt("format", "json", "Output format: text, html, json, terraform, terraform-json, ansible, nvchecker or ics")
+		validate = cmd.BoolOpt("validate-output", false, "Validate the json reports against their schema, see grm schema report")
+		parallel = cmd.IntOpt("parallel", runtime.NumCPU(), "Number of remote definitions reported at the same time")
+		quota    = cmd.StringOpt("quota", "", "Remote definitions exceeding the Github rate limit: warn, skip or wait for the reset")
+		redact   = cmd.BoolOpt("redact-private", false, "Replace the names, notes and links of private repositories with hashes")
+	)
+
+	cmd.Action = func() {
+		enableJsonErrors()
+
+		formatter, ok := reportFormats[*format]
+		if !ok {
+			log.Fatal(fmt.Sprintf("Unknown report format '%s', supported formats: %s", *format, reportFormatNames()))
+		}
+		if *validate && *format != "json" {
+			log.Fatal("Only json reports can be validated, please add --format json")
+		}
+		if *parallel < 1 {
+			log.Fatal(fmt.Sprintf("Invalid parallelism %d, at least one remote definition has to be reported at a time", *parallel))
+		}
+
+		strategy := readPlanStrategy(*quota)
+
+		headless(readSecretConfiguration(*secret))
+
+		remotes, starts := *names, make(map[string]time.Time)
+		if strategy != "" {
+			remotes = make([]string, 0)
+			for _, entry := range buildPlan(context.Background(), *names).Entries {
+				if !entry.Fits {
+					fields := map[string]interface{}{"remote": entry.Remote, "requests": entry.Requests, "reset": *entry.Start}
+					switch strategy {
+					case "warn":
+						logWarning("remote exceeds the rate limit", fields)
+					case "skip":
+						logWarning("remote skipped, it exceeds the rate limit", fields)
+						continue
+					case "wait":
+						logWarning("remote waits for the rate limit reset", fields)
+						starts[entry.Remote] = *entry.Start
+					}
+				}
+				remotes = append(remotes, entry.Remote)
+			}
+		}
+
+		// Every remote definition is read with its own client, the reports are written in the order of
+		// the remote definitions as soon as they are complete
+		outputs := make([]chan []byte, len(remotes))
+		slots := make(chan bool, *parallel)
+		for i, name := range remotes {
+			outputs[i] = make(chan []byte, 1)
+			go func(name string, output chan<- []byte) {
+				defer recoverCrash()
+				if start, ok := starts[name]; ok {
+					time.Sleep(time.Until(start))
+				}
+				slots <- true
+				defer func() { <-slots }()
+				output <- runReport(name, formatter, *validate, *redact)
+			}(name, outputs[i])
+		}
+		for _, output := range outputs {
+			os.Stdout.Write(<-output)
+		}
+	}
+}
+
+// runReport generates the report of a remote definition of a headless run
+func runReport(name string, formatter reportFormatter, validate, redact bool) []byte {
+	logInfo("report started", map[string]interface{}{"remote": name})
+
+	started := time.Now()
+	ctx, span := tracer.Start(context.Background(), "report "+name)
+	report := buildReport(ctx, name, reportOptions{checksums: true})
+	recordReportSnapshot(report)
+	if redact {
+		redactPrivate(report)
+	}
+	output := &bytes.Buffer{}
+	if err := formatter(output, report); err != nil {
+		log.Fatal("Could not write report: ", err)
+	}
+	if validate {
+		validateOutput("report", output.Bytes())
+	}
+	span.End()
+
+	recordReportMetrics(report, started)
+	pushMetrics(name)
+	flushTraces()
+
+	logInfo("report finished", map[string]interface{}{
+		"remote":       name,
+		"repositories": len(report.repositories),
+		"duration":     time.Since(started).Seconds(),
+	})
+	return output.Bytes()
+}
+
+// Set by headless runs, which must not write to the local filesystem unless configured to
+var headlessRun bool
+
+// headless replaces the local configuration and disables everything writing to the local filesystem,
+// the state must be kept in a remote backend
+func headless(c config.Configuration) {
+	headlessRun = true
+	configuration = c
+	httpCache = cache.NewCache("", 0)
+	progressOutput = ioutil.Discard
+	locale = readLocale("").In(readTimezone(""))
+	setup()
+
+	if !state.Remote(openState().Backend()) {
+		log.Fatal(fmt.Sprintf("Headless runs require a remote %s or GRM_STATE_BACKEND", config.StateBackend.Name()))
+	}
+}
+
+// readSecretConfiguration reads the configuration from a file or, prefixed with env:, an environment variable
+func readSecretConfiguration(source string) config.Configuration {
+	var data []byte
+	if strings.HasPrefix(source, "env:") {
+		variable := strings.TrimPrefix(source, "env:")
+		value, ok := os.LookupEnv(variable)
+		if !ok {
+			log.Fatal(fmt.Sprintf("Environment variable %s with the configuration is not set", variable))
+		}
+		data = []byte(value)
+	} else {
+		d, err := ioutil.ReadFile(source)
+		if err != nil {
+			log.Fatal(fmt.Sprintf("Could not read configuration from '%s': ", source), err)
+		}
+		data = d
+	}
+
+	c, err := config.ParseConfiguration(data)
+	if err != nil {
+		log.Fatal(fmt.Sprintf("Could not parse configuration from '%s': ", source), err)
+	}
+	return c
+}
+
+// jsonLogWriter turns the log package output into JSON lines. The log package is only used to report
+// failures, therefore all its messages are logged as errors with their error code.
+type jsonLogWriter struct {
+	writer io.Writer
+}
+
+func (w *jsonLogWriter) Write(p []byte) (int, error) {
+	message := strings.TrimSpace(string(p))
+	writeJsonLog(w.writer, "error", message, map[string]interface{}{"code": classifyError(message)})
+	return len(p), nil
+}
+
+func logInfo(message string, fields map[string]interface{}) {
+	writeJsonLog(os.Stderr, "info", message, fields)
+}
+
+func logWarning(message string, fields map[string]interface{}) {
+	writeJsonLog(os.Stderr, "warning", message, fields)
+}
+
+func writeJsonLog(writer io.Writer, level, message string, fields map[string]interface{}) {
+	entry := map[string]interface{}{
+		"time":    time.Now().UTC().Format(time.RFC3339),
+		"level":   level,
+		"message": message,
+	}
+	for k, v := range fields {
+		entry[k] = v
+	}
+	data, _ := json.Marshal(entry)
+	writer.Write(append(data, '\n'))
+}
@@ -0,0 +1,23 @@
+package main
+
+import (
+	"github.com/jawher/mow.cli"
+	"fmt"
+	"log"
+)
+
+func cmdSchema(cmd *cli.Cmd) {
+	cmd.Spec = "NAME"
+
+	var (
+		name = cmd.StringArg("NAME", "", "The name of the schema: report")
+	)
+
+	cmd.Action = func() {
+		s, ok := schemas[*name]
+		if !ok {
+			log.Fatal(fmt.Sprintf("Unknown schema '%s', supported schemas: %s", *name, schemaNames()))
+		}
+		fmt.Print(s)
+	}
+}
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"github.com/jawher/mow.cli"
+	"log"
+	"time"
+	"fmt"
+)
+
+func cmdServe(cmd *cli.Cmd) {
+	cmd.Spec = "NAME... [ --listen=<address> ] [ --interval=<interval> ] [ --fresh-days=<days> ] [ --stale-days=<days> ] [ --drain-timeout=<timeout> ] [ --redact-private ]"
+
+	var (
+		names     = cmd.StringsArg("NAME", nil, "The names of the remote definitions")
+		listen    = cmd.StringOpt("listen", ":8080", "The address to listen on")
+		interval  = cmd.StringOpt("interval", "1h", "Interval between report refreshes, e.g. 30m")
+		freshDays = cmd.IntOpt("fresh-days", 30, "Releases younger than this are shown as fresh (green)")
+		staleDays = cmd.IntOpt("stale-days", 180, "Releases older than this are shown as stale (red)")
+		drain     = cmd.StringOpt("drain-timeout", defaultDrainTimeout, "Time the running refresh and requests get to finish on SIGTERM")
+		redact    = cmd.BoolOpt("redact-private", false, "Serve the reports with hashes instead of the names, notes and links of private repositories")
+	)
+
+	cmd.Action = func() {
+		refresh := parseInterval(*interval)
+		drainTimeout, err := time.ParseDuration(*drain)
+		if err != nil {
+			log.Fatal(fmt.Sprintf("Could not parse drain timeout '%s': ", *drain), err)
+		}
+		if *freshDays > *staleDays {
+			log.Fatal("fresh-days must not be larger than stale-days")
+		}
+
+		users, err := readApiUsers()
+		if err != nil {
+			log.Fatal("Could not read the API users: ", err)
+		}
+
+		day := 24 * time.Hour
+		server := newReleaseServer(*names, reportOptions{}, refresh,
+			time.Duration(*freshDays)*day, time.Duration(*staleDays)*day, users, *redact)
+		serve(server, *listen, drainTimeout)
+	}
+}
+
+// parseInterval parses the interval between refreshes, it has to be positive
+func parseInterval(value string) time.Duration {
+	interval, err := time.ParseDuration(value)
+	if err != nil {
+		log.Fatal(fmt.Sprintf("Could not parse interval '%s': ", value), err)
+	}
+	if interval <= 0 {
+		log.Fatal(fmt.Sprintf("The interval '%s' must be positive", value))
+	}
+	return interval
+}
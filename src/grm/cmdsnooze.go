@@ -0,0 +1,51 @@
+package main
+
+import (
+	"github.com/jawher/mow.cli"
+	"fmt"
+	"log"
+	"time"
+)
+
+func cmdSnooze(cmd *cli.Cmd) {
+	cmd.Spec = "REMOTE_REPOSITORY ( --until=<date> | --remove ) [ --line=<line> ]"
+
+	var (
+		target = cmd.StringArg("REMOTE_REPOSITORY", "", "The remote definition and repository, e.g. remote/repository")
+		until  = cmd.StringOpt("until", "", "Hide the releases until this date in ISO format YYYY-MM-DD")
+		line   = cmd.StringOpt("line", "", "Only hide a release line, e.g. 1.2 for all 1.2.x releases")
+		remove = cmd.BoolOpt("remove", false, "Remove the snooze")
+	)
+
+	cmd.Action = func() {
+		if *target == "" {
+			log.Fatal("No remote repository specified")
+		}
+
+		name, repository := splitRemoteRepository(*target)
+		if *remove {
+			if !removeSnooze(name, repository, *line) {
+				log.Fatal(fmt.Sprintf("%s is not snoozed", *target))
+			}
+			saveState()
+			fmt.Println(fmt.Sprintf("Removed snooze of %s", repository))
+			return
+		}
+
+		date, err := time.ParseInLocation("2006-01-02", *until, readTimezone(""))
+		if err != nil {
+			log.Fatal(fmt.Sprintf("Could not parse date '%s', expected YYYY-MM-DD: ", *until), err)
+		}
+		if !date.After(time.Now()) {
+			log.Fatal(fmt.Sprintf("Date %s is not in the future", *until))
+		}
+
+		writeSnooze(name, repository, snooze{Until: date.UTC(), Line: *line})
+		saveState()
+		if *line != "" {
+			fmt.Println(fmt.Sprintf("Snoozed %s %s until %s", repository, *line, *until))
+		} else {
+			fmt.Println(fmt.Sprintf("Snoozed %s until %s", repository, *until))
+		}
+	}
+}
@@ -0,0 +1,54 @@
+package main
+
+import (
+	"github.com/jawher/mow.cli"
+	"fmt"
+	"log"
+	"os"
+	"time"
+)
+
+func cmdStats(cmd *cli.Cmd) {
+	cmd.Spec = "[ NAME... ] [ --months=<months> ] [ --format=<format> ] [ --tz=<timezone> ]"
+
+	var (
+		names  = cmd.StringsArg("NAME", nil, "The names of the remote definitions, default: all remote definitions")
+		months = cmd.IntOpt("months", 12, "Number of months to summarize, including the current month")
+		format = cmd.StringOpt("format", "text", "Output format: text or json")
+		tz     = cmd.StringOpt("tz", "", "Time zone of months, e.g. Europe/Madrid, default: UTC")
+	)
+
+	cmd.Action = func() {
+		if *format == "json" {
+			enableJsonErrors()
+		}
+		if *format != "text" && *format != "json" {
+			log.Fatal(fmt.Sprintf("Unknown stats format '%s', supported formats: text, json", *format))
+		}
+		if *months < 1 {
+			log.Fatal(fmt.Sprintf("Invalid number of months %d", *months))
+		}
+		locale = readLocale("").In(readTimezone(*tz))
+
+		records := make([]historyRecord, 0)
+		if len(*names) == 0 {
+			records = readHistories("")
+		}
+		for _, name := range *names {
+			records = append(records, readHistories(name)...)
+		}
+
+		stats := buildStats(records, *months, time.Now())
+		var err error
+		if *format == "json" {
+			err = formatStatsJson(os.Stdout, stats)
+		} else if len(stats) == 0 {
+			fmt.Println("No releases observed")
+		} else {
+			err = formatStatsText(os.Stdout, stats)
+		}
+		if err != nil {
+			log.Fatal("Could not write stats: ", err)
+		}
+	}
+}
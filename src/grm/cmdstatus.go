@@ -0,0 +1,43 @@
+package main
+
+import (
+	"github.com/jawher/mow.cli"
+	"fmt"
+	"log"
+	"os"
+)
+
+func cmdStatus(cmd *cli.Cmd) {
+	cmd.Spec = "[ NAME... ] [ --short ] [ --symbol=<symbol> ]"
+
+	var (
+		names  = cmd.StringsArg("NAME", nil, "The names of the remote definitions, default: all remote definitions")
+		short  = cmd.BoolOpt("short", false, "Print only the number of unacknowledged releases, for shell prompts")
+		symbol = cmd.StringOpt("symbol", "⬆", "The symbol following the number of --short")
+	)
+
+	cmd.Action = func() {
+		locale = readLocale("").In(readTimezone(""))
+
+		records := make([]historyRecord, 0)
+		if len(*names) == 0 {
+			records = readHistories("")
+		}
+		for _, name := range *names {
+			records = append(records, readHistories(name)...)
+		}
+		pending := pendingReleases(records)
+
+		if *short {
+			formatStatusShort(os.Stdout, pending, *symbol)
+			return
+		}
+		if len(pending) == 0 {
+			fmt.Println("No unacknowledged releases")
+			return
+		}
+		if err := formatStatusText(os.Stdout, pending); err != nil {
+			log.Fatal("Could not write status: ", err)
+		}
+	}
+}
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"github.com/jawher/mow.cli"
+	"fmt"
+	"log"
+	"os"
+	"text/tabwriter"
+	"grm/config"
+)
+
+func cmdUndo(cmd *cli.Cmd) {
+	cmd.Spec = "[ --list ] [ --yes ]"
+
+	var (
+		list = cmd.BoolOpt("l list", false, "Lists the configuration changes which can be undone, the latest first")
+		yes  = cmd.BoolOpt("y yes", false, "Accept all questions with yes")
+	)
+
+	cmd.Action = func() {
+		entries := config.ReadUndoEntries(*homeDir)
+		if *list {
+			if len(entries) == 0 {
+				fmt.Println("No configuration changes to undo")
+				return
+			}
+			table := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+			fmt.Fprintln(table, "Time\tCommand")
+			for _, entry := range entries {
+				fmt.Fprintln(table, fmt.Sprintf("%s\tgrm %s", entry.Time.Local().Format("2006-01-02 15:04:05"), entry.Command))
+			}
+			table.Flush()
+			return
+		}
+
+		if len(entries) == 0 {
+			fmt.Println("No configuration changes to undo")
+			return
+		}
+		if !*yes && !readYesNoQuestion(fmt.Sprintf("Revert the configuration change of 'grm %s' from %s?",
+			entries[0].Command, entries[0].Time.Local().Format("2006-01-02 15:04:05")), true) {
+			fmt.Println("Configuration not changed")
+			return
+		}
+
+		entry, err := config.Undo(*homeDir)
+		if err != nil {
+			log.Fatal("Could not undo the configuration change: ", err)
+		}
+		fmt.Println(fmt.Sprintf("Reverted the configuration change of 'grm %s' from %s", entry.Command,
+			entry.Time.Local().Format("2006-01-02 15:04:05")))
+	}
+}
@@ -0,0 +1,100 @@
+package main
+
+import (
+	"github.com/jawher/mow.cli"
+	"context"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"time"
+)
+
+func cmdWatch(cmd *cli.Cmd) {
+	cmd.Spec = "NAME... [ --interval=<interval> ] [ --output=<output> ] [ --health-listen=<address> ] [ --drain-timeout=<timeout> ]"
+
+	var (
+		names    = cmd.StringsArg("NAME", nil, "The names of the remote definitions")
+		interval = cmd.StringOpt("interval", "1h", "Interval between checks for new releases, e.g. 30m")
+		output   = cmd.StringOpt("output", "ndjson", "Output of the release events: ndjson or text")
+		listen   = cmd.StringOpt("health-listen", "", "The address to serve /healthz and /readyz on, default: disabled")
+		drain    = cmd.StringOpt("drain-timeout", defaultDrainTimeout, "Time the running check gets to finish on SIGTERM")
+	)
+
+	cmd.Action = func() {
+		refresh := parseInterval(*interval)
+		drainTimeout, err := time.ParseDuration(*drain)
+		if err != nil {
+			log.Fatal(fmt.Sprintf("Could not parse drain timeout '%s': ", *drain), err)
+		}
+		if *output != "ndjson" && *output != "text" {
+			log.Fatal(fmt.Sprintf("Unknown output '%s', supported outputs: ndjson, text", *output))
+		}
+		progressOutput = ioutil.Discard
+		locale = readLocale("").In(readTimezone(""))
+
+		sinks := make(map[string][]*sink)
+		for _, name := range *names {
+			sinks[name] = readSinks(name)
+		}
+
+		health.watchHealth(refresh)
+		if *listen != "" {
+			go serveHealth(*listen)
+		}
+
+		guard := &pollGuard{}
+		terminate := notifyShutdown()
+		go func() {
+			defer recoverCrash()
+			<-terminate
+			shutdown(guard, drainTimeout)
+		}()
+
+		hangup := notifyReload()
+		for {
+			if !guard.start() {
+				// The shutdown exits
+				select {}
+			}
+			responses.Reset()
+			for _, name := range *names {
+				if guard.stopped() {
+					break
+				}
+				ctx, span := tracer.Start(context.Background(), "watch")
+				report := buildReport(ctx, name, reportOptions{checksums: true})
+				span.End()
+
+				for _, event := range releaseEvents(report) {
+					if err := writeEvent(os.Stdout, *output, event); err != nil {
+						log.Fatal("Could not write release event: ", err)
+					}
+					publishEvent(sinks[name], event)
+				}
+			}
+			saveState()
+			flushTraces()
+			health.recordPoll()
+			guard.done()
+
+			// A reloaded configuration is applied right away
+			select {
+			case <-time.After(refresh):
+			case <-hangup:
+				reloadConfiguration(*names, func() error {
+					reloaded := make(map[string][]*sink)
+					for _, name := range *names {
+						s, err := parseSinks(name)
+						if err != nil {
+							return err
+						}
+						reloaded[name] = s
+					}
+					sinks = reloaded
+					return nil
+				})
+			}
+		}
+	}
+}
@@ -2,29 +2,47 @@ package config
 
 import (
 	"github.com/zieckey/goini"
+	"io"
+	"io/ioutil"
+	"path"
 	"path/filepath"
 	"os"
 	"log"
 	"fmt"
+	"strconv"
 	"strings"
+	"sync"
 )
 
+// Configuration reads the properties of the configuration file. Values read by their key are returned
+// with references to environment variables, files and secret stores resolved, an unresolvable reference
+// is returned as error. Whole sections are returned as written in the file, with the references, for
+// listing, exporting and copying properties without spreading the secrets.
 type Configuration interface {
 	Section(section Section) map[string]string
-	SectionGet(section Section, key Key, specifier string) (value string, ok bool)
-	SectionGetOverrides(section Section, key Key) map[string]string
+	SectionGet(section Section, key Key, specifier string) (value string, ok bool, err error)
+	SectionGetOverrides(section Section, key Key) (map[string]string, error)
 	NamedSections(section Section) []string
 	NamedSection(name string, section Section) map[string]string
-	NamedSectionGet(name string, section Section, key Key, specifier string) (value string, ok bool)
-	NamedSectionGetOverrides(name string, section Section, key Key) map[string]string
-	ApplyChanges(applyFunction func(mutator Mutator))
+	NamedSectionGet(name string, section Section, key Key, specifier string) (value string, ok bool, err error)
+	NamedSectionGetOverrides(name string, section Section, key Key) (map[string]string, error)
+	ApplyChanges(applyFunction func(mutator Mutator)) error
+	Snapshot() Configuration
+	Replace(other Configuration)
 }
 
+// configuration is safe for concurrent use. The parsed file is never changed once readers can see it,
+// changes are applied to a copy which replaces it as a whole.
 type configuration struct {
-	ini     *goini.INI
-	homeDir string
+	// mutex guards the ini pointer, writer serializes the changes
+	mutex     sync.RWMutex
+	writer    sync.Mutex
+	ini       *goini.INI
+	homeDir   string
+	readOnly  bool
 }
 
+// Mutator changes the copy of the configuration passed by ApplyChanges
 type Mutator interface {
 	Delete(section Section)
 	SectionSet(section Section, key Key, specifier, value string)
@@ -77,56 +95,199 @@ func (k key) Name() string {
 }
 
 var (
-	Remote Section = section{"Remote \"%s\"", true}
+	Core    Section = section{"Core", false}
+	Remote  Section = section{"Remote \"%s\"", true}
+	ApiUser Section = section{"ApiUser \"%s\"", true}
 )
 
 var sectionLookup = map[string]Section{
-	"Remote": Remote,
+	"Core":    Core,
+	"Remote":  Remote,
+	"ApiUser": ApiUser,
 }
 
 var (
 	Username          Key = key{"username", false, false}
 	Password          Key = key{"password", false, false}
 	Salt              Key = key{"salt", false, false}
+	Token             Key = key{"token", false, false}
+	XmppPassword      Key = key{"xmpp-password", false, false}
+	XmppSalt          Key = key{"xmpp-salt", false, false}
 	RemoteUser        Key = key{"user", false, true}
 	ShowPrivate       Key = key{"show-private", false, true}
 	RepositoryPattern Key = key{"repository-pattern", false, true}
+	LicensePolicy     Key = key{"license-policy", false, true}
+	Publish           Key = key{"publish", false, false}
+	GitRepositories   Key = key{"git-repositories", false, true}
+	GitProvider       Key = key{"git-provider", false, true}
+	DeployKey         Key = key{"deploy-key", false, true}
+	Provider          Key = key{"provider", false, true}
+	SourcehutUrl      Key = key{"sourcehut-url", false, true}
+	Projects          Key = key{"projects", false, true}
+	PatternFlags      Key = key{"pattern-flags", false, true}
+	Query             Key = key{"query", false, true}
+	Team              Key = key{"team", false, true}
+	MirrorToken       Key = key{"mirror-token", false, false}
 
 	ReleasePattern        Key = key{"release-pattern", true, true}
 	MilestonePattern      Key = key{"milestone-pattern", true, true}
 	RepositoryBlacklisted Key = key{"repository-blacklisted", true, true}
 	DownloadUrl           Key = key{"download-url", true, true}
+	VirusScanCmd          Key = key{"virus-scan-cmd", true, false}
+	SbomCmd               Key = key{"sbom-cmd", true, false}
+	StaleAfter            Key = key{"stale-after", true, true}
+	Highlight             Key = key{"highlight", true, true}
+	HighlightEscalate     Key = key{"highlight-escalate", true, true}
+	SummaryCmd            Key = key{"summary-cmd", true, false}
+	Owner                 Key = key{"owner", true, true}
+	PinnedVersion         Key = key{"pinned-version", true, true}
+	MaintainerAlerts      Key = key{"maintainer-alerts", true, true}
+	Subprojects           Key = key{"subprojects", true, true}
+	BetaPattern           Key = key{"beta-pattern", true, true}
+	NightlyPattern        Key = key{"nightly-pattern", true, true}
+	AnnouncementPattern   Key = key{"announcement-pattern", true, true}
+	AnnouncementCategory  Key = key{"announcement-category", true, true}
+	GitUrl                Key = key{"git-url", true, true}
+	ChartRepository       Key = key{"chart-repository", true, true}
+	ScrapeUrl             Key = key{"scrape-url", true, true}
+	ScrapePattern         Key = key{"scrape-pattern", true, true}
+	ScrapeSelector        Key = key{"scrape-selector", true, true}
+	MirrorTarget          Key = key{"mirror-target", true, true}
+	MirrorLayout          Key = key{"mirror-layout", true, true}
+
+	CacheMaxSize   Key = key{"cache-max-size", false, false}
+	PushgatewayUrl Key = key{"pushgateway-url", false, false}
+	StatsdAddress  Key = key{"statsd-address", false, false}
+	OtlpEndpoint   Key = key{"otlp-endpoint", false, false}
+	Language       Key = key{"lang", false, false}
+	Timezone       Key = key{"timezone", false, false}
+	StateBackend   Key = key{"state-backend", false, false}
+	AuditLog       Key = key{"audit-log", false, false}
+	ApiToken       Key = key{"api-token", false, false}
+	Encryption     Key = key{"encryption", false, false}
+	AgeRecipients  Key = key{"age-recipients", false, false}
+	AgeIdentity    Key = key{"age-identity", false, false}
+	SshAgentKey    Key = key{"ssh-agent-key", false, false}
+	EncryptionKey  Key = key{"encryption-key", false, false}
+	CryptoPolicy   Key = key{"crypto-policy", false, false}
+	OAuthClientId  Key = key{"oauth-client-id", false, false}
+
+	Role Key = key{"role", false, false}
 )
 
 var keyLookup = map[string]Key{
 	Username.Name():              Username,
 	Password.Name():              Password,
 	Salt.Name():                  Salt,
+	Token.Name():                 Token,
+	XmppPassword.Name():          XmppPassword,
+	XmppSalt.Name():              XmppSalt,
 	RemoteUser.Name():            RemoteUser,
 	ShowPrivate.Name():           ShowPrivate,
 	RepositoryPattern.Name():     RepositoryPattern,
+	LicensePolicy.Name():         LicensePolicy,
+	Publish.Name():               Publish,
+	GitUrl.Name():                GitUrl,
+	ChartRepository.Name():       ChartRepository,
+	ScrapeUrl.Name():             ScrapeUrl,
+	ScrapePattern.Name():         ScrapePattern,
+	ScrapeSelector.Name():        ScrapeSelector,
+	GitRepositories.Name():       GitRepositories,
+	GitProvider.Name():           GitProvider,
+	DeployKey.Name():             DeployKey,
+	Provider.Name():              Provider,
+	SourcehutUrl.Name():          SourcehutUrl,
+	Projects.Name():              Projects,
+	PatternFlags.Name():          PatternFlags,
+	Query.Name():                 Query,
+	Team.Name():                  Team,
+	MirrorToken.Name():           MirrorToken,
 	ReleasePattern.Name():        ReleasePattern,
 	MilestonePattern.Name():      MilestonePattern,
 	RepositoryBlacklisted.Name(): RepositoryBlacklisted,
 	DownloadUrl.Name():           DownloadUrl,
+	VirusScanCmd.Name():          VirusScanCmd,
+	SbomCmd.Name():               SbomCmd,
+	StaleAfter.Name():            StaleAfter,
+	Highlight.Name():             Highlight,
+	HighlightEscalate.Name():     HighlightEscalate,
+	SummaryCmd.Name():            SummaryCmd,
+	Owner.Name():                 Owner,
+	PinnedVersion.Name():         PinnedVersion,
+	MaintainerAlerts.Name():      MaintainerAlerts,
+	Subprojects.Name():           Subprojects,
+	BetaPattern.Name():           BetaPattern,
+	NightlyPattern.Name():        NightlyPattern,
+	AnnouncementPattern.Name():   AnnouncementPattern,
+	AnnouncementCategory.Name():  AnnouncementCategory,
+	MirrorTarget.Name():          MirrorTarget,
+	MirrorLayout.Name():          MirrorLayout,
+	CacheMaxSize.Name():          CacheMaxSize,
+	PushgatewayUrl.Name():        PushgatewayUrl,
+	StatsdAddress.Name():         StatsdAddress,
+	OtlpEndpoint.Name():          OtlpEndpoint,
+	Language.Name():              Language,
+	Timezone.Name():              Timezone,
+	StateBackend.Name():          StateBackend,
+	AuditLog.Name():              AuditLog,
+	ApiToken.Name():              ApiToken,
+	Encryption.Name():            Encryption,
+	AgeRecipients.Name():         AgeRecipients,
+	AgeIdentity.Name():           AgeIdentity,
+	SshAgentKey.Name():           SshAgentKey,
+	EncryptionKey.Name():         EncryptionKey,
+	CryptoPolicy.Name():          CryptoPolicy,
+	OAuthClientId.Name():         OAuthClientId,
+	Role.Name():                  Role,
 }
 
 func NewConfiguration(homeDir string) Configuration {
+	configuration, err := LoadConfiguration(homeDir)
+	if err != nil {
+		log.Fatal(fmt.Sprintf("Could not read config file from '%s'", filepath.Join(homeDir, "github-release-monitor", "config")), err)
+	}
+	return configuration
+}
+
+// LoadConfiguration reads the configuration file like NewConfiguration but returns a broken file or
+// one which can't be migrated as error, e.g. to keep the running configuration of a daemon
+func LoadConfiguration(homeDir string) (Configuration, error) {
 	grmPath := filepath.Join(homeDir, "github-release-monitor")
 	configPath := filepath.Join(grmPath, "config")
 
-	configuration := &configuration{homeDir: homeDir}
+	configuration := &configuration{
+		ini:     goini.New(),
+		homeDir: homeDir,
+	}
 
 	if _, err := os.Stat(configPath); err != nil {
-		return configuration
+		return configuration, nil
 	}
 
-	configuration.ini = goini.New()
 	if err := configuration.ini.ParseFile(configPath); err != nil {
-		log.Fatal(fmt.Sprintf("Could not read config file from '%s'", configPath), err)
+		return nil, err
+	}
+	if err := configuration.migrateFile(configPath); err != nil {
+		return nil, err
 	}
 
-	return configuration
+	return configuration, nil
+}
+
+// ParseConfiguration parses a complete configuration, e.g. mounted from a secret. The configuration
+// is read-only, changes are never written anywhere.
+func ParseConfiguration(data []byte) (Configuration, error) {
+	ini := goini.New()
+	ini.SetParseSection(true)
+	ini.SetSkipCommits(true)
+	if err := ini.Parse(data, goini.DefaultLineSeparator, goini.DefaultKeyValueSeparator); err != nil {
+		return nil, err
+	}
+	// Read-only configurations are only migrated in memory
+	if _, err := migrate(ini); err != nil {
+		return nil, err
+	}
+	return &configuration{ini: ini, readOnly: true}, nil
 }
 
 func KeyLookup(key string) Key {
@@ -161,7 +322,7 @@ func (c *configuration) Section(section Section) map[string]string {
 
 	sectionName := section.Name()
 
-	if kvmap, ok := c.ini.GetKvmap(sectionName); ok {
+	if kvmap, ok := c.current().GetKvmap(sectionName); ok {
 		overrides := make(map[string]string, len(kvmap))
 		for k, v := range kvmap {
 			overrides[k] = v
@@ -171,7 +332,7 @@ func (c *configuration) Section(section Section) map[string]string {
 	return make(map[string]string, 0)
 }
 
-func (c *configuration) SectionGet(section Section, key Key, specifier string) (value string, ok bool) {
+func (c *configuration) SectionGet(section Section, key Key, specifier string) (value string, ok bool, err error) {
 	if section.Named() {
 		log.Fatal("Tried to retrieve a named section without a name")
 	}
@@ -179,29 +340,16 @@ func (c *configuration) SectionGet(section Section, key Key, specifier string) (
 	return c.sectionGet(sectionName, key, specifier)
 }
 
-func (c *configuration) SectionGetOverrides(section Section, key Key) map[string]string {
+func (c *configuration) SectionGetOverrides(section Section, key Key) (map[string]string, error) {
 	if section.Named() {
 		log.Fatal("Tried to retrieve a named section without a name")
 	}
-
-	sectionName := section.Name()
-	keySpace := fmt.Sprintf("%s:", key.Name())
-
-	if kvmap, ok := c.ini.GetKvmap(sectionName); ok {
-		overrides := make(map[string]string, 0)
-		for k, v := range kvmap {
-			if strings.HasPrefix(k, keySpace) {
-				overrides[k] = v
-			}
-		}
-		return overrides
-	}
-	return make(map[string]string, 0)
+	return c.sectionGetOverrides(section.Name(), key)
 }
 
 func (c *configuration) NamedSections(section Section) []string {
 	sections := make([]string, 0)
-	for iniSection := range c.ini.GetAll() {
+	for iniSection := range c.current().GetAll() {
 		realSection := SectionLookup(iniSection)
 		if realSection == section {
 			sections = append(sections, iniSection)
@@ -217,7 +365,7 @@ func (c *configuration) NamedSection(name string, section Section) map[string]st
 
 	sectionName := buildSectionName(section, name)
 
-	if kvmap, ok := c.ini.GetKvmap(sectionName); ok {
+	if kvmap, ok := c.current().GetKvmap(sectionName); ok {
 		overrides := make(map[string]string, len(kvmap))
 		for k, v := range kvmap {
 			overrides[k] = v
@@ -227,7 +375,7 @@ func (c *configuration) NamedSection(name string, section Section) map[string]st
 	return make(map[string]string, 0)
 }
 
-func (c *configuration) NamedSectionGet(name string, section Section, key Key, specifier string) (value string, ok bool) {
+func (c *configuration) NamedSectionGet(name string, section Section, key Key, specifier string) (value string, ok bool, err error) {
 	if !section.Named() {
 		log.Fatal("Tried to retrieve a non-named section with a name")
 	}
@@ -235,33 +383,82 @@ func (c *configuration) NamedSectionGet(name string, section Section, key Key, s
 	return c.sectionGet(sectionName, key, specifier)
 }
 
-func (c *configuration) NamedSectionGetOverrides(name string, section Section, key Key) map[string]string {
+func (c *configuration) NamedSectionGetOverrides(name string, section Section, key Key) (map[string]string, error) {
 	if !section.Named() {
 		log.Fatal("Tried to retrieve a non-named section with a name")
 	}
+	return c.sectionGetOverrides(buildSectionName(section, name), key)
+}
 
-	sectionName := buildSectionName(section, name)
-	keySpace := fmt.Sprintf("%s:", key.Name())
+// sectionGet returns the value with its references resolved
+func (c *configuration) sectionGet(section string, key Key, specifier string) (value string, ok bool, err error) {
+	ini := c.current()
+	if key.Overloadable() && specifier != "" {
+		if v, ok := ini.SectionGet(section, buildOverloadedKey(key, specifier)); ok {
+			value, err := resolveKeyValue(key, v)
+			return value, true, err
+		}
+		if v, ok := wildcardGet(ini, section, key, specifier); ok {
+			value, err := resolveKeyValue(key, v)
+			return value, true, err
+		}
+	}
+	if v, ok := ini.SectionGet(section, key.Name()); ok {
+		value, err := resolveKeyValue(key, v)
+		return value, true, err
+	}
+	return "", false, nil
+}
 
-	if kvmap, ok := c.ini.GetKvmap(sectionName); ok {
-		overrides := make(map[string]string, 0)
-		for k, v := range kvmap {
-			if strings.HasPrefix(k, keySpace) {
-				overrides[k] = v
-			}
+// sectionGetOverrides returns the repository overrides of the key with their references resolved
+func (c *configuration) sectionGetOverrides(section string, key Key) (map[string]string, error) {
+	keySpace := fmt.Sprintf("%s:", key.Name())
+	overrides := make(map[string]string, 0)
+	kvmap, _ := c.current().GetKvmap(section)
+	for k, v := range kvmap {
+		if !strings.HasPrefix(k, keySpace) {
+			continue
 		}
-		return overrides
+		value, err := resolveKeyValue(key, v)
+		if err != nil {
+			return nil, err
+		}
+		overrides[k] = value
 	}
-	return make(map[string]string, 0)
+	return overrides, nil
 }
 
-func (c *configuration) sectionGet(section string, key Key, specifier string) (value string, ok bool) {
-	if key.Overloadable() && specifier != "" {
-		if v, ok := c.ini.SectionGet(section, buildOverloadedKey(key, specifier)); ok {
-			return v, true
+// wildcardGet looks up overrides with glob specifiers like release-pattern:terraform-provider-*. Exact
+// specifiers take precedence, among matching globs the longest pattern wins.
+func wildcardGet(ini *goini.INI, section string, key Key, specifier string) (value string, ok bool) {
+	kvmap, found := ini.GetKvmap(section)
+	if !found {
+		return "", false
+	}
+
+	keySpace := fmt.Sprintf("%s:", key.Name())
+	best := ""
+	for k, v := range kvmap {
+		if !strings.HasPrefix(k, keySpace) {
+			continue
+		}
+		pattern := strings.TrimPrefix(k, keySpace)
+		if !IsWildcard(pattern) {
+			continue
+		}
+		if matched, err := path.Match(pattern, specifier); err != nil || !matched {
+			continue
+		}
+		if !ok || len(pattern) > len(best) || (len(pattern) == len(best) && pattern < best) {
+			best, value, ok = pattern, v, true
 		}
 	}
-	return c.ini.SectionGet(section, key.Name())
+	return value, ok
+}
+
+// IsWildcard reports whether a specifier is a glob pattern matching several repositories
+func IsWildcard(specifier string) bool {
+	return strings.ContainsAny(specifier, "*?[")
 }
 
 func (c *configuration) Delete(section Section) {
@@ -330,17 +527,75 @@ func (c *configuration) NamedSectionDelete(name string, section Section, key Key
 	c.ini.Delete(sectionName, keySpace)
 }
 
-func (c *configuration) ApplyChanges(applyFunction func(config Mutator)) {
-	if c.ini == nil {
-		c.ini = goini.New()
+// ApplyChanges applies the changes to a copy of the configuration and writes it, readers only see the
+// changes once they are written. A configuration which can't be written is returned as error and
+// stays unchanged.
+func (c *configuration) ApplyChanges(applyFunction func(config Mutator)) error {
+	if c.readOnly {
+		log.Fatal("Configuration is read-only")
+	}
+	c.writer.Lock()
+	defer c.writer.Unlock()
+
+	if err := c.journal(); err != nil {
+		return err
+	}
+	// Readers see the configuration before or after all changes, never in between
+	changes := &configuration{ini: cloneIni(c.current()), homeDir: c.homeDir}
+	applyFunction(changes)
+	if err := changes.store(); err != nil {
+		return err
+	}
+
+	c.mutex.Lock()
+	c.ini = changes.ini
+	c.mutex.Unlock()
+	return nil
+}
+
+// Snapshot returns a read-only configuration with the current values, later changes don't affect it
+func (c *configuration) Snapshot() Configuration {
+	return &configuration{ini: c.current(), homeDir: c.homeDir, readOnly: true}
+}
+
+// Replace takes over all values of the other configuration at once, e.g. of the reloaded file
+func (c *configuration) Replace(other Configuration) {
+	c.writer.Lock()
+	defer c.writer.Unlock()
+
+	ini := other.(*configuration).current()
+	c.mutex.Lock()
+	c.ini = ini
+	c.mutex.Unlock()
+}
+
+// current returns the parsed file readers may see, it is never changed
+func (c *configuration) current() *goini.INI {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+	return c.ini
+}
+
+func cloneIni(ini *goini.INI) *goini.INI {
+	clone := goini.New()
+	if ini != nil {
+		clone.Merge(ini, true)
 	}
-	applyFunction(c)
-	c.store()
+	return clone
 }
 
-func (c *configuration) store() {
+func (c *configuration) store() error {
 	if c.ini == nil {
-		return
+		return nil
+	}
+	return c.write()
+}
+
+// write writes the configuration file, new configurations are marked with the current version. The
+// file is replaced as a whole, readers like a reload never see it half-written.
+func (c *configuration) write() error {
+	if _, ok := c.ini.SectionGet(Core.Name(), ConfigVersion.Name()); !ok {
+		c.ini.SectionSet(Core.Name(), ConfigVersion.Name(), strconv.Itoa(CurrentVersion))
 	}
 
 	grmPath := filepath.Join(c.homeDir, "github-release-monitor")
@@ -348,17 +603,36 @@ func (c *configuration) store() {
 
 	if _, err := os.Stat(grmPath); err != nil {
 		if err := os.MkdirAll(grmPath, os.ModePerm); err != nil {
-			log.Fatal(fmt.Sprintf("Could not create config directory '%s'", grmPath), err)
+			return fmt.Errorf("Could not create config directory '%s': %s", grmPath, err)
 		}
 	}
 
-	file, err := os.Create(configPath)
+	return replaceFile(configPath, c.ini.Write)
+}
+
+// replaceFile writes the file to a temporary file renamed over it, readers never see it half-written.
+// An existing file keeps its permissions.
+func replaceFile(path string, write func(writer io.Writer) error) error {
+	file, err := ioutil.TempFile(filepath.Dir(path), filepath.Base(path)+".tmp")
 	if err != nil {
-		log.Fatal(fmt.Sprintf("Could not create config file '%s'", configPath), err)
+		return fmt.Errorf("Could not create config file in '%s': %s", filepath.Dir(path), err)
+	}
+	defer os.Remove(file.Name())
+	if info, err := os.Stat(path); err == nil {
+		file.Chmod(info.Mode().Perm())
 	}
 
-	c.ini.Write(file)
-	println("Configuration written")
+	err = write(file)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return fmt.Errorf("Could not write config file '%s': %s", path, err)
+	}
+	if err := os.Rename(file.Name(), path); err != nil {
+		return fmt.Errorf("Could not write config file '%s': %s", path, err)
+	}
+	return nil
 }
 
 func buildSectionName(section Section, name string) string {
@@ -0,0 +1,83 @@
+package config
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestApplyChangesWritesFile(t *testing.T) {
+	home := t.TempDir()
+	c, err := LoadConfiguration(home)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = c.ApplyChanges(func(mutator Mutator) {
+		mutator.NamedSectionSet("hashicorp", Remote, RemoteUser, "", "hashicorp")
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	checkTemporaryFiles(t, home)
+	reloaded, err := LoadConfiguration(home)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if user, _, _ := reloaded.NamedSectionGet("hashicorp", Remote, RemoteUser, ""); user != "hashicorp" {
+		t.Errorf("written user %q", user)
+	}
+}
+
+func TestApplyChangesKeepsPermissions(t *testing.T) {
+	home := t.TempDir()
+	grmPath := filepath.Join(home, "github-release-monitor")
+	if err := os.MkdirAll(grmPath, 0700); err != nil {
+		t.Fatal(err)
+	}
+	configPath := filepath.Join(grmPath, "config")
+	if err := ioutil.WriteFile(configPath, []byte("[Core]\nconfig-version = 1\n"), 0640); err != nil {
+		t.Fatal(err)
+	}
+
+	c, err := LoadConfiguration(home)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := c.ApplyChanges(func(mutator Mutator) { mutator.SectionSet(Core, Language, "", "de") }); err != nil {
+		t.Fatal(err)
+	}
+	if info, err := os.Stat(configPath); err != nil || info.Mode().Perm() != 0640 {
+		t.Errorf("permissions %v, %v", info.Mode().Perm(), err)
+	}
+}
+
+func TestApplyChangesUnwritable(t *testing.T) {
+	home := t.TempDir()
+	c, err := LoadConfiguration(home)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// A directory in place of the file can't be replaced
+	if err := os.MkdirAll(filepath.Join(home, "github-release-monitor", "config"), 0700); err != nil {
+		t.Fatal(err)
+	}
+
+	err = c.ApplyChanges(func(mutator Mutator) { mutator.SectionSet(Core, Language, "", "de") })
+	if err == nil {
+		t.Fatal("changes reported as written")
+	}
+	if language, ok, _ := c.SectionGet(Core, Language, ""); ok {
+		t.Errorf("unwritten change visible, %q", language)
+	}
+	checkTemporaryFiles(t, home)
+}
+
+// checkTemporaryFiles fails if writing the configuration left temporary files behind
+func checkTemporaryFiles(t *testing.T, home string) {
+	files, _ := filepath.Glob(filepath.Join(home, "github-release-monitor", "config.tmp*"))
+	if len(files) != 0 {
+		t.Errorf("temporary files left in the config directory: %v", files)
+	}
+}
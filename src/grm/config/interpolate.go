@@ -0,0 +1,101 @@
+package config
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+// References to environment variables like ${GITHUB_TOKEN}, $$ is a literal $
+var environmentReferencePattern = regexp.MustCompile(`\$\$|\$\{([A-Za-z_][A-Za-z0-9_]*)\}`)
+
+// Values of the form file:/run/secrets/token are read from the file, URLs like file:///var/lib/grm
+// are kept
+const fileReferencePrefix = "file:"
+
+// Resolver reads the value referenced by a value like vault:secret/data/grm#token, the reference is
+// the value without the scheme
+type Resolver func(reference string) (string, error)
+
+var resolvers = struct {
+	sync.RWMutex
+	schemes map[string]Resolver
+}{schemes: make(map[string]Resolver)}
+
+// RegisterResolver resolves values starting with scheme: by the resolver, like secret stores
+func RegisterResolver(scheme string, resolver Resolver) {
+	resolvers.Lock()
+	defer resolvers.Unlock()
+	resolvers.schemes[scheme] = resolver
+}
+
+func lookupResolver(value string) (Resolver, string, bool) {
+	i := strings.Index(value, ":")
+	if i <= 0 {
+		return nil, "", false
+	}
+	resolvers.RLock()
+	defer resolvers.RUnlock()
+	resolver, ok := resolvers.schemes[value[:i]]
+	return resolver, value[i+1:], ok
+}
+
+// resolveValue replaces references to environment variables and files in a value, the configuration
+// file only keeps the references
+func resolveValue(value string) (string, error) {
+	if resolver, reference, ok := lookupResolver(value); ok {
+		return resolver(reference)
+	}
+
+	if strings.HasPrefix(value, fileReferencePrefix) && !strings.HasPrefix(value, fileReferencePrefix+"//") {
+		path := strings.TrimPrefix(value, fileReferencePrefix)
+		data, err := ioutil.ReadFile(path)
+		if err != nil {
+			return "", err
+		}
+		return strings.TrimRight(string(data), "\r\n"), nil
+	}
+
+	var err error
+	resolved := environmentReferencePattern.ReplaceAllStringFunc(value, func(reference string) string {
+		if reference == "$$" {
+			return "$"
+		}
+		name := environmentReferencePattern.FindStringSubmatch(reference)[1]
+		v, ok := os.LookupEnv(name)
+		if !ok && err == nil {
+			err = fmt.Errorf("environment variable %s is not set", name)
+		}
+		return v
+	})
+	return resolved, err
+}
+
+// IsReference returns true if the value references an environment variable, a file or a secret store
+func IsReference(value string) bool {
+	if _, _, ok := lookupResolver(value); ok {
+		return true
+	}
+	if strings.HasPrefix(value, fileReferencePrefix) && !strings.HasPrefix(value, fileReferencePrefix+"//") {
+		return true
+	}
+	for _, match := range environmentReferencePattern.FindAllString(value, -1) {
+		if match != "$$" {
+			return true
+		}
+	}
+	return false
+}
+
+// resolveKeyValue resolves a value read by its key, all values returned by the configuration are
+// resolved here
+func resolveKeyValue(key Key, value string) (string, error) {
+	resolved, err := resolveValue(value)
+	if err != nil {
+		return "", fmt.Errorf("could not resolve the value of %s: %s", key.Name(), err)
+	}
+	return resolved, nil
+}
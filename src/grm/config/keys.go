@@ -0,0 +1,155 @@
+package config
+
+import (
+	"sort"
+	"strings"
+)
+
+// Value types of keys, as listed by grm config keys
+const (
+	TypeString     = "string"
+	TypeBoolean    = "boolean"
+	TypeRegexp     = "regexp"
+	TypeUrl        = "url"
+	TypeList       = "list"
+	TypeCommand    = "command"
+	TypeDuration   = "duration"
+	TypePercentage = "percentage"
+	TypeSize       = "size"
+	TypePath       = "path"
+	TypeSecret     = "secret"
+)
+
+// KeyDescription documents a key of the key registry
+type KeyDescription struct {
+	Key         Key
+	Section     Section
+	Type        string
+	Description string
+}
+
+type keyDocumentation struct {
+	section     Section
+	valueType   string
+	description string
+}
+
+var keyDocumentations = map[Key]keyDocumentation{
+	Username:          {Remote, TypeString, "Username or app key, written by grm auth"},
+	Password:          {Remote, TypeSecret, "Encrypted password or secret, written by grm auth"},
+	Salt:              {Remote, TypeSecret, "Salt of the encrypted password, written by grm auth"},
+	Token:             {Remote, TypeSecret, "Plain access token, takes precedence over grm auth credentials"},
+	XmppPassword:      {Remote, TypeSecret, "Encrypted password of the XMPP sink, written by grm auth --xmpp"},
+	XmppSalt:          {Remote, TypeSecret, "Salt of the encrypted XMPP password, written by grm auth --xmpp"},
+	RemoteUser:        {Remote, TypeString, "Account or organization whose repositories are tracked"},
+	ShowPrivate:       {Remote, TypeBoolean, "Include private repositories like --private"},
+	RepositoryPattern: {Remote, TypeRegexp, "Tracks only repositories with matching names"},
+	Query:             {Remote, TypeString, "Github search query selecting the tracked repositories, like topic:terraform-provider archived:false"},
+	Team:              {Remote, TypeString, "Slug or name of the Github team of an organization, tracks only repositories the team has access to"},
+	PatternFlags:      {Remote, TypeString, "Default flags of all patterns, like i for case-insensitive"},
+	LicensePolicy:     {Remote, TypePath, "Policy file of allowed SPDX licenses"},
+	Publish:           {Remote, TypeList, "Notification sink URLs release events are published to"},
+	GitRepositories:   {Remote, TypeList, "Repositories whose tags are read with git"},
+	GitProvider:       {Remote, TypeString, "How git tags are read: fetch or ls-remote"},
+	DeployKey:         {Remote, TypePath, "SSH private key for git"},
+	Provider:          {Remote, TypeString, "Source of releases, by default github"},
+	SourcehutUrl:      {Remote, TypeUrl, "SourceHut instance, by default https://git.sr.ht"},
+	Projects:          {Remote, TypeList, "Projects, packages or charts tracked by registry providers"},
+	MirrorToken:       {Remote, TypeSecret, "Access token of an HTTP or Github Enterprise mirror-target, like an Artifactory access token"},
+
+	ReleasePattern:        {Remote, TypeRegexp, "Tags matching the pattern are releases"},
+	MilestonePattern:      {Remote, TypeRegexp, "Milestones matching the pattern belong to releases"},
+	RepositoryBlacklisted: {Remote, TypeBoolean, "Excludes the repository from reports"},
+	DownloadUrl:           {Remote, TypeUrl, "Download of a release, {version} is replaced by the version"},
+	VirusScanCmd:          {Remote, TypeCommand, "Scanner run on downloaded release assets"},
+	SbomCmd:               {Remote, TypeCommand, "Generates a software bill of materials of downloads"},
+	StaleAfter:            {Remote, TypeDuration, "Maximum age of the latest release, like 90d or 1y"},
+	Highlight:             {Remote, TypeList, "Keywords of release notes highlighted in reports"},
+	HighlightEscalate:     {Remote, TypeBoolean, "Always report highlighted releases regardless of --min-severity"},
+	SummaryCmd:            {Remote, TypeCommand, "Condenses release notes read from stdin"},
+	Owner:                 {Remote, TypeString, "Person or team responsible for the repository"},
+	PinnedVersion:         {Remote, TypeString, "Version in use, compared with the latest release"},
+	MaintainerAlerts:      {Remote, TypePercentage, "Share of replaced maintainers reported, like 30%"},
+	Subprojects:           {Remote, TypeList, "Tag prefixes of sub-projects, like api/ and cli/"},
+	BetaPattern:           {Remote, TypeRegexp, "Tags matching the pattern are beta releases"},
+	NightlyPattern:        {Remote, TypeRegexp, "Tags matching the pattern are nightly builds"},
+	AnnouncementPattern:   {Remote, TypeRegexp, "Discussion and pinned issue titles announcing releases"},
+	AnnouncementCategory:  {Remote, TypeString, "Discussion category of announcements, by default Announcements"},
+	GitUrl:                {Remote, TypeUrl, "Git remote of the repository, may contain {repository}"},
+	ChartRepository:       {Remote, TypeUrl, "Helm chart repository or oci:// registry"},
+	ScrapeUrl:             {Remote, TypeUrl, "Download page, may contain {repository}"},
+	ScrapePattern:         {Remote, TypeRegexp, "Extracts versions from the download page"},
+	ScrapeSelector:        {Remote, TypeString, "CSS selector of the elements containing versions"},
+	MirrorTarget:          {Remote, TypeUrl, "Target grm mirror uploads release assets to, an s3:// bucket, an HTTP repository or a ghe:// Github Enterprise owner"},
+	MirrorLayout:          {Remote, TypeString, "Path of mirrored assets in the mirror-target, by default {account}/{repository}/{tag}/{asset}"},
+
+	CacheMaxSize:   {Core, TypeSize, "Maximum size of the HTTP cache, by default 100MB"},
+	PushgatewayUrl: {Core, TypeUrl, "Prometheus Pushgateway report metrics are pushed to"},
+	StatsdAddress:  {Core, TypeString, "host:port of the statsd server report metrics are sent to"},
+	OtlpEndpoint:   {Core, TypeUrl, "OpenTelemetry endpoint report traces are exported to"},
+	Language:       {Core, TypeString, "Language of reports: en, de, es or fr"},
+	Timezone:       {Core, TypeString, "IANA time zone of dates in reports, by default UTC"},
+	StateBackend:   {Core, TypeUrl, "Backend of the state, like s3://bucket/grm/state.json"},
+	AuditLog:       {Core, TypePath, "Audit log of notifications, downloads, installations and mirrored assets, by default audit.log in the GRM directory"},
+	ApiToken:       {Core, TypeSecret, "Admin token of the serve API"},
+	Encryption:     {Core, TypeString, "Encryption of stored passwords: machine (default), key, age or ssh-agent"},
+	AgeRecipients:  {Core, TypePath, "age recipients file passwords are encrypted to"},
+	AgeIdentity:    {Core, TypePath, "age identity file passwords are decrypted with, may be a hardware key"},
+	EncryptionKey:  {Core, TypeSecret, "256 bit key of the key encryption, hex or base64, usually a reference like file:/run/secrets/grm-key"},
+	CryptoPolicy:   {Core, TypeString, "Crypto policy of the credential store: default or fips"},
+	OAuthClientId:  {Core, TypeString, "Client ID of the Github OAuth app authorizing grm init"},
+	SshAgentKey:    {Core, TypeString, "Fingerprint or comment of the SSH agent key passwords are encrypted with, by default the first"},
+
+	Role: {ApiUser, TypeString, "Role of the API user with the api-token of the section: viewer (default) or admin"},
+}
+
+// sharedKeyDocumentations documents keys which are also used in another section than the one of
+// keyDocumentations
+var sharedKeyDocumentations = []struct {
+	key Key
+	keyDocumentation
+}{
+	{ApiToken, keyDocumentation{ApiUser, TypeSecret, "Token of the API user, required"}},
+}
+
+// sectionRanks orders the sections of Keys
+var sectionRanks = map[Section]int{Core: 0, Remote: 1, ApiUser: 2}
+
+// Keys describes all keys which can be configured, ordered by section and name. Keys used in more
+// than one section are described once per section.
+func Keys() []KeyDescription {
+	keys := make([]KeyDescription, 0, len(keyLookup)+len(sharedKeyDocumentations))
+	for _, k := range keyLookup {
+		documentation := keyDocumentations[k]
+		section := documentation.section
+		if section == nil {
+			section = Remote
+		}
+		keys = append(keys, KeyDescription{
+			Key:         k,
+			Section:     section,
+			Type:        documentation.valueType,
+			Description: documentation.description,
+		})
+	}
+	for _, shared := range sharedKeyDocumentations {
+		keys = append(keys, KeyDescription{
+			Key:         shared.key,
+			Section:     shared.section,
+			Type:        shared.valueType,
+			Description: shared.description,
+		})
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].Section != keys[j].Section {
+			return sectionRanks[keys[i].Section] < sectionRanks[keys[j].Section]
+		}
+		return keys[i].Key.Name() < keys[j].Key.Name()
+	})
+	return keys
+}
+
+// SectionName names a section without its placeholder, like Remote
+func SectionName(section Section) string {
+	return strings.Split(section.Name(), " ")[0]
+}
@@ -0,0 +1,40 @@
+package config
+
+import "testing"
+
+func TestCommandKeysNotExportable(t *testing.T) {
+	// Imported configurations must not run commands, exported ones must not carry credentials
+	for _, description := range Keys() {
+		if (description.Type == TypeCommand || description.Type == TypeSecret) && description.Key.Exportable() {
+			t.Errorf("%s is exported", description.Key.Name())
+		}
+	}
+	if Publish.Exportable() {
+		t.Errorf("%s is exported", Publish.Name())
+	}
+}
+
+func TestKeysOrder(t *testing.T) {
+	keys := Keys()
+	sections := []Section{}
+	for i, description := range keys {
+		if i == 0 || keys[i-1].Section != description.Section {
+			sections = append(sections, description.Section)
+		} else if keys[i-1].Key.Name() > description.Key.Name() {
+			t.Errorf("%s listed before %s", keys[i-1].Key.Name(), description.Key.Name())
+		}
+	}
+	if len(sections) != 3 || sections[0] != Core || sections[1] != Remote || sections[2] != ApiUser {
+		t.Errorf("sections %v, expected Core, Remote, ApiUser", sections)
+	}
+
+	found := map[Section]bool{}
+	for _, description := range keys {
+		if description.Key == ApiToken {
+			found[description.Section] = true
+		}
+	}
+	if !found[Core] || !found[ApiUser] {
+		t.Errorf("%s described in %v", ApiToken.Name(), found)
+	}
+}
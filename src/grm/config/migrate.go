@@ -0,0 +1,110 @@
+package config
+
+import (
+	"github.com/zieckey/goini"
+	"io/ioutil"
+	"os"
+	"fmt"
+	"reflect"
+	"strconv"
+)
+
+// ConfigVersion marks the layout of keys and sections a configuration was written with
+var ConfigVersion Key = key{"config-version", false, false}
+
+// migration upgrades a configuration of the previous version to its version
+type migration struct {
+	version     int
+	description string
+	apply       func(ini *goini.INI)
+}
+
+// migrations in order, new layouts of keys and sections append a migration
+var migrations = []migration{
+	{1, "mark configurations written before versioning", func(ini *goini.INI) {}},
+}
+
+// CurrentVersion is the version of configurations written by this release
+var CurrentVersion = migrations[len(migrations)-1].version
+
+// readVersion returns the version of the configuration, 0 for configurations without marker
+func readVersion(ini *goini.INI) (int, error) {
+	value, ok := ini.SectionGet(Core.Name(), ConfigVersion.Name())
+	if !ok {
+		return 0, nil
+	}
+	version, err := strconv.Atoi(value)
+	if err != nil || version < 0 {
+		return 0, fmt.Errorf("Invalid %s '%s'", ConfigVersion.Name(), value)
+	}
+	return version, nil
+}
+
+// migrate applies the migrations newer than the version of the configuration and marks it with the
+// current version. It returns the previous version.
+func migrate(ini *goini.INI) (int, error) {
+	version, err := readVersion(ini)
+	if err != nil {
+		return 0, err
+	}
+	if version > CurrentVersion {
+		return 0, fmt.Errorf("The configuration has version %d, this release only knows up to version %d, please upgrade grm",
+			version, CurrentVersion)
+	}
+	for _, m := range migrations {
+		if m.version > version {
+			m.apply(ini)
+		}
+	}
+	ini.SectionSet(Core.Name(), ConfigVersion.Name(), strconv.Itoa(CurrentVersion))
+	return version, nil
+}
+
+// migrateFile upgrades the configuration file in place, the previous file is kept as backup next to
+// it, like config.v0.bak. Migrations which only change the version leave the file untouched, the new
+// version is written with the next change of the configuration.
+func (c *configuration) migrateFile(configPath string) error {
+	if version, err := readVersion(c.ini); err != nil || version == CurrentVersion {
+		return err
+	}
+
+	data, err := ioutil.ReadFile(configPath)
+	if err != nil {
+		return fmt.Errorf("Could not read config file '%s': %s", configPath, err)
+	}
+	original := cloneIni(c.ini)
+	previous, err := migrate(c.ini)
+	if err != nil {
+		return err
+	}
+	original.SectionSet(Core.Name(), ConfigVersion.Name(), strconv.Itoa(CurrentVersion))
+	if sameValues(original, c.ini) {
+		return nil
+	}
+
+	backupPath := fmt.Sprintf("%s.v%d.bak", configPath, previous)
+	if err := ioutil.WriteFile(backupPath, data, 0600); err != nil {
+		return fmt.Errorf("Could not write config backup '%s': %s", backupPath, err)
+	}
+
+	if err := c.write(); err != nil {
+		return err
+	}
+	fmt.Fprintln(os.Stderr, fmt.Sprintf("Configuration migrated from version %d to %d, the previous configuration is kept in '%s'",
+		previous, CurrentVersion, backupPath))
+	return nil
+}
+
+// sameValues tells if both configurations have the same values, empty sections are ignored
+func sameValues(ini, other *goini.INI) bool {
+	values := func(ini *goini.INI) goini.SectionMap {
+		sections := goini.SectionMap{}
+		for section, kv := range ini.GetAll() {
+			if len(kv) > 0 {
+				sections[section] = kv
+			}
+		}
+		return sections
+	}
+	return reflect.DeepEqual(values(ini), values(other))
+}
@@ -0,0 +1,185 @@
+package config
+
+import (
+	"fmt"
+	"github.com/zieckey/goini"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func parseIni(t *testing.T, content string) *goini.INI {
+	ini := goini.New()
+	ini.SetParseSection(true)
+	ini.SetSkipCommits(true)
+	if err := ini.Parse([]byte(content), goini.DefaultLineSeparator, goini.DefaultKeyValueSeparator); err != nil {
+		t.Fatal(err)
+	}
+	return ini
+}
+
+func TestMigrate(t *testing.T) {
+	// Migrations recording that they were applied, in a property of the core section
+	previousMigrations, previousVersion := migrations, CurrentVersion
+	defer func() { migrations, CurrentVersion = previousMigrations, previousVersion }()
+	record := func(name string) func(ini *goini.INI) {
+		return func(ini *goini.INI) {
+			applied, _ := ini.SectionGet(Core.Name(), "applied")
+			ini.SectionSet(Core.Name(), "applied", strings.TrimSpace(applied+" "+name))
+		}
+	}
+	migrations = []migration{{1, "first", record("first")}, {2, "second", record("second")}, {3, "third", record("third")}}
+	CurrentVersion = 3
+
+	tests := []struct {
+		name     string
+		content  string
+		previous int
+		applied  string
+	}{
+		{"without marker", "[Core]\nverbose = true\n", 0, "first second third"},
+		{"empty", "", 0, "first second third"},
+		{"version 1", "[Core]\nconfig-version = 1\n", 1, "second third"},
+		{"version 2", "[Core]\nconfig-version = 2\n", 2, "third"},
+		{"current", "[Core]\nconfig-version = 3\n", 3, ""},
+	}
+	for _, test := range tests {
+		ini := parseIni(t, test.content)
+		if previous, err := migrate(ini); err != nil || previous != test.previous {
+			t.Errorf("%s: previous version %d, expected %d, %v", test.name, previous, test.previous, err)
+		}
+		if applied, _ := ini.SectionGet(Core.Name(), "applied"); applied != test.applied {
+			t.Errorf("%s: applied %q, expected %q", test.name, applied, test.applied)
+		}
+		if version, _ := readVersion(ini); version != 3 {
+			t.Errorf("%s: marked with version %d", test.name, version)
+		}
+	}
+}
+
+func TestMigrateErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		err     string
+	}{
+		{"not a number", "[Core]\nconfig-version = one\n", "Invalid config-version 'one'"},
+		{"negative", "[Core]\nconfig-version = -1\n", "Invalid config-version '-1'"},
+		{"too new", fmt.Sprintf("[Core]\nconfig-version = %d\n", CurrentVersion+1), "please upgrade grm"},
+	}
+	for _, test := range tests {
+		if _, err := migrate(parseIni(t, test.content)); err == nil || !strings.Contains(err.Error(), test.err) {
+			t.Errorf("%s: error %v, expected %q", test.name, err, test.err)
+		}
+		if _, err := ParseConfiguration([]byte(test.content)); err == nil {
+			t.Errorf("%s: parsed", test.name)
+		}
+
+		// The file is neither migrated nor backed up
+		home := writeConfiguration(t, test.content)
+		if _, err := LoadConfiguration(home); err == nil || !strings.Contains(err.Error(), test.err) {
+			t.Errorf("%s: loaded, error %v", test.name, err)
+		}
+		if backups, _ := filepath.Glob(filepath.Join(home, "github-release-monitor", "*.bak")); len(backups) != 0 {
+			t.Errorf("%s: backups %v", test.name, backups)
+		}
+		if content, _ := ioutil.ReadFile(filepath.Join(home, "github-release-monitor", "config")); string(content) != test.content {
+			t.Errorf("%s: file changed to %q", test.name, content)
+		}
+	}
+}
+
+// writeConfiguration creates a home directory with the configuration file
+func writeConfiguration(t *testing.T, content string) string {
+	home := t.TempDir()
+	grmPath := filepath.Join(home, "github-release-monitor")
+	if err := os.MkdirAll(grmPath, 0700); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(grmPath, "config"), []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return home
+}
+
+func TestMigrateFile(t *testing.T) {
+	previousMigrations := migrations
+	defer func() { migrations = previousMigrations }()
+	rename := func(ini *goini.INI) {
+		if value, ok := ini.SectionGet(Core.Name(), "verbose"); ok {
+			ini.Delete(Core.Name(), "verbose")
+			ini.SectionSet(Core.Name(), "debug", value)
+		}
+	}
+
+	tests := []struct {
+		name    string
+		apply   func(ini *goini.INI)
+		content string
+		backup  string
+		key     string
+	}{
+		{"without marker", rename, "[Core]\nverbose = true\n", "config.v0.bak", "debug"},
+		{"only the marker", rename, "[Core]\ndebug = true\n", "", "debug"},
+		{"no-op migration", func(ini *goini.INI) {}, "[Core]\nverbose = true\n[Remote \"empty\"]\n", "", "verbose"},
+		{"current", rename, "[Core]\nconfig-version = 1\nverbose = true\n", "", "verbose"},
+	}
+	for _, test := range tests {
+		migrations = []migration{{1, test.name, test.apply}}
+		home := writeConfiguration(t, test.content)
+		grmPath := filepath.Join(home, "github-release-monitor")
+
+		c, err := LoadConfiguration(home)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if value, _ := c.(*configuration).ini.SectionGet(Core.Name(), test.key); value != "true" {
+			t.Errorf("%s: lost %s, %q", test.name, test.key, value)
+		}
+		if version, _ := readVersion(c.(*configuration).ini); version != CurrentVersion {
+			t.Errorf("%s: migrated to version %d", test.name, version)
+		}
+
+		files, _ := ioutil.ReadDir(grmPath)
+		backups := []string{}
+		for _, file := range files {
+			if strings.HasSuffix(file.Name(), ".bak") {
+				backups = append(backups, file.Name())
+			}
+		}
+		if strings.Join(backups, " ") != test.backup {
+			t.Errorf("%s: backups %v, expected %q", test.name, backups, test.backup)
+		}
+		if test.backup != "" {
+			if backup, _ := ioutil.ReadFile(filepath.Join(grmPath, test.backup)); string(backup) != test.content {
+				t.Errorf("%s: backup %q", test.name, backup)
+			}
+		} else if content, _ := ioutil.ReadFile(filepath.Join(grmPath, "config")); string(content) != test.content {
+			t.Errorf("%s: file changed to %q", test.name, content)
+		}
+
+		// The file is marked with the current version once it's written
+		if err := c.ApplyChanges(func(mutator Mutator) { mutator.SectionSet(Core, Language, "", "de") }); err != nil {
+			t.Fatal(err)
+		}
+		migrated, err := LoadConfiguration(home)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if version, _ := readVersion(migrated.(*configuration).ini); version != CurrentVersion {
+			t.Errorf("%s: file marked with version %d", test.name, version)
+		}
+	}
+}
+
+func TestParseConfigurationMigratesInMemory(t *testing.T) {
+	c, err := ParseConfiguration([]byte("[Core]\nverbose = true\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if version, _ := readVersion(c.(*configuration).ini); version != CurrentVersion {
+		t.Errorf("version %d", version)
+	}
+}
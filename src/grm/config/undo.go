@@ -0,0 +1,153 @@
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Number of configuration changes which can be undone
+const UndoDepth = 10
+
+// UndoEntry is the configuration file before a command changed it
+type UndoEntry struct {
+	Time    time.Time `json:"time"`
+	Command string    `json:"command"`
+	// Existed is false if the command created the configuration file
+	Existed bool   `json:"existed"`
+	Config  string `json:"config"`
+	path    string
+}
+
+// Describes the running command in the undo log, like remote remove hashicorp
+var changeDescription = ""
+
+// SetChangeDescription sets the description of the changes of the running command, the values following
+// secret properties, like config set NAME token <token>, are masked because grm undo --list prints them.
+// Sink URLs of publish are masked too, they carry passwords and tokens.
+func SetChangeDescription(description string) {
+	fields := strings.Fields(description)
+	for i := 0; i < len(fields)-1; i++ {
+		key := KeyLookup(fields[i])
+		if key != nil && (keyDocumentations[key].valueType == TypeSecret || key == Publish) && !strings.HasPrefix(fields[i+1], "-") {
+			fields[i+1] = "<redacted>"
+		}
+	}
+	changeDescription = strings.Join(fields, " ")
+}
+
+func undoPath(homeDir string) string {
+	return filepath.Join(homeDir, "github-release-monitor", "undo")
+}
+
+// journal records the configuration file before a change, every change is undone on its own, also the
+// ones of a daemon changing the configuration repeatedly
+func (c *configuration) journal() error {
+	if c.homeDir == "" {
+		return nil
+	}
+
+	configPath := filepath.Join(c.homeDir, "github-release-monitor", "config")
+	entry := UndoEntry{Time: time.Now().UTC(), Command: changeDescription}
+	if data, err := ioutil.ReadFile(configPath); err == nil {
+		entry.Existed, entry.Config = true, string(data)
+	}
+
+	directory := undoPath(c.homeDir)
+	if err := os.MkdirAll(directory, 0700); err != nil {
+		return fmt.Errorf("Could not create undo directory '%s': %s", directory, err)
+	}
+	data, _ := json.Marshal(entry)
+	path := filepath.Join(directory, fmt.Sprintf("%d.json", entry.Time.UnixNano()))
+	if err := ioutil.WriteFile(path, data, 0600); err != nil {
+		return fmt.Errorf("Could not write undo log '%s': %s", path, err)
+	}
+
+	// Only the latest changes are kept
+	entries := readJournal(c.homeDir)
+	for i := UndoDepth; i < len(entries); i++ {
+		os.Remove(entries[i].path)
+	}
+	return nil
+}
+
+// ReadUndoEntries returns the recorded configuration changes which can be undone, the latest first
+func ReadUndoEntries(homeDir string) []UndoEntry {
+	entries, _ := splitUnchanged(homeDir, readJournal(homeDir))
+	return entries
+}
+
+// splitUnchanged splits off the latest entries equal to the configuration file, commands failing
+// before changing anything leave those behind
+func splitUnchanged(homeDir string, entries []UndoEntry) ([]UndoEntry, []UndoEntry) {
+	current, err := ioutil.ReadFile(filepath.Join(homeDir, "github-release-monitor", "config"))
+	existed := err == nil
+	i := 0
+	for i < len(entries) && entries[i].Existed == existed && entries[i].Config == string(current) {
+		i++
+	}
+	return entries[i:], entries[:i]
+}
+
+func readJournal(homeDir string) []UndoEntry {
+	entries := make([]UndoEntry, 0)
+	files, err := ioutil.ReadDir(undoPath(homeDir))
+	if err != nil {
+		return entries
+	}
+	for _, file := range files {
+		if !strings.HasSuffix(file.Name(), ".json") {
+			continue
+		}
+		path := filepath.Join(undoPath(homeDir), file.Name())
+		data, err := ioutil.ReadFile(path)
+		if err != nil {
+			continue
+		}
+		entry := UndoEntry{}
+		if json.Unmarshal(data, &entry) != nil {
+			continue
+		}
+		entry.path = path
+		entries = append(entries, entry)
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].Time.After(entries[j].Time)
+	})
+	return entries
+}
+
+// Undo restores the configuration file before the latest recorded change and removes the change from
+// the log. Undo itself is not recorded.
+func Undo(homeDir string) (UndoEntry, error) {
+	entries, unchanged := splitUnchanged(homeDir, readJournal(homeDir))
+	for _, entry := range unchanged {
+		os.Remove(entry.path)
+	}
+	if len(entries) == 0 {
+		return UndoEntry{}, errors.New("no configuration changes to undo")
+	}
+	entry := entries[0]
+
+	var err error
+	configPath := filepath.Join(homeDir, "github-release-monitor", "config")
+	if entry.Existed {
+		err = replaceFile(configPath, func(writer io.Writer) error {
+			_, err := io.WriteString(writer, entry.Config)
+			return err
+		})
+	} else if err = os.Remove(configPath); os.IsNotExist(err) {
+		err = nil
+	}
+	if err != nil {
+		return entry, err
+	}
+	return entry, os.Remove(entry.path)
+}
@@ -0,0 +1,187 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"regexp"
+	"runtime"
+	"runtime/debug"
+	"sort"
+	"strings"
+	"time"
+	"grm/config"
+)
+
+// Exit status after a crash, log.Fatal exits with 1
+const crashExitStatus = 2
+
+// Credentials in URLs like redis://:password@redis:6379/0
+var urlCredentialsPattern = regexp.MustCompile(`://[^/@\s]+@`)
+
+// Webhook tokens in URL paths like https://chat.example.com/hooks/id/token
+var webhookPathPattern = regexp.MustCompile(`/hooks/[^?#\s]+`)
+
+// Query parameters like ?secret=shared-secret, secret ones match secretParameterPattern
+var queryParameterPattern = regexp.MustCompile(`[?&][^=&#\s]+=[^&#\s]*`)
+
+// recoverCrash turns a panic into a crash bundle instead of a raw stack trace. It has to be deferred
+// by main and by every goroutine, panics don't cross goroutines.
+func recoverCrash() {
+	r := recover()
+	if r == nil {
+		return
+	}
+
+	bundle := buildCrashBundle(r, debug.Stack())
+	fmt.Fprintln(os.Stderr, fmt.Sprintf("GRM crashed: %v", r))
+	path, err := writeCrashBundle(bundle)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, fmt.Sprintf("Could not write crash report: %s", err))
+		os.Stderr.Write(bundle)
+		os.Exit(crashExitStatus)
+	}
+	fmt.Fprintln(os.Stderr, fmt.Sprintf("A crash report was written to %s", path))
+	fmt.Fprintln(os.Stderr, "Please attach it to an issue of the GRM project, after checking it contains nothing confidential")
+	os.Exit(crashExitStatus)
+}
+
+// buildCrashBundle collects the panic, its stack trace, the versions and a summary of the configuration
+// without credentials
+func buildCrashBundle(r interface{}, stack []byte) []byte {
+	var bundle bytes.Buffer
+	fmt.Fprintln(&bundle, "GRM crash report")
+	fmt.Fprintln(&bundle, "")
+	fmt.Fprintln(&bundle, fmt.Sprintf("Time: %s", time.Now().UTC().Format(time.RFC3339)))
+	fmt.Fprintln(&bundle, fmt.Sprintf("Version: %s (%s)", buildVersion, buildDate))
+	fmt.Fprintln(&bundle, fmt.Sprintf("Go: %s %s/%s", runtime.Version(), runtime.GOOS, runtime.GOARCH))
+	fmt.Fprintln(&bundle, fmt.Sprintf("Command: %s", strings.Join(sanitizeArguments(os.Args[1:]), " ")))
+	fmt.Fprintln(&bundle, "")
+	fmt.Fprintln(&bundle, fmt.Sprintf("Panic: %v", r))
+	fmt.Fprintln(&bundle, "")
+	bundle.Write(stack)
+	fmt.Fprintln(&bundle, "")
+	fmt.Fprintln(&bundle, "Configuration:")
+	writeConfigurationSummary(&bundle)
+	return bundle.Bytes()
+}
+
+func writeCrashBundle(bundle []byte) (string, error) {
+	if err := os.MkdirAll(grmPath("crashes"), 0700); err != nil {
+		return "", err
+	}
+	path := grmPath("crashes", fmt.Sprintf("crash-%s.txt", time.Now().UTC().Format("20060102-150405")))
+	return path, ioutil.WriteFile(path, bundle, 0600)
+}
+
+// sanitizeArguments drops the values of credential options and secret properties, like
+// grm auth -p=<password> or grm config set NAME token <token>
+func sanitizeArguments(arguments []string) []string {
+	sanitized := make([]string, 0, len(arguments))
+	redactNext := false
+	for _, argument := range arguments {
+		switch {
+		case redactNext:
+			argument, redactNext = "<redacted>", false
+		case argument == "-p" || argument == "--password":
+			redactNext = true
+		case strings.HasPrefix(argument, "-p=") || strings.HasPrefix(argument, "--password="):
+			argument = argument[:strings.Index(argument, "=")+1] + "<redacted>"
+		}
+		sanitized = append(sanitized, redactSecrets(argument))
+	}
+
+	if value := configSetValue(arguments); value >= 0 {
+		sanitized[value] = "<redacted>"
+	}
+	return sanitized
+}
+
+// configSetValue returns the position of the value of grm config set when it sets a secret property,
+// -1 otherwise
+func configSetValue(arguments []string) int {
+	if len(arguments) < 2 || arguments[0] != "config" || arguments[1] != "set" {
+		return -1
+	}
+
+	positionals := []int{}
+	for i := 2; i < len(arguments); i++ {
+		switch argument := arguments[i]; {
+		case argument == "--repository":
+			i++
+		case strings.HasPrefix(argument, "-"):
+		default:
+			positionals = append(positionals, i)
+		}
+	}
+	if len(positionals) < 2 {
+		return -1
+	}
+
+	key := arguments[positionals[len(positionals)-2]]
+	if !secretProperty(strings.Split(key, ":")[0]) {
+		return -1
+	}
+	return positionals[len(positionals)-1]
+}
+
+// secretProperty tells if the values of a property are credentials
+func secretProperty(name string) bool {
+	if name == config.Username.Name() {
+		return true
+	}
+	for _, description := range config.Keys() {
+		if description.Key.Name() == name {
+			return description.Type == config.TypeSecret
+		}
+	}
+	return false
+}
+
+// redactSecrets removes credentials embedded in urls: the user information, secret query parameters
+// and webhook tokens like the ones of Mattermost (/hooks/<key>) or Rocket.Chat (/hooks/<id>/<token>)
+func redactSecrets(value string) string {
+	value = urlCredentialsPattern.ReplaceAllString(value, "://<redacted>@")
+	value = webhookPathPattern.ReplaceAllString(value, "/hooks/<redacted>")
+	return queryParameterPattern.ReplaceAllStringFunc(value, func(parameter string) string {
+		separator := strings.Index(parameter, "=")
+		if !secretParameterPattern.MatchString(parameter[1:separator]) {
+			return parameter
+		}
+		return parameter[:separator+1] + "<redacted>"
+	})
+}
+
+// writeConfigurationSummary lists the global properties and the properties of all remote definitions,
+// credentials are redacted
+func writeConfigurationSummary(bundle *bytes.Buffer) {
+	if configuration == nil {
+		fmt.Fprintln(bundle, "  not loaded")
+		return
+	}
+
+	writeSectionSummary(bundle, "[Core]", configuration.Section(config.Core))
+	sections := configuration.NamedSections(config.Remote)
+	sort.Strings(sections)
+	for _, section := range sections {
+		writeSectionSummary(bundle, "["+section+"]", configuration.NamedSection(config.ExtractSpecifier(section), config.Remote))
+	}
+}
+
+func writeSectionSummary(bundle *bytes.Buffer, title string, values map[string]string) {
+	keys := make([]string, 0, len(values))
+	for k := range values {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	fmt.Fprintln(bundle, "  "+title)
+	for _, k := range keys {
+		value := redactSecrets(values[k])
+		if secretProperty(strings.Split(k, ":")[0]) {
+			value = "<redacted>"
+		}
+		fmt.Fprintln(bundle, fmt.Sprintf("  %s = %s", k, value))
+	}
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+	"grm/config"
+)
+
+// Shapes of the credentials of providers, the auth command prompts for them
+const (
+	// credentialAnonymous: public APIs, nothing to store
+	credentialAnonymous = "anonymous"
+	// credentialToken: a personal access token
+	credentialToken = "token"
+	// credentialAppKey: the key and secret of an API application
+	credentialAppKey = "app-key"
+	// credentialUsernamePassword: a username with a password, a personal access token or an app password
+	credentialUsernamePassword = "username+password"
+)
+
+// credentialPrompts are the prompts of the username or key and of the secret of a credential shape
+var credentialPrompts = map[string][2]string{
+	credentialToken:            {"", "Token:"},
+	credentialAppKey:           {"Key:", "Secret:"},
+	credentialUsernamePassword: {"Username:", "Password:"},
+}
+
+var githubUsernamePattern = regexp.MustCompile(`^[a-zA-Z0-9](-?[a-zA-Z0-9])*$`)
+
+// validateCredentials checks the entered credentials of the provider before they are stored
+func validateCredentials(provider, shape, username, secret string) error {
+	if prompts := credentialPrompts[shape]; prompts[0] != "" && strings.TrimSpace(username) == "" {
+		return fmt.Errorf("%s must not be empty", strings.TrimSuffix(prompts[0], ":"))
+	}
+	if secret == "" {
+		return fmt.Errorf("%s must not be empty", strings.TrimSuffix(credentialPrompts[shape][1], ":"))
+	}
+	if shape == credentialToken && strings.ContainsAny(secret, " \t\r\n") {
+		return fmt.Errorf("tokens contain no whitespace")
+	}
+	switch provider {
+	case providerGithub:
+		if !githubUsernamePattern.MatchString(username) {
+			return fmt.Errorf("invalid Github username '%s'", username)
+		}
+	case providerFirefox:
+		// Keys of the add-ons API look like user:12345:67
+		if strings.Count(username, ":") != 2 {
+			return fmt.Errorf("invalid add-ons API key '%s', expected user:<id>:<number>", username)
+		}
+	}
+	return nil
+}
+
+// readCredentials returns the username or key and the secret stored by the auth command, or the
+// plain token property. ok is false without credentials.
+func readCredentials(name string) (string, string, bool) {
+	username, _ := namedSectionGet(name, config.Remote, config.Username, "")
+	if token, ok := namedSectionGet(name, config.Remote, config.Token, ""); ok && token != "" {
+		return username, token, true
+	}
+	password, okp := namedSectionGet(name, config.Remote, config.Password, "")
+	salt, oks := namedSectionGet(name, config.Remote, config.Salt, "")
+	if !okp || (!oks && !isEncryptedWithoutSalt(password)) {
+		return username, "", false
+	}
+	return username, decryptSecret(name, password, salt), true
+}
+
+// signJwt creates a short-lived JSON web token signed with HMAC SHA-256, like the add-ons API wants
+func signJwt(issuer, secret string) string {
+	nonce := make([]byte, 16)
+	rand.Read(nonce)
+	now := time.Now().Unix()
+	claims, _ := json.Marshal(map[string]interface{}{"iss": issuer, "jti": hex.EncodeToString(nonce), "iat": now, "exp": now + 60})
+
+	encoding := base64.RawURLEncoding
+	unsigned := encoding.EncodeToString([]byte(`{"alg":"HS256","typ":"JWT"}`)) + "." + encoding.EncodeToString(claims)
+	signature := hmac.New(sha256.New, []byte(secret))
+	signature.Write([]byte(unsigned))
+	return unsigned + "." + encoding.EncodeToString(signature.Sum(nil))
+}
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"grm/config"
+)
+
+// Crypto policies. fips restricts the encrypted credential store to algorithms approved by FIPS 140-3
+// and keys provided from outside GRM, builds with the fips tag always enforce it.
+const (
+	cryptoPolicyDefault = "default"
+	cryptoPolicyFips    = "fips"
+)
+
+func readCryptoPolicy() string {
+	value, ok := sectionGet(config.Core, config.CryptoPolicy, "")
+	if !ok || value == "" {
+		return defaultCryptoPolicy
+	}
+	switch value = strings.ToLower(value); value {
+	case cryptoPolicyFips:
+		return value
+	case cryptoPolicyDefault:
+		if defaultCryptoPolicy == cryptoPolicyFips {
+			log.Fatal(fmt.Sprintf("This build of GRM enforces the %s %s, please remove the %s property",
+				config.CryptoPolicy.Name(), cryptoPolicyFips, config.CryptoPolicy.Name()))
+		}
+		return value
+	}
+	log.Fatal(fmt.Sprintf("Unknown %s '%s', supported are: %s, %s", config.CryptoPolicy.Name(), value,
+		cryptoPolicyDefault, cryptoPolicyFips))
+	return ""
+}
+
+// checkCryptoPolicy returns an error if the crypto policy forbids the encryption scheme of passwords.
+// Only the key encryption uses approved algorithms, AES-GCM and HMAC-SHA256, with a key provided from
+// outside GRM, the machine id is no key.
+func checkCryptoPolicy(scheme string) error {
+	if readCryptoPolicy() != cryptoPolicyFips {
+		return nil
+	}
+	if !fipsModuleEnabled() {
+		return fmt.Errorf("%s %s requires the Go FIPS 140-3 module, please run GRM with GODEBUG=fips140=on "+
+			"or build it with ./build.sh --fips", config.CryptoPolicy.Name(), cryptoPolicyFips)
+	}
+	if scheme != encryptionKey {
+		return fmt.Errorf("%s encryption is not allowed by %s %s, please use %s encryption with an "+
+			"externally provided %s and run grm auth again", scheme, config.CryptoPolicy.Name(), cryptoPolicyFips,
+			encryptionKey, config.EncryptionKey.Name())
+	}
+	return nil
+}
+
+// checkSignaturePolicy returns an error if the crypto policy forbids the signature method, minisign
+// signs BLAKE2b hashes
+func checkSignaturePolicy(method string) error {
+	if method == "minisign" && readCryptoPolicy() == cryptoPolicyFips {
+		return errors.New("signature method 'minisign' is not allowed by crypto-policy fips, please use ssh or gpg")
+	}
+	return nil
+}
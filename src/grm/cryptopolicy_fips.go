@@ -0,0 +1,7 @@
+//go:build fips
+// +build fips
+
+package main
+
+// Builds with the fips tag enforce the fips crypto policy, see ./build.sh --fips
+const defaultCryptoPolicy = cryptoPolicyFips
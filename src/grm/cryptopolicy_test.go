@@ -0,0 +1,63 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCheckCryptoPolicy(t *testing.T) {
+	// The fips policy requires the Go FIPS 140-3 module before looking at the scheme
+	fipsError := "requires the Go FIPS 140-3 module"
+	if fipsModuleEnabled() {
+		fipsError = "is not allowed by crypto-policy fips"
+	}
+	tests := []struct {
+		policy string
+		scheme string
+		err    string
+	}{
+		{"default", encryptionMachine, ""},
+		{"default", encryptionAge, ""},
+		{"default", encryptionSshAgent, ""},
+		{"default", encryptionKey, ""},
+		{"FIPS", encryptionMachine, fipsError},
+		{"fips", encryptionAge, fipsError},
+		{"fips", encryptionSshAgent, fipsError},
+		{"fips", "machine (shared key)", fipsError},
+	}
+	for _, test := range tests {
+		useConfiguration(t, "[Core]\ncrypto-policy = "+test.policy+"\n")
+		err := checkCryptoPolicy(test.scheme)
+		switch {
+		case test.err == "" && err != nil:
+			t.Errorf("%s %s: %s", test.policy, test.scheme, err)
+		case test.err != "" && (err == nil || !strings.Contains(err.Error(), test.err)):
+			t.Errorf("%s %s: error %v, expected %q", test.policy, test.scheme, err, test.err)
+		}
+	}
+
+	useConfiguration(t, "[Core]\ncrypto-policy = fips\n")
+	if err := checkCryptoPolicy(encryptionKey); (err == nil) != fipsModuleEnabled() {
+		t.Errorf("key encryption under fips: %v", err)
+	}
+}
+
+func TestCheckSignaturePolicy(t *testing.T) {
+	tests := []struct {
+		policy  string
+		method  string
+		allowed bool
+	}{
+		{"default", "minisign", true},
+		{"default", "ssh", true},
+		{"fips", "minisign", false},
+		{"fips", "ssh", true},
+		{"fips", "gpg", true},
+	}
+	for _, test := range tests {
+		useConfiguration(t, "[Core]\ncrypto-policy = "+test.policy+"\n")
+		if err := checkSignaturePolicy(test.method); (err == nil) != test.allowed {
+			t.Errorf("%s %s: %v", test.policy, test.method, err)
+		}
+	}
+}
@@ -0,0 +1,53 @@
+package dataset
+
+import (
+	"fmt"
+	"time"
+)
+
+// ColumnType is the type of the values of a column
+type ColumnType int
+
+const (
+	// String columns hold string values
+	String ColumnType = iota
+	// Timestamp columns hold time.Time values, stored in UTC
+	Timestamp
+)
+
+type Column struct {
+	Name string
+	Type ColumnType
+}
+
+// Table is a flat table written by WriteSqlite and WriteParquet. Values are strings or time.Time
+// matching the column types, nil values are NULL.
+type Table struct {
+	Name    string
+	Columns []Column
+	Rows    [][]interface{}
+}
+
+// Append adds a row, it panics if the values don't match the columns
+func (t *Table) Append(values ...interface{}) {
+	if len(values) != len(t.Columns) {
+		panic(fmt.Sprintf("table %s has %d columns, got %d values", t.Name, len(t.Columns), len(values)))
+	}
+	for i, value := range values {
+		if value == nil {
+			continue
+		}
+		switch value.(type) {
+		case string:
+			if t.Columns[i].Type == String {
+				continue
+			}
+		case time.Time:
+			if t.Columns[i].Type == Timestamp {
+				continue
+			}
+		}
+		panic(fmt.Sprintf("column %s of table %s can't hold %T", t.Columns[i].Name, t.Name, value))
+	}
+	t.Rows = append(t.Rows, values)
+}
@@ -0,0 +1,272 @@
+package dataset
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"time"
+)
+
+// Parquet format constants, see https://github.com/apache/parquet-format
+const (
+	parquetMagic = "PAR1"
+
+	parquetInt64     = 2
+	parquetByteArray = 6
+
+	parquetOptional = 1
+
+	parquetUtf8            = 0
+	parquetTimestampMillis = 9
+
+	parquetPlain        = 0
+	parquetRle          = 3
+	parquetUncompressed = 0
+	parquetDataPage     = 0
+)
+
+// Thrift compact protocol types
+const (
+	thriftTrue   = 1
+	thriftFalse  = 2
+	thriftI32    = 5
+	thriftI64    = 6
+	thriftBinary = 8
+	thriftList   = 9
+	thriftStruct = 12
+)
+
+// thriftWriter encodes structs in the Thrift compact protocol used by Parquet metadata
+type thriftWriter struct {
+	buffer bytes.Buffer
+	// Last field ids of the enclosing structs, field ids are delta encoded
+	fields []int16
+}
+
+func (t *thriftWriter) varint(value uint64) {
+	data := make([]byte, binary.MaxVarintLen64)
+	t.buffer.Write(data[:binary.PutUvarint(data, value)])
+}
+
+func (t *thriftWriter) zigzag(value int64) {
+	t.varint(uint64((value << 1) ^ (value >> 63)))
+}
+
+func (t *thriftWriter) field(id int16, kind byte) {
+	last := t.fields[len(t.fields)-1]
+	if delta := id - last; delta > 0 && delta <= 15 {
+		t.buffer.WriteByte(byte(delta)<<4 | kind)
+	} else {
+		t.buffer.WriteByte(kind)
+		t.zigzag(int64(id))
+	}
+	t.fields[len(t.fields)-1] = id
+}
+
+func (t *thriftWriter) i32(id int16, value int32) {
+	t.field(id, thriftI32)
+	t.zigzag(int64(value))
+}
+
+func (t *thriftWriter) i64(id int16, value int64) {
+	t.field(id, thriftI64)
+	t.zigzag(value)
+}
+
+func (t *thriftWriter) bool(id int16, value bool) {
+	if value {
+		t.field(id, thriftTrue)
+	} else {
+		t.field(id, thriftFalse)
+	}
+}
+
+func (t *thriftWriter) binary(value string) {
+	t.varint(uint64(len(value)))
+	t.buffer.WriteString(value)
+}
+
+func (t *thriftWriter) string(id int16, value string) {
+	t.field(id, thriftBinary)
+	t.binary(value)
+}
+
+func (t *thriftWriter) list(id int16, kind byte, size int) {
+	t.field(id, thriftList)
+	if size < 15 {
+		t.buffer.WriteByte(byte(size)<<4 | kind)
+	} else {
+		t.buffer.WriteByte(0xf0 | kind)
+		t.varint(uint64(size))
+	}
+}
+
+// begin starts a struct, either as field or as list element with id 0
+func (t *thriftWriter) begin(id int16) {
+	if id != 0 {
+		t.field(id, thriftStruct)
+	}
+	t.fields = append(t.fields, 0)
+}
+
+func (t *thriftWriter) end() {
+	t.buffer.WriteByte(0)
+	t.fields = t.fields[:len(t.fields)-1]
+}
+
+func newThriftWriter() *thriftWriter {
+	return &thriftWriter{fields: []int16{0}}
+}
+
+type parquetChunk struct {
+	offset int64
+	size   int64
+	values int
+}
+
+// WriteParquet writes the table as uncompressed Parquet file with a single row group, all columns
+// are optional. Strings are UTF8 byte arrays, timestamps INT64 milliseconds since the epoch in UTC.
+func WriteParquet(writer io.Writer, table Table) error {
+	var file bytes.Buffer
+	file.WriteString(parquetMagic)
+
+	chunks := make([]parquetChunk, 0, len(table.Columns))
+	for i := range table.Columns {
+		var levels, values bytes.Buffer
+		defined := make([]bool, 0, len(table.Rows))
+		for _, row := range table.Rows {
+			defined = append(defined, row[i] != nil)
+			switch v := row[i].(type) {
+			case string:
+				binary.Write(&values, binary.LittleEndian, uint32(len(v)))
+				values.WriteString(v)
+			case time.Time:
+				binary.Write(&values, binary.LittleEndian, v.UnixNano()/int64(time.Millisecond))
+			}
+		}
+		writeDefinitionLevels(&levels, defined)
+
+		var data bytes.Buffer
+		binary.Write(&data, binary.LittleEndian, uint32(levels.Len()))
+		data.Write(levels.Bytes())
+		data.Write(values.Bytes())
+
+		header := newThriftWriter()
+		header.i32(1, parquetDataPage)
+		header.i32(2, int32(data.Len()))
+		header.i32(3, int32(data.Len()))
+		header.begin(5)
+		header.i32(1, int32(len(table.Rows)))
+		header.i32(2, parquetPlain)
+		header.i32(3, parquetRle)
+		header.i32(4, parquetRle)
+		header.end()
+		header.end()
+
+		chunk := parquetChunk{offset: int64(file.Len()), values: len(table.Rows)}
+		file.Write(header.buffer.Bytes())
+		file.Write(data.Bytes())
+		chunk.size = int64(file.Len()) - chunk.offset
+		chunks = append(chunks, chunk)
+	}
+
+	footer := newThriftWriter()
+	footer.i32(1, 1)
+	footer.list(2, thriftStruct, len(table.Columns)+1)
+	footer.begin(0)
+	footer.string(4, "schema")
+	footer.i32(5, int32(len(table.Columns)))
+	footer.end()
+	for _, column := range table.Columns {
+		footer.begin(0)
+		footer.i32(1, parquetType(column))
+		footer.i32(3, parquetOptional)
+		footer.string(4, column.Name)
+		if column.Type == Timestamp {
+			footer.i32(6, parquetTimestampMillis)
+			// Logical type TIMESTAMP(isAdjustedToUTC=true, unit=MILLIS)
+			footer.begin(10)
+			footer.begin(8)
+			footer.bool(1, true)
+			footer.begin(2)
+			footer.begin(1)
+			footer.end()
+			footer.end()
+			footer.end()
+			footer.end()
+		} else {
+			footer.i32(6, parquetUtf8)
+			// Logical type STRING
+			footer.begin(10)
+			footer.begin(1)
+			footer.end()
+			footer.end()
+		}
+		footer.end()
+	}
+	footer.i64(3, int64(len(table.Rows)))
+
+	var total int64
+	for _, chunk := range chunks {
+		total += chunk.size
+	}
+	footer.list(4, thriftStruct, 1)
+	footer.begin(0)
+	footer.list(1, thriftStruct, len(chunks))
+	for i, chunk := range chunks {
+		footer.begin(0)
+		footer.i64(2, chunk.offset)
+		footer.begin(3)
+		footer.i32(1, parquetType(table.Columns[i]))
+		footer.list(2, thriftI32, 2)
+		footer.zigzag(parquetPlain)
+		footer.zigzag(parquetRle)
+		footer.list(3, thriftBinary, 1)
+		footer.binary(table.Columns[i].Name)
+		footer.i32(4, parquetUncompressed)
+		footer.i64(5, int64(chunk.values))
+		footer.i64(6, chunk.size)
+		footer.i64(7, chunk.size)
+		footer.i64(9, chunk.offset)
+		footer.end()
+		footer.end()
+	}
+	footer.i64(2, total)
+	footer.i64(3, int64(len(table.Rows)))
+	footer.end()
+	footer.string(6, "github-release-monitor")
+	footer.end()
+
+	file.Write(footer.buffer.Bytes())
+	binary.Write(&file, binary.LittleEndian, uint32(footer.buffer.Len()))
+	file.WriteString(parquetMagic)
+
+	_, err := writer.Write(file.Bytes())
+	return err
+}
+
+func parquetType(column Column) int32 {
+	if column.Type == Timestamp {
+		return parquetInt64
+	}
+	return parquetByteArray
+}
+
+// writeDefinitionLevels encodes the levels of an optional column with the RLE hybrid encoding of
+// bit width 1, as runs of equal levels
+func writeDefinitionLevels(buffer *bytes.Buffer, defined []bool) {
+	data := make([]byte, binary.MaxVarintLen64)
+	for start := 0; start < len(defined); {
+		end := start
+		for end < len(defined) && defined[end] == defined[start] {
+			end++
+		}
+		buffer.Write(data[:binary.PutUvarint(data, uint64(end-start)<<1)])
+		if defined[start] {
+			buffer.WriteByte(1)
+		} else {
+			buffer.WriteByte(0)
+		}
+		start = end
+	}
+}
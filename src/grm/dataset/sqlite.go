@@ -0,0 +1,228 @@
+package dataset
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// The SQLite database file format, see https://www.sqlite.org/fileformat2.html
+const (
+	sqlitePageSize   = 4096
+	sqliteHeaderSize = 100
+	// Largest payload stored without overflow pages in a table b-tree leaf
+	sqliteMaxLocal = sqlitePageSize - 35
+
+	sqliteInteriorTable = 0x05
+	sqliteLeafTable     = 0x0d
+)
+
+type sqliteCell struct {
+	rowid int64
+	data  []byte
+}
+
+type sqlitePage struct {
+	number   int
+	interior bool
+	cells    []sqliteCell
+	// rightmost is the last child page of an interior page
+	rightmost int
+	// maxRowid is the largest rowid stored below the page
+	maxRowid int64
+}
+
+// WriteSqlite writes the table as SQLite database with a single table, timestamps are stored as
+// ISO 8601 text like SQLite's date and time functions expect
+func WriteSqlite(writer io.Writer, table Table) error {
+	columns := make([]string, 0, len(table.Columns))
+	for _, column := range table.Columns {
+		columns = append(columns, fmt.Sprintf("%s TEXT", sqliteQuote(column.Name)))
+	}
+
+	// Leaf pages start at page 2, page 1 holds the schema
+	leaves := make([]*sqlitePage, 0)
+	current := &sqlitePage{}
+	used := 0
+	for i, row := range table.Rows {
+		rowid := int64(i + 1)
+		record := sqliteRecord(row)
+		if len(record) > sqliteMaxLocal {
+			return fmt.Errorf("row %d of table %s is too large", rowid, table.Name)
+		}
+		cell := append(append(sqliteVarint(int64(len(record))), sqliteVarint(rowid)...), record...)
+		if 8+used+len(cell)+2 > sqlitePageSize {
+			leaves = append(leaves, current)
+			current, used = &sqlitePage{}, 0
+		}
+		current.cells = append(current.cells, sqliteCell{rowid, cell})
+		current.maxRowid = rowid
+		used += len(cell) + 2
+	}
+	leaves = append(leaves, current)
+
+	pages := make([]*sqlitePage, 0, len(leaves))
+	for _, leaf := range leaves {
+		leaf.number = len(pages) + 2
+		pages = append(pages, leaf)
+	}
+
+	// Interior levels until a single root page remains
+	level := leaves
+	for len(level) > 1 {
+		parents := make([]*sqlitePage, 0)
+		parent := &sqlitePage{interior: true}
+		used := 0
+		for _, child := range level {
+			cell := make([]byte, 4)
+			binary.BigEndian.PutUint32(cell, uint32(child.number))
+			cell = append(cell, sqliteVarint(child.maxRowid)...)
+			if 12+used+len(cell)+2 > sqlitePageSize {
+				parents = append(parents, parent)
+				parent, used = &sqlitePage{interior: true}, 0
+			}
+			parent.cells = append(parent.cells, sqliteCell{child.maxRowid, cell})
+			parent.maxRowid = child.maxRowid
+			used += len(cell) + 2
+		}
+		parents = append(parents, parent)
+		// Every interior page needs a cell besides the right-most pointer
+		if n := len(parents); n > 1 && len(parent.cells) == 1 {
+			previous := parents[n-2]
+			parent.cells = append([]sqliteCell{previous.cells[len(previous.cells)-1]}, parent.cells...)
+			previous.cells = previous.cells[:len(previous.cells)-1]
+			previous.maxRowid = previous.cells[len(previous.cells)-1].rowid
+		}
+
+		for _, p := range parents {
+			// The last cell of every interior page becomes its right-most pointer
+			last := p.cells[len(p.cells)-1]
+			p.rightmost = int(binary.BigEndian.Uint32(last.data[:4]))
+			p.cells = p.cells[:len(p.cells)-1]
+			p.number = len(pages) + 2
+			pages = append(pages, p)
+		}
+		level = parents
+	}
+	root := level[0].number
+
+	sql := fmt.Sprintf("CREATE TABLE %s (%s)", sqliteQuote(table.Name), strings.Join(columns, ", "))
+	schema := sqliteRecord([]interface{}{"table", table.Name, table.Name, int64(root), sql})
+	schemaPage := &sqlitePage{number: 1, cells: []sqliteCell{{1,
+		append(append(sqliteVarint(int64(len(schema))), sqliteVarint(1)...), schema...)}}}
+
+	if _, err := writer.Write(sqliteFileHeader(len(pages) + 1)); err != nil {
+		return err
+	}
+	if _, err := writer.Write(schemaPage.encode(sqliteHeaderSize)); err != nil {
+		return err
+	}
+	for _, page := range pages {
+		if _, err := writer.Write(page.encode(0)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func sqliteFileHeader(pages int) []byte {
+	header := make([]byte, sqliteHeaderSize)
+	copy(header, "SQLite format 3\x00")
+	binary.BigEndian.PutUint16(header[16:], sqlitePageSize)
+	header[18] = 1 // legacy write version
+	header[19] = 1 // legacy read version
+	header[21] = 64
+	header[22] = 32
+	header[23] = 32
+	binary.BigEndian.PutUint32(header[24:], 1) // file change counter
+	binary.BigEndian.PutUint32(header[28:], uint32(pages))
+	binary.BigEndian.PutUint32(header[40:], 1) // schema cookie
+	binary.BigEndian.PutUint32(header[44:], 4) // schema format
+	binary.BigEndian.PutUint32(header[56:], 1) // UTF-8
+	binary.BigEndian.PutUint32(header[92:], 1) // version valid for the change counter
+	binary.BigEndian.PutUint32(header[96:], 3040001)
+	return header
+}
+
+// encode lays out the page, offset is the size of the file header on page 1
+func (p *sqlitePage) encode(offset int) []byte {
+	page := make([]byte, sqlitePageSize-offset)
+	headerSize := 8
+	page[0] = sqliteLeafTable
+	if p.interior {
+		headerSize = 12
+		page[0] = sqliteInteriorTable
+		binary.BigEndian.PutUint32(page[8:], uint32(p.rightmost))
+	}
+	binary.BigEndian.PutUint16(page[3:], uint16(len(p.cells)))
+
+	// Cell content grows from the end of the page, offsets are relative to the page start
+	content := len(page)
+	for i, cell := range p.cells {
+		content -= len(cell.data)
+		copy(page[content:], cell.data)
+		binary.BigEndian.PutUint16(page[headerSize+2*i:], uint16(content+offset))
+	}
+	binary.BigEndian.PutUint16(page[5:], uint16(content+offset))
+	return page
+}
+
+// sqliteRecord encodes the values in the record format, strings and times as text
+func sqliteRecord(values []interface{}) []byte {
+	var types, body bytes.Buffer
+	for _, value := range values {
+		switch v := value.(type) {
+		case nil:
+			types.Write(sqliteVarint(0))
+		case string:
+			types.Write(sqliteVarint(int64(len(v))*2 + 13))
+			body.WriteString(v)
+		case time.Time:
+			text := v.UTC().Format("2006-01-02T15:04:05Z")
+			types.Write(sqliteVarint(int64(len(text))*2 + 13))
+			body.WriteString(text)
+		case int64:
+			data := make([]byte, 8)
+			binary.BigEndian.PutUint64(data, uint64(v))
+			types.Write(sqliteVarint(6))
+			body.Write(data)
+		}
+	}
+
+	// The header size includes its own varint, which is a single byte for all but huge headers
+	size := types.Len() + 1
+	if size > 127 {
+		size++
+	}
+	return append(append(sqliteVarint(int64(size)), types.Bytes()...), body.Bytes()...)
+}
+
+// sqliteVarint encodes big-endian base-128 varints of up to 9 bytes
+func sqliteVarint(value int64) []byte {
+	v := uint64(value)
+	if v > 0x00ffffffffffffff {
+		data := make([]byte, 9)
+		data[8] = byte(v)
+		v >>= 8
+		for i := 7; i >= 0; i-- {
+			data[i] = byte(v&0x7f) | 0x80
+			v >>= 7
+		}
+		return data
+	}
+
+	data := []byte{byte(v & 0x7f)}
+	v >>= 7
+	for v > 0 {
+		data = append([]byte{byte(v&0x7f) | 0x80}, data...)
+		v >>= 7
+	}
+	return data
+}
+
+func sqliteQuote(identifier string) string {
+	return `"` + strings.Replace(identifier, `"`, `""`, -1) + `"`
+}
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Query parameters whose values are redacted from logged URLs
+var secretParameterPattern = regexp.MustCompile(`(?i)token|key|secret|password|signature|sig|credential`)
+
+// debugOutput receives the HTTP trace of --debug-http, lines of concurrent requests aren't interleaved
+var (
+	debugOutput io.Writer = os.Stderr
+	debugMutex  sync.Mutex
+)
+
+// debugTransport logs every request with its response status, the rate limit and the time it took
+type debugTransport struct {
+	transport http.RoundTripper
+}
+
+// withHttpDebugging wraps the transport to trace its requests if --debug-http is set
+func withHttpDebugging(transport http.RoundTripper) http.RoundTripper {
+	if debugHttp == nil || !*debugHttp {
+		return transport
+	}
+	return &debugTransport{transport}
+}
+
+// Timeout of the requests to sinks, state backends and token endpoints
+const requestTimeout = 30 * time.Second
+
+// Timeout of the uploads of mirrors, release assets may take a while
+const uploadTimeout = 30 * time.Minute
+
+// newHttpClient returns a client of the shared HTTP cache
+func newHttpClient() *http.Client {
+	return &http.Client{Transport: withHttpDebugging(httpCache)}
+}
+
+// newTimeoutHttpClient returns a client of the shared HTTP cache whose requests fail after the timeout
+func newTimeoutHttpClient(timeout time.Duration) *http.Client {
+	client := newHttpClient()
+	client.Timeout = timeout
+	return client
+}
+
+func (t *debugTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	started := time.Now()
+	response, err := t.transport.RoundTrip(req)
+	elapsed := time.Since(started).Round(time.Millisecond)
+
+	line := fmt.Sprintf("[http] %s %s", req.Method, redactUrl(req.URL))
+	if err != nil {
+		line += fmt.Sprintf(" failed after %s: %s", elapsed, err)
+	} else {
+		line += fmt.Sprintf(" %s %s", response.Status, elapsed)
+		if rateLimit := formatRateLimit(response.Header); rateLimit != "" {
+			line += " " + rateLimit
+		}
+	}
+
+	debugMutex.Lock()
+	defer debugMutex.Unlock()
+	fmt.Fprintln(debugOutput, line)
+	return response, err
+}
+
+// redactUrl drops credentials of the URL and the values of secret query parameters
+func redactUrl(u *url.URL) string {
+	redacted := *u
+	if redacted.User != nil {
+		redacted.User = url.User("redacted")
+	}
+	query := redacted.Query()
+	for parameter := range query {
+		if secretParameterPattern.MatchString(parameter) {
+			query.Set(parameter, "redacted")
+		}
+	}
+	redacted.RawQuery = query.Encode()
+	return redacted.String()
+}
+
+// formatRateLimit renders the Github rate limit headers of a response, empty without them
+func formatRateLimit(header http.Header) string {
+	remaining, limit := header.Get("X-RateLimit-Remaining"), header.Get("X-RateLimit-Limit")
+	if remaining == "" {
+		return ""
+	}
+	rateLimit := fmt.Sprintf("rate-limit=%s/%s", remaining, limit)
+	if reset, err := strconv.ParseInt(header.Get("X-RateLimit-Reset"), 10, 64); err == nil {
+		rateLimit += fmt.Sprintf(" reset=%s", time.Unix(reset, 0).UTC().Format(time.RFC3339))
+	}
+	if resource := header.Get("X-RateLimit-Resource"); resource != "" {
+		rateLimit += " resource=" + strings.ToLower(resource)
+	}
+	return rateLimit
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"time"
+	"grm/state"
+)
+
+// Notification deliveries kept per remote definition for notify replay
+const deliveryHistory = 500
+
+const (
+	deliverySent   = "sent"
+	deliveryFailed = "failed"
+)
+
+// delivery is a release event sent to a sink, with the outcome of the latest attempt
+type delivery struct {
+	Event       releaseEvent `json:"event"`
+	Sink        string       `json:"sink"`
+	Status      string       `json:"status"`
+	Error       string       `json:"error,omitempty"`
+	Generated   time.Time    `json:"generated"`
+	LastAttempt time.Time    `json:"last_attempt"`
+	Attempts    int          `json:"attempts"`
+	key         string
+}
+
+// deliveryKey sorts the deliveries of a remote definition by the time the event was generated
+func deliveryKey(remote string, generated time.Time, s *sink, event releaseEvent) string {
+	checksum := sha256.Sum256([]byte(s.String() + "\x00" + event.Type + "\x00" + event.Repository + "\x00" + event.Tag))
+	return state.Key("deliveries", remote, generated.UTC().Format("20060102T150405.000000000Z")+"-"+hex.EncodeToString(checksum[:4]))
+}
+
+// recordDelivery keeps the outcome of sending the event to the sink, only the latest deliveries of the
+// remote definition are kept
+func recordDelivery(s *sink, event releaseEvent, err error) {
+	now := time.Now().UTC()
+	d := delivery{Event: event, Sink: s.String(), Status: deliverySent, Generated: now, LastAttempt: now, Attempts: 1}
+	if err != nil {
+		d.Status, d.Error = deliveryFailed, err.Error()
+	}
+	openState().Set(deliveryKey(event.Remote, now, s, event), d)
+
+	keys := openState().Keys(state.Key("deliveries", event.Remote) + "/")
+	for i := 0; i < len(keys)-deliveryHistory; i++ {
+		openState().Delete(keys[i])
+	}
+}
+
+// readDeliveries returns the recorded deliveries of the remote definition, the oldest first
+func readDeliveries(remote string) []delivery {
+	deliveries := make([]delivery, 0)
+	for _, key := range openState().Keys(state.Key("deliveries", remote) + "/") {
+		d := delivery{}
+		if openState().Get(key, &d) {
+			d.key = key
+			// The localized text of warnings isn't kept, replays send the English message
+			if d.Event.Warning != nil {
+				d.Event.Warning.text = d.Event.Warning.Message
+			}
+			deliveries = append(deliveries, d)
+		}
+	}
+	return deliveries
+}
+
+// redeliver sends the recorded event again and keeps the outcome
+func redeliver(d delivery, s *sink) error {
+	message, err := eventMessage(d.Event)
+	if err == nil {
+		err = s.Send(message)
+	}
+	auditNotification(s, d.Event, err)
+	d.Attempts++
+	d.LastAttempt = time.Now().UTC()
+	d.Status, d.Error = deliverySent, ""
+	if err != nil {
+		d.Status, d.Error = deliveryFailed, err.Error()
+	}
+	openState().Set(d.key, d)
+	return err
+}
@@ -0,0 +1,116 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+	"grm/config"
+)
+
+const (
+	githubDeviceCodeUrl  = "https://github.com/login/device/code"
+	githubAccessTokenUrl = "https://github.com/login/oauth/access_token"
+	// Scopes requested by the device flow, repo is needed for private repositories
+	githubDeviceScopes = "repo read:org"
+)
+
+// Client ID of the Github OAuth app of the device flow, set at build time with
+// -ldflags=-X=main.oauthClientId=<client id> or by the global oauth-client-id
+var oauthClientId = ""
+
+// deviceCode is the answer of Github to a device flow authorization request
+type deviceCode struct {
+	DeviceCode      string `json:"device_code"`
+	UserCode        string `json:"user_code"`
+	VerificationUri string `json:"verification_uri"`
+	ExpiresIn       int    `json:"expires_in"`
+	Interval        int    `json:"interval"`
+}
+
+// readOAuthClientId returns the client ID of the OAuth app, empty without app the device flow is not
+// available
+func readOAuthClientId() string {
+	if id := os.Getenv("GRM_OAUTH_CLIENT_ID"); id != "" {
+		return id
+	}
+	if id, ok := sectionGet(config.Core, config.OAuthClientId, ""); ok && id != "" {
+		return id
+	}
+	return oauthClientId
+}
+
+// requestDeviceCode starts the OAuth device flow, the user enters the user code at the verification URI
+func requestDeviceCode(client *http.Client, clientId string) (deviceCode, error) {
+	code := deviceCode{}
+	err := postOAuthForm(client, githubDeviceCodeUrl, url.Values{
+		"client_id": {clientId},
+		"scope":     {githubDeviceScopes},
+	}, &code)
+	if err == nil && code.DeviceCode == "" {
+		err = errors.New("Github returned no device code")
+	}
+	return code, err
+}
+
+// pollDeviceToken waits until the user authorized the device and returns the access token
+func pollDeviceToken(client *http.Client, clientId string, code deviceCode) (string, error) {
+	interval := time.Duration(code.Interval) * time.Second
+	if interval <= 0 {
+		interval = 5 * time.Second
+	}
+	expires := time.Now().Add(time.Duration(code.ExpiresIn) * time.Second)
+
+	for time.Now().Before(expires) {
+		time.Sleep(interval)
+
+		answer := struct {
+			AccessToken string `json:"access_token"`
+			Error       string `json:"error"`
+			Description string `json:"error_description"`
+		}{}
+		err := postOAuthForm(client, githubAccessTokenUrl, url.Values{
+			"client_id":   {clientId},
+			"device_code": {code.DeviceCode},
+			"grant_type":  {"urn:ietf:params:oauth:grant-type:device_code"},
+		}, &answer)
+		if err != nil {
+			return "", err
+		}
+
+		switch answer.Error {
+		case "":
+			return answer.AccessToken, nil
+		case "authorization_pending":
+		case "slow_down":
+			interval += 5 * time.Second
+		default:
+			return "", fmt.Errorf("%s: %s", answer.Error, answer.Description)
+		}
+	}
+	return "", errors.New("the device code expired before it was entered")
+}
+
+func postOAuthForm(client *http.Client, endpoint string, values url.Values, answer interface{}) error {
+	req, err := http.NewRequest(http.MethodPost, endpoint, strings.NewReader(values.Encode()))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+
+	response, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode != http.StatusOK {
+		return fmt.Errorf("%s responded with %s", endpoint, response.Status)
+	}
+	return json.NewDecoder(response.Body).Decode(answer)
+}
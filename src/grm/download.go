@@ -0,0 +1,216 @@
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"github.com/google/go-github/github"
+	"grm/config"
+	"grm/state"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+type downloadedAsset struct {
+	Name   string `json:"name"`
+	Url    string `json:"url"`
+	Sha256 string `json:"sha256"`
+	Size   int64  `json:"size"`
+	Path   string `json:"path"`
+}
+
+type downloadRecord struct {
+	Tag        string              `json:"tag"`
+	Downloaded time.Time           `json:"downloaded"`
+	Assets     []downloadedAsset   `json:"assets"`
+	Verdicts   []scanVerdict       `json:"verdicts,omitempty"`
+	Attested   []attestationResult `json:"attestations,omitempty"`
+	Installed  string              `json:"installed,omitempty"`
+}
+
+func downloadRecordKey(name, repository, tag string) string {
+	return state.Key("download", name, repository, tag)
+}
+
+func readDownloadRecord(name, repository, tag string) (downloadRecord, bool) {
+	record := downloadRecord{}
+	ok := openState().Get(downloadRecordKey(name, repository, tag), &record)
+	return record, ok
+}
+
+// readRelease reads the Github release for the given tag, or the latest release if no tag is given
+func readRelease(account, repository, tag string, client *github.Client) *github.RepositoryRelease {
+	ctx := context.Background()
+
+	for {
+		var (
+			release  *github.RepositoryRelease
+			response *github.Response
+			err      error
+		)
+		if tag == "" {
+			release, response, err = client.Repositories.GetLatestRelease(ctx, account, repository)
+		} else {
+			release, response, err = client.Repositories.GetReleaseByTag(ctx, account, repository, tag)
+		}
+
+		if response != nil && response.StatusCode == http.StatusNotFound {
+			return nil
+		}
+
+		if rateLimit(response) {
+			continue
+		}
+
+		if err != nil {
+			log.Fatal(fmt.Sprintf("Could not retrieve release for repository %s: ", repository), err)
+		}
+
+		return release
+	}
+}
+
+// extractVersion extracts the version from a tag name using the milestone pattern, the same way
+// releases are matched to milestones, and falls back to the tag name without sub-project prefix
+func extractVersion(name, repository, tag string) string {
+	subproject, prefix := subprojectOf(readSubprojects(name, repository), tag)
+	if p, ok := subprojectGet(name, config.MilestonePattern, repository, subproject); ok {
+		if pattern, err := compileNamePattern(name, p); err == nil {
+			if substrings := pattern.FindStringSubmatch(tag); len(substrings) > 1 {
+				return substrings[1]
+			}
+		}
+	}
+	return strings.TrimPrefix(tag, prefix)
+}
+
+// downloadAssets downloads all assets of a release into the target directory. If the release has no
+// assets attached, the configured download url is used instead.
+func downloadAssets(name, account, repository, tag string, release *github.RepositoryRelease,
+	client *github.Client, target string) []downloadedAsset {
+
+	if err := os.MkdirAll(target, os.ModePerm); err != nil {
+		log.Fatal(fmt.Sprintf("Could not create download directory '%s': ", target), err)
+	}
+
+	assets := make([]downloadedAsset, 0)
+	if release != nil {
+		for _, asset := range release.Assets {
+			fmt.Println(fmt.Sprintf("Downloading %s...", asset.GetName()))
+			body, err := openReleaseAsset(account, repository, asset, client)
+			if err != nil {
+				log.Fatal(fmt.Sprintf("Could not download asset %s: ", asset.GetName()), err)
+			}
+			assets = append(assets, storeAsset(asset.GetName(), asset.GetBrowserDownloadURL(), body, target))
+		}
+	}
+
+	if len(assets) == 0 {
+		downloadUrl, _ := namedSectionGet(name, config.Remote, config.DownloadUrl, repository)
+		if downloadUrl == "" {
+			return assets
+		}
+
+		downloadUrl = strings.Replace(downloadUrl, "{name}", account, -1)
+		downloadUrl = strings.Replace(downloadUrl, "{account}", account, -1)
+		downloadUrl = strings.Replace(downloadUrl, "{repository}", repository, -1)
+		downloadUrl = strings.Replace(downloadUrl, "{version}", extractVersion(name, repository, tag), -1)
+
+		fmt.Println(fmt.Sprintf("Downloading %s...", downloadUrl))
+		response, err := newHttpClient().Get(downloadUrl)
+		if err != nil {
+			log.Fatal(fmt.Sprintf("Could not download %s: ", downloadUrl), err)
+		}
+		if response.StatusCode != http.StatusOK {
+			response.Body.Close()
+			log.Fatal(fmt.Sprintf("Could not download %s: %s", downloadUrl, response.Status))
+		}
+
+		fileName := filepath.Base(response.Request.URL.Path)
+		if fileName == "" || fileName == "/" || fileName == "." {
+			fileName = fmt.Sprintf("%s-%s", repository, tag)
+		}
+		assets = append(assets, storeAsset(fileName, downloadUrl, response.Body, target))
+	}
+	return assets
+}
+
+func openReleaseAsset(account, repository string, asset github.ReleaseAsset, client *github.Client) (io.ReadCloser, error) {
+	body, redirect, err := client.Repositories.DownloadReleaseAsset(context.Background(), account, repository, asset.GetID())
+	if err != nil {
+		return nil, err
+	}
+	if body != nil {
+		return body, nil
+	}
+
+	// Assets are served from a storage backend which must not see the Github credentials
+	response, err := newHttpClient().Get(redirect)
+	if err != nil {
+		return nil, err
+	}
+	if response.StatusCode != http.StatusOK {
+		response.Body.Close()
+		return nil, fmt.Errorf("unexpected response: %s", response.Status)
+	}
+	return response.Body, nil
+}
+
+func storeAsset(fileName, url string, body io.ReadCloser, target string) downloadedAsset {
+	defer body.Close()
+
+	path := filepath.Join(target, filepath.Base(fileName))
+	file, err := os.Create(path)
+	if err != nil {
+		log.Fatal(fmt.Sprintf("Could not create file '%s': ", path), err)
+	}
+	defer file.Close()
+
+	hash := sha256.New()
+	size, err := io.Copy(io.MultiWriter(file, hash), body)
+	if err != nil {
+		log.Fatal(fmt.Sprintf("Could not download '%s': ", url), err)
+	}
+
+	return downloadedAsset{
+		Name:   filepath.Base(fileName),
+		Url:    url,
+		Sha256: hex.EncodeToString(hash.Sum(nil)),
+		Size:   size,
+		Path:   path,
+	}
+}
+
+// installAssets copies the downloaded assets into the installation directory
+func installAssets(assets []downloadedAsset, target string) {
+	if err := os.MkdirAll(target, os.ModePerm); err != nil {
+		log.Fatal(fmt.Sprintf("Could not create installation directory '%s': ", target), err)
+	}
+
+	for _, asset := range assets {
+		source, err := os.Open(asset.Path)
+		if err != nil {
+			log.Fatal(fmt.Sprintf("Could not open '%s': ", asset.Path), err)
+		}
+
+		path := filepath.Join(target, asset.Name)
+		destination, err := os.Create(path)
+		if err != nil {
+			source.Close()
+			log.Fatal(fmt.Sprintf("Could not create '%s': ", path), err)
+		}
+
+		_, err = io.Copy(destination, source)
+		source.Close()
+		destination.Close()
+		if err != nil {
+			log.Fatal(fmt.Sprintf("Could not install '%s': ", path), err)
+		}
+	}
+}
@@ -0,0 +1,46 @@
+package main
+
+import (
+	"log"
+	"os"
+	"regexp"
+)
+
+// Error codes of JSON error objects, automation relies on them, never rename one
+const (
+	errorAuthFailed    = "AUTH_FAILED"
+	errorRateLimited   = "RATE_LIMITED"
+	errorRepoNotFound  = "REPO_NOT_FOUND"
+	errorConfigInvalid = "CONFIG_INVALID"
+	errorUnknown       = "UNKNOWN"
+)
+
+// errorClassifiers recognize the failures by their messages, the first matching one wins. Github API
+// failures carry the status code of the response like "GET https://api.github.com/...: 404 Not Found".
+var errorClassifiers = []struct {
+	code    string
+	pattern *regexp.Regexp
+}{
+	{errorRateLimited, regexp.MustCompile(`(?i)rate limit|abuse detection|: 429 `)},
+	{errorAuthFailed, regexp.MustCompile(`(?i): 401 |bad credentials|requires authentication|please run 'grm auth|credentials`)},
+	{errorRepoNotFound, regexp.MustCompile(`(?i): 404 |repository not found|not found in`)},
+	{errorConfigInvalid, regexp.MustCompile(`(?i)cannot compile|unknown|invalid|could not parse|no .* specified|` +
+		`please set|not defined|configuration|config file`)},
+}
+
+// classifyError returns the error code of a failure message
+func classifyError(message string) string {
+	for _, classifier := range errorClassifiers {
+		if classifier.pattern.MatchString(message) {
+			return classifier.code
+		}
+	}
+	return errorUnknown
+}
+
+// enableJsonErrors writes failures as JSON objects with their error code to stderr, for commands
+// writing JSON to stdout
+func enableJsonErrors() {
+	log.SetFlags(0)
+	log.SetOutput(&jsonLogWriter{os.Stderr})
+}
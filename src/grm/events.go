@@ -0,0 +1,328 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/url"
+	"path/filepath"
+	"strings"
+	"sync"
+	"text/template"
+	"time"
+	"grm/config"
+	"grm/i18n"
+	"grm/notify"
+)
+
+// releaseEvent announces a release found for the first time. Events of type warning announce a
+// finding about a repository instead, their released time is the time of the detection.
+type releaseEvent struct {
+	Type    string `json:"type"`
+	Remote  string `json:"remote"`
+	Account string `json:"account"`
+	jsonRelease
+	Warning *eventWarning `json:"warning,omitempty"`
+}
+
+type eventWarning struct {
+	Kind    string   `json:"kind"`
+	Message string   `json:"message"`
+	Added   []string `json:"added,omitempty"`
+	Removed []string `json:"removed,omitempty"`
+	Admins  []string `json:"admins,omitempty"`
+	// text is the message in the language of the messages sent to sinks
+	text string
+}
+
+// releaseEvents returns the events of all reported releases not seen before the report, followed by
+// warnings about maintainer and checksum changes
+func releaseEvents(report *reportModel) []releaseEvent {
+	events := make([]releaseEvent, 0)
+	for _, rep := range report.repositories {
+		added := make(map[string]bool)
+		for _, entry := range report.added[rep.name] {
+			added[entry.Tag] = true
+		}
+		for _, rel := range rep.releases {
+			if !added[rel.name] || !rel.published || !report.reported(rel) {
+				continue
+			}
+			events = append(events, releaseEvent{"release", report.name, report.account, newJsonRelease(report, rep, rel), nil})
+		}
+	}
+
+	owners := make(map[string]string, len(report.repositories))
+	for _, rep := range report.repositories {
+		owners[rep.name] = rep.owner
+	}
+	for _, change := range report.maintainers {
+		release := jsonRelease{Repository: change.repository, Owner: owners[change.repository], Released: locale.Time(change.detected)}
+		events = append(events, releaseEvent{"warning", report.name, report.account, release, &eventWarning{
+			Kind:    "maintainers-changed",
+			Message: change.message(i18n.English),
+			Added:   change.added,
+			Removed: change.removed,
+			Admins:  change.admins,
+			text:    change.message(locale),
+		}})
+	}
+	for _, change := range report.checksums {
+		release := jsonRelease{Repository: change.repository, Tag: change.tag, Owner: owners[change.repository], Released: locale.Time(change.detected)}
+		events = append(events, releaseEvent{"warning", report.name, report.account, release, &eventWarning{
+			Kind:    "checksum-changed",
+			Message: change.message(i18n.English),
+			text:    change.message(locale),
+		}})
+	}
+	return events
+}
+
+// writeEvent writes the event as a single line, either JSON for ndjson output or readable text
+func writeEvent(writer io.Writer, output string, event releaseEvent) error {
+	if output == "text" && event.Warning != nil {
+		_, err := fmt.Fprintln(writer, fmt.Sprintf("%s: %s/%s warning: %s", locale.DateTime(event.Released),
+			event.Account, event.Repository, event.Warning.Message))
+		return err
+	}
+	if output == "text" {
+		_, err := fmt.Fprintln(writer, fmt.Sprintf("%s: %s/%s %s released %s", locale.DateTime(event.Released),
+			event.Account, event.Repository, event.Tag, event.MilestoneUrl))
+		return err
+	}
+	data, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	_, err = writer.Write(append(data, '\n'))
+	return err
+}
+
+// readSinks parses the comma separated message broker sinks of the remote definition's publish property
+func readSinks(name string) []*sink {
+	sinks, err := parseSinks(name)
+	if err != nil {
+		log.Fatal(err)
+	}
+	return sinks
+}
+
+func parseSinks(name string) ([]*sink, error) {
+	sinks := make([]*sink, 0)
+	value, ok := namedSectionGet(name, config.Remote, config.Publish, "")
+	if !ok {
+		return sinks, nil
+	}
+	for _, location := range strings.Split(value, ",") {
+		if strings.TrimSpace(location) == "" {
+			continue
+		}
+		parsed, err := notify.Parse(withSinkPassword(name, strings.TrimSpace(location)))
+		if err != nil {
+			return nil, fmt.Errorf("Could not configure %s sink '%s': %s", config.Publish.Name(), location, err)
+		}
+		parsed.Template = readSinkTemplate(strings.TrimSpace(location))
+		s, err := newSink(parsed, strings.TrimSpace(location))
+		if err != nil {
+			return nil, fmt.Errorf("Could not configure %s sink '%s': %s", config.Publish.Name(), location, err)
+		}
+		sinks = append(sinks, s)
+	}
+	return sinks, nil
+}
+
+var notificationFunctions = template.FuncMap{
+	"t":    func(message string, args ...interface{}) string { return locale.T(message, args...) },
+	"date": func(t time.Time) string { return locale.Date(t) },
+	"join": strings.Join,
+}
+
+// readSinkTemplate loads the Go template given by the template parameter of the sink, like
+// matrix://...?template=/etc/grm/release.tmpl. The template is executed with the release event.
+func readSinkTemplate(location string) *template.Template {
+	u, err := url.Parse(location)
+	if err != nil || u.Query().Get("template") == "" {
+		return nil
+	}
+	file := u.Query().Get("template")
+	tmpl, err := template.New(filepath.Base(file)).Funcs(notificationFunctions).ParseFiles(file)
+	if err != nil {
+		log.Fatal(fmt.Sprintf("Could not read the sink template %s: ", file), err)
+	}
+	return tmpl
+}
+
+// withSinkPassword adds the encrypted XMPP password stored by 'grm auth --xmpp' to XMPP sinks without password
+func withSinkPassword(name, location string) string {
+	u, err := url.Parse(location)
+	if err != nil || u.Scheme != "xmpp" || u.User == nil {
+		return location
+	}
+	if _, ok := u.User.Password(); ok {
+		return location
+	}
+
+	password, ok := namedSectionGet(name, config.Remote, config.XmppPassword, "")
+	if !ok {
+		return location
+	}
+	salt, _ := namedSectionGet(name, config.Remote, config.XmppSalt, "")
+	u.User = url.UserPassword(u.User.Username(), decryptSecret(name, password, salt))
+	return u.String()
+}
+
+// publishEvent sends the event to all sinks accepting it and within their rate limit, failures are
+// only logged to not lose the other sinks and can be sent again with notify replay
+func publishEvent(sinks []*sink, event releaseEvent) {
+	if len(sinks) == 0 {
+		return
+	}
+	message, err := eventMessage(event)
+	if err != nil {
+		log.Println("Could not encode release event: ", err)
+		return
+	}
+	for _, sink := range sinks {
+		if !sink.accepts(event) {
+			continue
+		}
+		if !sink.allow(event.Remote, time.Now()) {
+			log.Println(fmt.Sprintf("Rate limit of %s reached, dropped %s event of %s %s", sink, event.Type, event.Repository, event.Tag))
+			continue
+		}
+		err := sink.Send(message)
+		if err != nil {
+			log.Println(fmt.Sprintf("Could not publish release event to %s: %s", sink, err))
+		}
+		recordDelivery(sink, event, err)
+		auditNotification(sink, event, err)
+	}
+}
+
+// eventMessage renders the release event for notification sinks
+func eventMessage(event releaseEvent) (notify.Message, error) {
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return notify.Message{}, err
+	}
+
+	fields := make([]notify.Field, 0)
+	add := func(name, value string) {
+		if value != "" {
+			fields = append(fields, notify.Field{Name: name, Value: value})
+		}
+	}
+	add(locale.T("Owner"), event.Owner)
+	if event.Warning != nil {
+		add(locale.T("Date"), locale.Date(event.Released))
+		return warningMessage(event, fields, payload), nil
+	}
+	add(locale.T("Severity"), event.Severity)
+	add(locale.T("Date"), locale.Date(event.Released))
+	add(locale.T("Highlights"), strings.Join(event.Highlights, ", "))
+	add(strings.TrimSuffix(locale.T("Summary:"), ":"), event.Summary)
+	add(locale.T("Download"), event.DownloadUrl)
+
+	// Mentioning the owner pings the right people in chat rooms
+	text := fmt.Sprintf("%s %s", locale.T("New %s release: %s (%s)", event.Repository, event.Tag, locale.Date(event.Released)), event.MilestoneUrl)
+	if event.Owner != "" {
+		text = event.Owner + ": " + text
+	}
+
+	return notify.Message{
+		Title:  fmt.Sprintf("%s %s", event.Repository, event.Tag),
+		Text:   text,
+		Url:    event.MilestoneUrl,
+		Fields: fields,
+		Values: map[string]string{
+			"type":       event.Type,
+			"remote":     event.Remote,
+			"account":    event.Account,
+			"repository": event.Repository,
+			"owner":      event.Owner,
+			"severity":   event.Severity,
+		},
+		Payload: payload,
+		Data:    event,
+	}, nil
+}
+
+// warningMessage renders warning events, the text starts with the owner like release messages
+func warningMessage(event releaseEvent, fields []notify.Field, payload []byte) notify.Message {
+	text := locale.T("Warning for %s: %s", event.Repository, event.Warning.text)
+	if event.Owner != "" {
+		text = event.Owner + ": " + text
+	}
+	return notify.Message{
+		Title:  locale.T("Warning for %s", event.Repository),
+		Text:   text,
+		Url:    fmt.Sprintf("https://github.com/%s/%s", event.Account, event.Repository),
+		Fields: fields,
+		Values: map[string]string{
+			"type":       event.Type,
+			"remote":     event.Remote,
+			"account":    event.Account,
+			"repository": event.Repository,
+			"owner":      event.Owner,
+			"severity":   event.Type,
+		},
+		Payload: payload,
+		Data:    event,
+	}
+}
+
+// eventBroker fans out release events to all current subscribers. Slow subscribers miss events
+// instead of blocking the refresh.
+type eventBroker struct {
+	mutex       sync.Mutex
+	subscribers map[chan releaseEvent]bool
+	closed      bool
+}
+
+func newEventBroker() *eventBroker {
+	return &eventBroker{subscribers: make(map[chan releaseEvent]bool)}
+}
+
+func (b *eventBroker) subscribe() chan releaseEvent {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	subscriber := make(chan releaseEvent, 100)
+	if b.closed {
+		close(subscriber)
+		return subscriber
+	}
+	b.subscribers[subscriber] = true
+	return subscriber
+}
+
+// close ends all subscriptions, e.g. on shutdown
+func (b *eventBroker) close() {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	for subscriber := range b.subscribers {
+		close(subscriber)
+	}
+	b.subscribers = make(map[chan releaseEvent]bool)
+	b.closed = true
+}
+
+func (b *eventBroker) unsubscribe(subscriber chan releaseEvent) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	delete(b.subscribers, subscriber)
+}
+
+func (b *eventBroker) publish(event releaseEvent) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	for subscriber := range b.subscribers {
+		select {
+		case subscriber <- event:
+		default:
+		}
+	}
+}
@@ -0,0 +1,201 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+	"grm/config"
+)
+
+// Extension stores, variables to point tests at other servers
+var (
+	vscodeMarketplaceUrl = "https://marketplace.visualstudio.com"
+	chromeUpdateUrl      = "https://clients2.google.com/service/update2/crx"
+	firefoxAddonsUrl     = "https://addons.mozilla.org"
+)
+
+// Marketplace query flags: include versions, files and asset URIs
+const vscodeQueryFlags = 0x1 | 0x2 | 0x80
+
+// Filter type of the Marketplace query by publisher.extension name
+const vscodeFilterName = 7
+
+// Browser version announced to the Chrome update service, extensions may require a minimum version
+const chromeProductVersion = "130.0"
+
+type vscodeQueryResult struct {
+	Results []struct {
+		Extensions []struct {
+			Versions []struct {
+				Version     string    `json:"version"`
+				LastUpdated time.Time `json:"lastUpdated"`
+			} `json:"versions"`
+		} `json:"extensions"`
+	} `json:"results"`
+}
+
+// chromeUpdate is the answer of the update service, only the current version of an extension
+type chromeUpdate struct {
+	Apps []struct {
+		Id          string `xml:"appid,attr"`
+		UpdateCheck struct {
+			Status   string `xml:"status,attr"`
+			Version  string `xml:"version,attr"`
+			Codebase string `xml:"codebase,attr"`
+		} `xml:"updatecheck"`
+	} `xml:"app"`
+}
+
+type firefoxVersions struct {
+	Next    string `json:"next"`
+	Results []struct {
+		Version      string `json:"version"`
+		ReleaseNotes string `json:"release_notes"`
+		File         struct {
+			Url     string    `json:"url"`
+			Created time.Time `json:"created"`
+		} `json:"file"`
+	} `json:"results"`
+}
+
+// buildExtensionReport reads the versions of the editor or browser extensions listed by the projects
+// property, so extension updates can be reviewed before they roll out. Versions stand in for tags.
+func buildExtensionReport(name, provider, repositoryPattern string, since time.Time) *reportModel {
+	client := newProviderClient(name)
+	repos := readListedRepositories(name, config.Projects, repositoryPattern)
+	return buildProviderReport(name, "", repos, since, func(extension string) []providerRelease {
+		switch provider {
+		case providerVscode:
+			return readVscodeVersions(client, extension)
+		case providerChrome:
+			return readChromeVersion(client, name, extension)
+		default:
+			key, secret, _ := readCredentials(name)
+			return readFirefoxVersions(client, extension, key, secret)
+		}
+	})
+}
+
+// readVscodeVersions reads the versions of a Marketplace extension named publisher.extension.
+// Platform specific builds list a version once per platform.
+func readVscodeVersions(client *http.Client, extension string) []providerRelease {
+	parts := strings.SplitN(extension, ".", 2)
+	if len(parts) != 2 {
+		log.Fatal(fmt.Sprintf("Invalid extension '%s', expected publisher.extension", extension))
+	}
+
+	query := map[string]interface{}{
+		"filters": []interface{}{map[string]interface{}{
+			"criteria": []interface{}{map[string]interface{}{"filterType": vscodeFilterName, "value": extension}},
+		}},
+		"flags": vscodeQueryFlags,
+	}
+	body, _ := json.Marshal(query)
+	request, err := http.NewRequest("POST", vscodeMarketplaceUrl+"/_apis/public/gallery/extensionquery", bytes.NewReader(body))
+	if err != nil {
+		log.Fatal(fmt.Sprintf("Could not create request for extension %s: ", extension), err)
+	}
+	request.Header.Set("Content-Type", "application/json")
+	request.Header.Set("Accept", "application/json;api-version=3.0-preview.1")
+
+	result := vscodeQueryResult{}
+	if err := json.Unmarshal(readProvider(client, request), &result); err != nil {
+		log.Fatal(fmt.Sprintf("Could not parse versions of extension %s: ", extension), err)
+	}
+
+	tags := make([]providerRelease, 0)
+	seen := make(map[string]bool)
+	for _, r := range result.Results {
+		for _, e := range r.Extensions {
+			for _, version := range e.Versions {
+				if seen[version.Version] {
+					continue
+				}
+				seen[version.Version] = true
+				tags = append(tags, providerRelease{
+					version:  version.Version,
+					created:  version.LastUpdated.UTC(),
+					notesUrl: fmt.Sprintf("%s/items/%s/changelog", vscodeMarketplaceUrl, url.QueryEscape(extension)),
+					downloadUrl: fmt.Sprintf("%s/_apis/public/gallery/publishers/%s/vsextensions/%s/%s/vspackage",
+						vscodeMarketplaceUrl, parts[0], parts[1], version.Version),
+				})
+			}
+		}
+	}
+	return tags
+}
+
+// readChromeVersion asks the Chrome update service for the current version of the extension ID. The
+// Web Store has no version history, earlier versions are known from the release history only and a
+// new version is dated by the first report seeing it.
+func readChromeVersion(client *http.Client, name, extension string) []providerRelease {
+	query := url.Values{}
+	query.Set("response", "updatecheck")
+	query.Set("prodversion", chromeProductVersion)
+	query.Set("acceptformat", "crx2,crx3")
+	query.Set("x", fmt.Sprintf("id=%s&uc", extension))
+	request, err := http.NewRequest("GET", chromeUpdateUrl+"?"+query.Encode(), nil)
+	if err != nil {
+		log.Fatal(fmt.Sprintf("Could not create request for extension %s: ", extension), err)
+	}
+
+	update := chromeUpdate{}
+	if err := xml.Unmarshal(readProvider(client, request), &update); err != nil {
+		log.Fatal(fmt.Sprintf("Could not parse update of extension %s: ", extension), err)
+	}
+	if len(update.Apps) == 0 || update.Apps[0].UpdateCheck.Status != "ok" {
+		log.Fatal(fmt.Sprintf("Chrome extension %s not found", extension))
+	}
+	check := update.Apps[0].UpdateCheck
+
+	created := time.Now().UTC()
+	for _, entry := range readHistory(name, extension) {
+		if entry.Tag == check.Version {
+			created = entry.Released
+		}
+	}
+	return []providerRelease{{
+		version:     check.Version,
+		created:     created,
+		firstSeen:   true,
+		notesUrl:    "https://chromewebstore.google.com/detail/" + extension,
+		downloadUrl: check.Codebase,
+	}}
+}
+
+// readFirefoxVersions reads the versions of a Firefox add-on, given by its slug or GUID, with the
+// add-ons API. With the API key of the auth command unlisted add-ons of the key's account are readable.
+func readFirefoxVersions(client *http.Client, addon, key, secret string) []providerRelease {
+	tags := make([]providerRelease, 0)
+	next := fmt.Sprintf("%s/api/v5/addons/addon/%s/versions/?page_size=50&lang=en-US", firefoxAddonsUrl, url.PathEscape(addon))
+	for next != "" {
+		request, err := http.NewRequest("GET", next, nil)
+		if err != nil {
+			log.Fatal(fmt.Sprintf("Could not create request for add-on %s: ", addon), err)
+		}
+		if secret != "" {
+			request.Header.Set("Authorization", "JWT "+signJwt(key, secret))
+		}
+		versions := firefoxVersions{}
+		if err := json.Unmarshal(readProvider(client, request), &versions); err != nil {
+			log.Fatal(fmt.Sprintf("Could not parse versions of add-on %s: ", addon), err)
+		}
+		for _, version := range versions.Results {
+			tags = append(tags, providerRelease{
+				version:     version.Version,
+				created:     version.File.Created.UTC(),
+				notesUrl:    fmt.Sprintf("%s/firefox/addon/%s/versions/", firefoxAddonsUrl, url.PathEscape(addon)),
+				downloadUrl: version.File.Url,
+				notes:       version.ReleaseNotes,
+			})
+		}
+		next = versions.Next
+	}
+	return tags
+}
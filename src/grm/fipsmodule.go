@@ -0,0 +1,11 @@
+package main
+
+import (
+	"crypto/fips140"
+)
+
+// fipsModuleEnabled returns true if Go's cryptography runs in FIPS 140-3 mode, enabled by
+// GODEBUG=fips140=on or building with GOFIPS140
+func fipsModuleEnabled() bool {
+	return fips140.Enabled()
+}
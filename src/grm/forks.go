@@ -0,0 +1,149 @@
+package main
+
+import (
+	"github.com/google/go-github/github"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"text/tabwriter"
+)
+
+// Fork states, from nothing to do to an upstream release missing in the fork
+const (
+	forkUpToDate       = "up-to-date"
+	forkBehind         = "behind"
+	forkReleaseMissing = "release-missing"
+)
+
+var forkColors = map[string]string{
+	forkUpToDate:       ansiGreen,
+	forkBehind:         ansiYellow,
+	forkReleaseMissing: ansiBoldRed,
+}
+
+// forkDivergence compares the default branch of a fork with the default branch and the latest
+// release of its upstream repository
+type forkDivergence struct {
+	Remote     string `json:"remote"`
+	Repository string `json:"repository"`
+	Branch     string `json:"branch"`
+	Upstream   string `json:"upstream"`
+	// Behind are the upstream commits missing in the fork, Ahead the commits only in the fork
+	Behind int `json:"behind"`
+	Ahead  int `json:"ahead"`
+	// LatestRelease is the tag of upstream's latest release, empty without releases
+	LatestRelease string `json:"latest_release,omitempty"`
+	ReleaseMerged bool   `json:"release_merged"`
+	State         string `json:"state"`
+	CompareUrl    string `json:"compare_url"`
+}
+
+// readForks compares all forks of the remote account with their upstream repositories
+func readForks(ctx context.Context, name string, repositories []*github.Repository, account string, client *github.Client) []forkDivergence {
+	forks := make([]forkDivergence, 0)
+	for _, repo := range repositories {
+		if !repo.GetFork() {
+			continue
+		}
+		fmt.Fprintln(progressOutput, fmt.Sprintf("Comparing %s with upstream...", repo.GetName()))
+		forks = append(forks, readForkDivergence(ctx, name, account, repo.GetName(), client))
+	}
+	return forks
+}
+
+func readForkDivergence(ctx context.Context, name, account, repository string, client *github.Client) forkDivergence {
+	fork := readRepository(account, repository, client)
+	parent := fork.GetParent()
+	upstreamAccount, upstreamRepository := parent.GetOwner().GetLogin(), parent.GetName()
+	// Compared in the upstream repository, the fork's branch is the base
+	base := fmt.Sprintf("%s:%s", account, fork.GetDefaultBranch())
+
+	comparison := compareCommits(ctx, upstreamAccount, upstreamRepository, base, parent.GetDefaultBranch(), client)
+	divergence := forkDivergence{
+		Remote:     name,
+		Repository: repository,
+		Branch:     fork.GetDefaultBranch(),
+		Upstream:   parent.GetFullName(),
+		Behind:     comparison.GetAheadBy(),
+		Ahead:      comparison.GetBehindBy(),
+		CompareUrl: comparison.GetHTMLURL(),
+	}
+
+	if latest := readLatestRelease(ctx, upstreamAccount, upstreamRepository, client); latest != nil {
+		divergence.LatestRelease = latest.GetTagName()
+		// The release is merged if the fork's branch contains all commits of the tag
+		divergence.ReleaseMerged = compareCommits(ctx, upstreamAccount, upstreamRepository, base, latest.GetTagName(), client).GetAheadBy() == 0
+	}
+
+	switch {
+	case divergence.Behind == 0:
+		divergence.State = forkUpToDate
+	case divergence.LatestRelease != "" && !divergence.ReleaseMerged:
+		divergence.State = forkReleaseMissing
+	default:
+		divergence.State = forkBehind
+	}
+	return divergence
+}
+
+func compareCommits(ctx context.Context, account, repository, base, head string, client *github.Client) *github.CommitsComparison {
+	for {
+		comparison, response, err := client.Repositories.CompareCommits(ctx, account, repository, base, head)
+		if rateLimit(response) {
+			continue
+		}
+
+		if err != nil {
+			log.Fatal(fmt.Sprintf("Could not compare %s with %s in repository %s/%s: ", base, head, account, repository), err)
+		}
+
+		return comparison
+	}
+}
+
+// readLatestRelease returns the latest published Github release, or nil if there is none
+func readLatestRelease(ctx context.Context, account, repository string, client *github.Client) *github.RepositoryRelease {
+	for {
+		release, response, err := client.Repositories.GetLatestRelease(ctx, account, repository)
+		if rateLimit(response) {
+			continue
+		}
+
+		if response != nil && response.StatusCode == http.StatusNotFound {
+			return nil
+		}
+
+		if err != nil {
+			log.Fatal(fmt.Sprintf("Could not retrieve the latest release of repository %s/%s: ", account, repository), err)
+		}
+
+		return release
+	}
+}
+
+func formatForksText(writer io.Writer, forks []forkDivergence, remotes bool) error {
+	table := tabwriter.NewWriter(writer, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(table, "Repository\tUpstream\tBehind\tAhead\tLatest release\tState")
+	for _, fork := range forks {
+		name := fork.Repository
+		if remotes {
+			name = fork.Remote + "/" + fork.Repository
+		}
+		release := orDash(fork.LatestRelease)
+		if fork.LatestRelease != "" && !fork.ReleaseMerged {
+			release += " (missing)"
+		}
+		fmt.Fprintln(table, fmt.Sprintf("%s\t%s\t%d\t%d\t%s\t%s", name, fork.Upstream, fork.Behind, fork.Ahead,
+			release, colorize(writer, fork.State, forkColors[fork.State])))
+	}
+	return table.Flush()
+}
+
+func formatForksJson(writer io.Writer, forks []forkDivergence) error {
+	encoder := json.NewEncoder(writer)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(forks)
+}
@@ -0,0 +1,333 @@
+package main
+
+import (
+	"github.com/google/go-github/github"
+	"context"
+	"encoding/json"
+	"io"
+	"log"
+	"fmt"
+	"strings"
+	"regexp"
+	"text/template"
+	"unicode"
+)
+
+// Release assets not being installable artifacts, like checksums or signatures
+var ignoredAssetPattern = regexp.MustCompile(`(?i)(checksums?|sha256sums?)(\.txt)?$|\.(sha256|sha512|sig|asc|pem|minisig|sbom|json|intoto\.jsonl)$`)
+
+// assetPattern detects a platform in the name of a release asset
+type assetPattern struct {
+	platform string
+	pattern  *regexp.Regexp
+}
+
+// Platforms of release assets, the most specific pattern first as names may match several, like
+// darwin64 also matching win64 or macOS_64bit_arm64 also matching 64bit
+var (
+	assetOsPatterns = []assetPattern{
+		{"darwin", regexp.MustCompile(`(?i)darwin|macos|osx|apple`)},
+		{"windows", regexp.MustCompile(`(?i)windows|win64|win32|\.exe$|\.msi$`)},
+		{"linux", regexp.MustCompile(`(?i)linux`)},
+	}
+	assetArchPatterns = []assetPattern{
+		{"arm64", regexp.MustCompile(`(?i)arm64|aarch64`)},
+		{"amd64", regexp.MustCompile(`(?i)amd64|x86_64|x64|64bit`)},
+	}
+)
+
+// matchPlatform returns the platform of the first pattern matching the asset name
+func matchPlatform(patterns []assetPattern, name string) string {
+	for _, p := range patterns {
+		if p.pattern.MatchString(name) {
+			return p.platform
+		}
+	}
+	return ""
+}
+
+type platformAsset struct {
+	Os     string
+	Arch   string
+	Url    string
+	Sha256 string
+	Name   string
+}
+
+type packageManifest struct {
+	Name        string
+	ClassName   string
+	Description string
+	Homepage    string
+	License     string
+	Version     string
+	Binary      string
+	Repository  string
+	Assets      []platformAsset
+}
+
+func (m packageManifest) Asset(os, arch string) *platformAsset {
+	for i, asset := range m.Assets {
+		if asset.Os == os && asset.Arch == arch {
+			return &m.Assets[i]
+		}
+	}
+	return nil
+}
+
+// Generic returns an asset without any detected platform, used for platform independent releases. It
+// is nil if all assets are built for a platform.
+func (m packageManifest) Generic() *platformAsset {
+	for i, asset := range m.Assets {
+		if asset.Os == "" {
+			return &m.Assets[i]
+		}
+	}
+	return nil
+}
+
+func (m packageManifest) HasOs(os string) bool {
+	for _, asset := range m.Assets {
+		if asset.Os == os {
+			return true
+		}
+	}
+	return false
+}
+
+var generateFunctions = template.FuncMap{
+	"list": func(values ...string) []string { return values },
+	"ruby": rubyString,
+}
+
+// Every value is a Ruby string literal, release metadata can't inject code into the formula
+var brewFormulaTemplate = template.Must(template.New("brew").Funcs(generateFunctions).Parse(`class {{.ClassName}} < Formula
+  desc {{ruby .Description}}
+  homepage {{ruby .Homepage}}
+  version {{ruby .Version}}
+{{- if .License}}
+  license {{ruby .License}}
+{{- end}}
+{{- if or (.HasOs "darwin") (.HasOs "linux")}}
+{{- range $os := (list "darwin" "linux")}}{{if $.HasOs $os}}
+
+  on_{{if eq $os "darwin"}}macos{{else}}linux{{end}} do
+{{- with $.Asset $os "arm64"}}
+    if Hardware::CPU.arm?
+      url {{ruby .Url}}
+      sha256 {{ruby .Sha256}}
+    end
+{{- end}}
+{{- with $.Asset $os "amd64"}}
+    if Hardware::CPU.intel?
+      url {{ruby .Url}}
+      sha256 {{ruby .Sha256}}
+    end
+{{- end}}
+  end
+{{- end}}{{end}}
+{{- else}}{{with .Generic}}
+  url {{ruby .Url}}
+  sha256 {{ruby .Sha256}}
+{{- end}}{{end}}
+
+  def install
+    bin.install {{ruby .Binary}}
+  end
+
+  test do
+    system bin/{{ruby .Binary}}, "--version"
+  end
+end
+`))
+
+// scoopManifest is the JSON manifest of a Scoop app
+type scoopManifest struct {
+	Version      string                       `json:"version"`
+	Description  string                       `json:"description"`
+	Homepage     string                       `json:"homepage"`
+	License      string                       `json:"license"`
+	Architecture map[string]scoopArchitecture `json:"architecture,omitempty"`
+	Url          string                       `json:"url,omitempty"`
+	Hash         string                       `json:"hash,omitempty"`
+	Bin          string                       `json:"bin"`
+	Checkver     scoopCheckver                `json:"checkver"`
+}
+
+type scoopArchitecture struct {
+	Url  string `json:"url"`
+	Hash string `json:"hash"`
+}
+
+type scoopCheckver struct {
+	Github string `json:"github"`
+}
+
+// rubyString quotes the value as double quoted Ruby string, without interpolation
+func rubyString(value string) string {
+	var quoted strings.Builder
+	quoted.WriteByte('"')
+	for _, r := range value {
+		switch r {
+		case '\\', '"', '#':
+			quoted.WriteRune('\\')
+			quoted.WriteRune(r)
+		case '\n':
+			quoted.WriteString(`\n`)
+		case '\r':
+			quoted.WriteString(`\r`)
+		case '\t':
+			quoted.WriteString(`\t`)
+		default:
+			if unicode.IsControl(r) {
+				fmt.Fprintf(&quoted, `\u{%x}`, r)
+			} else {
+				quoted.WriteRune(r)
+			}
+		}
+	}
+	quoted.WriteByte('"')
+	return quoted.String()
+}
+
+func renderBrewFormula(writer io.Writer, manifest packageManifest) error {
+	if !manifest.HasOs("darwin") && !manifest.HasOs("linux") && manifest.Generic() == nil {
+		return fmt.Errorf("%s has no macOS, Linux or platform independent asset for a formula", manifest.Repository)
+	}
+	return brewFormulaTemplate.Execute(writer, manifest)
+}
+
+func renderScoopManifest(writer io.Writer, manifest packageManifest) error {
+	scoop := scoopManifest{
+		Version:     manifest.Version,
+		Description: manifest.Description,
+		Homepage:    manifest.Homepage,
+		License:     manifest.License,
+		Bin:         manifest.Binary + ".exe",
+		Checkver:    scoopCheckver{manifest.Homepage},
+	}
+	if scoop.License == "" {
+		scoop.License = "Unknown"
+	}
+	if manifest.HasOs("windows") {
+		scoop.Architecture = make(map[string]scoopArchitecture)
+		if asset := manifest.Asset("windows", "amd64"); asset != nil {
+			scoop.Architecture["64bit"] = scoopArchitecture{asset.Url, asset.Sha256}
+		}
+		if asset := manifest.Asset("windows", "arm64"); asset != nil {
+			scoop.Architecture["arm64"] = scoopArchitecture{asset.Url, asset.Sha256}
+		}
+	} else if asset := manifest.Generic(); asset != nil {
+		scoop.Url, scoop.Hash = asset.Url, asset.Sha256
+	} else {
+		return fmt.Errorf("%s has no Windows or platform independent asset for a Scoop manifest", manifest.Repository)
+	}
+
+	encoder := json.NewEncoder(writer)
+	encoder.SetIndent("", "    ")
+	encoder.SetEscapeHTML(false)
+	return encoder.Encode(scoop)
+}
+
+// splitRemoteRepository splits arguments in the form of remote/repository
+func splitRemoteRepository(value string) (string, string) {
+	tokens := strings.SplitN(value, "/", 2)
+	if len(tokens) != 2 || tokens[0] == "" || tokens[1] == "" {
+		log.Fatal(fmt.Sprintf("Expected remote/repository but got '%s'", value))
+	}
+	return tokens[0], tokens[1]
+}
+
+// buildPackageManifest collects the metadata and checksums of the latest release of a repository
+func buildPackageManifest(name, repository string) packageManifest {
+	client, username := newGithubClient(name)
+	account := readRemoteAccount(name, username)
+
+	release := readRelease(account, repository, "", client)
+	if release == nil {
+		log.Fatal(fmt.Sprintf("No release found for repository %s", repository))
+	}
+	tag := release.GetTagName()
+
+	record, ok := readDownloadRecord(name, repository, tag)
+	assets := record.Assets
+	if !ok {
+		assets = downloadAssets(name, account, repository, tag, release, client,
+			grmPath("downloads", name, repository, tag))
+	}
+
+	repo := readRepository(account, repository, client)
+
+	manifest := packageManifest{
+		Name:        repository,
+		ClassName:   formulaClassName(repository),
+		Description: repo.GetDescription(),
+		Homepage:    repo.GetHTMLURL(),
+		License:     repo.GetLicense().GetSPDXID(),
+		Version:     strings.TrimPrefix(extractVersion(name, repository, tag), "v"),
+		Binary:      repository,
+		Repository:  fmt.Sprintf("%s/%s", account, repository),
+	}
+	if manifest.License == "NOASSERTION" {
+		manifest.License = ""
+	}
+
+	for _, asset := range assets {
+		if ignoredAssetPattern.MatchString(asset.Name) {
+			continue
+		}
+		platform := platformAsset{
+			Url:    asset.Url,
+			Sha256: asset.Sha256,
+			Name:   asset.Name,
+		}
+		platform.Os = matchPlatform(assetOsPatterns, asset.Name)
+		platform.Arch = matchPlatform(assetArchPatterns, asset.Name)
+		// Assets of other architectures like i386 or armv7 must not take the place of the Intel build
+		if platform.Os != "" && platform.Arch == "" {
+			continue
+		}
+		// Prefer the first asset per platform, additional variants (e.g. musl builds) are skipped
+		if platform.Os != "" && manifest.Asset(platform.Os, platform.Arch) != nil {
+			continue
+		}
+		manifest.Assets = append(manifest.Assets, platform)
+	}
+
+	if len(manifest.Assets) == 0 {
+		log.Fatal(fmt.Sprintf("Release %s of %s has no installable assets", tag, repository))
+	}
+	return manifest
+}
+
+func readRepository(account, repository string, client *github.Client) *github.Repository {
+	ctx := context.Background()
+
+	for {
+		repo, response, err := client.Repositories.Get(ctx, account, repository)
+
+		if rateLimit(response) {
+			continue
+		}
+
+		if err != nil {
+			log.Fatal(fmt.Sprintf("Could not retrieve repository %s: ", repository), err)
+		}
+
+		return repo
+	}
+}
+
+// formulaClassName converts a repository name into a Homebrew class name, e.g. terraform-docs => TerraformDocs
+func formulaClassName(repository string) string {
+	tokens := strings.FieldsFunc(repository, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	className := ""
+	for _, token := range tokens {
+		runes := []rune(token)
+		runes[0] = unicode.ToUpper(runes[0])
+		className += string(runes)
+	}
+	return className
+}
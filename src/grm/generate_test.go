@@ -0,0 +1,71 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestMatchPlatform(t *testing.T) {
+	tests := []struct {
+		name string
+		os   string
+		arch string
+	}{
+		{"terraform_0.11.8_darwin_amd64.zip", "darwin", "amd64"},
+		{"terraform_0.11.8_darwin64.tar.gz", "darwin", ""},
+		{"foo_macOS_64bit_arm64.tar.gz", "darwin", "arm64"},
+		{"foo_Linux_64bit.tar.gz", "linux", "amd64"},
+		{"foo-linux-aarch64.tar.gz", "linux", "arm64"},
+		{"foo_windows_x86_64.zip", "windows", "amd64"},
+		{"foo-win64.exe", "windows", ""},
+		{"foo-setup.msi", "windows", ""},
+		{"foo-linux-i386.tar.gz", "linux", ""},
+		{"foo.jar", "", ""},
+	}
+	for _, test := range tests {
+		os, arch := matchPlatform(assetOsPatterns, test.name), matchPlatform(assetArchPatterns, test.name)
+		if os != test.os || arch != test.arch {
+			t.Errorf("%s: %s/%s, expected %s/%s", test.name, os, arch, test.os, test.arch)
+		}
+	}
+}
+
+func TestRenderPackageManifests(t *testing.T) {
+	linux := platformAsset{Os: "linux", Arch: "amd64", Url: "https://example.com/foo_linux_amd64.tar.gz", Name: "foo_linux_amd64.tar.gz"}
+	windows := platformAsset{Os: "windows", Arch: "amd64", Url: "https://example.com/foo_windows_amd64.zip", Name: "foo_windows_amd64.zip"}
+	generic := platformAsset{Url: "https://example.com/foo.jar", Name: "foo.jar"}
+
+	tests := []struct {
+		name   string
+		assets []platformAsset
+		brew   string
+		scoop  string
+	}{
+		{"linux only", []platformAsset{linux}, "foo_linux_amd64", ""},
+		{"windows only", []platformAsset{windows}, "", "foo_windows_amd64"},
+		{"all platforms", []platformAsset{linux, windows}, "foo_linux_amd64", "foo_windows_amd64"},
+		{"platform independent", []platformAsset{linux, generic}, "foo_linux_amd64", "foo.jar"},
+	}
+	for _, test := range tests {
+		manifest := packageManifest{Name: "foo", ClassName: "Foo", Version: "1.0.0", Binary: "foo", Repository: "acme/foo", Assets: test.assets}
+
+		var brew bytes.Buffer
+		err := renderBrewFormula(&brew, manifest)
+		switch {
+		case test.brew == "" && err == nil:
+			t.Errorf("%s: formula rendered %s", test.name, brew.String())
+		case test.brew != "" && (err != nil || !strings.Contains(brew.String(), test.brew)):
+			t.Errorf("%s: formula %s, %v", test.name, brew.String(), err)
+		}
+
+		var scoop bytes.Buffer
+		err = renderScoopManifest(&scoop, manifest)
+		switch {
+		case test.scoop == "" && err == nil:
+			t.Errorf("%s: Scoop manifest rendered %s", test.name, scoop.String())
+		case test.scoop != "" && (err != nil || !strings.Contains(scoop.String(), test.scoop)):
+			t.Errorf("%s: Scoop manifest %s, %v", test.name, scoop.String(), err)
+		}
+	}
+}
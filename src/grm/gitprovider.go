@@ -0,0 +1,173 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"log"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+	"grm/config"
+)
+
+// Providers reading tags with git: fetch mirrors the tagged commits to learn their dates, ls-remote
+// only lists the tags and works with any git host
+const (
+	gitFetch    = "fetch"
+	gitLsRemote = "ls-remote"
+)
+
+// gitRemote returns whether the remote definition is read with git instead of the Github API
+func gitRemote(name string) bool {
+	if url, ok := namedSectionGet(name, config.Remote, config.GitUrl, ""); ok && url != "" {
+		return true
+	}
+	repositories, ok := namedSectionGet(name, config.Remote, config.GitRepositories, "")
+	return ok && repositories != ""
+}
+
+// buildGitReport reads the tags of the repositories of the git-repositories property with git, e.g.
+// from a private mirror reachable over SSH with a deploy key but without Github API access, or from
+// hosts like cgit, Gerrit or self-hosted servers without any API. Without the API there are no milestones,
+// release notes or repository details, the tags alone make the releases.
+func buildGitReport(name, repositoryPattern string, since time.Time) *reportModel {
+	account, _ := namedSectionGet(name, config.Remote, config.RemoteUser, "")
+	provider := gitFetch
+	if p, ok := namedSectionGet(name, config.Remote, config.GitProvider, ""); ok && p != "" {
+		provider = p
+	}
+	if provider != gitFetch && provider != gitLsRemote {
+		log.Fatal(fmt.Sprintf("Unknown %s '%s', supported providers: %s, %s", config.GitProvider.Name(), provider, gitFetch, gitLsRemote))
+	}
+	repos := readListedRepositories(name, config.GitRepositories, repositoryPattern)
+
+	return buildProviderReport(name, account, repos, since, func(repository string) []providerRelease {
+		url, ok := namedSectionGet(name, config.Remote, config.GitUrl, repository)
+		if !ok || url == "" {
+			log.Fatal(fmt.Sprintf("No %s defined for repository %s", config.GitUrl.Name(), repository))
+		}
+		url = strings.Replace(url, "{repository}", repository, -1)
+
+		if provider == gitLsRemote {
+			return listRemoteTags(name, url, repository)
+		}
+		return fetchGitTags(name, url, repository)
+	})
+}
+
+// fetchGitTags fetches the tags of the repository into a bare mirror below the grm directory. Only
+// the tagged commits are fetched, not the history.
+func fetchGitTags(name, url, repository string) []providerRelease {
+	mirror := grmPath("mirrors", name, repository+".git")
+	if _, err := os.Stat(mirror); err != nil {
+		if err := os.MkdirAll(mirror, 0700); err != nil {
+			log.Fatal(fmt.Sprintf("Could not create mirror directory '%s': ", mirror), err)
+		}
+		if _, err := runGit(name, "init", "--bare", "--quiet", mirror); err != nil {
+			log.Fatal(fmt.Sprintf("Could not create mirror of repository %s: ", repository), err)
+		}
+	}
+
+	if _, err := runGit(name, "--git-dir", mirror, "fetch", "--quiet", "--depth=1", "--force", "--prune", "--no-tags",
+		"--", url, "+refs/tags/*:refs/tags/*"); err != nil {
+		log.Fatal(fmt.Sprintf("Could not fetch tags of repository %s from %s: ", repository, url), err)
+	}
+
+	// Annotated tags are dereferenced to the date and SHA of their commit
+	output, err := runGit(name, "--git-dir", mirror, "for-each-ref",
+		"--format=%(refname:strip=2)%00%(committerdate:iso-strict)%00%(*committerdate:iso-strict)%00%(objectname)%00%(*objectname)",
+		"refs/tags")
+	if err != nil {
+		log.Fatal(fmt.Sprintf("Could not list tags of repository %s: ", repository), err)
+	}
+
+	tags := make([]providerRelease, 0)
+	for _, line := range strings.Split(strings.TrimSpace(output), "\n") {
+		fields := strings.Split(line, "\x00")
+		if len(fields) != 5 {
+			continue
+		}
+		tag, date, commit := fields[0], fields[1], fields[3]
+		if fields[2] != "" {
+			date, commit = fields[2], fields[4]
+		}
+		created, err := time.Parse(time.RFC3339, date)
+		if err != nil {
+			continue
+		}
+		tags = append(tags, providerRelease{version: tag, commit: commit, created: created})
+	}
+	return tags
+}
+
+// listRemoteTags lists the tags of any git URL without cloning. The listing has no dates, tags are
+// dated by the release history, new tags by now.
+func listRemoteTags(name, url, repository string) []providerRelease {
+	output, err := runGit(name, "ls-remote", "--tags", "--", url)
+	if err != nil {
+		log.Fatal(fmt.Sprintf("Could not list tags of repository %s from %s: ", repository, url), err)
+	}
+
+	seen := make(map[string]time.Time)
+	for _, entry := range readHistory(name, repository) {
+		seen[entry.Tag] = entry.Released
+	}
+
+	// Annotated tags are listed twice, the peeled ^{} entry holds the SHA of the commit
+	commits := make(map[string]string)
+	names := make([]string, 0)
+	for _, line := range strings.Split(strings.TrimSpace(output), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) != 2 || !strings.HasPrefix(fields[1], "refs/tags/") {
+			continue
+		}
+		tag := strings.TrimPrefix(fields[1], "refs/tags/")
+		if strings.HasSuffix(tag, "^{}") {
+			commits[strings.TrimSuffix(tag, "^{}")] = fields[0]
+			continue
+		}
+		if _, ok := commits[tag]; !ok {
+			names = append(names, tag)
+			commits[tag] = fields[0]
+		}
+	}
+
+	now := time.Now().UTC()
+	tags := make([]providerRelease, 0, len(names))
+	for _, tag := range names {
+		created, ok := seen[tag]
+		if !ok {
+			created = now
+		}
+		tags = append(tags, providerRelease{version: tag, commit: commits[tag], created: created, firstSeen: true})
+	}
+	return tags
+}
+
+// runGit runs git non-interactively, with the deploy-key property as SSH identity. URLs of the ext
+// transport would run commands and are refused.
+func runGit(name string, args ...string) (string, error) {
+	var stdout, stderr bytes.Buffer
+	cmd := exec.Command("git", append([]string{"-c", "protocol.ext.allow=never"}, args...)...)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	cmd.Env = append(os.Environ(), "GIT_TERMINAL_PROMPT=0")
+	if key, ok := namedSectionGet(name, config.Remote, config.DeployKey, ""); ok && key != "" {
+		// git runs the SSH command through the shell
+		cmd.Env = append(cmd.Env, fmt.Sprintf("GIT_SSH_COMMAND=ssh -i %s -o IdentitiesOnly=yes -o BatchMode=yes", shellQuote(key)))
+	}
+	if err := cmd.Run(); err != nil {
+		message := strings.TrimSpace(stderr.String())
+		if message == "" {
+			message = err.Error()
+		}
+		return "", fmt.Errorf("git failed: %s", message)
+	}
+	return stdout.String(), nil
+}
+
+// shellQuote quotes the value as a single shell word
+func shellQuote(value string) string {
+	return "'" + strings.Replace(value, "'", `'\''`, -1) + "'"
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+	"grm/config"
+)
+
+// Go module proxy, a variable to point tests at other servers
+var goProxyUrl = "https://proxy.golang.org"
+
+type goProxyInfo struct {
+	Version string    `json:"Version"`
+	Time    time.Time `json:"Time"`
+}
+
+// buildGoProxyReport reads the versions of the Go modules listed by the projects property from the
+// module proxy. Versions stand in for tags, the module zip of a version is its download.
+func buildGoProxyReport(name, repositoryPattern string, since time.Time) *reportModel {
+	client := newProviderClient(name)
+	repos := readListedRepositories(name, config.Projects, repositoryPattern)
+	return buildProviderReport(name, "", repos, since, func(module string) []providerRelease {
+		return readGoModuleVersions(client, name, module)
+	})
+}
+
+// readGoModuleVersions lists the versions of the module. The list has no dates, versions of the
+// release history keep their date, new versions are dated by their info.
+func readGoModuleVersions(client *http.Client, name, module string) []providerRelease {
+	known := make(map[string]time.Time)
+	for _, entry := range readHistory(name, module) {
+		known[entry.Tag] = entry.Released
+	}
+
+	moduleUrl := goProxyUrl + "/" + escapeModulePath(module)
+	request, err := http.NewRequest("GET", moduleUrl+"/@v/list", nil)
+	if err != nil {
+		log.Fatal(fmt.Sprintf("Could not create request for module %s: ", module), err)
+	}
+
+	tags := make([]providerRelease, 0)
+	for _, version := range strings.Fields(string(readProvider(client, request))) {
+		created, ok := known[version]
+		if !ok {
+			created = readGoModuleInfo(client, moduleUrl, version).Time
+		}
+		tags = append(tags, providerRelease{
+			version:     version,
+			created:     created.UTC(),
+			notesUrl:    fmt.Sprintf("https://pkg.go.dev/%s@%s", module, version),
+			downloadUrl: fmt.Sprintf("%s/@v/%s.zip", moduleUrl, version),
+		})
+	}
+	return tags
+}
+
+func readGoModuleInfo(client *http.Client, moduleUrl, version string) goProxyInfo {
+	request, err := http.NewRequest("GET", fmt.Sprintf("%s/@v/%s.info", moduleUrl, version), nil)
+	if err != nil {
+		log.Fatal(fmt.Sprintf("Could not create request for version %s: ", version), err)
+	}
+	info := goProxyInfo{}
+	if err := json.Unmarshal(readProvider(client, request), &info); err != nil {
+		log.Fatal(fmt.Sprintf("Could not parse info of version %s: ", version), err)
+	}
+	return info
+}
+
+// escapeModulePath escapes upper case letters like the module proxy protocol requires, e.g.
+// github.com/BurntSushi/toml becomes github.com/!burnt!sushi/toml
+func escapeModulePath(module string) string {
+	var escaped strings.Builder
+	for _, r := range module {
+		if r >= 'A' && r <= 'Z' {
+			escaped.WriteRune('!')
+			r += 'a' - 'A'
+		}
+		escaped.WriteRune(r)
+	}
+	return escaped.String()
+}
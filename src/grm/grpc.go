@@ -0,0 +1,159 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	grmpb "grm/proto"
+)
+
+// grpcServer serves the operations of the REST API and the release events through the gRPC interface
+// specified in proto/grm.proto
+type grpcServer struct {
+	*releaseServer
+}
+
+// authorizeGrpc requires the token of an API user like authorize, failures are answered with a gRPC
+// status. The methods check the role themselves, all calls are POST requests.
+func (s *releaseServer) authorizeGrpc(handler http.Handler) http.Handler {
+	return http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		user, enabled := s.authenticate(request)
+		switch {
+		case !enabled:
+			grmpb.WriteError(writer, grmpb.Errorf(grmpb.Unimplemented, "API disabled, no API token configured"))
+		case user == nil:
+			grmpb.WriteError(writer, grmpb.Errorf(grmpb.Unauthenticated, "invalid or missing API token"))
+		default:
+			handler.ServeHTTP(writer, request.WithContext(context.WithValue(request.Context(), apiUserContextKey{}, user)))
+		}
+	})
+}
+
+func requireAdmin(ctx context.Context) error {
+	if user := contextUser(ctx); user == nil || user.role != roleAdmin {
+		return grmpb.Errorf(grmpb.PermissionDenied, "the API user is a viewer, only admins may change anything")
+	}
+	return nil
+}
+
+func (s grpcServer) ListRemotes(ctx context.Context, request *grmpb.ListRemotesRequest) (*grmpb.ListRemotesResponse, error) {
+	response := &grmpb.ListRemotesResponse{}
+	for _, remote := range s.remotes() {
+		response.Remotes = append(response.Remotes, &grmpb.Remote{
+			Name:         remote.Name,
+			Account:      remote.Account,
+			Repositories: int32(remote.Repositories),
+			Updated:      remote.Updated,
+		})
+	}
+	return response, nil
+}
+
+func (s grpcServer) Refresh(ctx context.Context, request *grmpb.RefreshRequest) (*grmpb.RefreshResponse, error) {
+	if err := requireAdmin(ctx); err != nil {
+		return nil, err
+	}
+	s.scheduleRefresh()
+	return &grmpb.RefreshResponse{}, nil
+}
+
+func (s grpcServer) ListReleases(ctx context.Context, request *grmpb.ListReleasesRequest) (*grmpb.ListReleasesResponse, error) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	report, rep := s.findRepository(request.Account, request.Repository)
+	if rep == nil {
+		return nil, grmpb.Errorf(grmpb.NotFound, "unknown repository")
+	}
+
+	response := &grmpb.ListReleasesResponse{}
+	for _, rel := range rep.releases {
+		response.Releases = append(response.Releases, grpcRelease(newJsonRelease(report, rep, rel)))
+	}
+	return response, nil
+}
+
+func (s grpcServer) Acknowledge(ctx context.Context, request *grmpb.AcknowledgeRequest) (*grmpb.Release, error) {
+	if err := requireAdmin(ctx); err != nil {
+		return nil, err
+	}
+	return s.acknowledgeRelease(request, &acknowledgement{Comment: request.Comment, By: contextUser(ctx).name})
+}
+
+func (s grpcServer) RemoveAcknowledgement(ctx context.Context, request *grmpb.AcknowledgeRequest) (*grmpb.Release, error) {
+	if err := requireAdmin(ctx); err != nil {
+		return nil, err
+	}
+	return s.acknowledgeRelease(request, nil)
+}
+
+func (s grpcServer) acknowledgeRelease(request *grmpb.AcknowledgeRequest, ack *acknowledgement) (*grmpb.Release, error) {
+	rel, found, err := s.acknowledge(request.Account, request.Repository, request.Tag, ack)
+	switch {
+	case !found:
+		return nil, grmpb.Errorf(grmpb.NotFound, "unknown release")
+	case err != nil:
+		return nil, grmpb.Errorf(grmpb.Internal, "%s", err)
+	}
+	return grpcRelease(rel), nil
+}
+
+// WatchReleases streams the releases found by refreshes like /events, without the warnings
+func (s grpcServer) WatchReleases(request *grmpb.WatchReleasesRequest, stream grmpb.WatchReleasesServer) error {
+	minimum := severityRank(request.MinSeverity)
+	if request.MinSeverity != "" && minimum < 0 {
+		return grmpb.Errorf(grmpb.InvalidArgument, "unknown severity %s, supported severities: patch, minor, major, breaking", request.MinSeverity)
+	}
+	remotes := make(map[string]bool)
+	for _, remote := range request.Remotes {
+		remotes[remote] = true
+	}
+
+	subscriber := s.events.subscribe()
+	defer s.events.unsubscribe(subscriber)
+	for {
+		select {
+		case event, ok := <-subscriber:
+			if !ok {
+				return nil
+			}
+			if event.Type != "release" || (len(remotes) > 0 && !remotes[event.Remote]) ||
+				(request.MinSeverity != "" && severityRank(event.Severity) < minimum) {
+				continue
+			}
+			if err := stream.Send(grpcRelease(event.jsonRelease)); err != nil {
+				return err
+			}
+		case <-stream.Context().Done():
+			return nil
+		}
+	}
+}
+
+func grpcRelease(rel jsonRelease) *grmpb.Release {
+	release := &grmpb.Release{
+		Repository:   rel.Repository,
+		Tag:          rel.Tag,
+		Version:      rel.Version,
+		Released:     rel.Released,
+		Severity:     rel.Severity,
+		Highlights:   rel.Highlights,
+		Summary:      rel.Summary,
+		MilestoneUrl: rel.MilestoneUrl,
+		DownloadUrl:  rel.DownloadUrl,
+		Sha256:       rel.Sha256,
+	}
+	for _, reference := range rel.References {
+		release.References = append(release.References, &grmpb.Reference{Kind: reference.Kind, Id: reference.Id, Url: reference.Url})
+	}
+	if rel.Acknowledged != nil {
+		release.Acknowledged = *rel.Acknowledged
+	}
+	return release
+}
+
+// grpcProtocols lets the listener of grm serve accept gRPC calls, which are HTTP/2 requests, without TLS
+func grpcProtocols() *http.Protocols {
+	protocols := &http.Protocols{}
+	protocols.SetHTTP1(true)
+	protocols.SetUnencryptedHTTP2(true)
+	return protocols
+}
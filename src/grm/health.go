@@ -0,0 +1,128 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// Polls an instance may miss before it is reported unhealthy, at least for the minimum delay as polls
+// of large accounts take a while
+const (
+	healthMissedPolls  = 3
+	healthMinimumDelay = 15 * time.Minute
+)
+
+// healthStatus is the state of a daemon reported by /healthz and /readyz
+type healthStatus struct {
+	mutex    sync.Mutex
+	interval time.Duration
+	started  time.Time
+	lastPoll time.Time
+	polls    int
+	errors   int
+}
+
+// health of the running daemon, API errors are counted for every command
+var health = &healthStatus{started: time.Now()}
+
+// healthReport is the JSON body of /healthz and /readyz
+type healthReport struct {
+	Status    string     `json:"status"`
+	Started   time.Time  `json:"started"`
+	LastPoll  *time.Time `json:"last_poll,omitempty"`
+	Polls     int        `json:"polls"`
+	ApiErrors int        `json:"api_errors"`
+}
+
+// watchHealth starts tracking the polls of a daemon checking in the given interval
+func (h *healthStatus) watchHealth(interval time.Duration) {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	h.interval = interval
+	h.started = time.Now()
+}
+
+// recordPoll marks a complete poll of all remote definitions
+func (h *healthStatus) recordPoll() {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	h.lastPoll = time.Now()
+	h.polls++
+}
+
+func (h *healthStatus) recordApiError() {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	h.errors++
+}
+
+// report tells whether the daemon is alive, which means it polled within the last intervals, and
+// whether it is ready, which means it completed the first poll
+func (h *healthStatus) report() (report healthReport, alive, ready bool) {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	report = healthReport{Status: "ok", Started: h.started, Polls: h.polls, ApiErrors: h.errors}
+	since := h.started
+	if h.polls > 0 {
+		lastPoll := h.lastPoll
+		report.LastPoll = &lastPoll
+		since = h.lastPoll
+	}
+	delay := healthMissedPolls * h.interval
+	if delay < healthMinimumDelay {
+		delay = healthMinimumDelay
+	}
+	alive = time.Since(since) <= delay
+	ready = h.polls > 0
+	return report, alive, ready
+}
+
+// handleHealth serves /healthz, failing if the polls got stuck so orchestrators restart the instance
+func (h *healthStatus) handleHealth(writer http.ResponseWriter, request *http.Request) {
+	report, alive, _ := h.report()
+	status := http.StatusOK
+	if !alive {
+		report.Status = "stuck"
+		status = http.StatusServiceUnavailable
+	}
+	writeHealth(writer, status, report)
+}
+
+// handleReady serves /readyz, failing until the first poll completed
+func (h *healthStatus) handleReady(writer http.ResponseWriter, request *http.Request) {
+	report, _, ready := h.report()
+	status := http.StatusOK
+	if !ready {
+		report.Status = "starting"
+		status = http.StatusServiceUnavailable
+	}
+	writeHealth(writer, status, report)
+}
+
+func writeHealth(writer http.ResponseWriter, status int, report healthReport) {
+	writer.Header().Set("Content-Type", "application/json")
+	writer.Header().Set("Cache-Control", "no-cache")
+	writer.WriteHeader(status)
+	json.NewEncoder(writer).Encode(report)
+}
+
+// serveHealth serves only the health endpoints, for daemons without HTTP server
+func serveHealth(address string) {
+	defer recoverCrash()
+	mux := http.NewServeMux()
+	registerHealth(mux)
+	if err := http.ListenAndServe(address, mux); err != nil {
+		log.Fatal(fmt.Sprintf("Could not listen on %s: ", address), err)
+	}
+}
+
+// registerHealth serves /healthz and /readyz, both without authentication
+func registerHealth(mux *http.ServeMux) {
+	mux.HandleFunc("/healthz", health.handleHealth)
+	mux.HandleFunc("/readyz", health.handleReady)
+}
@@ -0,0 +1,348 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+	"grm/config"
+)
+
+// Media type of the OCI manifest of a chart and its annotation holding the creation date
+const (
+	ociManifestType      = "application/vnd.oci.image.manifest.v1+json"
+	ociCreatedAnnotation = "org.opencontainers.image.created"
+)
+
+// helmChartVersion is a version of a chart, as listed by the index of a chart repository or as
+// stored in the config of an OCI chart
+type helmChartVersion struct {
+	Name       string   `json:"name"`
+	Version    string   `json:"version"`
+	AppVersion string   `json:"appVersion"`
+	Created    string   `json:"created"`
+	Urls       []string `json:"urls"`
+}
+
+type helmIndex struct {
+	Entries map[string][]helmChartVersion `json:"entries"`
+}
+
+type ociManifest struct {
+	Config struct {
+		Digest string `json:"digest"`
+	} `json:"config"`
+	Annotations map[string]string `json:"annotations"`
+}
+
+// buildHelmReport reads the versions of the charts listed by the projects property from the chart
+// repository of the chart-repository property, either a classic repository with an index.yaml or an
+// OCI registry like oci://registry-1.docker.io/bitnamicharts. The chart version stands in for the tag,
+// the version of the packaged application is reported separately. Private repositories and
+// registries are read with the credentials of the auth command.
+func buildHelmReport(name, repositoryPattern string, since time.Time) *reportModel {
+	client := newProviderClient(name)
+	username, password, _ := readCredentials(name)
+	repos := readListedRepositories(name, config.Projects, repositoryPattern)
+
+	// Charts of a remote definition usually share the repository, its index is read once
+	indexes := make(map[string]map[string][]helmChartVersion)
+	return buildProviderReport(name, "", repos, since, func(chart string) []providerRelease {
+		repositoryUrl, ok := namedSectionGet(name, config.Remote, config.ChartRepository, chart)
+		if !ok || repositoryUrl == "" {
+			log.Fatal(fmt.Sprintf("No %s defined for chart %s", config.ChartRepository.Name(), chart))
+		}
+		repositoryUrl = strings.TrimSuffix(repositoryUrl, "/")
+
+		if strings.HasPrefix(repositoryUrl, "oci://") {
+			registry := &ociRegistry{client: client, username: username, password: password}
+			return readOciChartVersions(registry, name, strings.TrimPrefix(repositoryUrl, "oci://"), chart, since)
+		}
+		index, ok := indexes[repositoryUrl]
+		if !ok {
+			index = readHelmIndex(client, repositoryUrl, username, password)
+			indexes[repositoryUrl] = index
+		}
+		return helmChartTags(repositoryUrl, index[chart])
+	})
+}
+
+func readHelmIndex(client *http.Client, repositoryUrl, username, password string) map[string][]helmChartVersion {
+	request, err := http.NewRequest("GET", repositoryUrl+"/index.yaml", nil)
+	if err != nil {
+		log.Fatal(fmt.Sprintf("Could not create request for chart repository %s: ", repositoryUrl), err)
+	}
+	if password != "" {
+		request.SetBasicAuth(username, password)
+	}
+	index, err := parseHelmIndex(readProvider(client, request))
+	if err != nil {
+		log.Fatal(fmt.Sprintf("Could not parse index of chart repository %s: ", repositoryUrl), err)
+	}
+	return index
+}
+
+func helmChartTags(repositoryUrl string, versions []helmChartVersion) []providerRelease {
+	base, err := url.Parse(repositoryUrl + "/")
+	if err != nil {
+		log.Fatal(fmt.Sprintf("Invalid chart repository %s: ", repositoryUrl), err)
+	}
+	tags := make([]providerRelease, 0, len(versions))
+	for _, version := range versions {
+		created, err := time.Parse(time.RFC3339Nano, version.Created)
+		if err != nil {
+			continue
+		}
+		tag := providerRelease{version: version.Version, created: created.UTC(), appVersion: version.AppVersion}
+		// Chart URLs may be relative to the repository
+		if len(version.Urls) > 0 {
+			if download, err := base.Parse(version.Urls[0]); err == nil {
+				tag.downloadUrl = download.String()
+			}
+		}
+		tags = append(tags, tag)
+	}
+	return tags
+}
+
+// parseHelmIndex reads the charts of a repository index. Indexes are YAML written by Helm, without a
+// YAML parser only the subset Helm writes is understood: the entries map of charts, each with a list
+// of versions whose scalar properties and urls are read. JSON indexes are valid YAML too.
+func parseHelmIndex(data []byte) (map[string][]helmChartVersion, error) {
+	if bytes.HasPrefix(bytes.TrimSpace(data), []byte("{")) {
+		index := helmIndex{}
+		err := json.Unmarshal(data, &index)
+		return index.Entries, err
+	}
+
+	entries := make(map[string][]helmChartVersion)
+	section, chart, list := "", "", ""
+	chartIndent, itemIndent := -1, -1
+	for number, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimRight(line, " \r")
+		content := strings.TrimLeft(line, " ")
+		indent := len(line) - len(content)
+		if content == "" || strings.HasPrefix(content, "#") {
+			continue
+		}
+		if indent == 0 {
+			section = strings.TrimSuffix(content, ":")
+			continue
+		}
+		if section != "entries" {
+			continue
+		}
+		if chartIndent < 0 {
+			chartIndent = indent
+		}
+
+		item := strings.HasPrefix(content, "- ") || content == "-"
+		switch {
+		case indent == chartIndent && !item:
+			chart, itemIndent, list = unquoteYaml(strings.TrimSuffix(content, ":")), -1, ""
+			continue
+		case chart == "":
+			continue
+		case item && (itemIndent < 0 || indent == itemIndent):
+			// A new version of the chart, its first property follows the dash
+			itemIndent, list = indent, ""
+			entries[chart] = append(entries[chart], helmChartVersion{})
+			content, indent, item = strings.TrimLeft(strings.TrimPrefix(content, "-"), " "), indent+2, false
+			if content == "" {
+				continue
+			}
+		}
+		versions := entries[chart]
+		if len(versions) == 0 || indent < itemIndent+2 {
+			continue
+		}
+		version := &versions[len(versions)-1]
+
+		// Lists of a version are written at the indentation of its properties or below
+		if item && list == "urls" && indent <= itemIndent+4 {
+			version.Urls = append(version.Urls, unquoteYaml(strings.TrimLeft(strings.TrimPrefix(content, "-"), " ")))
+			continue
+		}
+		if item || indent != itemIndent+2 {
+			continue
+		}
+
+		separator := strings.Index(content, ":")
+		if separator < 0 {
+			return nil, fmt.Errorf("line %d: expected a property of chart %s", number+1, chart)
+		}
+		key, value := content[:separator], unquoteYaml(strings.TrimSpace(content[separator+1:]))
+		list = key
+		switch key {
+		case "name":
+			version.Name = value
+		case "version":
+			version.Version = value
+		case "appVersion":
+			version.AppVersion = value
+		case "created":
+			version.Created = value
+		}
+	}
+	return entries, nil
+}
+
+func unquoteYaml(value string) string {
+	if len(value) >= 2 && value[0] == '\'' && value[len(value)-1] == '\'' {
+		return strings.Replace(value[1:len(value)-1], "''", "'", -1)
+	}
+	if unquoted, err := strconv.Unquote(value); err == nil && strings.HasPrefix(value, "\"") {
+		return unquoted
+	}
+	return value
+}
+
+// readOciChartVersions lists the tags of a chart in an OCI registry. The listing has no dates, tags
+// of the release history keep their date. Newer tags are dated and versioned by their manifest and
+// the chart metadata stored as its config.
+func readOciChartVersions(registry *ociRegistry, name, registryPath, chart string, since time.Time) []providerRelease {
+	reference := registryPath + "/" + chart
+	separator := strings.Index(reference, "/")
+	registry.base = "https://" + reference[:separator]
+	repository := reference[separator+1:]
+
+	known := make(map[string]time.Time)
+	for _, entry := range readHistory(name, chart) {
+		known[entry.Tag] = entry.Released
+	}
+
+	list := struct {
+		Tags []string `json:"tags"`
+	}{}
+	if err := json.Unmarshal(registry.read(fmt.Sprintf("/v2/%s/tags/list?n=1000", repository), "", repository), &list); err != nil {
+		log.Fatal(fmt.Sprintf("Could not parse tags of chart %s: ", reference), err)
+	}
+
+	now := time.Now().UTC()
+	tags := make([]providerRelease, 0, len(list.Tags))
+	for _, tag := range list.Tags {
+		// OCI tags may not contain +, Helm replaces it with _ in chart versions
+		version := strings.Replace(tag, "_", "+", -1)
+		seen, ok := known[version]
+		if ok && !since.Before(seen) {
+			continue
+		}
+
+		manifest := ociManifest{}
+		if err := json.Unmarshal(registry.read(fmt.Sprintf("/v2/%s/manifests/%s", repository, tag), ociManifestType, repository), &manifest); err != nil {
+			log.Fatal(fmt.Sprintf("Could not parse manifest of chart %s:%s: ", reference, tag), err)
+		}
+		chart := helmChartVersion{}
+		if err := json.Unmarshal(registry.read(fmt.Sprintf("/v2/%s/blobs/%s", repository, manifest.Config.Digest), "", repository), &chart); err != nil {
+			log.Fatal(fmt.Sprintf("Could not parse metadata of chart %s:%s: ", reference, tag), err)
+		}
+		created, firstSeen := now, true
+		if c, err := time.Parse(time.RFC3339, manifest.Annotations[ociCreatedAnnotation]); err == nil {
+			created, firstSeen = c.UTC(), false
+		} else if ok {
+			created = seen
+		}
+		tags = append(tags, providerRelease{
+			version:     version,
+			created:     created,
+			firstSeen:   firstSeen,
+			appVersion:  chart.AppVersion,
+			downloadUrl: "oci://" + reference + ":" + tag,
+		})
+	}
+	return tags
+}
+
+// ociRegistry reads from the distribution API of an OCI registry. Public registries like Docker Hub
+// and ghcr.io want an anonymous bearer token, which is requested on the first 401. With credentials
+// the token is requested with them, registries without token service get them as basic auth.
+type ociRegistry struct {
+	client   *http.Client
+	base     string
+	token    string
+	username string
+	password string
+	basic    bool
+}
+
+func (r *ociRegistry) read(path, accept, repository string) []byte {
+	for {
+		request, err := http.NewRequest("GET", r.base+path, nil)
+		if err != nil {
+			log.Fatal(fmt.Sprintf("Could not create request for %s: ", r.base+path), err)
+		}
+		if accept != "" {
+			request.Header.Set("Accept", accept)
+		}
+		if r.token != "" {
+			request.Header.Set("Authorization", "Bearer "+r.token)
+		} else if r.basic {
+			request.SetBasicAuth(r.username, r.password)
+		}
+
+		response, err := r.client.Do(request)
+		if err != nil {
+			log.Fatal(fmt.Sprintf("Could not read %s: ", request.URL), err)
+		}
+		body, err := ioutil.ReadAll(response.Body)
+		response.Body.Close()
+		if err != nil {
+			log.Fatal(fmt.Sprintf("Could not read %s: ", request.URL), err)
+		}
+
+		if response.StatusCode == http.StatusUnauthorized && r.token == "" && !r.basic {
+			challenge := response.Header.Get("WWW-Authenticate")
+			if strings.HasPrefix(challenge, "Basic ") && r.password != "" {
+				r.basic = true
+			} else {
+				r.token = r.readToken(challenge, repository)
+			}
+			continue
+		}
+		if response.StatusCode != http.StatusOK {
+			log.Fatal(fmt.Sprintf("Could not read %s: %s", request.URL, response.Status))
+		}
+		return body
+	}
+}
+
+// readToken requests a pull token from the realm of the bearer challenge
+func (r *ociRegistry) readToken(challenge, repository string) string {
+	if !strings.HasPrefix(challenge, "Bearer ") {
+		log.Fatal(fmt.Sprintf("Registry %s requires unsupported authentication '%s'", r.base, challenge))
+	}
+	parameters := make(map[string]string)
+	for _, parameter := range strings.Split(strings.TrimPrefix(challenge, "Bearer "), ",") {
+		if pair := strings.SplitN(strings.TrimSpace(parameter), "=", 2); len(pair) == 2 {
+			parameters[pair[0]] = strings.Trim(pair[1], "\"")
+		}
+	}
+	query := url.Values{}
+	query.Set("service", parameters["service"])
+	query.Set("scope", fmt.Sprintf("repository:%s:pull", repository))
+	request, err := http.NewRequest("GET", parameters["realm"]+"?"+query.Encode(), nil)
+	if err != nil {
+		log.Fatal(fmt.Sprintf("Could not create token request for registry %s: ", r.base), err)
+	}
+	if r.password != "" {
+		request.SetBasicAuth(r.username, r.password)
+	}
+
+	token := struct {
+		Token       string `json:"token"`
+		AccessToken string `json:"access_token"`
+	}{}
+	if err := json.Unmarshal(readProvider(r.client, request), &token); err != nil {
+		log.Fatal(fmt.Sprintf("Could not parse token of registry %s: ", r.base), err)
+	}
+	if token.Token != "" {
+		return token.Token
+	}
+	return token.AccessToken
+}
@@ -0,0 +1,67 @@
+package main
+
+import (
+	"github.com/google/go-github/github"
+	"strings"
+	"strconv"
+	"log"
+	"fmt"
+	"grm/config"
+)
+
+// readHighlightRules reads the comma separated keywords of the highlight property
+func readHighlightRules(name, repository string) []string {
+	value, ok := namedSectionGet(name, config.Remote, config.Highlight, repository)
+	if !ok {
+		return nil
+	}
+
+	keywords := make([]string, 0)
+	for _, keyword := range strings.Split(value, ",") {
+		if keyword = strings.TrimSpace(keyword); keyword != "" {
+			keywords = append(keywords, keyword)
+		}
+	}
+	return keywords
+}
+
+// highlightReleases marks the new releases whose notes contain any of the configured keywords. Keywords
+// match case-insensitive anywhere in a word, so deprecat matches deprecated and deprecation.
+func highlightReleases(name string, rep *repository) {
+	keywords := readHighlightRules(name, rep.name)
+	if len(keywords) == 0 {
+		return
+	}
+
+	escalate := false
+	if e, ok := namedSectionGet(name, config.Remote, config.HighlightEscalate, rep.name); ok {
+		b, err := strconv.ParseBool(e)
+		if err != nil {
+			log.Fatal(fmt.Sprintf("Could not parse %s '%s': ", config.HighlightEscalate.Name(), e), err)
+		}
+		escalate = b
+	}
+
+	for _, rel := range rep.releases {
+		if !rel.published {
+			continue
+		}
+
+		notes := strings.ToLower(rel.notes)
+		for _, keyword := range keywords {
+			if strings.Contains(notes, strings.ToLower(keyword)) {
+				rel.highlights = append(rel.highlights, keyword)
+			}
+		}
+		rel.escalated = escalate && len(rel.highlights) > 0
+	}
+}
+
+// readReleaseNotes combines the notes of the Github release and the description of the release's milestone
+func readReleaseNotes(account, repository string, rel *release, client *github.Client) string {
+	notes := rel.milestone.GetDescription()
+	if githubRelease := readRelease(account, repository, rel.name, client); githubRelease != nil {
+		notes = githubRelease.GetBody() + "\n" + notes
+	}
+	return notes
+}
@@ -0,0 +1,99 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+	"grm/dataset"
+	"grm/state"
+)
+
+// historyRecord is an observed release of a repository
+type historyRecord struct {
+	remote     string
+	repository string
+	historyEntry
+}
+
+// readHistories collects the stored release histories of a remote definition, of a single repository
+// or with an empty target of all remote definitions
+func readHistories(target string) []historyRecord {
+	prefix := state.Key("history") + "/"
+	exact := ""
+	if strings.Contains(target, "/") {
+		exact = historyKey(splitRemoteRepository(target))
+	} else if target != "" {
+		prefix = state.Key("history", target) + "/"
+	}
+
+	records := make([]historyRecord, 0)
+	for _, key := range openState().Keys(prefix) {
+		if exact != "" && key != exact {
+			continue
+		}
+		tokens := strings.SplitN(strings.TrimPrefix(key, state.Key("history")+"/"), "/", 2)
+		if len(tokens) != 2 {
+			continue
+		}
+		for _, entry := range readHistory(tokens[0], tokens[1]) {
+			records = append(records, historyRecord{tokens[0], tokens[1], entry})
+		}
+	}
+
+	sort.SliceStable(records, func(i, j int) bool {
+		return records[i].firstSeen().Before(records[j].firstSeen())
+	})
+	return records
+}
+
+// seenBetween returns the records found first in the time range, including from and excluding until
+func seenBetween(records []historyRecord, from, until time.Time) []historyRecord {
+	selected := make([]historyRecord, 0)
+	for _, record := range records {
+		seen := record.firstSeen()
+		if !seen.Before(from) && seen.Before(until) {
+			selected = append(selected, record)
+		}
+	}
+	return selected
+}
+
+// historyTable flattens the records into the releases table written by history export, the time a
+// release was seen first is NULL for releases recorded before it was tracked
+func historyTable(records []historyRecord) dataset.Table {
+	table := dataset.Table{
+		Name: "releases",
+		Columns: []dataset.Column{
+			{Name: "remote", Type: dataset.String},
+			{Name: "repository", Type: dataset.String},
+			{Name: "tag", Type: dataset.String},
+			{Name: "released", Type: dataset.Timestamp},
+			{Name: "seen", Type: dataset.Timestamp},
+		},
+	}
+	for _, record := range records {
+		var seen interface{}
+		if !record.Seen.IsZero() {
+			seen = record.Seen
+		}
+		table.Append(record.remote, record.repository, record.Tag, record.Released, seen)
+	}
+	return table
+}
+
+func printHistory(writer io.Writer, records []historyRecord) error {
+	table := tabwriter.NewWriter(writer, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(table, "Repository\tTag\tReleased\tFirst seen")
+	for _, record := range records {
+		seen := "-"
+		if !record.Seen.IsZero() {
+			seen = locale.DateTime(record.Seen)
+		}
+		fmt.Fprintln(table, fmt.Sprintf("%s/%s\t%s\t%s\t%s", record.remote, record.repository, record.Tag,
+			locale.Date(record.Released), seen))
+	}
+	return table.Flush()
+}
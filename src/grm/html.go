@@ -0,0 +1,195 @@
+package main
+
+import (
+	"io"
+	"html/template"
+	"strings"
+	"time"
+)
+
+type htmlRelease struct {
+	Repository  string
+	Owner       string
+	Name        string
+	Created     string
+	Severity    string
+	Highlights  string
+	Summary     string
+	NotesUrl    string
+	DownloadUrl string
+	// Acknowledged is the date of the acknowledgement, empty if not acknowledged yet
+	Acknowledged string
+	// Description and Metadata of the repository, only with --with-metadata
+	Description string
+	Metadata    string
+	// CiStatus of the tagged commit, only with --with-ci-status
+	CiStatus string
+	// AppVersion of a Helm chart release
+	AppVersion string
+}
+
+var htmlFunctions = template.FuncMap{
+	"t": func(message string, args ...interface{}) string { return locale.T(message, args...) },
+}
+
+var htmlReportTemplate = template.Must(template.New("html").Funcs(htmlFunctions).Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>{{t "Releases of %s" .Account}}</title>
+<style>
+body { font-family: sans-serif; margin: 2em; }
+table { border-collapse: collapse; width: 100%; }
+th, td { text-align: left; padding: 0.4em 0.8em; border-bottom: 1px solid #ddd; }
+tr.highlight { background: #fdecea; }
+tr.highlight td.highlights { color: #b71c1c; font-weight: bold; }
+tr.unacknowledged td.acknowledged { font-weight: bold; }
+p.summary { white-space: pre-line; color: #555; margin: 0.3em 0 0; }
+p.metadata { color: #555; font-size: 0.9em; margin: 0.3em 0 0; }
+p.ci, p.app { font-size: 0.9em; margin: 0.3em 0 0; }
+.severity-breaking, .severity-major { font-weight: bold; }
+</style>
+</head>
+<body>
+<h1>{{t "Releases of %s" .Account}}</h1>
+<p>{{t "Generated %s" .Generated}}</p>
+<table>
+<tr>{{if .Owners}}<th>{{t "Owner"}}</th>{{end}}<th>{{t "Repository"}}</th><th>{{t "Release"}}</th><th>{{t "Date"}}</th><th>{{t "Severity"}}</th><th>{{t "Highlights"}}</th><th>{{t "Acknowledged"}}</th><th>{{t "Links"}}</th></tr>
+{{- range .Releases}}
+<tr class="{{if .Highlights}}highlight {{end}}{{if not .Acknowledged}}unacknowledged{{end}}">
+{{if $.Owners}}<td>{{.Owner}}</td>{{end}}<td>{{.Repository}}{{if .Description}}<p class="metadata">{{.Description}}</p>{{end}}{{if .Metadata}}<p class="metadata">{{.Metadata}}</p>{{end}}</td>
+<td>{{.Name}}{{if .AppVersion}}<p class="app">{{t "App version: %s" .AppVersion}}</p>{{end}}{{if .CiStatus}}<p class="ci">{{t "CI: %s" .CiStatus}}</p>{{end}}{{if .Summary}}<p class="summary">{{.Summary}}</p>{{end}}</td>
+<td>{{.Created}}</td>
+<td class="severity-{{.Severity}}">{{.Severity}}</td>
+<td class="highlights">{{.Highlights}}</td>
+<td class="acknowledged">{{if .Acknowledged}}{{.Acknowledged}}{{else}}{{t "no"}}{{end}}</td>
+<td>{{if .NotesUrl}}<a href="{{.NotesUrl}}">{{t "Release Notes"}}</a>{{end}}{{if and .NotesUrl .DownloadUrl}} | {{end}}{{if .DownloadUrl}}<a href="{{.DownloadUrl}}">{{t "Download"}}</a>{{end}}</td>
+</tr>
+{{- end}}
+</table>
+{{- if .Stale}}
+<h2>{{t "Stale repositories:"}}</h2>
+<ul>
+{{- range .Stale}}
+<li>{{.}}</li>
+{{- end}}
+</ul>
+{{- end}}
+{{- if .Anomalies}}
+<h2>{{t "Release cadence anomalies:"}}</h2>
+<ul>
+{{- range .Anomalies}}
+<li>{{.}}</li>
+{{- end}}
+</ul>
+{{- end}}
+{{- if .Maintainers}}
+<h2>{{t "Maintainer changes:"}}</h2>
+<ul>
+{{- range .Maintainers}}
+<li>{{.}}</li>
+{{- end}}
+</ul>
+{{- end}}
+{{- if .Checksums}}
+<h2>{{t "Changed checksums:"}}</h2>
+<ul>
+{{- range .Checksums}}
+<li>{{.}}</li>
+{{- end}}
+</ul>
+{{- end}}
+{{- if .Announcements}}
+<h2>{{t "Announcements:"}}</h2>
+<ul>
+{{- range .Announcements}}
+<li>{{.Repository}}: <a href="{{.Url}}">{{.Title}}</a> ({{.Kind}}, {{.Created}})</li>
+{{- end}}
+</ul>
+{{- end}}
+</body>
+</html>
+`))
+
+// formatHtml writes a standalone HTML page of the new releases, highlighted releases are marked
+func formatHtml(writer io.Writer, report *reportModel) error {
+	releases := make([]htmlRelease, 0)
+	owners := false
+	for _, rep := range ownedRepositories(report.repositories) {
+		for _, rel := range rep.releases {
+			if !rel.published || !report.reported(rel) {
+				continue
+			}
+			acknowledged := ""
+			if ack, ok := readAcknowledgement(report.name, rep.stateName(), rel.name); ok {
+				acknowledged = locale.Date(ack.Acknowledged)
+			}
+			owners = owners || rep.owner != ""
+			description, metadata := "", ""
+			if rep.metadata != nil {
+				description, metadata = rep.metadata.Description, rep.metadata.counts()
+			}
+			releases = append(releases, htmlRelease{
+				Repository:   releaseLineName(rep.name, rel.subproject, rel.channel),
+				Owner:        rep.owner,
+				Name:         rel.name,
+				Created:      locale.Date(rel.created),
+				Severity:     rel.severity,
+				Highlights:   strings.Join(rel.highlights, ", "),
+				Summary:      rel.summary,
+				NotesUrl:     rel.milestoneUrl,
+				DownloadUrl:  rel.downloadUrl,
+				Acknowledged: acknowledged,
+				Description:  description,
+				Metadata:     metadata,
+				CiStatus:     ciText(rel),
+				AppVersion:   rel.appVersion,
+			})
+		}
+	}
+
+	stale := make([]string, 0, len(report.stale))
+	for _, s := range report.stale {
+		stale = append(stale, strings.TrimPrefix(locale.T(" * %s: latest release %s (%s) is %s old, threshold %s", s.repository,
+			s.latest.Tag, locale.Date(s.latest.Released), locale.Days(time.Since(s.latest.Released)),
+			locale.Days(s.threshold)), " * "))
+	}
+	anomalies := make([]string, 0, len(report.anomalies))
+	for _, anomaly := range report.anomalies {
+		anomalies = append(anomalies, anomaly.repository+": "+anomaly.message(locale))
+	}
+	maintainers := make([]string, 0, len(report.maintainers))
+	for _, change := range report.maintainers {
+		maintainers = append(maintainers, change.repository+": "+change.message(locale))
+	}
+	checksums := make([]string, 0, len(report.checksums))
+	for _, change := range report.checksums {
+		checksums = append(checksums, change.repository+": "+change.message(locale))
+	}
+	announcements := make([]map[string]string, 0, len(report.announcements))
+	for _, a := range report.announcements {
+		kind := locale.T("discussion")
+		if a.kind == announcementPinnedIssue {
+			kind = locale.T("pinned issue")
+		}
+		announcements = append(announcements, map[string]string{
+			"Repository": a.repository,
+			"Title":      a.title,
+			"Url":        a.url,
+			"Kind":       kind,
+			"Created":    locale.Date(a.created),
+		})
+	}
+
+	return htmlReportTemplate.Execute(writer, map[string]interface{}{
+		"Account":       report.account,
+		"Generated":     locale.DateTime(time.Now()),
+		"Releases":      releases,
+		"Owners":        owners,
+		"Stale":         stale,
+		"Anomalies":     anomalies,
+		"Maintainers":   maintainers,
+		"Checksums":     checksums,
+		"Announcements": announcements,
+	})
+}
@@ -0,0 +1,201 @@
+package i18n
+
+var german = map[string]string{
+	"Found %s repositories":   "%s Repositories gefunden",
+	"Owner: %s":               "Verantwortlich: %s",
+	"Unassigned:":             "Nicht zugewiesen:",
+	"New %s release: %s (%s)": "Neues %s Release: %s (%s)",
+	"Highlights: %s":          "Hervorgehoben: %s",
+	"Severity: %s":            "Schweregrad: %s",
+	"Severity: %s (release notes mention '%s')": "Schweregrad: %s (Release Notes erwähnen '%s')",
+	"Summary:":                 "Zusammenfassung:",
+	"Release Notes: %s":        "Release Notes: %s",
+	"Download: %s":             "Download: %s",
+	"Acknowledged: %s":         "Bestätigt: %s",
+	"Repository: %s":           "Repository: %s",
+	"%s stars":                 "%s Sterne",
+	"%s open issues":           "%s offene Issues",
+	"topics: %s":               "Themen: %s",
+	"Not acknowledged yet: %s": "Noch nicht bestätigt: %s",
+	"Scans:":                   "Scans:",
+	"Attestations:":            "Attestierungen:",
+	"Stale repositories:":      "Veraltete Repositories:",
+	" * %s: latest release %s (%s) is %s old, threshold %s": " * %s: letztes Release %s (%s) ist %s alt, Schwelle %s",
+	"Release cadence anomalies:":                            "Auffälligkeiten im Release-Rhythmus:",
+	"no release for %s, usually releases every %s":          "%s ohne Release, üblicherweise alle %s",
+	"%s releases on %s, usually releases every %s":          "%s Releases am %s, üblicherweise alle %s",
+	"Maintainer changes:":                                   "Geänderte Maintainer:",
+	"maintainers changed: %s":                               "Maintainer geändert: %s",
+	"Changed checksums:":                                    "Geänderte Prüfsummen:",
+	"checksum of %s %s changed upstream from %s to %s":      "Prüfsumme von %s %s wurde upstream von %s zu %s geändert",
+	"new admins %s":                                         "neue Admins %s",
+	"added %s":                                              "hinzugekommen %s",
+	"removed %s":                                            "entfernt %s",
+	"Warning for %s: %s":                                    "Warnung für %s: %s",
+	"Warning for %s":                                        "Warnung für %s",
+	"No license policy violations found":                    "Keine Verstöße gegen die Lizenzrichtlinie gefunden",
+	"License policy violations:":                            "Verstöße gegen die Lizenzrichtlinie:",
+	" * %s: license changed from %s to %s":                  " * %s: Lizenz von %s zu %s geändert",
+	" * %s: license %s is not on the allowlist":             " * %s: Lizenz %s ist nicht erlaubt",
+	"Releases of %s":                                        "Releases von %s",
+	"Generated %s":                                          "Erstellt %s",
+	"Repository":                                            "Repository",
+	"Owner":                                                 "Verantwortlich",
+	"Release":                                               "Release",
+	"Date":                                                  "Datum",
+	"Severity":                                              "Schweregrad",
+	"Highlights":                                            "Hervorgehoben",
+	"Links":                                                 "Links",
+	"Release Notes":                                         "Release Notes",
+	"Download":                                              "Download",
+	"Acknowledged":                                          "Bestätigt",
+	"no":                                                    "nein",
+	"less than a day":                                       "weniger als ein Tag",
+	"1 day":                                                 "1 Tag",
+	"%s days":                                               "%s Tage",
+	"today":                                                 "heute",
+	"yesterday":                                             "gestern",
+	"%s days ago":                                           "vor %s Tagen",
+	"%s weeks ago":                                          "vor %s Wochen",
+	"%s months ago":                                         "vor %s Monaten",
+	"%s years ago":                                          "vor %s Jahren",
+	"Announcements:":                                        "Ankündigungen:",
+	"discussion \"%s\" (%s): %s":                            "Diskussion „%s“ (%s): %s",
+	"pinned issue \"%s\" (%s): %s":                          "angeheftetes Issue „%s“ (%s): %s",
+	"discussion":                                            "Diskussion",
+	"pinned issue":                                          "angeheftetes Issue",
+}
+
+var spanish = map[string]string{
+	"Found %s repositories":   "%s repositorios encontrados",
+	"Owner: %s":               "Responsable: %s",
+	"Unassigned:":             "Sin asignar:",
+	"New %s release: %s (%s)": "Nueva versión de %s: %s (%s)",
+	"Highlights: %s":          "Destacado: %s",
+	"Severity: %s":            "Severidad: %s",
+	"Severity: %s (release notes mention '%s')": "Severidad: %s (las notas mencionan '%s')",
+	"Summary:":                 "Resumen:",
+	"Release Notes: %s":        "Notas de la versión: %s",
+	"Download: %s":             "Descarga: %s",
+	"Acknowledged: %s":         "Revisada: %s",
+	"Repository: %s":           "Repositorio: %s",
+	"%s stars":                 "%s estrellas",
+	"%s open issues":           "%s issues abiertas",
+	"topics: %s":               "temas: %s",
+	"Not acknowledged yet: %s": "Aún sin revisar: %s",
+	"Scans:":                   "Análisis:",
+	"Attestations:":            "Atestaciones:",
+	"Stale repositories:":      "Repositorios inactivos:",
+	" * %s: latest release %s (%s) is %s old, threshold %s": " * %s: la última versión %s (%s) tiene %s, límite %s",
+	"Release cadence anomalies:":                            "Anomalías en el ritmo de versiones:",
+	"no release for %s, usually releases every %s":          "sin versiones desde hace %s, normalmente cada %s",
+	"%s releases on %s, usually releases every %s":          "%s versiones el %s, normalmente cada %s",
+	"Maintainer changes:":                                   "Cambios de mantenedores:",
+	"maintainers changed: %s":                               "mantenedores cambiados: %s",
+	"Changed checksums:":                                    "Sumas de verificación cambiadas:",
+	"checksum of %s %s changed upstream from %s to %s":      "la suma de verificación de %s %s cambió en origen de %s a %s",
+	"new admins %s":                                         "nuevos administradores %s",
+	"added %s":                                              "añadidos %s",
+	"removed %s":                                            "eliminados %s",
+	"Warning for %s: %s":                                    "Aviso para %s: %s",
+	"Warning for %s":                                        "Aviso para %s",
+	"No license policy violations found":                    "No se encontraron infracciones de la política de licencias",
+	"License policy violations:":                            "Infracciones de la política de licencias:",
+	" * %s: license changed from %s to %s":                  " * %s: la licencia cambió de %s a %s",
+	" * %s: license %s is not on the allowlist":             " * %s: la licencia %s no está permitida",
+	"Releases of %s":                                        "Versiones de %s",
+	"Generated %s":                                          "Generado %s",
+	"Repository":                                            "Repositorio",
+	"Owner":                                                 "Responsable",
+	"Release":                                               "Versión",
+	"Date":                                                  "Fecha",
+	"Severity":                                              "Severidad",
+	"Highlights":                                            "Destacado",
+	"Links":                                                 "Enlaces",
+	"Release Notes":                                         "Notas de la versión",
+	"Download":                                              "Descarga",
+	"Acknowledged":                                          "Revisada",
+	"no":                                                    "no",
+	"less than a day":                                       "menos de un día",
+	"1 day":                                                 "1 día",
+	"%s days":                                               "%s días",
+	"today":                                                 "hoy",
+	"yesterday":                                             "ayer",
+	"%s days ago":                                           "hace %s días",
+	"%s weeks ago":                                          "hace %s semanas",
+	"%s months ago":                                         "hace %s meses",
+	"%s years ago":                                          "hace %s años",
+	"Announcements:":                                        "Anuncios:",
+	"discussion \"%s\" (%s): %s":                            "discusión «%s» (%s): %s",
+	"pinned issue \"%s\" (%s): %s":                          "issue fijado «%s» (%s): %s",
+	"discussion":                                            "discusión",
+	"pinned issue":                                          "issue fijado",
+}
+
+var french = map[string]string{
+	"Found %s repositories":   "%s dépôts trouvés",
+	"Owner: %s":               "Responsable : %s",
+	"Unassigned:":             "Non attribués :",
+	"New %s release: %s (%s)": "Nouvelle version de %s : %s (%s)",
+	"Highlights: %s":          "Mis en évidence : %s",
+	"Severity: %s":            "Gravité : %s",
+	"Severity: %s (release notes mention '%s')": "Gravité : %s (les notes mentionnent '%s')",
+	"Summary:":                 "Résumé :",
+	"Release Notes: %s":        "Notes de version : %s",
+	"Download: %s":             "Téléchargement : %s",
+	"Acknowledged: %s":         "Validée : %s",
+	"Repository: %s":           "Dépôt : %s",
+	"%s stars":                 "%s étoiles",
+	"%s open issues":           "%s tickets ouverts",
+	"topics: %s":               "sujets : %s",
+	"Not acknowledged yet: %s": "Pas encore validée : %s",
+	"Scans:":                   "Analyses :",
+	"Attestations:":            "Attestations :",
+	"Stale repositories:":      "Dépôts inactifs :",
+	" * %s: latest release %s (%s) is %s old, threshold %s": " * %s : la dernière version %s (%s) date de %s, seuil %s",
+	"Release cadence anomalies:":                            "Anomalies du rythme de publication :",
+	"no release for %s, usually releases every %s":          "aucune version depuis %s, habituellement tous les %s",
+	"%s releases on %s, usually releases every %s":          "%s versions le %s, habituellement tous les %s",
+	"Maintainer changes:":                                   "Changements de mainteneurs :",
+	"maintainers changed: %s":                               "mainteneurs modifiés : %s",
+	"Changed checksums:":                                    "Sommes de contrôle modifiées :",
+	"checksum of %s %s changed upstream from %s to %s":      "la somme de contrôle de %s %s a changé en amont de %s à %s",
+	"new admins %s":                                         "nouveaux administrateurs %s",
+	"added %s":                                              "ajoutés %s",
+	"removed %s":                                            "retirés %s",
+	"Warning for %s: %s":                                    "Avertissement pour %s : %s",
+	"Warning for %s":                                        "Avertissement pour %s",
+	"No license policy violations found":                    "Aucune violation de la politique de licences",
+	"License policy violations:":                            "Violations de la politique de licences :",
+	" * %s: license changed from %s to %s":                  " * %s : licence changée de %s à %s",
+	" * %s: license %s is not on the allowlist":             " * %s : la licence %s n'est pas autorisée",
+	"Releases of %s":                                        "Versions de %s",
+	"Generated %s":                                          "Généré %s",
+	"Repository":                                            "Dépôt",
+	"Owner":                                                 "Responsable",
+	"Release":                                               "Version",
+	"Date":                                                  "Date",
+	"Severity":                                              "Gravité",
+	"Highlights":                                            "Mis en évidence",
+	"Links":                                                 "Liens",
+	"Release Notes":                                         "Notes de version",
+	"Download":                                              "Téléchargement",
+	"Acknowledged":                                          "Validée",
+	"no":                                                    "non",
+	"less than a day":                                       "moins d'un jour",
+	"1 day":                                                 "1 jour",
+	"%s days":                                               "%s jours",
+	"today":                                                 "aujourd'hui",
+	"yesterday":                                             "hier",
+	"%s days ago":                                           "il y a %s jours",
+	"%s weeks ago":                                          "il y a %s semaines",
+	"%s months ago":                                         "il y a %s mois",
+	"%s years ago":                                          "il y a %s ans",
+	"Announcements:":                                        "Annonces :",
+	"discussion \"%s\" (%s): %s":                            "discussion « %s » (%s) : %s",
+	"pinned issue \"%s\" (%s): %s":                          "issue épinglé « %s » (%s) : %s",
+	"discussion":                                            "discussion",
+	"pinned issue":                                          "issue épinglé",
+	"CI: %s":                                                "CI : %s",
+	"App version: %s":                                       "Version de l'application : %s",
+}
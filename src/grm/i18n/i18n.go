@@ -0,0 +1,130 @@
+package i18n
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Locale formats dates, numbers and relative times and translates report messages. Messages are
+// identified by their English format string, missing translations fall back to English. Dates are
+// rendered in the locale's time zone, UTC unless set otherwise.
+type Locale struct {
+	Tag        string
+	dateFormat string
+	thousands  string
+	messages   map[string]string
+	location   *time.Location
+}
+
+var English = &Locale{
+	Tag:        "en",
+	dateFormat: "2006-01-02",
+	thousands:  ",",
+	messages:   map[string]string{},
+}
+
+var locales = map[string]*Locale{
+	"en": English,
+	"de": {"de", "02.01.2006", ".", german, nil},
+	"es": {"es", "02/01/2006", ".", spanish, nil},
+	"fr": {"fr", "02/01/2006", " ", french, nil},
+}
+
+// Lookup finds the locale for a language tag like de, de-AT or de_DE.UTF-8
+func Lookup(tag string) (*Locale, bool) {
+	tag = strings.ToLower(tag)
+	if i := strings.IndexAny(tag, "-_."); i >= 0 {
+		tag = tag[:i]
+	}
+	locale, ok := locales[tag]
+	return locale, ok
+}
+
+func Tags() []string {
+	tags := make([]string, 0, len(locales))
+	for tag := range locales {
+		tags = append(tags, tag)
+	}
+	sort.Strings(tags)
+	return tags
+}
+
+// T translates the message and formats it with the arguments
+func (l *Locale) T(message string, args ...interface{}) string {
+	if translation, ok := l.messages[message]; ok {
+		message = translation
+	}
+	if len(args) == 0 {
+		return message
+	}
+	return fmt.Sprintf(message, args...)
+}
+
+// In returns a copy of the locale rendering dates in the given time zone
+func (l *Locale) In(location *time.Location) *Locale {
+	locale := *l
+	locale.location = location
+	return &locale
+}
+
+// Time converts the time into the locale's time zone
+func (l *Locale) Time(t time.Time) time.Time {
+	if l.location == nil {
+		return t.UTC()
+	}
+	return t.In(l.location)
+}
+
+func (l *Locale) Date(t time.Time) string {
+	return l.Time(t).Format(l.dateFormat)
+}
+
+func (l *Locale) DateTime(t time.Time) string {
+	return l.Time(t).Format(l.dateFormat + " 15:04 MST")
+}
+
+// Number formats an integer with the locale's thousands separator
+func (l *Locale) Number(n int) string {
+	digits := strconv.Itoa(n)
+	sign := ""
+	if n < 0 {
+		sign, digits = "-", digits[1:]
+	}
+	for i := len(digits) - 3; i > 0; i -= 3 {
+		digits = digits[:i] + l.thousands + digits[i:]
+	}
+	return sign + digits
+}
+
+// Days formats a duration in whole days
+func (l *Locale) Days(duration time.Duration) string {
+	days := int(duration.Hours() / 24)
+	switch {
+	case days < 1:
+		return l.T("less than a day")
+	case days == 1:
+		return l.T("1 day")
+	}
+	return l.T("%s days", l.Number(days))
+}
+
+// Ago formats the time relative to now, e.g. 3 weeks ago
+func (l *Locale) Ago(t time.Time) string {
+	days := int(time.Since(t).Hours() / 24)
+	switch {
+	case days < 1:
+		return l.T("today")
+	case days == 1:
+		return l.T("yesterday")
+	case days < 14:
+		return l.T("%s days ago", l.Number(days))
+	case days < 60:
+		return l.T("%s weeks ago", l.Number(days/7))
+	case days < 730:
+		return l.T("%s months ago", l.Number(days/30))
+	}
+	return l.T("%s years ago", l.Number(days/365))
+}
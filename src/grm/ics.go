@@ -0,0 +1,121 @@
+package main
+
+import (
+	"io"
+	"fmt"
+	"time"
+	"strings"
+	"bytes"
+)
+
+const icsTimestamp = "20060102T150405Z"
+
+// formatIcs writes an iCalendar (RFC 5545) calendar of the reported releases and upcoming milestone due dates
+func formatIcs(writer io.Writer, report *reportModel) error {
+	calendar := &icsWriter{}
+	stamp := time.Now().UTC().Format(icsTimestamp)
+
+	calendar.line("BEGIN:VCALENDAR")
+	calendar.line("VERSION:2.0")
+	calendar.line("PRODID:-//Github Release Monitor//grm//EN")
+	calendar.line("CALSCALE:GREGORIAN")
+	calendar.line("X-WR-CALNAME:" + icsEscape(fmt.Sprintf("Releases of %s", report.account)))
+
+	for _, rep := range report.repositories {
+		for _, rel := range rep.releases {
+			if !report.reported(rel) {
+				continue
+			}
+
+			description := ""
+			if rel.milestoneUrl != "" {
+				description = "Release Notes: " + rel.milestoneUrl
+			}
+			if rel.downloadUrl != "" {
+				description = strings.TrimSpace(description + "\nDownload: " + rel.downloadUrl)
+			}
+
+			calendar.event(icsEvent{
+				uid:         fmt.Sprintf("release-%s-%s-%s@grm", report.account, rep.name, rel.name),
+				stamp:       stamp,
+				date:        rel.created,
+				summary:     fmt.Sprintf("%s %s released", rep.name, rel.name),
+				description: description,
+				url:         releaseUrl(report.account, rep, rel),
+			})
+		}
+
+		for _, milestone := range rep.milestones {
+			calendar.event(icsEvent{
+				uid:         fmt.Sprintf("milestone-%s-%s-%d@grm", report.account, rep.name, milestone.GetNumber()),
+				stamp:       stamp,
+				date:        milestone.GetDueOn(),
+				summary:     fmt.Sprintf("%s %s due", rep.name, milestone.GetTitle()),
+				description: milestone.GetDescription(),
+				url:         milestone.GetHTMLURL(),
+			})
+		}
+	}
+
+	calendar.line("END:VCALENDAR")
+	_, err := writer.Write(calendar.buffer.Bytes())
+	return err
+}
+
+type icsEvent struct {
+	uid         string
+	stamp       string
+	date        time.Time
+	summary     string
+	description string
+	url         string
+}
+
+type icsWriter struct {
+	buffer bytes.Buffer
+}
+
+// event writes an all day event
+func (w *icsWriter) event(event icsEvent) {
+	date := locale.Time(event.date)
+	w.line("BEGIN:VEVENT")
+	w.line("UID:" + icsEscape(event.uid))
+	w.line("DTSTAMP:" + event.stamp)
+	w.line("DTSTART;VALUE=DATE:" + date.Format("20060102"))
+	w.line("DTEND;VALUE=DATE:" + date.AddDate(0, 0, 1).Format("20060102"))
+	w.line("SUMMARY:" + icsEscape(event.summary))
+	if event.description != "" {
+		w.line("DESCRIPTION:" + icsEscape(event.description))
+	}
+	if event.url != "" {
+		w.line("URL:" + event.url)
+	}
+	w.line("TRANSP:TRANSPARENT")
+	w.line("END:VEVENT")
+}
+
+// line writes a content line terminated by CRLF, folding lines longer than 75 octets
+func (w *icsWriter) line(content string) {
+	limit := 75
+	for len(content) > limit {
+		cut := limit
+		// Never split inside of a multi-byte UTF-8 sequence
+		for cut > 0 && content[cut]&0xC0 == 0x80 {
+			cut--
+		}
+		w.buffer.WriteString(content[:cut] + "\r\n ")
+		content = content[cut:]
+		// Continuation lines start with a space
+		limit = 74
+	}
+	w.buffer.WriteString(content + "\r\n")
+}
+
+func icsEscape(value string) string {
+	value = strings.Replace(value, "\\", "\\\\", -1)
+	value = strings.Replace(value, ";", "\\;", -1)
+	value = strings.Replace(value, ",", "\\,", -1)
+	value = strings.Replace(value, "\r\n", "\\n", -1)
+	value = strings.Replace(value, "\n", "\\n", -1)
+	return value
+}
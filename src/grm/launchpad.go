@@ -0,0 +1,118 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"path"
+	"time"
+	"grm/config"
+)
+
+// Launchpad API root, a variable to point tests at other servers
+var launchpadApi = "https://api.launchpad.net/1.0"
+
+// launchpadCollection is a page of a Launchpad collection, the next page is linked
+type launchpadCollection struct {
+	Entries            json.RawMessage `json:"entries"`
+	NextCollectionLink string          `json:"next_collection_link"`
+}
+
+// launchpadRelease is a release of a project series
+type launchpadRelease struct {
+	Version             string `json:"version"`
+	DateReleased        string `json:"date_released"`
+	ReleaseNotes        string `json:"release_notes"`
+	Changelog           string `json:"changelog"`
+	WebLink             string `json:"web_link"`
+	FilesCollectionLink string `json:"files_collection_link"`
+}
+
+type launchpadFile struct {
+	FileLink string `json:"file_link"`
+	FileType string `json:"file_type"`
+}
+
+// buildLaunchpadReport reads the releases of all series of the Launchpad projects listed by the
+// projects property. The version of a release stands in for the tag.
+func buildLaunchpadReport(name, repositoryPattern string, since time.Time) *reportModel {
+	client := newProviderClient(name)
+	repos := readListedRepositories(name, config.Projects, repositoryPattern)
+	return buildProviderReport(name, "", repos, since, func(project string) []providerRelease {
+		tags := make([]providerRelease, 0)
+		for _, release := range readLaunchpadReleases(client, project) {
+			released, err := time.Parse(time.RFC3339, release.DateReleased)
+			if err != nil || !since.Before(released) {
+				continue
+			}
+			tags = append(tags, providerRelease{
+				version:     release.Version,
+				created:     released,
+				notesUrl:    release.WebLink,
+				downloadUrl: readLaunchpadDownload(client, release),
+				notes:       release.ReleaseNotes + "\n" + release.Changelog,
+			})
+		}
+		return tags
+	})
+}
+
+func readLaunchpadReleases(client *http.Client, project string) []launchpadRelease {
+	releases := make([]launchpadRelease, 0)
+	for _, page := range readLaunchpadCollection(client, fmt.Sprintf("%s/%s/releases", launchpadApi, project)) {
+		entries := make([]launchpadRelease, 0)
+		if err := json.Unmarshal(page, &entries); err != nil {
+			log.Fatal(fmt.Sprintf("Could not parse releases of Launchpad project %s: ", project), err)
+		}
+		releases = append(releases, entries...)
+	}
+	return releases
+}
+
+// readLaunchpadDownload returns the download page of the release tarball, or of the first file if
+// there is no tarball
+func readLaunchpadDownload(client *http.Client, release launchpadRelease) string {
+	if release.FilesCollectionLink == "" {
+		return ""
+	}
+	files := make([]launchpadFile, 0)
+	for _, page := range readLaunchpadCollection(client, release.FilesCollectionLink) {
+		entries := make([]launchpadFile, 0)
+		if err := json.Unmarshal(page, &entries); err != nil {
+			log.Fatal(fmt.Sprintf("Could not parse files of Launchpad release %s: ", release.Version), err)
+		}
+		files = append(files, entries...)
+	}
+	if len(files) == 0 {
+		return ""
+	}
+	file := files[0]
+	for _, f := range files {
+		if f.FileType == "Code Release Tarball" {
+			file = f
+			break
+		}
+	}
+	return release.WebLink + "/+download/" + path.Base(file.FileLink)
+}
+
+// readLaunchpadCollection follows the next links of a collection and returns the entries of all pages
+func readLaunchpadCollection(client *http.Client, url string) []json.RawMessage {
+	pages := make([]json.RawMessage, 0)
+	for url != "" {
+		request, err := http.NewRequest("GET", url, nil)
+		if err != nil {
+			log.Fatal(fmt.Sprintf("Could not create request for %s: ", url), err)
+		}
+		request.Header.Set("Accept", "application/json")
+
+		collection := launchpadCollection{}
+		if err := json.Unmarshal(readProvider(client, request), &collection); err != nil {
+			log.Fatal(fmt.Sprintf("Could not parse %s: ", url), err)
+		}
+		pages = append(pages, collection.Entries)
+		url = collection.NextCollectionLink
+	}
+	return pages
+}
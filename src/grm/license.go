@@ -0,0 +1,136 @@
+package main
+
+import (
+	"github.com/google/go-github/github"
+	"context"
+	"log"
+	"fmt"
+	"net/http"
+	"os"
+	"io"
+	"bufio"
+	"strings"
+	"grm/config"
+	"grm/state"
+	"time"
+)
+
+// Used when Github could not detect any license file in the repository
+const noLicense = "NONE"
+
+type licenseRecord struct {
+	License  string    `json:"license"`
+	Detected time.Time `json:"detected"`
+}
+
+type licenseFinding struct {
+	repository string
+	license    string
+	previous   string
+	allowed    bool
+}
+
+func (f licenseFinding) changed() bool {
+	return f.previous != "" && f.previous != f.license
+}
+
+// readLicensePolicy reads a policy file with one allowed SPDX license identifier per line,
+// empty lines and lines starting with # are ignored
+func readLicensePolicy(path string) map[string]bool {
+	file, err := os.Open(path)
+	if err != nil {
+		log.Fatal(fmt.Sprintf("Could not read license policy file '%s': ", path), err)
+	}
+	defer file.Close()
+
+	allowed := make(map[string]bool)
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		allowed[strings.ToUpper(line)] = true
+	}
+	if err := scanner.Err(); err != nil {
+		log.Fatal(fmt.Sprintf("Could not read license policy file '%s': ", path), err)
+	}
+	return allowed
+}
+
+// checkLicenses detects the license of every repository and reports those which changed since the
+// last check or, if a policy file is configured, are not on the allowlist
+func checkLicenses(name, account string, repositories []*github.Repository, client *github.Client) []licenseFinding {
+	var allowed map[string]bool
+	if p, ok := namedSectionGet(name, config.Remote, config.LicensePolicy, ""); ok && p != "" {
+		allowed = readLicensePolicy(p)
+	}
+
+	findings := make([]licenseFinding, 0)
+	for _, repository := range repositories {
+		repoName := repository.GetName()
+		license := readLicense(account, repoName, client)
+
+		key := state.Key("license", name, repoName)
+		previous := licenseRecord{}
+		openState().Get(key, &previous)
+
+		finding := licenseFinding{
+			repository: repoName,
+			license:    license,
+			previous:   previous.License,
+			allowed:    allowed == nil || allowed[strings.ToUpper(license)],
+		}
+
+		if previous.License != license {
+			openState().Set(key, licenseRecord{License: license, Detected: time.Now().UTC()})
+		}
+
+		if finding.changed() || !finding.allowed {
+			findings = append(findings, finding)
+		}
+	}
+	return findings
+}
+
+func readLicense(account, repository string, client *github.Client) string {
+	ctx := context.Background()
+
+	for {
+		license, response, err := client.Repositories.License(ctx, account, repository)
+		if response != nil && response.StatusCode == http.StatusNotFound {
+			return noLicense
+		}
+
+		if rateLimit(response) {
+			continue
+		}
+
+		if err != nil {
+			log.Fatal(fmt.Sprintf("Could not retrieve license for repository %s: ", repository), err)
+		}
+
+		if spdx := license.GetLicense().GetSPDXID(); spdx != "" {
+			return spdx
+		}
+		return license.GetLicense().GetKey()
+	}
+}
+
+func printLicenseFindings(writer io.Writer, findings []licenseFinding) {
+	if len(findings) == 0 {
+		fmt.Fprintln(writer, locale.T("No license policy violations found"))
+		return
+	}
+
+	fmt.Fprintln(writer, locale.T("License policy violations:"))
+	for _, finding := range findings {
+		if finding.changed() {
+			fmt.Fprintln(writer, locale.T(" * %s: license changed from %s to %s", finding.repository,
+				finding.previous, finding.license))
+		}
+		if !finding.allowed {
+			fmt.Fprintln(writer, locale.T(" * %s: license %s is not on the allowlist", finding.repository, finding.license))
+		}
+	}
+}
@@ -19,43 +19,121 @@ import (
 	"crypto/rand"
 	"github.com/google/go-github/github"
 	"time"
+	"sync"
+	"net/http"
 	"grm/config"
 	"github.com/denisbrodbeck/machineid"
+	"grm/cache"
+	"grm/state"
+	"grm/i18n"
+	"grm/secrets"
+	"grm/notify"
+	"grm/cloud"
 )
 
 var (
 	homeDir       *string
 	verbose       *bool
-	machineKey    []byte
+	debugHttp     *bool
 	configuration config.Configuration
-	buildVersion  = "unknown"
-	buildDate     = "unknown"
+	httpCache     *cache.Cache
+	responses     *cache.Memory
+	// stateStore is opened by openState on first use
+	stateStore   *state.Store
+	stateMutex   sync.Mutex
+	locale       = i18n.English
+	buildVersion = "unknown"
+	buildDate    = "unknown"
 )
 
 func main() {
+	defer recoverCrash()
 	app := cli.App("grm", "Github Release Monitor")
 
 	verbose = app.BoolOpt("v verbose", false, "Verbose logging mode")
+	debugHttp = app.BoolOpt("debug-http", false, "Log all HTTP requests with status, rate limit and timing to stderr")
 	homeDir = app.StringOpt("h home", readUserHome(), "Specify a base directory for the configuration, default: current user's home")
-	machineKey = generateMachineKey()
 
 	app.Version("version", fmt.Sprintf("Github-Release-Monitor (GRM)\nGit Revision %s (Date: %s UTC)", buildVersion, buildDate))
 
 	app.Before = func() {
+		registerSecretStores()
+		config.SetChangeDescription(strings.Join(sanitizeArguments(os.Args[1:]), " "))
 		configuration = config.NewConfiguration(*homeDir)
+		httpCache = cache.NewCache(grmPath("cache"), cacheMaxSize())
+		responses = cache.NewMemory(cache.DefaultMemoryMaxSize)
+		setup()
 	}
 
 	app.Command("report", "Generates a release report for the remote Github users", cmdReport)
+	app.Command("download", "Downloads, scans and installs release assets", cmdDownload)
+	app.Command("mirror", "Uploads new release assets to an internal artifact store", cmdMirror)
+	app.Command("generate", "Generates package manager manifests from the latest release", cmdGenerate)
+	app.Command("serve", "Serves release status badges for the remote Github users", cmdServe)
+	app.Command("watch", "Prints an event stream of new releases for the remote Github users", cmdWatch)
+	app.Command("run", "Generates release reports headless, e.g. as Kubernetes CronJob", cmdRun)
+	app.Command("plan", "Estimates the Github API requests of reporting remote definitions", cmdPlan)
+	app.Command("notify", "Replays recorded notifications", cmdNotify)
+	app.Command("audit", "Lists the sent notifications and the downloaded, installed and mirrored assets", cmdAudit)
+	app.Command("health", "Checks the health of a running serve or watch command", cmdHealth)
+	app.Command("ack", "Acknowledges releases as reviewed or adopted", cmdAck)
+	app.Command("snooze", "Hides repositories from reports and notifications until a date", cmdSnooze)
+	app.Command("matrix", "Compares pinned, installed and latest versions of all repositories", cmdMatrix)
+	app.Command("history", "Lists the releases observed so far", cmdHistory)
+	app.Command("status", "Counts the new releases not acknowledged yet, e.g. for shell prompts", cmdStatus)
+	app.Command("stats", "Summarizes the release frequency of the remote Github users", cmdStats)
+	app.Command("forks", "Shows how far forks diverged from their upstream repositories", cmdForks)
+	app.Command("pattern", "Tests the configured patterns against repository and tag names", cmdPattern)
+	app.Command("schema", "Prints the JSON schema of the structured output", cmdSchema)
+	app.Command("init", "Sets up the first remote definition step by step", cmdInit)
 	app.Command("auth", "Configures authorization credentials for remote Github users", cmdAuth)
 	app.Command("remote", "Configures remote Github user definitions", cmdRemote)
 	app.Command("config", "Sets, gets configuration properties for remote Github users", cmdConfig)
+	app.Command("undo", "Reverts the latest configuration change", cmdUndo)
 	app.Command("export", "Exports configuration properties for remote Github users", cmdExport)
 	app.Command("import", "Imports configuration properties for remote Github users", cmdImport)
+	app.Command("cache", "Inspects and clears the shared HTTP cache", cmdCache)
 	app.Command("license", "Prints all license information for vendored dependencies", cmdLicenses)
 
 	app.Run(os.Args)
 }
 
+// setup initializes tracing and the HTTP clients of the packages from the configuration and drops a state store read with a previous
+// configuration. The state store is only opened by commands using it, the others keep working with an
+// unreachable state backend.
+func setup() {
+	tracer = newTracer()
+	notify.HttpClient = newTimeoutHttpClient(requestTimeout)
+	state.HttpClient = newTimeoutHttpClient(requestTimeout)
+	cloud.HttpClient = newTimeoutHttpClient(requestTimeout)
+	stateMutex.Lock()
+	stateStore = nil
+	stateMutex.Unlock()
+}
+
+// openState returns the state store, it is read from the configured backend on first use
+func openState() *state.Store {
+	stateMutex.Lock()
+	defer stateMutex.Unlock()
+	if stateStore == nil {
+		backend := readStateBackend()
+		s, err := state.Open(backend)
+		if err != nil {
+			log.Fatal(fmt.Sprintf("Could not read state from '%s': ", backend), err)
+		}
+		stateStore = s
+	}
+	return stateStore
+}
+
+// registerSecretStores resolves configuration values referencing secret stores, like
+// token=vault:secret/data/grm#token
+func registerSecretStores() {
+	config.RegisterResolver("vault", secrets.Vault)
+	config.RegisterResolver("aws-secretsmanager", secrets.AwsSecretsManager)
+	config.RegisterResolver("gcp-secretmanager", secrets.GcpSecretManager)
+}
+
 func readUserHome() string {
 	user, err := user.Current()
 	if err != nil {
@@ -71,6 +149,79 @@ func readUserHome() string {
 	return homeDir
 }
 
+func grmPath(elements ...string) string {
+	return filepath.Join(append([]string{*homeDir, "github-release-monitor"}, elements...)...)
+}
+
+// sectionGet reads a global property, a reference which can't be resolved stops GRM, a missing secret
+// can't be replaced by anything
+func sectionGet(section config.Section, key config.Key, specifier string) (string, bool) {
+	value, ok, err := configuration.SectionGet(section, key, specifier)
+	if err != nil {
+		log.Fatal("Could not read the configuration: ", err)
+	}
+	return value, ok
+}
+
+// namedSectionGet reads a property of a named section like a remote definition, a reference which
+// can't be resolved stops GRM
+func namedSectionGet(name string, section config.Section, key config.Key, specifier string) (string, bool) {
+	value, ok, err := configuration.NamedSectionGet(name, section, key, specifier)
+	if err != nil {
+		log.Fatal(fmt.Sprintf("Could not read the configuration of %s: ", name), err)
+	}
+	return value, ok
+}
+
+// applyChanges changes the configuration file, a file which can't be written stops GRM
+func applyChanges(applyFunction func(mutator config.Mutator)) {
+	if err := configuration.ApplyChanges(applyFunction); err != nil {
+		log.Fatal(err)
+	}
+	fmt.Fprintln(os.Stderr, "Configuration written")
+}
+
+func cacheMaxSize() int64 {
+	if s, ok := sectionGet(config.Core, config.CacheMaxSize, ""); ok {
+		size, err := cache.ParseSize(s)
+		if err != nil {
+			log.Fatal(fmt.Sprintf("Could not parse %s '%s': ", config.CacheMaxSize.Name(), s), err)
+		}
+		return size
+	}
+	return cache.DefaultMaxSize
+}
+
+// readStateBackend selects where the state is kept, the GRM_STATE_BACKEND environment variable
+// takes precedence over the global state-backend property, default: the state.json file
+func readStateBackend() state.Backend {
+	location := os.Getenv("GRM_STATE_BACKEND")
+	if location == "" {
+		location, _ = sectionGet(config.Core, config.StateBackend, "")
+	}
+	if location == "" {
+		return state.NewFileBackend(grmPath("state.json"))
+	}
+
+	backend, err := state.ParseBackend(location)
+	if err != nil {
+		log.Fatal(fmt.Sprintf("Could not configure %s '%s': ", config.StateBackend.Name(), location), err)
+	}
+	return backend
+}
+
+// saveState writes the state store back if it was opened
+func saveState() {
+	stateMutex.Lock()
+	defer stateMutex.Unlock()
+	if stateStore == nil {
+		return
+	}
+	if err := stateStore.Save(); err != nil {
+		log.Fatal(fmt.Sprintf("Could not write state to '%s': ", stateStore.Backend()), err)
+	}
+}
+
 func generateMachineKey() []byte {
 	machineId, err := machineid.ID()
 	if err != nil {
@@ -185,16 +336,52 @@ func decrypt(value, salt string, key []byte) string {
 	return string(decrypted)
 }
 
+// rateLimit waits for the reset of an exhausted rate limit and tells to retry the request. Clients of
+// newGithubClient already hold back further requests of the credential until then.
 func rateLimit(response *github.Response) bool {
-	if response.Remaining > 0 {
+	if response == nil || response.Remaining > 0 {
+		return false
+	}
+	if response.StatusCode != http.StatusForbidden && response.StatusCode != http.StatusTooManyRequests {
 		return false
 	}
 
-	delta := time.Now().UTC().Unix() - response.Reset.Unix()
-	time.Sleep(time.Duration(delta) * time.Nanosecond)
+	time.Sleep(time.Until(response.Reset.Time))
 	return true
 }
 
 func hasMorePages(response *github.Response) bool {
 	return response.NextPage != 0
 }
+
+// readTimezone loads the time zone from the option or the global timezone property, default: UTC
+func readTimezone(tz string) *time.Location {
+	if tz == "" {
+		tz, _ = sectionGet(config.Core, config.Timezone, "")
+	}
+	if tz == "" {
+		return time.UTC
+	}
+
+	location, err := time.LoadLocation(tz)
+	if err != nil {
+		log.Fatal(fmt.Sprintf("Unknown time zone '%s': ", tz), err)
+	}
+	return location
+}
+
+// readLocale selects the report language from the option, the global lang property or English
+func readLocale(lang string) *i18n.Locale {
+	if lang == "" {
+		lang, _ = sectionGet(config.Core, config.Language, "")
+	}
+	if lang == "" {
+		return i18n.English
+	}
+
+	l, ok := i18n.Lookup(lang)
+	if !ok {
+		log.Fatal(fmt.Sprintf("Unsupported language '%s', supported languages: %s", lang, strings.Join(i18n.Tags(), ", ")))
+	}
+	return l
+}
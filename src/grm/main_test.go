@@ -0,0 +1,27 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+	"grm/cache"
+	"grm/config"
+)
+
+func TestMain(m *testing.M) {
+	httpCache = cache.NewCache("", 0)
+	progressOutput = ioutil.Discard
+	registerSecretStores()
+	os.Exit(m.Run())
+}
+
+// useConfiguration makes the given configuration file content the configuration of the test
+func useConfiguration(t *testing.T, content string) {
+	c, err := config.ParseConfiguration([]byte(content))
+	if err != nil {
+		t.Fatal(err)
+	}
+	previous := configuration
+	configuration = c
+	t.Cleanup(func() { configuration = previous })
+}
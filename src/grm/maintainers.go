@@ -0,0 +1,224 @@
+package main
+
+import (
+	"github.com/google/go-github/github"
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"grm/config"
+	"grm/i18n"
+	"grm/state"
+)
+
+// Number of contributors with the most commits compared between reports
+const maintainerTopCommitters = 10
+
+// maintainerRecord is the set of admins and top committers of a repository seen by the last report,
+// admins are unknown without push access to the repository
+type maintainerRecord struct {
+	Admins     []string  `json:"admins,omitempty"`
+	Committers []string  `json:"committers"`
+	Checked    time.Time `json:"checked"`
+}
+
+// maintainerChange is a significant change of the admins and top committers of a repository
+type maintainerChange struct {
+	repository string
+	added      []string
+	removed    []string
+	admins     []string
+	detected   time.Time
+}
+
+func (c maintainerChange) message(l *i18n.Locale) string {
+	parts := make([]string, 0, 3)
+	if len(c.admins) > 0 {
+		parts = append(parts, l.T("new admins %s", strings.Join(c.admins, ", ")))
+	}
+	if len(c.added) > 0 {
+		parts = append(parts, l.T("added %s", strings.Join(c.added, ", ")))
+	}
+	if len(c.removed) > 0 {
+		parts = append(parts, l.T("removed %s", strings.Join(c.removed, ", ")))
+	}
+	return l.T("maintainers changed: %s", strings.Join(parts, "; "))
+}
+
+func maintainersKey(name, repository string) string {
+	return state.Key("maintainers", name, repository)
+}
+
+// parseMaintainerThreshold parses the maintainer-alerts property, the percentage of the previous
+// admins and top committers which have to be replaced, like 30%
+func parseMaintainerThreshold(value string) (int, error) {
+	threshold, err := strconv.Atoi(strings.TrimSuffix(strings.TrimSpace(value), "%"))
+	if err != nil || threshold < 1 || threshold > 100 {
+		return 0, fmt.Errorf("invalid threshold '%s', expected a percentage between 1%% and 100%%", value)
+	}
+	return threshold, nil
+}
+
+// detectMaintainerChanges compares the admins and top committers of the repositories with the
+// maintainer-alerts property to those of the last report. The first report only records them.
+func detectMaintainerChanges(ctx context.Context, name, account string, repositories []*github.Repository, client *github.Client) []maintainerChange {
+	changes := make([]maintainerChange, 0)
+	for _, repo := range repositories {
+		value, ok := namedSectionGet(name, config.Remote, config.MaintainerAlerts, repo.GetName())
+		if !ok || value == "" {
+			continue
+		}
+		threshold, err := parseMaintainerThreshold(value)
+		if err != nil {
+			log.Fatal(fmt.Sprintf("Could not parse %s '%s': ", config.MaintainerAlerts.Name(), value), err)
+		}
+
+		current := maintainerRecord{
+			Admins:     readAdmins(ctx, account, repo.GetName(), client),
+			Committers: readTopCommitters(ctx, account, repo.GetName(), client),
+			Checked:    time.Now().UTC(),
+		}
+		previous := maintainerRecord{}
+		known := openState().Get(maintainersKey(name, repo.GetName()), &previous)
+		openState().Set(maintainersKey(name, repo.GetName()), current)
+		if !known {
+			continue
+		}
+
+		if change, ok := compareMaintainers(previous, current, threshold); ok {
+			change.repository = repo.GetName()
+			changes = append(changes, change)
+		}
+	}
+	return changes
+}
+
+// compareMaintainers reports a change if at least threshold percent of the previous admins and top
+// committers were replaced. New admins are always reported, they can publish releases on their own.
+func compareMaintainers(previous, current maintainerRecord, threshold int) (maintainerChange, bool) {
+	// Admins are only compared if both reports could read them
+	before, after := previous.Committers, current.Committers
+	change := maintainerChange{detected: current.Checked}
+	if previous.Admins != nil && current.Admins != nil {
+		before = union(previous.Admins, previous.Committers)
+		after = union(current.Admins, current.Committers)
+		change.admins = difference(current.Admins, previous.Admins)
+	}
+	change.added = difference(difference(after, before), change.admins)
+	change.removed = difference(before, after)
+
+	replaced := len(change.added) + len(change.admins)
+	if len(change.removed) > replaced {
+		replaced = len(change.removed)
+	}
+	if len(before) == 0 {
+		return change, len(change.admins) > 0 || replaced > 0
+	}
+	return change, len(change.admins) > 0 || replaced*100 >= threshold*len(before)
+}
+
+func union(a, b []string) []string {
+	return difference(append(append([]string{}, a...), b...), nil)
+}
+
+// difference returns the sorted distinct values of a missing in b
+func difference(a, b []string) []string {
+	excluded := make(map[string]bool, len(b))
+	for _, value := range b {
+		excluded[value] = true
+	}
+	result := make([]string, 0)
+	for _, value := range a {
+		if !excluded[value] {
+			result = append(result, value)
+			excluded[value] = true
+		}
+	}
+	sort.Strings(result)
+	return result
+}
+
+// readAdmins returns the collaborators with admin permission, or nil if the account may not list
+// the collaborators, which requires push access
+func readAdmins(ctx context.Context, account, repository string, client *github.Client) []string {
+	admins := make([]string, 0)
+
+	page := 1
+	for {
+		users, response, err := client.Repositories.ListCollaborators(ctx, account, repository, &github.ListCollaboratorsOptions{
+			ListOptions: github.ListOptions{
+				PerPage: 100,
+				Page:    page,
+			},
+		})
+
+		if rateLimit(response) {
+			continue
+		}
+
+		if response != nil && (response.StatusCode == http.StatusForbidden || response.StatusCode == http.StatusNotFound) {
+			return nil
+		}
+
+		if err != nil {
+			log.Fatal(fmt.Sprintf("Could not retrieve collaborators for repository %s: ", repository), err)
+		}
+
+		for _, user := range users {
+			if user.GetPermissions()["admin"] {
+				admins = append(admins, user.GetLogin())
+			}
+		}
+
+		if hasMorePages(response) {
+			page++
+			continue
+		}
+
+		sort.Strings(admins)
+		return admins
+	}
+}
+
+// readTopCommitters returns the contributors with the most commits
+func readTopCommitters(ctx context.Context, account, repository string, client *github.Client) []string {
+	for {
+		contributors, response, err := client.Repositories.ListContributors(ctx, account, repository, &github.ListContributorsOptions{
+			ListOptions: github.ListOptions{
+				PerPage: maintainerTopCommitters,
+			},
+		})
+
+		if rateLimit(response) {
+			continue
+		}
+
+		if err != nil {
+			log.Fatal(fmt.Sprintf("Could not retrieve contributors for repository %s: ", repository), err)
+		}
+
+		committers := make([]string, 0, len(contributors))
+		for _, contributor := range contributors {
+			committers = append(committers, contributor.GetLogin())
+		}
+		sort.Strings(committers)
+		return committers
+	}
+}
+
+func printMaintainerChanges(writer io.Writer, changes []maintainerChange) {
+	if len(changes) == 0 {
+		return
+	}
+
+	fmt.Fprintln(writer, colorize(writer, locale.T("Maintainer changes:"), ansiBoldRed))
+	for _, change := range changes {
+		fmt.Fprintln(writer, fmt.Sprintf(" * %s: %s", change.repository, change.message(locale)))
+	}
+	fmt.Fprintln(writer, "")
+}
@@ -0,0 +1,147 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+	"grm/config"
+	"grm/state"
+)
+
+const (
+	ansiGreen  = "\x1b[32m"
+	ansiYellow = "\x1b[33m"
+)
+
+// Drifts besides the semver bumps patch, minor and major
+const (
+	driftNone    = "up-to-date"
+	driftUnknown = "unknown"
+)
+
+// matrixRow compares the versions of a repository, empty versions are unknown
+type matrixRow struct {
+	Remote     string `json:"remote"`
+	Repository string `json:"repository"`
+	Pinned     string `json:"pinned,omitempty"`
+	Installed  string `json:"installed,omitempty"`
+	Latest     string `json:"latest"`
+	Drift      string `json:"drift"`
+}
+
+// readInstalledVersion returns the version of the most recent release installed by 'grm download --install'
+func readInstalledVersion(name, repository string) string {
+	installed := downloadRecord{}
+	for _, key := range openState().Keys(state.Key("download", name, repository) + "/") {
+		record := downloadRecord{}
+		if openState().Get(key, &record) && record.Installed != "" && record.Downloaded.After(installed.Downloaded) {
+			installed = record
+		}
+	}
+	if installed.Tag == "" {
+		return ""
+	}
+	return extractVersion(name, repository, installed.Tag)
+}
+
+// buildMatrix compares the pinned and installed versions of every repository of the report with its
+// latest release
+func buildMatrix(report *reportModel) []matrixRow {
+	rows := make([]matrixRow, 0)
+	for _, rep := range report.repositories {
+		latest := rep.latestRelease()
+		if latest == nil {
+			continue
+		}
+		pinned, _ := namedSectionGet(report.name, config.Remote, config.PinnedVersion, rep.stateName())
+		row := matrixRow{
+			Remote:     report.name,
+			Repository: rep.name,
+			Pinned:     pinned,
+			Installed:  readInstalledVersion(report.name, rep.stateName()),
+			Latest:     extractVersion(report.name, rep.stateName(), latest.name),
+		}
+		row.Drift = maxDrift(drift(row.Pinned, row.Latest), drift(row.Installed, row.Latest))
+		rows = append(rows, row)
+	}
+	return rows
+}
+
+// drift classifies how far the version is behind the latest version: up-to-date, patch, minor or major
+func drift(version, latest string) string {
+	if version == "" {
+		return ""
+	}
+	current, ok := parseSemver(version)
+	newest, okl := parseSemver(latest)
+	if !ok || !okl {
+		if strings.TrimPrefix(version, "v") == strings.TrimPrefix(latest, "v") {
+			return driftNone
+		}
+		return driftUnknown
+	}
+	if !current.less(newest) {
+		return driftNone
+	}
+	return newest.bump(current)
+}
+
+// maxDrift returns the larger drift, versions which aren't known don't count
+func maxDrift(a, b string) string {
+	rank := func(d string) int {
+		switch d {
+		case "":
+			return -2
+		case driftNone:
+			return -1
+		case driftUnknown:
+			return len(severities)
+		}
+		return severityRank(d)
+	}
+	if rank(a) >= rank(b) {
+		return a
+	}
+	return b
+}
+
+var driftColors = map[string]string{
+	driftNone:    ansiGreen,
+	"patch":      ansiGreen,
+	"minor":      ansiYellow,
+	"major":      ansiBoldRed,
+	driftUnknown: ansiBold,
+}
+
+func formatMatrixText(writer io.Writer, rows []matrixRow, remotes bool) error {
+	table := tabwriter.NewWriter(writer, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(table, "Repository\tPinned\tInstalled\tLatest\tDrift")
+	for _, row := range rows {
+		name := row.Repository
+		if remotes {
+			name = row.Remote + "/" + row.Repository
+		}
+		indicator := orDash(row.Drift)
+		if code, ok := driftColors[row.Drift]; ok {
+			indicator = colorize(writer, indicator, code)
+		}
+		fmt.Fprintln(table, fmt.Sprintf("%s\t%s\t%s\t%s\t%s", name, orDash(row.Pinned), orDash(row.Installed),
+			row.Latest, indicator))
+	}
+	return table.Flush()
+}
+
+func formatMatrixJson(writer io.Writer, rows []matrixRow) error {
+	encoder := json.NewEncoder(writer)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(rows)
+}
+
+func orDash(value string) string {
+	if value == "" {
+		return "-"
+	}
+	return value
+}
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+	"grm/config"
+)
+
+// Maven Central repository and search API, variables to point tests at other servers
+var (
+	mavenCentralUrl = "https://repo1.maven.org/maven2"
+	mavenSearchUrl  = "https://search.maven.org/solrsearch/select"
+)
+
+// Number of versions per page of the search API, its maximum
+const mavenSearchRows = 200
+
+// mavenSearch is a page of the artifact versions found by the search API
+type mavenSearch struct {
+	Response struct {
+		NumFound int `json:"numFound"`
+		Docs     []struct {
+			Version   string `json:"v"`
+			Timestamp int64  `json:"timestamp"`
+		} `json:"docs"`
+	} `json:"response"`
+}
+
+// buildMavenReport reads the versions of the Maven Central artifacts listed as group:artifact by the
+// projects property. Versions stand in for tags, the jar of a version is its download.
+func buildMavenReport(name, repositoryPattern string, since time.Time) *reportModel {
+	client := newProviderClient(name)
+	repos := readListedRepositories(name, config.Projects, repositoryPattern)
+	return buildProviderReport(name, "", repos, since, func(coordinates string) []providerRelease {
+		return readMavenVersions(client, coordinates)
+	})
+}
+
+func readMavenVersions(client *http.Client, coordinates string) []providerRelease {
+	parts := strings.Split(coordinates, ":")
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		log.Fatal(fmt.Sprintf("Invalid Maven coordinates '%s', expected group:artifact", coordinates))
+	}
+	group, artifact := parts[0], parts[1]
+	artifactUrl := fmt.Sprintf("%s/%s/%s", mavenCentralUrl, strings.Replace(group, ".", "/", -1), artifact)
+
+	tags := make([]providerRelease, 0)
+	for start := 0; ; start += mavenSearchRows {
+		query := url.Values{}
+		query.Set("q", fmt.Sprintf("g:\"%s\" AND a:\"%s\"", group, artifact))
+		query.Set("core", "gav")
+		query.Set("rows", fmt.Sprint(mavenSearchRows))
+		query.Set("start", fmt.Sprint(start))
+		query.Set("wt", "json")
+		request, err := http.NewRequest("GET", mavenSearchUrl+"?"+query.Encode(), nil)
+		if err != nil {
+			log.Fatal(fmt.Sprintf("Could not create search request for %s: ", coordinates), err)
+		}
+
+		search := mavenSearch{}
+		if err := json.Unmarshal(readProvider(client, request), &search); err != nil {
+			log.Fatal(fmt.Sprintf("Could not parse versions of %s: ", coordinates), err)
+		}
+		for _, doc := range search.Response.Docs {
+			tags = append(tags, providerRelease{
+				version:     doc.Version,
+				created:     time.Unix(0, doc.Timestamp*int64(time.Millisecond)).UTC(),
+				notesUrl:    fmt.Sprintf("https://central.sonatype.com/artifact/%s/%s/%s", group, artifact, doc.Version),
+				downloadUrl: fmt.Sprintf("%s/%s/%s-%s.jar", artifactUrl, doc.Version, artifact, doc.Version),
+			})
+		}
+
+		if len(search.Response.Docs) == 0 || start+mavenSearchRows >= search.Response.NumFound {
+			return tags
+		}
+	}
+}
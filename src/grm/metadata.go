@@ -0,0 +1,90 @@
+package main
+
+import (
+	"github.com/google/go-github/github"
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+	"grm/state"
+)
+
+// Metadata is fetched again once the cached copy in the state store is older
+const metadataMaxAge = 24 * time.Hour
+
+// repositoryMetadata are the Github repository details shown by reports with --with-metadata
+type repositoryMetadata struct {
+	Description string   `json:"description,omitempty"`
+	Stars       int      `json:"stars"`
+	OpenIssues  int      `json:"open_issues"`
+	License     string   `json:"license,omitempty"`
+	Topics      []string `json:"topics,omitempty"`
+}
+
+// cachedMetadata is the state store record of the metadata with the time it was fetched
+type cachedMetadata struct {
+	repositoryMetadata
+	Fetched time.Time `json:"fetched"`
+}
+
+func metadataKey(name, repository string) string {
+	return state.Key("metadata", name, repository)
+}
+
+// enrichRepositories attaches the metadata to the reported repositories, recently fetched metadata
+// is taken from the state store
+func enrichRepositories(ctx context.Context, report *reportModel, client *github.Client) {
+	for _, rep := range report.repositories {
+		rep.metadata = readMetadata(ctx, report.name, report.account, rep.name, client)
+	}
+}
+
+func readMetadata(ctx context.Context, name, account, repository string, client *github.Client) *repositoryMetadata {
+	cached := cachedMetadata{}
+	if openState().Get(metadataKey(name, repository), &cached) && time.Since(cached.Fetched) < metadataMaxAge {
+		return &cached.repositoryMetadata
+	}
+
+	for {
+		repo, response, err := client.Repositories.Get(ctx, account, repository)
+		if rateLimit(response) {
+			continue
+		}
+
+		if err != nil {
+			log.Fatal(fmt.Sprintf("Could not retrieve metadata for repository %s: ", repository), err)
+		}
+
+		metadata := repositoryMetadata{
+			Description: repo.GetDescription(),
+			Stars:       repo.GetStargazersCount(),
+			OpenIssues:  repo.GetOpenIssuesCount(),
+			License:     repo.GetLicense().GetSPDXID(),
+			Topics:      repo.Topics,
+		}
+		openState().Set(metadataKey(name, repository), cachedMetadata{metadata, time.Now().UTC()})
+		return &metadata
+	}
+}
+
+// text formats the metadata for the text report, the description followed by the counts
+func (m *repositoryMetadata) text() string {
+	if m.Description == "" {
+		return m.counts()
+	}
+	return fmt.Sprintf("%s (%s)", m.Description, m.counts())
+}
+
+// counts formats stars, open issues, license and topics
+func (m *repositoryMetadata) counts() string {
+	parts := []string{locale.T("%s stars", locale.Number(m.Stars)),
+		locale.T("%s open issues", locale.Number(m.OpenIssues))}
+	if m.License != "" {
+		parts = append(parts, m.License)
+	}
+	if len(m.Topics) > 0 {
+		parts = append(parts, locale.T("topics: %s", strings.Join(m.Topics, ", ")))
+	}
+	return strings.Join(parts, ", ")
+}
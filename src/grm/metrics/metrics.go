@@ -0,0 +1,218 @@
+package metrics
+
+import (
+	"io"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"bytes"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+const (
+	Counter = "counter"
+	Gauge   = "gauge"
+)
+
+// Registry collects counters and gauges of a single grm run. Metrics are identified by their name
+// and labels and can be written in the Prometheus text format or pushed to statsd.
+type Registry struct {
+	mutex       sync.Mutex
+	definitions map[string]definition
+	samples     map[string]*sample
+}
+
+type definition struct {
+	kind string
+	help string
+}
+
+type sample struct {
+	name   string
+	labels map[string]string
+	value  float64
+}
+
+func NewRegistry() *Registry {
+	return &Registry{
+		definitions: make(map[string]definition),
+		samples:     make(map[string]*sample),
+	}
+}
+
+// Define registers the type and help text of a metric
+func (r *Registry) Define(name, kind, help string) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.definitions[name] = definition{kind, help}
+}
+
+// Add increments the metric with the given labels by delta
+func (r *Registry) Add(name string, labels map[string]string, delta float64) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.sample(name, labels).value += delta
+}
+
+// Set sets the metric with the given labels to value
+func (r *Registry) Set(name string, labels map[string]string, value float64) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.sample(name, labels).value = value
+}
+
+// Value returns the current value of the metric with the given labels
+func (r *Registry) Value(name string, labels map[string]string) float64 {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	if s, ok := r.samples[sampleKey(name, labels)]; ok {
+		return s.value
+	}
+	return 0
+}
+
+func (r *Registry) sample(name string, labels map[string]string) *sample {
+	key := sampleKey(name, labels)
+	s, ok := r.samples[key]
+	if !ok {
+		s = &sample{name: name, labels: labels}
+		r.samples[key] = s
+	}
+	return s
+}
+
+func (r *Registry) sorted() []*sample {
+	samples := make([]*sample, 0, len(r.samples))
+	for _, s := range r.samples {
+		samples = append(samples, s)
+	}
+	sort.Slice(samples, func(i, j int) bool {
+		return sampleKey(samples[i].name, samples[i].labels) < sampleKey(samples[j].name, samples[j].labels)
+	})
+	return samples
+}
+
+// WriteText writes all metrics in the Prometheus text exposition format
+func (r *Registry) WriteText(writer io.Writer) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	var buffer bytes.Buffer
+	described := make(map[string]bool)
+	for _, s := range r.sorted() {
+		if !described[s.name] {
+			if d, ok := r.definitions[s.name]; ok {
+				buffer.WriteString(fmt.Sprintf("# HELP %s %s\n", s.name, d.help))
+				buffer.WriteString(fmt.Sprintf("# TYPE %s %s\n", s.name, d.kind))
+			}
+			described[s.name] = true
+		}
+		buffer.WriteString(fmt.Sprintf("%s %s\n", sampleKey(s.name, s.labels), strconv.FormatFloat(s.value, 'g', -1, 64)))
+	}
+	_, err := writer.Write(buffer.Bytes())
+	return err
+}
+
+// PushGateway pushes all metrics to a Prometheus Pushgateway, replacing the metrics of the same grouping
+func (r *Registry) PushGateway(gateway, job string, grouping map[string]string) error {
+	target := strings.TrimSuffix(gateway, "/") + "/metrics/job/" + url.PathEscape(job)
+	keys := sortedKeys(grouping)
+	for _, key := range keys {
+		target += "/" + url.PathEscape(key) + "/" + url.PathEscape(grouping[key])
+	}
+
+	var body bytes.Buffer
+	if err := r.WriteText(&body); err != nil {
+		return err
+	}
+
+	request, err := http.NewRequest("PUT", target, &body)
+	if err != nil {
+		return err
+	}
+	request.Header.Set("Content-Type", "text/plain; version=0.0.4")
+
+	client := &http.Client{Timeout: 30 * time.Second}
+	response, err := client.Do(request)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode/100 != 2 {
+		return fmt.Errorf("unexpected response: %s", response.Status)
+	}
+	return nil
+}
+
+// Statsd sends all metrics as statsd gauges over UDP. Labels are appended to the metric name,
+// e.g. grm_new_releases{remote="work"} becomes grm_new_releases.work
+func (r *Registry) Statsd(address string) error {
+	connection, err := net.Dial("udp", address)
+	if err != nil {
+		return err
+	}
+	defer connection.Close()
+
+	r.mutex.Lock()
+	lines := make([]string, 0, len(r.samples))
+	for _, s := range r.sorted() {
+		name := s.name
+		for _, key := range sortedKeys(s.labels) {
+			name += "." + statsdEscape(s.labels[key])
+		}
+		lines = append(lines, fmt.Sprintf("%s:%s|g", name, strconv.FormatFloat(s.value, 'f', -1, 64)))
+	}
+	r.mutex.Unlock()
+
+	// Keep packets below the common MTU
+	packet := ""
+	for _, line := range lines {
+		if packet != "" && len(packet)+len(line)+1 > 1400 {
+			if _, err := connection.Write([]byte(packet)); err != nil {
+				return err
+			}
+			packet = ""
+		}
+		if packet != "" {
+			packet += "\n"
+		}
+		packet += line
+	}
+	if packet != "" {
+		_, err = connection.Write([]byte(packet))
+	}
+	return err
+}
+
+func sampleKey(name string, labels map[string]string) string {
+	if len(labels) == 0 {
+		return name
+	}
+	pairs := make([]string, 0, len(labels))
+	for _, key := range sortedKeys(labels) {
+		value := strings.Replace(labels[key], "\\", "\\\\", -1)
+		value = strings.Replace(value, "\"", "\\\"", -1)
+		value = strings.Replace(value, "\n", "\\n", -1)
+		pairs = append(pairs, fmt.Sprintf("%s=\"%s\"", key, value))
+	}
+	return fmt.Sprintf("%s{%s}", name, strings.Join(pairs, ","))
+}
+
+func sortedKeys(values map[string]string) []string {
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func statsdEscape(value string) string {
+	return strings.NewReplacer(".", "_", ":", "_", "|", "_", "@", "_", " ", "_").Replace(value)
+}
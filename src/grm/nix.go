@@ -0,0 +1,139 @@
+package main
+
+import (
+	"os"
+	"fmt"
+	"log"
+	"regexp"
+	"strings"
+	"io/ioutil"
+	"path/filepath"
+	"encoding/hex"
+	"encoding/base64"
+)
+
+var (
+	nixPackagePatterns = []*regexp.Regexp{
+		regexp.MustCompile(`\bpname\s*=\s*"([^"]+)"`),
+		regexp.MustCompile(`\brepo\s*=\s*"([^"]+)"`),
+	}
+	nixVersionPattern = regexp.MustCompile(`(\bversion\s*=\s*")[^"]*(")`)
+	nixHashPattern    = regexp.MustCompile(`(\bhash\s*=\s*")sha256-[^"]*(")`)
+	nixSha256Pattern  = regexp.MustCompile(`(\bsha256\s*=\s*")[^"]*(")`)
+	nixUrlPattern     = regexp.MustCompile(`\burl\s*=\s*"([^"]+)"`)
+
+	// Fetchers whose hash covers the unpacked source tree instead of the downloaded file
+	nixUnpackingFetchers = regexp.MustCompile(`\b(fetchFromGitHub|fetchFromGitLab|fetchFromSourcehut|fetchzip|fetchgit)\b`)
+)
+
+// updateNixExpressions updates the version and hash of every nix expression in the directory packaging
+// one of the reported repositories. The hash is calculated from the file the url of a fetchurl style
+// source points to for the new version, the hash of fetchFromGitHub sources covers the unpacked tree
+// and has to be updated by nix itself.
+func updateNixExpressions(directory string, report *reportModel) {
+	inventory, _ := buildInventory(report)
+
+	err := filepath.Walk(directory, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() || !strings.HasSuffix(path, ".nix") {
+			return nil
+		}
+
+		data, err := ioutil.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		expression := string(data)
+
+		entry, ok := inventory[nixPackageName(path, expression)]
+		if !ok {
+			return nil
+		}
+
+		updated := replaceFirst(nixVersionPattern, expression, entry.Version)
+		if updated == expression {
+			return nil
+		}
+
+		source := nixSourceUrl(expression, entry.Version)
+		if source == "" {
+			fmt.Fprintln(progressOutput, fmt.Sprintf("Updated version of %s to %s, the hash must be updated manually", path, entry.Version))
+			return ioutil.WriteFile(path, []byte(updated), info.Mode())
+		}
+
+		fmt.Fprintln(progressOutput, fmt.Sprintf("Calculating checksum of %s...", source))
+		sha256, err := checksumUrl(source)
+		if err != nil {
+			fmt.Fprintln(progressOutput, fmt.Sprintf("Could not calculate checksum of %s, %s is unchanged: %s", source, path, err))
+			return nil
+		}
+		checksum, err := hex.DecodeString(sha256)
+		if err != nil {
+			return err
+		}
+		updated = replaceFirst(nixHashPattern, updated, "sha256-"+base64.StdEncoding.EncodeToString(checksum))
+		updated = replaceFirst(nixSha256Pattern, updated, sha256)
+		fmt.Fprintln(progressOutput, fmt.Sprintf("Updated %s to %s", path, entry.Version))
+
+		return ioutil.WriteFile(path, []byte(updated), info.Mode())
+	})
+
+	if err != nil {
+		log.Fatal(fmt.Sprintf("Could not update nix expressions in '%s': ", directory), err)
+	}
+}
+
+// nixSourceUrl returns the url the fetchurl source of the expression downloads for the version, nothing
+// if the hash doesn't cover the downloaded file or the url needs nix to be evaluated
+func nixSourceUrl(expression, version string) string {
+	if nixUnpackingFetchers.MatchString(expression) {
+		return ""
+	}
+	// A url without the version still points to the old release
+	substrings := nixUrlPattern.FindStringSubmatch(expression)
+	if len(substrings) < 2 || !strings.Contains(substrings[1], "version}") {
+		return ""
+	}
+
+	pname := ""
+	if p := nixPackagePatterns[0].FindStringSubmatch(expression); len(p) > 1 {
+		pname = p[1]
+	}
+	url := substrings[1]
+	for _, attribute := range []string{"", "finalAttrs."} {
+		url = strings.Replace(url, "${"+attribute+"version}", version, -1)
+		if pname != "" {
+			url = strings.Replace(url, "${"+attribute+"pname}", pname, -1)
+		}
+	}
+	if strings.Contains(url, "${") {
+		return ""
+	}
+	return url
+}
+
+// nixPackageName detects the packaged repository by the pname or repo attribute, falling back to
+// the file name, or the directory name for default.nix files
+func nixPackageName(path, expression string) string {
+	for _, pattern := range nixPackagePatterns {
+		if substrings := pattern.FindStringSubmatch(expression); len(substrings) > 1 {
+			return substrings[1]
+		}
+	}
+
+	if filepath.Base(path) == "default.nix" {
+		return filepath.Base(filepath.Dir(path))
+	}
+	return strings.TrimSuffix(filepath.Base(path), ".nix")
+}
+
+// replaceFirst replaces the quoted value of the first attribute matched by the pattern
+func replaceFirst(pattern *regexp.Regexp, expression, value string) string {
+	match := pattern.FindStringSubmatchIndex(expression)
+	if match == nil {
+		return expression
+	}
+	return expression[:match[3]] + value + expression[match[4]:]
+}
@@ -0,0 +1,95 @@
+package notify
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// attachmentPublisher posts Slack compatible attachments to incoming webhooks, as understood by
+// Mattermost and Rocket.Chat. The webhook path is the topic.
+type attachmentPublisher struct {
+	name     string
+	server   string
+	channel  string
+	username string
+}
+
+type attachmentField struct {
+	Title string `json:"title"`
+	Value string `json:"value"`
+	Short bool   `json:"short"`
+}
+
+type attachment struct {
+	Fallback  string            `json:"fallback"`
+	Color     string            `json:"color,omitempty"`
+	Title     string            `json:"title"`
+	TitleLink string            `json:"title_link,omitempty"`
+	Fields    []attachmentField `json:"fields,omitempty"`
+}
+
+func newAttachmentPublisher(name string, u *url.URL) *attachmentPublisher {
+	return &attachmentPublisher{
+		name:     name,
+		server:   "https://" + u.Host,
+		channel:  u.Query().Get("channel"),
+		username: u.Query().Get("username"),
+	}
+}
+
+// severityColors colors attachments by the severity of the release, warnings have severity warning
+var severityColors = map[string]string{
+	"patch":    "#2eb886",
+	"minor":    "#439fe0",
+	"major":    "#daa038",
+	"breaking": "#a30200",
+	"warning":  "#e01e5a",
+}
+
+func (a *attachmentPublisher) Publish(hook string, message Message) error {
+	fields := make([]attachmentField, 0, len(message.Fields))
+	for _, field := range message.Fields {
+		fields = append(fields, attachmentField{field.Name, field.Value, len(field.Value) < 40})
+	}
+
+	payload := map[string]interface{}{
+		"text": message.Text,
+		"attachments": []attachment{{
+			Fallback:  message.Text,
+			Color:     severityColors[message.Values["severity"]],
+			Title:     message.Title,
+			TitleLink: message.Url,
+			Fields:    fields,
+		}},
+	}
+	if message.Body != "" {
+		payload = map[string]interface{}{"text": message.Body}
+	}
+	if a.channel != "" {
+		payload["channel"] = a.channel
+	}
+	if a.username != "" {
+		payload["username"] = a.username
+	}
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	response, err := HttpClient.Post(a.server+"/"+hook, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode != http.StatusOK {
+		data, _ := ioutil.ReadAll(response.Body)
+		return fmt.Errorf("%s responded with %s: %s", a.name, response.Status, strings.TrimSpace(string(data)))
+	}
+	return nil
+}
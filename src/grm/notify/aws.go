@@ -0,0 +1,73 @@
+package notify
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"grm/cloud"
+)
+
+// awsPublisher publishes to SNS topics or SQS queues of an AWS account through their query APIs
+type awsPublisher struct {
+	service string
+	account string
+	region  string
+}
+
+func newAwsPublisher(service string, u *url.URL) *awsPublisher {
+	return &awsPublisher{service, u.Host, u.Query().Get("region")}
+}
+
+func (a *awsPublisher) Publish(topic string, message Message) error {
+	payload := message.Payload
+	credentials, err := cloud.ReadAwsCredentials()
+	if err != nil {
+		return err
+	}
+	if a.region != "" {
+		credentials.Region = a.region
+	}
+
+	endpoint := fmt.Sprintf("https://%s.%s.amazonaws.com/", a.service, credentials.Region)
+	form := url.Values{}
+	if a.service == "sns" {
+		form.Set("Action", "Publish")
+		form.Set("Version", "2010-03-31")
+		form.Set("TopicArn", fmt.Sprintf("arn:aws:sns:%s:%s:%s", credentials.Region, a.account, topic))
+		form.Set("Message", string(payload))
+	} else {
+		form.Set("Action", "SendMessage")
+		form.Set("Version", "2012-11-05")
+		form.Set("QueueUrl", fmt.Sprintf("https://sqs.%s.amazonaws.com/%s/%s", credentials.Region, a.account, topic))
+		form.Set("MessageBody", string(payload))
+	}
+	body := []byte(form.Encode())
+
+	req, err := http.NewRequest(http.MethodPost, endpoint, strings.NewReader(string(body)))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded; charset=utf-8")
+	credentials.SignV4(req, body, a.service)
+
+	response, err := HttpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode != http.StatusOK {
+		data, _ := ioutil.ReadAll(response.Body)
+		failure := struct {
+			Message string `xml:"Error>Message"`
+		}{}
+		if xml.Unmarshal(data, &failure) == nil && failure.Message != "" {
+			return fmt.Errorf("%s: %s", strings.ToUpper(a.service), failure.Message)
+		}
+		return fmt.Errorf("%s responded with %s", strings.ToUpper(a.service), response.Status)
+	}
+	return nil
+}
@@ -0,0 +1,84 @@
+package notify
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// Breaking releases are pushed with at least this priority, which makes Gotify clients alert loudly
+const gotifyBreakingPriority = 8
+
+// gotifyPublisher pushes messages to a Gotify server with an application token, the path is the
+// message endpoint, usually /message
+type gotifyPublisher struct {
+	server   string
+	token    string
+	priority int
+}
+
+func newGotifyPublisher(u *url.URL) *gotifyPublisher {
+	publisher := &gotifyPublisher{server: "https://" + u.Host, priority: 5}
+	if u.Scheme == "gotify+http" {
+		publisher.server = "http://" + u.Host
+	}
+	if u.User != nil {
+		publisher.token, _ = u.User.Password()
+	}
+	if priority, err := strconv.Atoi(u.Query().Get("priority")); err == nil {
+		publisher.priority = priority
+	}
+	return publisher
+}
+
+func (g *gotifyPublisher) Publish(path string, message Message) error {
+	text := fmt.Sprintf("[%s](%s)", message.Text, message.Url)
+	for _, field := range message.Fields {
+		text += fmt.Sprintf("  \n%s: %s", field.Name, field.Value)
+	}
+	if message.Body != "" {
+		text = message.Body
+	}
+
+	priority := g.priority
+	if message.Values["severity"] == "breaking" && priority < gotifyBreakingPriority {
+		priority = gotifyBreakingPriority
+	}
+
+	body, err := json.Marshal(map[string]interface{}{
+		"title":    message.Title,
+		"message":  text,
+		"priority": priority,
+		"extras": map[string]interface{}{
+			"client::display":      map[string]string{"contentType": "text/markdown"},
+			"client::notification": map[string]interface{}{"click": map[string]string{"url": message.Url}},
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest(http.MethodPost, g.server+"/"+path, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Gotify-Key", g.token)
+
+	response, err := HttpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode != http.StatusOK {
+		data, _ := ioutil.ReadAll(response.Body)
+		return fmt.Errorf("Gotify responded with %s: %s", response.Status, strings.TrimSpace(string(data)))
+	}
+	return nil
+}
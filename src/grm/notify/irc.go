@@ -0,0 +1,149 @@
+package notify
+
+import (
+	"bufio"
+	"crypto/tls"
+	"encoding/base64"
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// ircPublisher joins the channel and posts a one line announcement, optionally authenticated with
+// SASL PLAIN. A connection is opened per announcement.
+type ircPublisher struct {
+	address  string
+	tls      bool
+	nick     string
+	password string
+}
+
+func newIrcPublisher(u *url.URL) *ircPublisher {
+	publisher := &ircPublisher{address: u.Host, tls: u.Scheme == "ircs", nick: "grm"}
+	if !strings.Contains(publisher.address, ":") {
+		if publisher.tls {
+			publisher.address += ":6697"
+		} else {
+			publisher.address += ":6667"
+		}
+	}
+	if u.User != nil {
+		publisher.nick = u.User.Username()
+		publisher.password, _ = u.User.Password()
+	}
+	return publisher
+}
+
+func (i *ircPublisher) Publish(channel string, message Message) error {
+	if !strings.HasPrefix(channel, "#") && !strings.HasPrefix(channel, "&") {
+		channel = "#" + channel
+	}
+
+	var (
+		conn net.Conn
+		err  error
+	)
+	dialer := &net.Dialer{Timeout: timeout}
+	if i.tls {
+		host, _, _ := net.SplitHostPort(i.address)
+		conn, err = tls.DialWithDialer(dialer, "tcp", i.address, &tls.Config{ServerName: host})
+	} else {
+		conn, err = dialer.Dial("tcp", i.address)
+	}
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	// Registration on busy networks takes a while, e.g. for ident and hostname lookups
+	conn.SetDeadline(time.Now().Add(60 * time.Second))
+
+	nick := i.nick
+	send := func(format string, args ...interface{}) error {
+		_, err := fmt.Fprintf(conn, format+"\r\n", args...)
+		return err
+	}
+
+	if i.password != "" {
+		send("CAP REQ :sasl")
+	}
+	send("NICK %s", nick)
+	if err := send("USER %s 0 * :Github Release Monitor", nick); err != nil {
+		return err
+	}
+
+	// Only the first line of the announcement, IRC messages can't span lines
+	text := strings.SplitN(message.Text, "\n", 2)[0]
+
+	reader := bufio.NewReader(conn)
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			return err
+		}
+		prefix, command, params := parseIrcLine(strings.TrimRight(line, "\r\n"))
+
+		switch command {
+		case "PING":
+			send("PONG :%s", strings.Join(params, " "))
+		case "CAP":
+			if len(params) > 1 && params[1] == "ACK" {
+				send("AUTHENTICATE PLAIN")
+			} else if len(params) > 1 && params[1] == "NAK" {
+				return fmt.Errorf("IRC server %s doesn't support SASL", i.address)
+			}
+		case "AUTHENTICATE":
+			credentials := i.nick + "\x00" + i.nick + "\x00" + i.password
+			send("AUTHENTICATE %s", base64.StdEncoding.EncodeToString([]byte(credentials)))
+		case "903":
+			send("CAP END")
+		case "902", "904", "905":
+			return fmt.Errorf("IRC SASL authentication failed: %s", strings.Join(params, " "))
+		case "433":
+			nick += "_"
+			send("NICK %s", nick)
+		case "001":
+			send("JOIN %s", channel)
+		case "JOIN":
+			if strings.HasPrefix(prefix, nick+"!") {
+				send("PRIVMSG %s :%s", channel, text)
+				send("QUIT :done")
+			}
+		case "403", "404", "471", "473", "474", "475", "477":
+			return fmt.Errorf("IRC: %s", strings.Join(params, " "))
+		case "ERROR":
+			// The server closes the link after QUIT
+			return nil
+		}
+	}
+}
+
+// parseIrcLine splits a message into prefix, command and parameters, the trailing parameter may contain spaces
+func parseIrcLine(line string) (string, string, []string) {
+	prefix := ""
+	if strings.HasPrefix(line, ":") {
+		tokens := strings.SplitN(line[1:], " ", 2)
+		prefix = tokens[0]
+		line = ""
+		if len(tokens) > 1 {
+			line = tokens[1]
+		}
+	}
+
+	trailing := ""
+	hasTrailing := false
+	if index := strings.Index(line, " :"); index >= 0 {
+		trailing, hasTrailing = line[index+2:], true
+		line = line[:index]
+	}
+
+	params := strings.Fields(line)
+	if len(params) == 0 {
+		return prefix, "", nil
+	}
+	if hasTrailing {
+		params = append(params, trailing)
+	}
+	return prefix, params[0], params[1:]
+}
@@ -0,0 +1,79 @@
+package notify
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// kafkaRestPublisher produces records through the Confluent Kafka REST proxy, v2 API
+type kafkaRestPublisher struct {
+	endpoint string
+	user     string
+	password string
+}
+
+func newKafkaRestPublisher(u *url.URL) *kafkaRestPublisher {
+	publisher := &kafkaRestPublisher{
+		endpoint: fmt.Sprintf("%s://%s", strings.TrimPrefix(u.Scheme, "kafka+"), u.Host),
+	}
+	if u.User != nil {
+		publisher.user = u.User.Username()
+		publisher.password, _ = u.User.Password()
+	}
+	return publisher
+}
+
+func (k *kafkaRestPublisher) Publish(topic string, message Message) error {
+	payload := message.Payload
+	// The REST proxy embeds JSON values, templates may render plain text
+	if !json.Valid(payload) {
+		payload, _ = json.Marshal(string(payload))
+	}
+	body, err := json.Marshal(map[string]interface{}{
+		"records": []map[string]json.RawMessage{{"value": payload}},
+	})
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest(http.MethodPost, k.endpoint+"/topics/"+url.PathEscape(topic), bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/vnd.kafka.json.v2+json")
+	req.Header.Set("Accept", "application/vnd.kafka.v2+json")
+	if k.user != "" {
+		req.SetBasicAuth(k.user, k.password)
+	}
+
+	response, err := HttpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+
+	data, _ := ioutil.ReadAll(response.Body)
+	if response.StatusCode != http.StatusOK {
+		return fmt.Errorf("Kafka REST proxy responded with %s: %s", response.Status, strings.TrimSpace(string(data)))
+	}
+
+	// Errors are reported per record
+	result := struct {
+		Offsets []struct {
+			Error string `json:"error"`
+		} `json:"offsets"`
+	}{}
+	if err := json.Unmarshal(data, &result); err == nil {
+		for _, offset := range result.Offsets {
+			if offset.Error != "" {
+				return fmt.Errorf("Kafka: %s", offset.Error)
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,72 @@
+package notify
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"html"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// matrixPublisher posts notices to a Matrix room through the client-server API of the homeserver
+type matrixPublisher struct {
+	homeserver string
+	token      string
+}
+
+func newMatrixPublisher(u *url.URL) *matrixPublisher {
+	publisher := &matrixPublisher{homeserver: "https://" + u.Host}
+	if u.Scheme == "matrix+http" {
+		publisher.homeserver = "http://" + u.Host
+	}
+	if u.User != nil {
+		publisher.token, _ = u.User.Password()
+	}
+	return publisher
+}
+
+func (m *matrixPublisher) Publish(room string, message Message) error {
+	formatted := fmt.Sprintf(`<a href="%s"><b>%s</b></a>`, html.EscapeString(message.Url), html.EscapeString(message.Title))
+	for _, field := range message.Fields {
+		formatted += fmt.Sprintf("<br>%s: %s", html.EscapeString(field.Name), html.EscapeString(field.Value))
+	}
+	content := map[string]string{
+		"msgtype":        "m.notice",
+		"body":           message.Text,
+		"format":         "org.matrix.custom.html",
+		"formatted_body": formatted,
+	}
+	if message.Body != "" {
+		content = map[string]string{"msgtype": "m.notice", "body": message.Body}
+	}
+	body, err := json.Marshal(content)
+	if err != nil {
+		return err
+	}
+
+	// The transaction id makes retries of the same request idempotent
+	endpoint := fmt.Sprintf("%s/_matrix/client/v3/rooms/%s/send/m.room.message/grm-%d", m.homeserver,
+		url.PathEscape(room), time.Now().UnixNano())
+	req, err := http.NewRequest(http.MethodPut, endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+m.token)
+
+	response, err := HttpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode != http.StatusOK {
+		data, _ := ioutil.ReadAll(response.Body)
+		return fmt.Errorf("Matrix homeserver responded with %s: %s", response.Status, strings.TrimSpace(string(data)))
+	}
+	return nil
+}
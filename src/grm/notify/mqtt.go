@@ -0,0 +1,162 @@
+package notify
+
+import (
+	"bytes"
+	"crypto/tls"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// MQTT 3.1.1 control packet types
+const (
+	mqttConnect    = 1
+	mqttConnack    = 2
+	mqttPublish    = 3
+	mqttPuback     = 4
+	mqttDisconnect = 14
+)
+
+// mqttPublisher publishes with QoS 1 using MQTT 3.1.1, a connection is opened per publish
+type mqttPublisher struct {
+	address  string
+	tls      bool
+	user     string
+	password string
+}
+
+func newMqttPublisher(u *url.URL) *mqttPublisher {
+	publisher := &mqttPublisher{address: u.Host, tls: u.Scheme == "mqtts"}
+	if !strings.Contains(publisher.address, ":") {
+		if publisher.tls {
+			publisher.address += ":8883"
+		} else {
+			publisher.address += ":1883"
+		}
+	}
+	if u.User != nil {
+		publisher.user = u.User.Username()
+		publisher.password, _ = u.User.Password()
+	}
+	return publisher
+}
+
+func (m *mqttPublisher) Publish(topic string, message Message) error {
+	payload := message.Payload
+	var (
+		conn net.Conn
+		err  error
+	)
+	dialer := &net.Dialer{Timeout: timeout}
+	if m.tls {
+		host, _, _ := net.SplitHostPort(m.address)
+		conn, err = tls.DialWithDialer(dialer, "tcp", m.address, &tls.Config{ServerName: host})
+	} else {
+		conn, err = dialer.Dial("tcp", m.address)
+	}
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(timeout))
+
+	// Variable header: protocol name, level 4, flags and a keep alive of 60 seconds
+	flags := byte(0x02) // clean session
+	if m.user != "" {
+		flags |= 0x80
+	}
+	if m.password != "" {
+		flags |= 0x40
+	}
+	connect := append(mqttString("MQTT"), 4, flags, 0, 60)
+	connect = append(connect, mqttString(fmt.Sprintf("grm-%d", time.Now().UnixNano()))...)
+	if m.user != "" {
+		connect = append(connect, mqttString(m.user)...)
+	}
+	if m.password != "" {
+		connect = append(connect, mqttString(m.password)...)
+	}
+	if err := mqttWrite(conn, mqttConnect<<4, connect); err != nil {
+		return err
+	}
+
+	packetType, body, err := mqttRead(conn)
+	if err != nil {
+		return err
+	}
+	if packetType != mqttConnack || len(body) < 2 {
+		return fmt.Errorf("unexpected MQTT packet %d instead of CONNACK", packetType)
+	}
+	if body[1] != 0 {
+		return fmt.Errorf("MQTT connection refused with code %d", body[1])
+	}
+
+	// QoS 1 with packet identifier 1, the broker acknowledges with PUBACK
+	publish := append(mqttString(topic), 0, 1)
+	publish = append(publish, payload...)
+	if err := mqttWrite(conn, mqttPublish<<4|0x02, publish); err != nil {
+		return err
+	}
+	packetType, _, err = mqttRead(conn)
+	if err != nil {
+		return err
+	}
+	if packetType != mqttPuback {
+		return fmt.Errorf("unexpected MQTT packet %d instead of PUBACK", packetType)
+	}
+
+	return mqttWrite(conn, mqttDisconnect<<4, nil)
+}
+
+func mqttString(value string) []byte {
+	return append([]byte{byte(len(value) >> 8), byte(len(value))}, value...)
+}
+
+func mqttWrite(writer io.Writer, header byte, body []byte) error {
+	packet := bytes.NewBuffer([]byte{header})
+	// Remaining length, 7 bits per byte with continuation bit
+	length := len(body)
+	for {
+		digit := byte(length % 128)
+		length /= 128
+		if length > 0 {
+			digit |= 0x80
+		}
+		packet.WriteByte(digit)
+		if length == 0 {
+			break
+		}
+	}
+	packet.Write(body)
+	_, err := writer.Write(packet.Bytes())
+	return err
+}
+
+func mqttRead(reader io.Reader) (byte, []byte, error) {
+	header := make([]byte, 1)
+	if _, err := io.ReadFull(reader, header); err != nil {
+		return 0, nil, err
+	}
+
+	length, multiplier := 0, 1
+	for {
+		digit := make([]byte, 1)
+		if _, err := io.ReadFull(reader, digit); err != nil {
+			return 0, nil, err
+		}
+		length += int(digit[0]&0x7f) * multiplier
+		if digit[0]&0x80 == 0 {
+			break
+		}
+		multiplier *= 128
+	}
+
+	body := make([]byte, length)
+	if _, err := io.ReadFull(reader, body); err != nil {
+		return 0, nil, err
+	}
+	return header[0] >> 4, body, nil
+}
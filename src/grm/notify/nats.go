@@ -0,0 +1,90 @@
+package notify
+
+import (
+	"bufio"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const timeout = 10 * time.Second
+
+// natsPublisher speaks the NATS client protocol, a connection is opened per publish since
+// release events are rare
+type natsPublisher struct {
+	address  string
+	tls      bool
+	user     string
+	password string
+}
+
+func newNatsPublisher(u *url.URL) *natsPublisher {
+	publisher := &natsPublisher{address: u.Host, tls: u.Scheme == "tls"}
+	if !strings.Contains(publisher.address, ":") {
+		publisher.address += ":4222"
+	}
+	if u.User != nil {
+		publisher.user = u.User.Username()
+		publisher.password, _ = u.User.Password()
+	}
+	return publisher
+}
+
+func (n *natsPublisher) Publish(topic string, message Message) error {
+	payload := message.Payload
+	conn, err := net.DialTimeout("tcp", n.address, timeout)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(timeout))
+
+	reader := bufio.NewReader(conn)
+	// The server greets with INFO before anything else
+	info, err := reader.ReadString('\n')
+	if err != nil {
+		return err
+	}
+	if !strings.HasPrefix(info, "INFO ") {
+		return fmt.Errorf("unexpected NATS greeting '%s'", strings.TrimSpace(info))
+	}
+
+	if n.tls {
+		host, _, _ := net.SplitHostPort(n.address)
+		secure := tls.Client(conn, &tls.Config{ServerName: host})
+		if err := secure.Handshake(); err != nil {
+			return err
+		}
+		conn = secure
+		reader = bufio.NewReader(conn)
+	}
+
+	options, _ := json.Marshal(map[string]interface{}{
+		"verbose":  true,
+		"pedantic": false,
+		"name":     "grm",
+		"lang":     "go",
+		"user":     n.user,
+		"pass":     n.password,
+	})
+	request := fmt.Sprintf("CONNECT %s\r\nPUB %s %d\r\n%s\r\n", options, topic, len(payload), payload)
+	if _, err := conn.Write([]byte(request)); err != nil {
+		return err
+	}
+
+	// Verbose mode acknowledges CONNECT and PUB with +OK each
+	for i := 0; i < 2; i++ {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			return err
+		}
+		if strings.HasPrefix(line, "-ERR") {
+			return fmt.Errorf("NATS: %s", strings.TrimSpace(strings.TrimPrefix(line, "-ERR")))
+		}
+	}
+	return nil
+}
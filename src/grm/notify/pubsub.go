@@ -0,0 +1,58 @@
+package notify
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"grm/cloud"
+)
+
+// pubsubPublisher publishes to Google Cloud Pub/Sub topics of a project
+type pubsubPublisher struct {
+	project string
+}
+
+func newPubsubPublisher(u *url.URL) *pubsubPublisher {
+	return &pubsubPublisher{u.Host}
+}
+
+func (p *pubsubPublisher) Publish(topic string, message Message) error {
+	payload := message.Payload
+	token, err := cloud.GoogleAccessToken()
+	if err != nil {
+		return err
+	}
+
+	// The JSON encoding of []byte is base64, as expected by Pub/Sub
+	body, err := json.Marshal(map[string]interface{}{
+		"messages": []map[string]interface{}{{"data": payload}},
+	})
+	if err != nil {
+		return err
+	}
+
+	endpoint := fmt.Sprintf("https://pubsub.googleapis.com/v1/projects/%s/topics/%s:publish",
+		url.PathEscape(p.project), url.PathEscape(topic))
+	req, err := http.NewRequest(http.MethodPost, endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	response, err := HttpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode != http.StatusOK {
+		data, _ := ioutil.ReadAll(response.Body)
+		return fmt.Errorf("Pub/Sub responded with %s: %s", response.Status, strings.TrimSpace(string(data)))
+	}
+	return nil
+}
@@ -0,0 +1,94 @@
+package notify
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// webhookPublisher posts the JSON payload to any HTTP endpoint. With a secret the payload is signed
+// with HMAC-SHA256, receivers verify the X-Grm-Signature-256 header like Github webhook signatures.
+// Query parameters other than the ones of grm are passed on to the receiver.
+type webhookPublisher struct {
+	server string
+	secret string
+	query  url.Values
+}
+
+// Query parameters configuring the sink in grm: the secret, the template and the throttling
+var sinkParameters = []string{"secret", "template", "min-severity", "advisories", "rate"}
+
+func newWebhookPublisher(u *url.URL) *webhookPublisher {
+	query := u.Query()
+	secret := query.Get("secret")
+	for _, parameter := range sinkParameters {
+		query.Del(parameter)
+	}
+	return &webhookPublisher{
+		server: fmt.Sprintf("%s://%s", strings.TrimPrefix(u.Scheme, "webhook+"), u.Host),
+		secret: secret,
+		query:  query,
+	}
+}
+
+// endpoint returns the URL of the path, its query parameters are merged with the ones of the sink
+func (w *webhookPublisher) endpoint(path string) (string, error) {
+	u, err := url.Parse(w.server + "/" + path)
+	if err != nil {
+		return "", err
+	}
+	query := u.Query()
+	for name, values := range w.query {
+		for _, value := range values {
+			query.Add(name, value)
+		}
+	}
+	u.RawQuery = query.Encode()
+	return u.String(), nil
+}
+
+// sign returns the signature header value of the payload: sha256=<hex encoded HMAC>
+func sign(secret string, payload []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(payload)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+func (w *webhookPublisher) Publish(path string, message Message) error {
+	endpoint, err := w.endpoint(path)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequest(http.MethodPost, endpoint, bytes.NewReader(message.Payload))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "grm")
+	event := message.Values["type"]
+	if event == "" {
+		event = "release"
+	}
+	req.Header.Set("X-Grm-Event", event)
+	if w.secret != "" {
+		req.Header.Set("X-Grm-Signature-256", sign(w.secret, message.Payload))
+	}
+
+	response, err := HttpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode/100 != 2 {
+		data, _ := ioutil.ReadAll(response.Body)
+		return fmt.Errorf("webhook responded with %s: %s", response.Status, strings.TrimSpace(string(data)))
+	}
+	return nil
+}
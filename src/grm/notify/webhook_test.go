@@ -0,0 +1,94 @@
+package notify
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestSign(t *testing.T) {
+	tests := []struct {
+		secret    string
+		payload   string
+		signature string
+	}{
+		// Example of the Github webhook documentation
+		{"It's a Secret to Everybody", "Hello, World!", "sha256=757107ea0eb2509fc211221cce984b8a37570b6d7586c22c46f4379c8b043e17"},
+		{"key", "The quick brown fox jumps over the lazy dog", "sha256=f7bc83f430538424b13298e6aa6fb143ef4d59a14946175997479dbc2d1a3cd8"},
+		{"", "", "sha256=b613679a0814d9ec772f95d778c35fc5ff1697c493715653c6c712144292c5ad"},
+	}
+	for _, test := range tests {
+		if signature := sign(test.secret, []byte(test.payload)); signature != test.signature {
+			t.Errorf("%q %q: signature %s, expected %s", test.secret, test.payload, signature, test.signature)
+		}
+	}
+}
+
+func TestWebhookPublish(t *testing.T) {
+	var received *http.Request
+	var body []byte
+	server := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		received = request
+		body, _ = ioutil.ReadAll(request.Body)
+		if request.URL.Path == "/failing" {
+			http.Error(writer, "receiver broken", http.StatusInternalServerError)
+		}
+	}))
+	defer server.Close()
+	host := strings.TrimPrefix(server.URL, "http://")
+
+	payload := []byte(`{"repository":"terraform","tag":"v0.11.8"}`)
+	tests := []struct {
+		name      string
+		sink      string
+		path      string
+		event     string
+		query     string
+		signature string
+		err       string
+	}{
+		{"signed", "webhook+http://" + host + "?secret=s3cret", "hooks", "release", "",
+			sign("s3cret", payload), ""},
+		{"unsigned", "webhook+http://" + host, "hooks", "release", "", "", ""},
+		{"event type", "webhook+http://" + host, "hooks", "maintainers", "", "", ""},
+		{"receiver parameters", "webhook+http://" + host + "?secret=s3cret&token=abc&rate=5&min-severity=major&advisories=true&template=t",
+			"hooks?channel=ops", "release", "channel=ops&token=abc", sign("s3cret", payload), ""},
+		{"failing receiver", "webhook+http://" + host, "failing", "release", "", "", "webhook responded with 500 Internal Server Error: receiver broken"},
+	}
+	for _, test := range tests {
+		u, err := url.Parse(test.sink)
+		if err != nil {
+			t.Fatal(err)
+		}
+		values := map[string]string{}
+		if test.event != "release" {
+			values["type"] = test.event
+		}
+		err = newWebhookPublisher(u).Publish(test.path, Message{Payload: payload, Values: values})
+		if test.err != "" {
+			if err == nil || err.Error() != test.err {
+				t.Errorf("%s: error %v, expected %q", test.name, err, test.err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: %s", test.name, err)
+			continue
+		}
+		if signature := received.Header.Get("X-Grm-Signature-256"); signature != test.signature {
+			t.Errorf("%s: signature %q, expected %q", test.name, signature, test.signature)
+		}
+		if event := received.Header.Get("X-Grm-Event"); event != test.event {
+			t.Errorf("%s: event %q, expected %q", test.name, event, test.event)
+		}
+		if received.URL.RawQuery != test.query {
+			t.Errorf("%s: query %q, expected %q", test.name, received.URL.RawQuery, test.query)
+		}
+		if string(body) != string(payload) {
+			t.Errorf("%s: payload %s", test.name, body)
+		}
+	}
+}
@@ -0,0 +1,208 @@
+package notify
+
+import (
+	"crypto/tls"
+	"encoding/base64"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const (
+	xmppStreamNamespace = "http://etherx.jabber.org/streams"
+	xmppTlsNamespace    = "urn:ietf:params:xml:ns:xmpp-tls"
+	xmppSaslNamespace   = "urn:ietf:params:xml:ns:xmpp-sasl"
+	xmppBindNamespace   = "urn:ietf:params:xml:ns:xmpp-bind"
+)
+
+// xmppPublisher sends the announcement as chat message to a contact or joins a multi-user chat
+// room to post it there. Connections always use STARTTLS and SASL PLAIN.
+type xmppPublisher struct {
+	user     string
+	domain   string
+	password string
+	muc      bool
+}
+
+type xmppFeatures struct {
+	StartTls *struct{} `xml:"urn:ietf:params:xml:ns:xmpp-tls starttls"`
+}
+
+func newXmppPublisher(u *url.URL) *xmppPublisher {
+	publisher := &xmppPublisher{domain: u.Hostname()}
+	if u.User != nil {
+		publisher.user = u.User.Username()
+		publisher.password, _ = u.User.Password()
+	}
+	_, publisher.muc = u.Query()["muc"]
+	return publisher
+}
+
+// xmppAddress resolves the client port of the domain through its SRV record, default: domain:5222
+func xmppAddress(domain string) string {
+	if _, records, err := net.LookupSRV("xmpp-client", "tcp", domain); err == nil && len(records) > 0 {
+		return net.JoinHostPort(strings.TrimSuffix(records[0].Target, "."), fmt.Sprint(records[0].Port))
+	}
+	return net.JoinHostPort(domain, "5222")
+}
+
+func (x *xmppPublisher) Publish(to string, message Message) error {
+	if x.password == "" {
+		return errors.New("XMPP sink requires a password")
+	}
+
+	var conn net.Conn
+	conn, err := net.DialTimeout("tcp", xmppAddress(x.domain), timeout)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(60 * time.Second))
+
+	decoder, features, err := x.openStream(conn)
+	if err != nil {
+		return err
+	}
+	if features.StartTls == nil {
+		return fmt.Errorf("XMPP server %s doesn't offer STARTTLS", x.domain)
+	}
+	fmt.Fprintf(conn, "<starttls xmlns='%s'/>", xmppTlsNamespace)
+	if element, err := nextElement(decoder); err != nil {
+		return err
+	} else if element.Name.Local != "proceed" {
+		return fmt.Errorf("XMPP STARTTLS failed: %s", element.Name.Local)
+	}
+	secure := tls.Client(conn, &tls.Config{ServerName: x.domain})
+	if err := secure.Handshake(); err != nil {
+		return err
+	}
+	conn = secure
+
+	if decoder, _, err = x.openStream(conn); err != nil {
+		return err
+	}
+	credentials := base64.StdEncoding.EncodeToString([]byte("\x00" + x.user + "\x00" + x.password))
+	fmt.Fprintf(conn, "<auth xmlns='%s' mechanism='PLAIN'>%s</auth>", xmppSaslNamespace, credentials)
+	if element, err := nextElement(decoder); err != nil {
+		return err
+	} else if element.Name.Local != "success" {
+		return fmt.Errorf("XMPP authentication of %s@%s failed", x.user, x.domain)
+	}
+
+	if decoder, _, err = x.openStream(conn); err != nil {
+		return err
+	}
+	fmt.Fprintf(conn, "<iq type='set' id='bind'><bind xmlns='%s'><resource>grm</resource></bind></iq>", xmppBindNamespace)
+	if err := expectIq(decoder, "bind"); err != nil {
+		return err
+	}
+
+	messageType := "chat"
+	if x.muc {
+		messageType = "groupchat"
+		fmt.Fprintf(conn, "<presence to='%s/grm'><x xmlns='http://jabber.org/protocol/muc'><history maxstanzas='0'/></x></presence>",
+			xmlEscape(to))
+		if err := awaitPresence(decoder, to); err != nil {
+			return err
+		}
+	}
+
+	fmt.Fprintf(conn, "<message to='%s' type='%s'><body>%s</body></message></stream:stream>", xmlEscape(to),
+		messageType, xmlEscape(message.Text))
+	return nil
+}
+
+// openStream opens a new XML stream on the connection and returns the stream features of the server
+func (x *xmppPublisher) openStream(conn io.ReadWriter) (*xml.Decoder, xmppFeatures, error) {
+	features := xmppFeatures{}
+	fmt.Fprintf(conn, "<?xml version='1.0'?><stream:stream to='%s' xmlns='jabber:client' xmlns:stream='%s' version='1.0'>",
+		xmlEscape(x.domain), xmppStreamNamespace)
+
+	decoder := xml.NewDecoder(conn)
+	element, err := nextElement(decoder)
+	if err != nil {
+		return nil, features, err
+	}
+	if element.Name.Space != xmppStreamNamespace || element.Name.Local != "stream" {
+		return nil, features, fmt.Errorf("unexpected XMPP element %s", element.Name.Local)
+	}
+
+	element, err = nextElement(decoder)
+	if err != nil {
+		return nil, features, err
+	}
+	if element.Name.Local != "features" {
+		return nil, features, fmt.Errorf("unexpected XMPP element %s instead of features", element.Name.Local)
+	}
+	return decoder, features, decoder.DecodeElement(&features, element)
+}
+
+// nextElement returns the next start element, skipping whitespace and other tokens
+func nextElement(decoder *xml.Decoder) (*xml.StartElement, error) {
+	for {
+		token, err := decoder.Token()
+		if err != nil {
+			return nil, err
+		}
+		if element, ok := token.(xml.StartElement); ok {
+			return &element, nil
+		}
+	}
+}
+
+func expectIq(decoder *xml.Decoder, id string) error {
+	for {
+		element, err := nextElement(decoder)
+		if err != nil {
+			return err
+		}
+		stanza := struct {
+			Id   string `xml:"id,attr"`
+			Type string `xml:"type,attr"`
+		}{}
+		if err := decoder.DecodeElement(&stanza, element); err != nil {
+			return err
+		}
+		if element.Name.Local == "iq" && stanza.Id == id {
+			if stanza.Type != "result" {
+				return fmt.Errorf("XMPP request %s failed", id)
+			}
+			return nil
+		}
+	}
+}
+
+// awaitPresence waits for the presence of the room confirming the join
+func awaitPresence(decoder *xml.Decoder, room string) error {
+	for {
+		element, err := nextElement(decoder)
+		if err != nil {
+			return err
+		}
+		stanza := struct {
+			From string `xml:"from,attr"`
+			Type string `xml:"type,attr"`
+		}{}
+		if err := decoder.DecodeElement(&stanza, element); err != nil {
+			return err
+		}
+		if element.Name.Local != "presence" || !strings.HasPrefix(stanza.From, room+"/") {
+			continue
+		}
+		if stanza.Type == "error" {
+			return fmt.Errorf("could not join XMPP room %s", room)
+		}
+		return nil
+	}
+}
+
+func xmlEscape(value string) string {
+	var builder strings.Builder
+	xml.EscapeText(&builder, []byte(value))
+	return strings.Replace(builder.String(), "'", "&#39;", -1)
+}
@@ -0,0 +1,64 @@
+package notify
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// zulipPublisher posts to a Zulip stream, each repository gets its own topic by default
+type zulipPublisher struct {
+	server string
+	email  string
+	apiKey string
+	topic  string
+}
+
+func newZulipPublisher(u *url.URL) *zulipPublisher {
+	publisher := &zulipPublisher{server: "https://" + u.Host, topic: u.Query().Get("topic")}
+	if publisher.topic == "" {
+		publisher.topic = "{repository}"
+	}
+	if u.User != nil {
+		publisher.email = u.User.Username()
+		publisher.apiKey, _ = u.User.Password()
+	}
+	return publisher
+}
+
+func (z *zulipPublisher) Publish(stream string, message Message) error {
+	content := fmt.Sprintf("**[%s](%s)**", message.Title, message.Url)
+	for _, field := range message.Fields {
+		content += fmt.Sprintf("\n%s: %s", field.Name, field.Value)
+	}
+	if message.Body != "" {
+		content = message.Body
+	}
+
+	form := url.Values{
+		"type":    {"stream"},
+		"to":      {stream},
+		"topic":   {expand(z.topic, message.Values)},
+		"content": {content},
+	}
+	req, err := http.NewRequest(http.MethodPost, z.server+"/api/v1/messages", strings.NewReader(form.Encode()))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.SetBasicAuth(z.email, z.apiKey)
+
+	response, err := HttpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode != http.StatusOK {
+		data, _ := ioutil.ReadAll(response.Body)
+		return fmt.Errorf("Zulip responded with %s: %s", response.Status, strings.TrimSpace(string(data)))
+	}
+	return nil
+}
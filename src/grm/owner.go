@@ -0,0 +1,51 @@
+package main
+
+import (
+	"sort"
+)
+
+// ownerGroup holds the repositories assigned to one owner, the owner of unassigned repositories is empty
+type ownerGroup struct {
+	owner        string
+	repositories []*repository
+}
+
+func (g ownerGroup) title() string {
+	if g.owner == "" {
+		return locale.T("Unassigned:")
+	}
+	return locale.T("Owner: %s", g.owner)
+}
+
+// groupByOwner groups the repositories by their owner property, sorted by owner with unassigned
+// repositories last. The repositories keep their order within a group.
+func groupByOwner(repositories []*repository) []ownerGroup {
+	groups := make([]ownerGroup, 0)
+	index := make(map[string]int)
+	for _, rep := range repositories {
+		i, ok := index[rep.owner]
+		if !ok {
+			i = len(groups)
+			index[rep.owner] = i
+			groups = append(groups, ownerGroup{owner: rep.owner})
+		}
+		groups[i].repositories = append(groups[i].repositories, rep)
+	}
+
+	sort.SliceStable(groups, func(i, j int) bool {
+		if groups[i].owner == "" || groups[j].owner == "" {
+			return groups[j].owner == "" && groups[i].owner != ""
+		}
+		return groups[i].owner < groups[j].owner
+	})
+	return groups
+}
+
+// ownedRepositories orders the repositories by owner like groupByOwner
+func ownedRepositories(repositories []*repository) []*repository {
+	ordered := make([]*repository, 0, len(repositories))
+	for _, group := range groupByOwner(repositories) {
+		ordered = append(ordered, group.repositories...)
+	}
+	return ordered
+}
@@ -0,0 +1,291 @@
+package main
+
+import (
+	"github.com/google/go-github/github"
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"regexp"
+	"strings"
+	"text/tabwriter"
+	"grm/config"
+)
+
+// namePattern matches names against the comma separated regular expressions of a pattern property.
+// Expressions prefixed with ! exclude names. A name matches if it matches any of the including
+// expressions, or there are only excluding ones, and none of the excluding expressions. Exclusions
+// always win.
+type namePattern struct {
+	expression string
+	include    []*regexp.Regexp
+	exclude    []*regexp.Regexp
+}
+
+// compileNamePattern compiles the pattern with the default flags of the remote definition's
+// pattern-flags property, like i for case-insensitive patterns. Expressions can clear default flags
+// with their own flags, like (?-i).
+func compileNamePattern(name, expression string) (*namePattern, error) {
+	flags, _ := namedSectionGet(name, config.Remote, config.PatternFlags, "")
+	flags = strings.TrimSpace(flags)
+	if strings.Trim(flags, "imsU") != "" {
+		return nil, fmt.Errorf("invalid %s '%s', supported flags: i, m, s, U", config.PatternFlags.Name(), flags)
+	}
+
+	pattern := &namePattern{expression: expression}
+	for _, part := range splitPatternList(expression) {
+		exclude := strings.HasPrefix(part, "!")
+		part = strings.TrimPrefix(part, "!")
+		if flags != "" {
+			part = "(?" + flags + ")" + part
+		}
+		compiled, err := regexp.Compile(part)
+		if err != nil {
+			return nil, err
+		}
+		if exclude {
+			pattern.exclude = append(pattern.exclude, compiled)
+		} else {
+			pattern.include = append(pattern.include, compiled)
+		}
+	}
+	return pattern, nil
+}
+
+// mustCompileNamePattern compiles the pattern or stops with the invalid expression
+func mustCompileNamePattern(name, expression string) *namePattern {
+	pattern, err := compileNamePattern(name, expression)
+	if err != nil {
+		log.Fatal(fmt.Sprintf("Cannot compile regex: %s: ", expression), err)
+	}
+	return pattern
+}
+
+// splitPatternList splits a pattern property at the commas separating expressions. Commas inside
+// of repetitions like {1,3}, character classes and groups, or escaped like \, belong to the expression.
+func splitPatternList(expression string) []string {
+	parts := make([]string, 0)
+	depth, start := 0, 0
+	for i := 0; i < len(expression); i++ {
+		switch expression[i] {
+		case '\\':
+			i++
+		case '(', '[', '{':
+			depth++
+		case ')', ']', '}':
+			if depth > 0 {
+				depth--
+			}
+		case ',':
+			if depth == 0 {
+				parts = append(parts, strings.TrimSpace(expression[start:i]))
+				start = i + 1
+			}
+		}
+	}
+	parts = append(parts, strings.TrimSpace(expression[start:]))
+
+	// A single expression is kept as is, including its surrounding whitespace
+	if len(parts) == 1 {
+		return []string{expression}
+	}
+	return parts
+}
+
+func (p *namePattern) excluded(name string) bool {
+	for _, pattern := range p.exclude {
+		if pattern.MatchString(name) {
+			return true
+		}
+	}
+	return false
+}
+
+func (p *namePattern) MatchString(name string) bool {
+	if p.excluded(name) {
+		return false
+	}
+	if len(p.include) == 0 {
+		return true
+	}
+	for _, pattern := range p.include {
+		if pattern.MatchString(name) {
+			return true
+		}
+	}
+	return false
+}
+
+// FindStringSubmatch returns the submatches of the first including expression matching the name
+func (p *namePattern) FindStringSubmatch(name string) []string {
+	if p.excluded(name) {
+		return nil
+	}
+	for _, pattern := range p.include {
+		if substrings := pattern.FindStringSubmatch(name); substrings != nil {
+			return substrings
+		}
+	}
+	return nil
+}
+
+func (p *namePattern) String() string {
+	return p.expression
+}
+
+// patternMatch explains how the configured patterns treat a tag of a repository
+type patternMatch struct {
+	tag            string
+	subproject     string
+	releasePattern string
+	// released is false if the release pattern filters the tag out of reports
+	released  bool
+	channel   string
+	milestone string
+	// milestoneFound is only known for live tags, nil for sample names
+	milestoneFound *bool
+	err            error
+}
+
+// compiledPatterns caches the compiled patterns of a remote definition by their expression
+type compiledPatterns struct {
+	name     string
+	patterns map[string]*namePattern
+}
+
+func (c compiledPatterns) compile(expression string) (*namePattern, error) {
+	if pattern, ok := c.patterns[expression]; ok {
+		return pattern, nil
+	}
+	pattern, err := compileNamePattern(c.name, expression)
+	if err != nil {
+		return nil, err
+	}
+	c.patterns[expression] = pattern
+	return pattern, nil
+}
+
+// matchPatterns applies the release, milestone and channel patterns of the repository to the tags
+// like a report would, without stopping at invalid patterns
+func matchPatterns(name, repository string, tags []string, milestones []*github.Milestone) []patternMatch {
+	prefixes := readSubprojects(name, repository)
+	classifier := readChannelClassifier(name, repository)
+	patterns := compiledPatterns{name, make(map[string]*namePattern)}
+
+	matches := make([]patternMatch, 0, len(tags))
+	for _, tag := range tags {
+		match := patternMatch{tag: tag, released: true, channel: classifier.channel(tag)}
+		match.subproject, _ = subprojectOf(prefixes, tag)
+
+		if expression, ok := subprojectGet(name, config.ReleasePattern, repository, match.subproject); ok && expression != "" {
+			match.releasePattern = expression
+			pattern, err := patterns.compile(expression)
+			if err != nil {
+				match.err = fmt.Errorf("invalid %s: %s", config.ReleasePattern.Name(), err)
+				matches = append(matches, match)
+				continue
+			}
+			match.released = pattern.MatchString(tag)
+		}
+
+		if expression, ok := subprojectGet(name, config.MilestonePattern, repository, match.subproject); ok && expression != "" {
+			pattern, err := patterns.compile(expression)
+			if err != nil {
+				match.err = fmt.Errorf("invalid %s: %s", config.MilestonePattern.Name(), err)
+				matches = append(matches, match)
+				continue
+			}
+			if substrings := pattern.FindStringSubmatch(tag); len(substrings) > 1 {
+				match.milestone = substrings[1]
+			}
+		}
+
+		if milestones != nil && match.milestone != "" {
+			found := false
+			for _, milestone := range milestones {
+				if milestone.GetTitle() == match.milestone {
+					found = true
+					break
+				}
+			}
+			match.milestoneFound = &found
+		}
+		matches = append(matches, match)
+	}
+	return matches
+}
+
+// matchRepositoryPattern checks the repository against the repository-pattern property and the
+// blacklist, it returns an explanation if the repository is skipped by reports
+func matchRepositoryPattern(name, repository string) (string, string) {
+	expression, _ := namedSectionGet(name, config.Remote, config.RepositoryPattern, "")
+	if expression != "" {
+		pattern, err := compileNamePattern(name, expression)
+		if err != nil {
+			return expression, fmt.Sprintf("invalid %s: %s", config.RepositoryPattern.Name(), err)
+		}
+		if !pattern.MatchString(repository) {
+			return expression, "skipped, the repository pattern does not match"
+		}
+	}
+	if isBlacklisted(name, repository) {
+		return expression, fmt.Sprintf("skipped, %s is set", config.RepositoryBlacklisted.Name())
+	}
+	return expression, "matches"
+}
+
+// readTagNames returns the names of all tags of the repository, unfiltered by the release pattern
+func readTagNames(ctx context.Context, account, repository string, client *github.Client) []string {
+	names := make([]string, 0)
+
+	page := 1
+	for {
+		tags, response, err := client.Repositories.ListTags(ctx, account, repository, &github.ListOptions{
+			PerPage: 100,
+			Page:    page,
+		})
+
+		if rateLimit(response) {
+			continue
+		}
+
+		if err != nil {
+			log.Fatal(fmt.Sprintf("Could not retrieve tags for repository %s: ", repository), err)
+		}
+
+		for _, tag := range tags {
+			names = append(names, tag.GetName())
+		}
+
+		if hasMorePages(response) {
+			page++
+			continue
+		}
+
+		return names
+	}
+}
+
+func formatPatternMatchesText(writer io.Writer, matches []patternMatch) error {
+	table := tabwriter.NewWriter(writer, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(table, "Tag\tSub-project\tRelease pattern\tRelease\tChannel\tMilestone")
+	for _, match := range matches {
+		if match.err != nil {
+			fmt.Fprintln(table, fmt.Sprintf("%s\t%s\t%s\t%s\t-\t-", match.tag, orDash(match.subproject),
+				orDash(match.releasePattern), colorize(writer, match.err.Error(), ansiBoldRed)))
+			continue
+		}
+
+		released := colorize(writer, "yes", ansiGreen)
+		if !match.released {
+			released = colorize(writer, "filtered", ansiYellow)
+		}
+		milestone := orDash(match.milestone)
+		if match.milestoneFound != nil && !*match.milestoneFound {
+			milestone += " (missing)"
+		}
+		fmt.Fprintln(table, fmt.Sprintf("%s\t%s\t%s\t%s\t%s\t%s", match.tag, orDash(match.subproject),
+			orDash(match.releasePattern), released, match.channel, milestone))
+	}
+	return table.Flush()
+}
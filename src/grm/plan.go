@@ -0,0 +1,193 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"sync"
+	"text/tabwriter"
+	"time"
+	"grm/state"
+)
+
+// Requests per repository of a remote definition never reported: the milestones and tags and a
+// commit per tag
+const defaultRequestsPerRepository = 12
+
+// planRecord is the Github API usage of the last report of a remote definition
+type planRecord struct {
+	Repositories int       `json:"repositories"`
+	Requests     int       `json:"requests"`
+	Recorded     time.Time `json:"recorded"`
+}
+
+// planEntry is the estimated cost of a remote definition and when it fits into the quota
+type planEntry struct {
+	Remote       string     `json:"remote"`
+	Provider     string     `json:"provider"`
+	Repositories int        `json:"repositories"`
+	Requests     int        `json:"requests"`
+	Basis        string     `json:"basis"`
+	Quota        string     `json:"quota,omitempty"`
+	Fits         bool       `json:"fits"`
+	Start        *time.Time `json:"start,omitempty"`
+}
+
+// planQuota is the Github rate limit shared by the remote definitions of a user
+type planQuota struct {
+	User      string    `json:"user"`
+	Limit     int       `json:"limit"`
+	Remaining int       `json:"remaining"`
+	Reset     time.Time `json:"reset"`
+	Requests  int       `json:"requests"`
+}
+
+type runPlan struct {
+	Entries []planEntry  `json:"remotes"`
+	Quotas  []*planQuota `json:"quotas"`
+}
+
+// Github API requests sent per remote definition by the running command
+var apiRequests = struct {
+	sync.Mutex
+	counts map[string]int
+}{counts: make(map[string]int)}
+
+func countApiRequest(name string) {
+	apiRequests.Lock()
+	defer apiRequests.Unlock()
+	apiRequests.counts[name]++
+}
+
+func readApiRequests(name string) int {
+	apiRequests.Lock()
+	defer apiRequests.Unlock()
+	return apiRequests.counts[name]
+}
+
+func planKey(name string) string {
+	return state.Key("plan", name)
+}
+
+// recordPlan keeps the API usage of a Github report as estimate of the next one
+func recordPlan(name string, repositories, requests int) {
+	openState().Set(planKey(name), planRecord{repositories, requests, time.Now().UTC()})
+}
+
+// buildPlan estimates the Github API requests of reporting the remote definitions in the given order.
+// Remote definitions of the same user share the rate limit, those not fitting into the remaining
+// requests start after the reset. Responses revalidated from the HTTP cache don't count against
+// the rate limit, the estimate is an upper bound.
+func buildPlan(ctx context.Context, names []string) *runPlan {
+	plan := &runPlan{Entries: make([]planEntry, 0), Quotas: make([]*planQuota, 0)}
+	quotas := make(map[string]*planQuota)
+
+	for _, name := range names {
+		entry := planEntry{Remote: name, Provider: readProviderName(name), Fits: true}
+		if entry.Provider != providerGithub {
+			entry.Basis = "no Github API"
+			plan.Entries = append(plan.Entries, entry)
+			continue
+		}
+
+		client, username := newGithubClient(name)
+		record := planRecord{}
+		if openState().Get(planKey(name), &record) {
+			entry.Repositories, entry.Requests = record.Repositories, record.Requests
+			entry.Basis = fmt.Sprintf("report of %s", locale.Time(record.Recorded).Format("2006-01-02 15:04"))
+		} else {
+			account := readRemoteAccount(name, username)
+			if team, ok := readTeamRepositories(ctx, name, account, client); ok {
+				entry.Repositories = len(team)
+			} else if query, ok := readRepositoryQuery(name, account); ok {
+				entry.Repositories = countRepositoryQuery(ctx, query, client)
+			} else {
+				user, _, err := client.Users.Get(ctx, account)
+				if err != nil {
+					log.Fatal(fmt.Sprintf("Could not read Github account '%s': ", account), err)
+				}
+				entry.Repositories = user.GetPublicRepos() + user.GetTotalPrivateRepos()
+			}
+			entry.Requests = (entry.Repositories+99)/100 + entry.Repositories*defaultRequestsPerRepository
+			entry.Basis = "estimate"
+		}
+
+		entry.Quota = username
+		quota, ok := quotas[username]
+		if !ok {
+			limits, _, err := client.RateLimits(ctx)
+			if err != nil {
+				log.Fatal(fmt.Sprintf("Could not read the rate limit of %s: ", username), err)
+			}
+			quota = &planQuota{User: username, Limit: limits.Core.Limit, Remaining: limits.Core.Remaining, Reset: limits.Core.Reset.Time}
+			quotas[username] = quota
+			plan.Quotas = append(plan.Quotas, quota)
+		}
+
+		quota.Requests += entry.Requests
+		if quota.Requests > quota.Remaining {
+			entry.Fits = false
+			entry.Start = &quota.Reset
+		}
+		plan.Entries = append(plan.Entries, entry)
+	}
+	return plan
+}
+
+// readPlanStrategy checks the --quota strategy of run
+func readPlanStrategy(strategy string) string {
+	switch strategy {
+	case "", "warn", "skip", "wait":
+		return strategy
+	}
+	log.Fatal(fmt.Sprintf("Unknown quota strategy '%s', supported strategies: warn, skip, wait", strategy))
+	return ""
+}
+
+// fits reports whether all remote definitions can be reported with the remaining requests
+func (p *runPlan) fits() bool {
+	for _, entry := range p.Entries {
+		if !entry.Fits {
+			return false
+		}
+	}
+	return true
+}
+
+func formatPlanText(writer io.Writer, plan *runPlan) error {
+	table := tabwriter.NewWriter(writer, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(table, "Remote\tProvider\tRepositories\tRequests\tBasis\tQuota\tStart")
+	for _, entry := range plan.Entries {
+		start := "now"
+		if entry.Start != nil {
+			start = "after reset " + locale.Time(*entry.Start).Format("15:04")
+		}
+		fmt.Fprintln(table, fmt.Sprintf("%s\t%s\t%d\t%d\t%s\t%s\t%s", entry.Remote, entry.Provider, entry.Repositories,
+			entry.Requests, entry.Basis, orDash(entry.Quota), start))
+	}
+	if err := table.Flush(); err != nil {
+		return err
+	}
+
+	for _, quota := range plan.Quotas {
+		fmt.Fprintln(writer, fmt.Sprintf("\n%s: %d requests needed, %d of %d remaining until %s", quota.User, quota.Requests,
+			quota.Remaining, quota.Limit, locale.Time(quota.Reset).Format("15:04")))
+		if quota.Requests > quota.Limit {
+			fmt.Fprintln(writer, "The estimate exceeds the hourly limit, the remote definitions need more than one reset")
+		}
+	}
+	if plan.fits() {
+		fmt.Fprintln(writer, "\nAll remote definitions fit into the remaining requests")
+	} else {
+		fmt.Fprintln(writer, "\nSome remote definitions don't fit into the remaining requests, see grm run --quota")
+	}
+	return nil
+}
+
+func formatPlanJson(writer io.Writer, plan *runPlan) error {
+	encoder := json.NewEncoder(writer)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(plan)
+}
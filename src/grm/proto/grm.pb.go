@@ -0,0 +1,406 @@
+// Package grmpb holds the messages, the server handler and the client of the gRPC interface of
+// grm serve, specified in grm.proto.
+//
+// The code is written after grm.proto instead of being generated by protoc, grm doesn't depend on
+// the gRPC and protobuf libraries. Changes of grm.proto have to be made here too, wire_test.go
+// checks both agree and compares the encoding with the one of protoc generated code. Timestamps are
+// time.Time values, the zero time stands for an unset timestamp.
+package grmpb
+
+import (
+	"time"
+)
+
+// Message is implemented by all messages of grm.proto
+type Message interface {
+	Marshal() []byte
+	Unmarshal(data []byte) error
+}
+
+type Remote struct {
+	Name         string
+	Account      string
+	Repositories int32
+	Updated      time.Time
+}
+
+func (m *Remote) Marshal() []byte {
+	e := encoder{}
+	e.string(1, m.Name)
+	e.string(2, m.Account)
+	e.int32(3, m.Repositories)
+	e.timestamp(4, m.Updated)
+	return e.buf
+}
+
+func (m *Remote) Unmarshal(data []byte) error {
+	d := decoder{data}
+	for d.more() {
+		field, wireType, err := d.tag()
+		if err != nil {
+			return err
+		}
+		switch {
+		case field == 1 && wireType == wireBytes:
+			m.Name, err = d.string()
+		case field == 2 && wireType == wireBytes:
+			m.Account, err = d.string()
+		case field == 3 && wireType == wireVarint:
+			m.Repositories, err = d.int32()
+		case field == 4 && wireType == wireBytes:
+			m.Updated, err = d.timestamp()
+		default:
+			err = d.skip(wireType)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+type Reference struct {
+	Kind string
+	Id   string
+	Url  string
+}
+
+func (m *Reference) Marshal() []byte {
+	e := encoder{}
+	e.string(1, m.Kind)
+	e.string(2, m.Id)
+	e.string(3, m.Url)
+	return e.buf
+}
+
+func (m *Reference) Unmarshal(data []byte) error {
+	d := decoder{data}
+	for d.more() {
+		field, wireType, err := d.tag()
+		if err != nil {
+			return err
+		}
+		switch {
+		case field == 1 && wireType == wireBytes:
+			m.Kind, err = d.string()
+		case field == 2 && wireType == wireBytes:
+			m.Id, err = d.string()
+		case field == 3 && wireType == wireBytes:
+			m.Url, err = d.string()
+		default:
+			err = d.skip(wireType)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+type Release struct {
+	Repository   string
+	Tag          string
+	Version      string
+	Released     time.Time
+	Severity     string
+	Highlights   []string
+	Summary      string
+	MilestoneUrl string
+	DownloadUrl  string
+	Sha256       string
+	References   []*Reference
+	Acknowledged time.Time
+}
+
+func (m *Release) Marshal() []byte {
+	e := encoder{}
+	e.string(1, m.Repository)
+	e.string(2, m.Tag)
+	e.string(3, m.Version)
+	e.timestamp(4, m.Released)
+	e.string(5, m.Severity)
+	e.strings(6, m.Highlights)
+	e.string(7, m.Summary)
+	e.string(8, m.MilestoneUrl)
+	e.string(9, m.DownloadUrl)
+	e.string(10, m.Sha256)
+	for _, reference := range m.References {
+		e.message(11, reference)
+	}
+	e.timestamp(12, m.Acknowledged)
+	return e.buf
+}
+
+func (m *Release) Unmarshal(data []byte) error {
+	d := decoder{data}
+	for d.more() {
+		field, wireType, err := d.tag()
+		if err != nil {
+			return err
+		}
+		switch {
+		case field == 1 && wireType == wireBytes:
+			m.Repository, err = d.string()
+		case field == 2 && wireType == wireBytes:
+			m.Tag, err = d.string()
+		case field == 3 && wireType == wireBytes:
+			m.Version, err = d.string()
+		case field == 4 && wireType == wireBytes:
+			m.Released, err = d.timestamp()
+		case field == 5 && wireType == wireBytes:
+			m.Severity, err = d.string()
+		case field == 6 && wireType == wireBytes:
+			var highlight string
+			highlight, err = d.string()
+			m.Highlights = append(m.Highlights, highlight)
+		case field == 7 && wireType == wireBytes:
+			m.Summary, err = d.string()
+		case field == 8 && wireType == wireBytes:
+			m.MilestoneUrl, err = d.string()
+		case field == 9 && wireType == wireBytes:
+			m.DownloadUrl, err = d.string()
+		case field == 10 && wireType == wireBytes:
+			m.Sha256, err = d.string()
+		case field == 11 && wireType == wireBytes:
+			reference := &Reference{}
+			err = d.message(reference)
+			m.References = append(m.References, reference)
+		case field == 12 && wireType == wireBytes:
+			m.Acknowledged, err = d.timestamp()
+		default:
+			err = d.skip(wireType)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+type ListRemotesRequest struct{}
+
+func (m *ListRemotesRequest) Marshal() []byte {
+	return nil
+}
+
+func (m *ListRemotesRequest) Unmarshal(data []byte) error {
+	return skipAll(data)
+}
+
+type ListRemotesResponse struct {
+	Remotes []*Remote
+}
+
+func (m *ListRemotesResponse) Marshal() []byte {
+	e := encoder{}
+	for _, remote := range m.Remotes {
+		e.message(1, remote)
+	}
+	return e.buf
+}
+
+func (m *ListRemotesResponse) Unmarshal(data []byte) error {
+	d := decoder{data}
+	for d.more() {
+		field, wireType, err := d.tag()
+		if err != nil {
+			return err
+		}
+		switch {
+		case field == 1 && wireType == wireBytes:
+			remote := &Remote{}
+			err = d.message(remote)
+			m.Remotes = append(m.Remotes, remote)
+		default:
+			err = d.skip(wireType)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+type RefreshRequest struct{}
+
+func (m *RefreshRequest) Marshal() []byte {
+	return nil
+}
+
+func (m *RefreshRequest) Unmarshal(data []byte) error {
+	return skipAll(data)
+}
+
+type RefreshResponse struct{}
+
+func (m *RefreshResponse) Marshal() []byte {
+	return nil
+}
+
+func (m *RefreshResponse) Unmarshal(data []byte) error {
+	return skipAll(data)
+}
+
+type ListReleasesRequest struct {
+	Account    string
+	Repository string
+}
+
+func (m *ListReleasesRequest) Marshal() []byte {
+	e := encoder{}
+	e.string(1, m.Account)
+	e.string(2, m.Repository)
+	return e.buf
+}
+
+func (m *ListReleasesRequest) Unmarshal(data []byte) error {
+	d := decoder{data}
+	for d.more() {
+		field, wireType, err := d.tag()
+		if err != nil {
+			return err
+		}
+		switch {
+		case field == 1 && wireType == wireBytes:
+			m.Account, err = d.string()
+		case field == 2 && wireType == wireBytes:
+			m.Repository, err = d.string()
+		default:
+			err = d.skip(wireType)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+type ListReleasesResponse struct {
+	Releases []*Release
+}
+
+func (m *ListReleasesResponse) Marshal() []byte {
+	e := encoder{}
+	for _, release := range m.Releases {
+		e.message(1, release)
+	}
+	return e.buf
+}
+
+func (m *ListReleasesResponse) Unmarshal(data []byte) error {
+	d := decoder{data}
+	for d.more() {
+		field, wireType, err := d.tag()
+		if err != nil {
+			return err
+		}
+		switch {
+		case field == 1 && wireType == wireBytes:
+			release := &Release{}
+			err = d.message(release)
+			m.Releases = append(m.Releases, release)
+		default:
+			err = d.skip(wireType)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+type AcknowledgeRequest struct {
+	Account    string
+	Repository string
+	Tag        string
+	Comment    string
+}
+
+func (m *AcknowledgeRequest) Marshal() []byte {
+	e := encoder{}
+	e.string(1, m.Account)
+	e.string(2, m.Repository)
+	e.string(3, m.Tag)
+	e.string(4, m.Comment)
+	return e.buf
+}
+
+func (m *AcknowledgeRequest) Unmarshal(data []byte) error {
+	d := decoder{data}
+	for d.more() {
+		field, wireType, err := d.tag()
+		if err != nil {
+			return err
+		}
+		switch {
+		case field == 1 && wireType == wireBytes:
+			m.Account, err = d.string()
+		case field == 2 && wireType == wireBytes:
+			m.Repository, err = d.string()
+		case field == 3 && wireType == wireBytes:
+			m.Tag, err = d.string()
+		case field == 4 && wireType == wireBytes:
+			m.Comment, err = d.string()
+		default:
+			err = d.skip(wireType)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+type WatchReleasesRequest struct {
+	// Remotes limits the stream to releases of these remote definitions, all if empty
+	Remotes []string
+	// MinSeverity limits the stream to releases of at least this severity: patch, minor, major or
+	// breaking
+	MinSeverity string
+}
+
+func (m *WatchReleasesRequest) Marshal() []byte {
+	e := encoder{}
+	e.strings(1, m.Remotes)
+	e.string(2, m.MinSeverity)
+	return e.buf
+}
+
+func (m *WatchReleasesRequest) Unmarshal(data []byte) error {
+	d := decoder{data}
+	for d.more() {
+		field, wireType, err := d.tag()
+		if err != nil {
+			return err
+		}
+		switch {
+		case field == 1 && wireType == wireBytes:
+			var remote string
+			remote, err = d.string()
+			m.Remotes = append(m.Remotes, remote)
+		case field == 2 && wireType == wireBytes:
+			m.MinSeverity, err = d.string()
+		default:
+			err = d.skip(wireType)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// skipAll checks the fields of messages without any known field
+func skipAll(data []byte) error {
+	d := decoder{data}
+	for d.more() {
+		_, wireType, err := d.tag()
+		if err == nil {
+			err = d.skip(wireType)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,323 @@
+package grmpb
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+)
+
+// ServiceName is the full name of the Grm service, the path of a method is /grm.v1.Grm/<method>
+const ServiceName = "grm.v1.Grm"
+
+const contentType = "application/grpc"
+
+// Largest message accepted, releases with all their highlights and references are far smaller
+const maxMessageSize = 4 << 20
+
+// GrmServer is implemented by the server of the Grm service
+type GrmServer interface {
+	ListRemotes(ctx context.Context, request *ListRemotesRequest) (*ListRemotesResponse, error)
+	Refresh(ctx context.Context, request *RefreshRequest) (*RefreshResponse, error)
+	ListReleases(ctx context.Context, request *ListReleasesRequest) (*ListReleasesResponse, error)
+	Acknowledge(ctx context.Context, request *AcknowledgeRequest) (*Release, error)
+	RemoveAcknowledgement(ctx context.Context, request *AcknowledgeRequest) (*Release, error)
+	WatchReleases(request *WatchReleasesRequest, stream WatchReleasesServer) error
+}
+
+// WatchReleasesServer sends the releases of a WatchReleases call
+type WatchReleasesServer interface {
+	Send(release *Release) error
+	Context() context.Context
+}
+
+// grmHandler serves the calls of the Grm service over HTTP/2, the HTTP server has to accept HTTP/2
+// without TLS (h2c) unless it serves TLS
+type grmHandler struct {
+	server GrmServer
+}
+
+// NewGrmHandler returns the HTTP handler of the Grm service, mounted at /grm.v1.Grm/
+func NewGrmHandler(server GrmServer) http.Handler {
+	return &grmHandler{server}
+}
+
+func (h *grmHandler) ServeHTTP(writer http.ResponseWriter, request *http.Request) {
+	if request.Method != http.MethodPost || !strings.HasPrefix(request.Header.Get("Content-Type"), contentType) {
+		http.Error(writer, "only gRPC calls are served here", http.StatusUnsupportedMediaType)
+		return
+	}
+	if request.ProtoMajor != 2 {
+		http.Error(writer, "gRPC requires HTTP/2", http.StatusHTTPVersionNotSupported)
+		return
+	}
+
+	writer.Header().Set("Content-Type", contentType)
+	writer.Header().Set("Trailer", "Grpc-Status, Grpc-Message")
+	writer.WriteHeader(http.StatusOK)
+	// Streams may wait long for their first message, the client learns about the accepted call first
+	if flusher, ok := writer.(http.Flusher); ok {
+		flusher.Flush()
+	}
+	err := h.call(writer, request)
+	setStatus(writer.Header(), err)
+}
+
+func (h *grmHandler) call(writer http.ResponseWriter, request *http.Request) error {
+	ctx := request.Context()
+	switch strings.TrimPrefix(request.URL.Path, "/"+ServiceName+"/") {
+	case "ListRemotes":
+		in := &ListRemotesRequest{}
+		return unary(writer, request, in, func() (Message, error) { return h.server.ListRemotes(ctx, in) })
+	case "Refresh":
+		in := &RefreshRequest{}
+		return unary(writer, request, in, func() (Message, error) { return h.server.Refresh(ctx, in) })
+	case "ListReleases":
+		in := &ListReleasesRequest{}
+		return unary(writer, request, in, func() (Message, error) { return h.server.ListReleases(ctx, in) })
+	case "Acknowledge":
+		in := &AcknowledgeRequest{}
+		return unary(writer, request, in, func() (Message, error) { return h.server.Acknowledge(ctx, in) })
+	case "RemoveAcknowledgement":
+		in := &AcknowledgeRequest{}
+		return unary(writer, request, in, func() (Message, error) { return h.server.RemoveAcknowledgement(ctx, in) })
+	case "WatchReleases":
+		in := &WatchReleasesRequest{}
+		if err := readRequest(request.Body, in); err != nil {
+			return err
+		}
+		return h.server.WatchReleases(in, &watchReleasesServer{writer, ctx})
+	}
+	return Errorf(Unimplemented, "unknown method %s", request.URL.Path)
+}
+
+// unary reads the request message, calls the method and writes its response message
+func unary(writer http.ResponseWriter, request *http.Request, in Message, method func() (Message, error)) error {
+	if err := readRequest(request.Body, in); err != nil {
+		return err
+	}
+	out, err := method()
+	if err != nil {
+		return err
+	}
+	return writeMessage(writer, out)
+}
+
+func readRequest(reader io.Reader, in Message) error {
+	switch err := readMessage(reader, in); {
+	case err == io.EOF:
+		return Errorf(InvalidArgument, "the request message is missing")
+	case err != nil:
+		return err
+	}
+	return nil
+}
+
+type watchReleasesServer struct {
+	writer http.ResponseWriter
+	ctx    context.Context
+}
+
+func (s *watchReleasesServer) Send(release *Release) error {
+	return writeMessage(s.writer, release)
+}
+
+func (s *watchReleasesServer) Context() context.Context {
+	return s.ctx
+}
+
+// writeMessage writes a length prefixed message, uncompressed
+func writeMessage(writer io.Writer, m Message) error {
+	data := m.Marshal()
+	frame := make([]byte, 5, 5+len(data))
+	binary.BigEndian.PutUint32(frame[1:], uint32(len(data)))
+	if _, err := writer.Write(append(frame, data...)); err != nil {
+		return Errorf(Unavailable, "%s", err)
+	}
+	if flusher, ok := writer.(http.Flusher); ok {
+		flusher.Flush()
+	}
+	return nil
+}
+
+// readMessage reads a length prefixed message, io.EOF if the stream ended before the next message
+func readMessage(reader io.Reader, m Message) error {
+	header := make([]byte, 5)
+	if _, err := io.ReadFull(reader, header); err == io.EOF {
+		return err
+	} else if err != nil {
+		return Errorf(Internal, "could not read the message: %s", err)
+	}
+	if header[0] != 0 {
+		return Errorf(Unimplemented, "compressed messages are not supported")
+	}
+
+	length := binary.BigEndian.Uint32(header[1:])
+	if length > maxMessageSize {
+		return Errorf(ResourceExhausted, "message of %d bytes exceeds the limit of %d bytes", length, maxMessageSize)
+	}
+	data := make([]byte, length)
+	if _, err := io.ReadFull(reader, data); err != nil {
+		return Errorf(Internal, "could not read the message: %s", err)
+	}
+	if err := m.Unmarshal(data); err != nil {
+		return Errorf(Internal, "could not decode the message: %s", err)
+	}
+	return nil
+}
+
+// GrmClient calls the Grm service of grm serve
+type GrmClient struct {
+	address string
+	token   string
+	client  *http.Client
+}
+
+// NewGrmClient returns a client of the server at the address, like http://localhost:8080 for HTTP/2
+// without TLS or https://grm.example.com. The token is an API token of grm serve.
+func NewGrmClient(address, token string) *GrmClient {
+	protocols := &http.Protocols{}
+	if strings.HasPrefix(address, "http://") {
+		protocols.SetUnencryptedHTTP2(true)
+	} else {
+		protocols.SetHTTP2(true)
+	}
+	// Calls end with their context, streams never end by themselves
+	transport := &http.Transport{Proxy: http.ProxyFromEnvironment, Protocols: protocols}
+	return &GrmClient{strings.TrimSuffix(address, "/"), token, &http.Client{Transport: transport}}
+}
+
+func (c *GrmClient) ListRemotes(ctx context.Context, in *ListRemotesRequest) (*ListRemotesResponse, error) {
+	out := &ListRemotesResponse{}
+	if err := c.invoke(ctx, "ListRemotes", in, out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *GrmClient) Refresh(ctx context.Context, in *RefreshRequest) (*RefreshResponse, error) {
+	out := &RefreshResponse{}
+	if err := c.invoke(ctx, "Refresh", in, out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *GrmClient) ListReleases(ctx context.Context, in *ListReleasesRequest) (*ListReleasesResponse, error) {
+	out := &ListReleasesResponse{}
+	if err := c.invoke(ctx, "ListReleases", in, out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *GrmClient) Acknowledge(ctx context.Context, in *AcknowledgeRequest) (*Release, error) {
+	out := &Release{}
+	if err := c.invoke(ctx, "Acknowledge", in, out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *GrmClient) RemoveAcknowledgement(ctx context.Context, in *AcknowledgeRequest) (*Release, error) {
+	out := &Release{}
+	if err := c.invoke(ctx, "RemoveAcknowledgement", in, out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// WatchReleases opens the stream of releases, it ends with the context or the shutdown of the server
+func (c *GrmClient) WatchReleases(ctx context.Context, in *WatchReleasesRequest) (*WatchReleasesClient, error) {
+	response, err := c.open(ctx, "WatchReleases", in)
+	if err != nil {
+		return nil, err
+	}
+	return &WatchReleasesClient{response}, nil
+}
+
+// WatchReleasesClient receives the releases of a WatchReleases call
+type WatchReleasesClient struct {
+	response *http.Response
+}
+
+// Recv returns the next release, io.EOF once the server ended the stream
+func (s *WatchReleasesClient) Recv() (*Release, error) {
+	release := &Release{}
+	switch err := readMessage(s.response.Body, release); {
+	case err == io.EOF:
+		s.response.Body.Close()
+		if err := readStatus(s.response.Trailer); err != nil {
+			return nil, err
+		}
+		return nil, io.EOF
+	case err != nil:
+		s.response.Body.Close()
+		return nil, err
+	}
+	return release, nil
+}
+
+// Close ends the stream
+func (s *WatchReleasesClient) Close() error {
+	return s.response.Body.Close()
+}
+
+func (c *GrmClient) invoke(ctx context.Context, method string, in, out Message) error {
+	response, err := c.open(ctx, method, in)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+
+	err = readMessage(response.Body, out)
+	// The trailers follow the end of the body
+	io.Copy(ioutil.Discard, response.Body)
+	if status := readStatus(response.Trailer); status != nil {
+		return status
+	}
+	if err == io.EOF {
+		return Errorf(Internal, "the server sent no response message")
+	}
+	return err
+}
+
+// open sends the request message and returns the response once its headers arrived, failed calls
+// without messages are returned as error
+func (c *GrmClient) open(ctx context.Context, method string, in Message) (*http.Response, error) {
+	body := &bytes.Buffer{}
+	writeMessage(body, in)
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, c.address+"/"+ServiceName+"/"+method, body)
+	if err != nil {
+		return nil, Errorf(Internal, "%s", err)
+	}
+	request.Header.Set("Content-Type", contentType)
+	request.Header.Set("Te", "trailers")
+	if c.token != "" {
+		request.Header.Set("Authorization", "Bearer "+c.token)
+	}
+
+	response, err := c.client.Do(request)
+	if err != nil {
+		if ctx.Err() == context.Canceled {
+			return nil, Errorf(Canceled, "%s", err)
+		}
+		return nil, Errorf(Unavailable, "%s", err)
+	}
+	if response.StatusCode != http.StatusOK || !strings.HasPrefix(response.Header.Get("Content-Type"), contentType) {
+		response.Body.Close()
+		return nil, httpStatus(response)
+	}
+	// Responses without messages may carry the status in the headers instead of trailers
+	if response.Header.Get("Grpc-Status") != "" {
+		if err := readStatus(response.Header); err != nil {
+			response.Body.Close()
+			return nil, err
+		}
+		response.Trailer = response.Header
+	}
+	return response, nil
+}
@@ -0,0 +1,164 @@
+package grmpb
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestMessageRoundTrip(t *testing.T) {
+	released := time.Date(2018, 8, 29, 12, 30, 0, 500, time.UTC)
+	tests := []struct {
+		name    string
+		message Message
+		decoded Message
+	}{
+		{"empty request", &ListRemotesRequest{}, &ListRemotesRequest{}},
+		{"remotes", &ListRemotesResponse{Remotes: []*Remote{
+			{Name: "hashicorp", Account: "hashicorp", Repositories: 42, Updated: released},
+			{Name: "negative", Repositories: -1},
+		}}, &ListRemotesResponse{}},
+		{"release", &Release{
+			Repository:   "terraform",
+			Tag:          "v0.11.8",
+			Version:      "0.11.8",
+			Released:     released,
+			Severity:     "minor",
+			Highlights:   []string{"", "provider"},
+			MilestoneUrl: "https://github.com/hashicorp/terraform/milestone/1",
+			References:   []*Reference{{Kind: "cve", Id: "CVE-2018-1000", Url: "https://nvd.nist.gov/vuln/detail/CVE-2018-1000"}},
+			Acknowledged: released.Add(time.Hour),
+		}, &Release{}},
+		{"acknowledge", &AcknowledgeRequest{Account: "hashicorp", Repository: "terraform", Tag: "v0.11.8", Comment: "deployed ✓"}, &AcknowledgeRequest{}},
+		{"watch", &WatchReleasesRequest{Remotes: []string{"a", "b"}, MinSeverity: "major"}, &WatchReleasesRequest{}},
+	}
+	for _, test := range tests {
+		if err := test.decoded.Unmarshal(test.message.Marshal()); err != nil {
+			t.Errorf("%s: %s", test.name, err)
+			continue
+		}
+		if !reflect.DeepEqual(test.message, test.decoded) {
+			t.Errorf("%s: decoded %+v, expected %+v", test.name, test.decoded, test.message)
+		}
+	}
+}
+
+func TestUnmarshalInvalid(t *testing.T) {
+	tests := []struct {
+		name string
+		data []byte
+	}{
+		{"truncated varint", []byte{0x08, 0x80}},
+		{"length beyond the end", []byte{0x0a, 0x05, 'a'}},
+		{"field number 0", []byte{0x00, 0x01}},
+		{"unsupported wire type", []byte{0x0b}},
+	}
+	for _, test := range tests {
+		if err := (&Release{}).Unmarshal(test.data); err == nil {
+			t.Errorf("%s: no error", test.name)
+		}
+	}
+}
+
+func TestUnmarshalSkipsUnknownFields(t *testing.T) {
+	e := encoder{}
+	e.string(1, "terraform")
+	e.int64(99, 7)
+	e.string(100, "added later")
+	e.string(2, "v0.11.8")
+
+	release := &Release{}
+	if err := release.Unmarshal(e.buf); err != nil {
+		t.Fatal(err)
+	}
+	if release.Repository != "terraform" || release.Tag != "v0.11.8" {
+		t.Errorf("decoded %+v", release)
+	}
+}
+
+// testServer answers ListReleases for one repository and streams two releases
+type testServer struct {
+	GrmServer
+}
+
+func (testServer) ListReleases(ctx context.Context, request *ListReleasesRequest) (*ListReleasesResponse, error) {
+	if request.Repository != "terraform" {
+		return nil, Errorf(NotFound, "unknown repository %s", request.Repository)
+	}
+	return &ListReleasesResponse{Releases: []*Release{{Repository: "terraform", Tag: "v0.11.8"}}}, nil
+}
+
+func (testServer) WatchReleases(request *WatchReleasesRequest, stream WatchReleasesServer) error {
+	for _, tag := range []string{"v0.11.8", "v0.11.9"} {
+		if err := stream.Send(&Release{Repository: "terraform", Tag: tag, Severity: request.MinSeverity}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func newTestClient(t *testing.T) *GrmClient {
+	server := httptest.NewUnstartedServer(NewGrmHandler(testServer{}))
+	server.Config.Protocols = &http.Protocols{}
+	server.Config.Protocols.SetHTTP1(true)
+	server.Config.Protocols.SetUnencryptedHTTP2(true)
+	server.Start()
+	t.Cleanup(server.Close)
+	return NewGrmClient(server.URL, "")
+}
+
+func TestUnaryCall(t *testing.T) {
+	client := newTestClient(t)
+	tests := []struct {
+		repository string
+		code       Code
+		tags       int
+	}{
+		{"terraform", OK, 1},
+		{"unknown 100%", NotFound, 0},
+	}
+	for _, test := range tests {
+		response, err := client.ListReleases(context.Background(), &ListReleasesRequest{Account: "hashicorp", Repository: test.repository})
+		if code := StatusOf(err).Code; code != test.code {
+			t.Errorf("%s: status %d, expected %d (%v)", test.repository, code, test.code, err)
+			continue
+		}
+		if err == nil && len(response.Releases) != test.tags {
+			t.Errorf("%s: %d releases, expected %d", test.repository, len(response.Releases), test.tags)
+		}
+		if err != nil && StatusOf(err).Message != "unknown repository "+test.repository {
+			t.Errorf("%s: message %q", test.repository, StatusOf(err).Message)
+		}
+	}
+}
+
+func TestStream(t *testing.T) {
+	client := newTestClient(t)
+	stream, err := client.WatchReleases(context.Background(), &WatchReleasesRequest{MinSeverity: "major"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer stream.Close()
+
+	tags := []string{}
+	for {
+		release, err := stream.Recv()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		if release.Severity != "major" {
+			t.Errorf("request not received, severity %q", release.Severity)
+		}
+		tags = append(tags, release.Tag)
+	}
+	if !reflect.DeepEqual(tags, []string{"v0.11.8", "v0.11.9"}) {
+		t.Errorf("received %v", tags)
+	}
+}
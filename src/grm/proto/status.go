@@ -0,0 +1,122 @@
+package grmpb
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// Code is a gRPC status code
+type Code uint32
+
+const (
+	OK                Code = 0
+	Canceled          Code = 1
+	Unknown           Code = 2
+	InvalidArgument   Code = 3
+	NotFound          Code = 5
+	PermissionDenied  Code = 7
+	ResourceExhausted Code = 8
+	Unimplemented     Code = 12
+	Internal          Code = 13
+	Unavailable       Code = 14
+	Unauthenticated   Code = 16
+)
+
+// Status is the error of a failed call, sent in the grpc-status and grpc-message trailers
+type Status struct {
+	Code    Code
+	Message string
+}
+
+func (s *Status) Error() string {
+	return fmt.Sprintf("rpc error: code = %d desc = %s", s.Code, s.Message)
+}
+
+// Errorf returns a status error with a formatted message
+func Errorf(code Code, format string, a ...interface{}) error {
+	return &Status{code, fmt.Sprintf(format, a...)}
+}
+
+// StatusOf returns the status of an error, errors of other types are unknown
+func StatusOf(err error) *Status {
+	if err == nil {
+		return &Status{OK, ""}
+	}
+	var status *Status
+	if errors.As(err, &status) {
+		return status
+	}
+	return &Status{Unknown, err.Error()}
+}
+
+// WriteError answers a request with the status of the error only, like a failed authentication.
+// Nothing may have been written to the response before.
+func WriteError(writer http.ResponseWriter, err error) {
+	writer.Header().Set("Content-Type", contentType)
+	setStatus(writer.Header(), err)
+	writer.WriteHeader(http.StatusOK)
+}
+
+func setStatus(header http.Header, err error) {
+	status := StatusOf(err)
+	header.Set("Grpc-Status", strconv.Itoa(int(status.Code)))
+	if status.Message != "" {
+		header.Set("Grpc-Message", encodeMessage(status.Message))
+	}
+}
+
+// readStatus returns the status of the trailers or the headers of a response without messages, nil if
+// the call succeeded
+func readStatus(header http.Header) error {
+	value := header.Get("Grpc-Status")
+	if value == "" {
+		return Errorf(Internal, "the server sent no status")
+	}
+	code, err := strconv.ParseUint(value, 10, 32)
+	if err != nil {
+		return Errorf(Internal, "invalid status %s", value)
+	}
+	if Code(code) == OK {
+		return nil
+	}
+	message, err := url.PathUnescape(header.Get("Grpc-Message"))
+	if err != nil {
+		message = header.Get("Grpc-Message")
+	}
+	return &Status{Code(code), message}
+}
+
+// httpStatus maps the status of a response which isn't a gRPC response, like a proxy error, to a code
+func httpStatus(response *http.Response) error {
+	code := Unknown
+	switch response.StatusCode {
+	case http.StatusBadRequest:
+		code = Internal
+	case http.StatusUnauthorized:
+		code = Unauthenticated
+	case http.StatusForbidden:
+		code = PermissionDenied
+	case http.StatusNotFound:
+		code = Unimplemented
+	case http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		code = Unavailable
+	}
+	return Errorf(code, "unexpected HTTP status %s", response.Status)
+}
+
+// encodeMessage percent encodes the status message, the header only carries printable ASCII
+func encodeMessage(message string) string {
+	encoded := strings.Builder{}
+	for i := 0; i < len(message); i++ {
+		if c := message[i]; c < ' ' || c > '~' || c == '%' {
+			fmt.Fprintf(&encoded, "%%%02X", c)
+		} else {
+			encoded.WriteByte(c)
+		}
+	}
+	return encoded.String()
+}
@@ -0,0 +1,196 @@
+package grmpb
+
+import (
+	"encoding/binary"
+	"errors"
+	"time"
+)
+
+// Wire types of the protocol buffers encoding, the messages of grm.proto only use varints and
+// length delimited fields
+const (
+	wireVarint  = 0
+	wireFixed64 = 1
+	wireBytes   = 2
+	wireFixed32 = 5
+)
+
+var errTruncated = errors.New("truncated protocol buffers message")
+
+// encoder appends fields in the protocol buffers wire format, fields with default values are left
+// out like proto3 does
+type encoder struct {
+	buf []byte
+}
+
+func (e *encoder) tag(field, wireType int) {
+	e.varint(uint64(field<<3 | wireType))
+}
+
+func (e *encoder) varint(value uint64) {
+	e.buf = binary.AppendUvarint(e.buf, value)
+}
+
+func (e *encoder) bytes(field int, value []byte) {
+	e.tag(field, wireBytes)
+	e.varint(uint64(len(value)))
+	e.buf = append(e.buf, value...)
+}
+
+func (e *encoder) string(field int, value string) {
+	if value != "" {
+		e.bytes(field, []byte(value))
+	}
+}
+
+// strings encodes a repeated string, empty elements are kept
+func (e *encoder) strings(field int, values []string) {
+	for _, value := range values {
+		e.bytes(field, []byte(value))
+	}
+}
+
+func (e *encoder) int64(field int, value int64) {
+	if value != 0 {
+		e.tag(field, wireVarint)
+		e.varint(uint64(value))
+	}
+}
+
+func (e *encoder) int32(field int, value int32) {
+	// Negative values are sign extended to 64 bits
+	e.int64(field, int64(value))
+}
+
+// message encodes an embedded message, it is written even if empty
+func (e *encoder) message(field int, m Message) {
+	e.bytes(field, m.Marshal())
+}
+
+// timestamp encodes a google.protobuf.Timestamp, the zero time is left out
+func (e *encoder) timestamp(field int, value time.Time) {
+	if value.IsZero() {
+		return
+	}
+	timestamp := encoder{}
+	timestamp.int64(1, value.Unix())
+	timestamp.int32(2, int32(value.Nanosecond()))
+	e.bytes(field, timestamp.buf)
+}
+
+// decoder reads the fields of a message in the protocol buffers wire format
+type decoder struct {
+	buf []byte
+}
+
+func (d *decoder) more() bool {
+	return len(d.buf) > 0
+}
+
+func (d *decoder) tag() (field, wireType int, err error) {
+	value, err := d.varint()
+	if err != nil {
+		return 0, 0, err
+	}
+	if value>>3 == 0 {
+		return 0, 0, errors.New("invalid field number 0")
+	}
+	return int(value >> 3), int(value & 7), nil
+}
+
+func (d *decoder) varint() (uint64, error) {
+	value, n := binary.Uvarint(d.buf)
+	if n <= 0 {
+		return 0, errTruncated
+	}
+	d.buf = d.buf[n:]
+	return value, nil
+}
+
+func (d *decoder) bytes() ([]byte, error) {
+	length, err := d.varint()
+	if err != nil {
+		return nil, err
+	}
+	if length > uint64(len(d.buf)) {
+		return nil, errTruncated
+	}
+	value := d.buf[:length]
+	d.buf = d.buf[length:]
+	return value, nil
+}
+
+func (d *decoder) string() (string, error) {
+	value, err := d.bytes()
+	return string(value), err
+}
+
+func (d *decoder) int64() (int64, error) {
+	value, err := d.varint()
+	return int64(value), err
+}
+
+func (d *decoder) int32() (int32, error) {
+	value, err := d.varint()
+	return int32(value), err
+}
+
+func (d *decoder) message(m Message) error {
+	value, err := d.bytes()
+	if err != nil {
+		return err
+	}
+	return m.Unmarshal(value)
+}
+
+func (d *decoder) timestamp() (time.Time, error) {
+	value, err := d.bytes()
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	var seconds int64
+	var nanos int32
+	timestamp := decoder{value}
+	for timestamp.more() {
+		field, wireType, err := timestamp.tag()
+		if err != nil {
+			return time.Time{}, err
+		}
+		switch {
+		case field == 1 && wireType == wireVarint:
+			seconds, err = timestamp.int64()
+		case field == 2 && wireType == wireVarint:
+			nanos, err = timestamp.int32()
+		default:
+			err = timestamp.skip(wireType)
+		}
+		if err != nil {
+			return time.Time{}, err
+		}
+	}
+	return time.Unix(seconds, int64(nanos)).UTC(), nil
+}
+
+// skip drops a field unknown to the message, like ones added by newer versions of grm.proto
+func (d *decoder) skip(wireType int) error {
+	switch wireType {
+	case wireVarint:
+		_, err := d.varint()
+		return err
+	case wireBytes:
+		_, err := d.bytes()
+		return err
+	case wireFixed64, wireFixed32:
+		size := 8
+		if wireType == wireFixed32 {
+			size = 4
+		}
+		if len(d.buf) < size {
+			return errTruncated
+		}
+		d.buf = d.buf[size:]
+		return nil
+	}
+	return errors.New("unsupported wire type")
+}
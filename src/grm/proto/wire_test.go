@@ -0,0 +1,204 @@
+package grmpb
+
+import (
+	"bytes"
+	"encoding/hex"
+	"io/ioutil"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestMarshalGolden compares the encoding with the one of protoc generated code, the expected bytes
+// are written by google.golang.org/protobuf with deterministic marshaling from grm.proto
+func TestMarshalGolden(t *testing.T) {
+	released := time.Date(2018, 8, 29, 12, 30, 0, 0, time.UTC)
+	tests := []struct {
+		name    string
+		message Message
+		decoded Message
+		golden  string
+	}{
+		{"empty request", &ListRemotesRequest{}, &ListRemotesRequest{}, ""},
+		{"remote", &Remote{Name: "hashicorp", Account: "hashicorp", Repositories: 42, Updated: released.Add(500)}, &Remote{},
+			"0a096861736869636f727012096861736869636f7270182a220908c8a39adc0510f403"},
+		{"negative int32", &Remote{Name: "n", Repositories: -1}, &Remote{},
+			"0a016e18ffffffffffffffffff01"},
+		{"remotes", &ListRemotesResponse{Remotes: []*Remote{{Name: "n", Repositories: -1}}}, &ListRemotesResponse{},
+			"0a0e0a016e18ffffffffffffffffff01"},
+		{"release", &Release{
+			Repository:   "terraform",
+			Tag:          "v0.11.8",
+			Version:      "0.11.8",
+			Released:     released,
+			Severity:     "minor",
+			Highlights:   []string{"", "HCL2"},
+			References:   []*Reference{{Kind: "cve", Id: "CVE-2018-1000"}},
+			Acknowledged: released.Add(time.Hour),
+		}, &Release{},
+			"0a097465727261666f726d120776302e31312e381a06302e31312e38220608c8a39adc052a056d696e6f723200320448434c325a140a03637665120d4356452d323031382d31303030620608d8bf9adc05"},
+		{"acknowledge", &AcknowledgeRequest{Account: "hashicorp", Repository: "terraform", Tag: "v0.11.8", Comment: "deployed ✓"}, &AcknowledgeRequest{},
+			"0a096861736869636f727012097465727261666f726d1a0776302e31312e38220c6465706c6f79656420e29c93"},
+		{"watch", &WatchReleasesRequest{Remotes: []string{"a", "b"}, MinSeverity: "major"}, &WatchReleasesRequest{},
+			"0a01610a016212056d616a6f72"},
+	}
+	for _, test := range tests {
+		golden, _ := hex.DecodeString(test.golden)
+		if data := test.message.Marshal(); !bytes.Equal(data, golden) {
+			t.Errorf("%s: encoded %x, expected %s", test.name, data, test.golden)
+		}
+		if err := test.decoded.Unmarshal(golden); err != nil || !reflect.DeepEqual(test.decoded, test.message) {
+			t.Errorf("%s: decoded %+v, expected %+v (%v)", test.name, test.decoded, test.message, err)
+		}
+	}
+}
+
+func TestFrameGolden(t *testing.T) {
+	golden, _ := hex.DecodeString("000000000d0a01610a016212056d616a6f72")
+	var frame bytes.Buffer
+	if err := writeMessage(&frame, &WatchReleasesRequest{Remotes: []string{"a", "b"}, MinSeverity: "major"}); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(frame.Bytes(), golden) {
+		t.Errorf("frame %x, expected %x", frame.Bytes(), golden)
+	}
+
+	request := &WatchReleasesRequest{}
+	if err := readMessage(bytes.NewReader(golden), request); err != nil || request.MinSeverity != "major" || len(request.Remotes) != 2 {
+		t.Errorf("read %+v, %v", request, err)
+	}
+	compressed := append([]byte{1}, golden[1:]...)
+	if err := readMessage(bytes.NewReader(compressed), request); StatusOf(err).Code != Unimplemented {
+		t.Errorf("compressed frame read with %v", err)
+	}
+}
+
+var (
+	protoMessagePattern = regexp.MustCompile(`(?s)\nmessage (\w+) \{(.*?)\n?\}`)
+	protoFieldPattern   = regexp.MustCompile(`(?m)^\s*(repeated )?([\w.]+) (\w+) = (\d+);`)
+)
+
+// TestProtoInSync checks every message of grm.proto against its Go type: the same fields, each one
+// encoded with the field number and wire type of grm.proto
+func TestProtoInSync(t *testing.T) {
+	messages := map[string]Message{
+		"Remote":               &Remote{},
+		"Reference":            &Reference{},
+		"Release":              &Release{},
+		"ListRemotesRequest":   &ListRemotesRequest{},
+		"ListRemotesResponse":  &ListRemotesResponse{},
+		"RefreshRequest":       &RefreshRequest{},
+		"RefreshResponse":      &RefreshResponse{},
+		"ListReleasesRequest":  &ListReleasesRequest{},
+		"ListReleasesResponse": &ListReleasesResponse{},
+		"AcknowledgeRequest":   &AcknowledgeRequest{},
+		"WatchReleasesRequest": &WatchReleasesRequest{},
+	}
+	definition, err := ioutil.ReadFile("grm.proto")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	found := 0
+	for _, match := range protoMessagePattern.FindAllStringSubmatch(string(definition), -1) {
+		name := match[1]
+		message, ok := messages[name]
+		if !ok {
+			t.Errorf("%s: no Go type", name)
+			continue
+		}
+		found++
+		fields := protoFieldPattern.FindAllStringSubmatch(match[2], -1)
+		value := reflect.ValueOf(message).Elem()
+		if value.NumField() != len(fields) {
+			t.Errorf("%s: %d Go fields, grm.proto has %d", name, value.NumField(), len(fields))
+		}
+		for _, field := range fields {
+			repeated, protoType, number := field[1] != "", field[2], field[4]
+			goName := goFieldName(field[3])
+			goField := value.FieldByName(goName)
+			if !goField.IsValid() {
+				t.Errorf("%s: no field %s", name, goName)
+				continue
+			}
+			wireType, ok := setField(goField, protoType, repeated)
+			if !ok {
+				t.Errorf("%s.%s: type %s doesn't match %s", name, goName, goField.Type(), field[0])
+				continue
+			}
+			tag := strconv.Itoa(wireType)
+			if tags := encodedTags(t, message.Marshal()); tags != number+":"+tag {
+				t.Errorf("%s.%s: encoded as %s, expected %s:%s", name, goName, tags, number, tag)
+			}
+			goField.Set(reflect.Zero(goField.Type()))
+		}
+	}
+	if found != len(messages) {
+		t.Errorf("%d messages in grm.proto, %d Go types", found, len(messages))
+	}
+}
+
+// goFieldName is the name protoc gives the Go field of a field of grm.proto
+func goFieldName(name string) string {
+	parts := strings.Split(name, "_")
+	for i, part := range parts {
+		parts[i] = strings.ToUpper(part[:1]) + part[1:]
+	}
+	return strings.Join(parts, "")
+}
+
+// setField sets a field to a value which is encoded, returns the wire type of the field, false if
+// its Go type doesn't match the type of grm.proto
+func setField(field reflect.Value, protoType string, repeated bool) (int, bool) {
+	fieldType := field.Type()
+	if repeated {
+		if fieldType.Kind() != reflect.Slice {
+			return 0, false
+		}
+		fieldType = fieldType.Elem()
+	}
+
+	var element reflect.Value
+	wireType := wireBytes
+	switch {
+	case protoType == "string" && fieldType.Kind() == reflect.String:
+		element = reflect.ValueOf("grm").Convert(fieldType)
+	case protoType == "int32" && fieldType.Kind() == reflect.Int32:
+		element = reflect.ValueOf(int32(1)).Convert(fieldType)
+		wireType = wireVarint
+	case protoType == "google.protobuf.Timestamp" && fieldType == reflect.TypeOf(time.Time{}):
+		element = reflect.ValueOf(time.Unix(1, 0))
+	case fieldType.Kind() == reflect.Ptr && fieldType.Elem().Name() == protoType:
+		element = reflect.New(fieldType.Elem())
+	default:
+		return 0, false
+	}
+
+	if repeated {
+		field.Set(reflect.Append(reflect.MakeSlice(field.Type(), 0, 1), element))
+	} else {
+		field.Set(element)
+	}
+	return wireType, true
+}
+
+// encodedTags returns the field number and wire type of the encoded fields
+func encodedTags(t *testing.T, data []byte) string {
+	tags := []string{}
+	d := decoder{data}
+	for d.more() {
+		field, wireType, err := d.tag()
+		if err == nil {
+			err = d.skip(wireType)
+		}
+		if err != nil {
+			t.Error(err)
+			break
+		}
+		tags = append(tags, strconv.Itoa(field)+":"+strconv.Itoa(wireType))
+	}
+	return strings.Join(tags, ",")
+}
@@ -0,0 +1,259 @@
+package main
+
+import (
+	"github.com/google/go-github/github"
+	"context"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+	"grm/config"
+)
+
+// Repository providers of remote definitions, Github unless the provider property says otherwise.
+// Remote definitions with the git-url or git-repositories property are read with git.
+const (
+	providerGithub      = "github"
+	providerGit         = "git"
+	providerSourcehut   = "sourcehut"
+	providerLaunchpad   = "launchpad"
+	providerSourceforge = "sourceforge"
+	providerMaven       = "maven"
+	providerGoProxy     = "goproxy"
+	providerHelm        = "helm"
+	providerTerraform   = "terraform"
+	providerVscode      = "vscode"
+	providerChrome      = "chrome"
+	providerFirefox     = "firefox"
+	providerScrape      = "scrape"
+)
+
+var providers = []string{providerGithub, providerGit, providerSourcehut, providerLaunchpad, providerSourceforge, providerMaven,
+	providerGoProxy, providerHelm, providerTerraform, providerVscode, providerChrome, providerFirefox, providerScrape}
+
+// providerCapabilities tell what a provider knows beyond versions and dates, report options a
+// provider lacks the capability for are skipped with a notice
+type providerCapabilities int
+
+const (
+	// capabilityDownloads: releases link downloads, which can be checksummed
+	capabilityDownloads providerCapabilities = 1 << iota
+	// capabilityRepositoryApi: the Github API with repository metadata, CI states and licenses
+	capabilityRepositoryApi
+)
+
+func (c providerCapabilities) has(capability providerCapabilities) bool {
+	return c&capability != 0
+}
+
+// releaseProvider builds the report of a remote definition with its capabilities, the auth command
+// asks for the credentials of its shape
+type releaseProvider struct {
+	capabilities providerCapabilities
+	credentials  string
+	build        func(ctx context.Context, name string, options reportOptions, repositoryPattern string, since time.Time) *reportModel
+}
+
+var releaseProviders = map[string]releaseProvider{
+	providerGithub: {capabilityDownloads | capabilityRepositoryApi, credentialUsernamePassword, buildGithubReport},
+	providerGit: {0, credentialAnonymous, func(ctx context.Context, name string, options reportOptions, repositoryPattern string, since time.Time) *reportModel {
+		return buildGitReport(name, repositoryPattern, since)
+	}},
+	providerSourcehut: {capabilityDownloads, credentialToken, func(ctx context.Context, name string, options reportOptions, repositoryPattern string, since time.Time) *reportModel {
+		return buildSourcehutReport(name, repositoryPattern, readVisibility(name, options.private) == "all", since)
+	}},
+	providerLaunchpad: {capabilityDownloads, credentialAnonymous, func(ctx context.Context, name string, options reportOptions, repositoryPattern string, since time.Time) *reportModel {
+		return buildLaunchpadReport(name, repositoryPattern, since)
+	}},
+	providerSourceforge: {capabilityDownloads, credentialAnonymous, func(ctx context.Context, name string, options reportOptions, repositoryPattern string, since time.Time) *reportModel {
+		return buildSourceforgeReport(name, repositoryPattern, since)
+	}},
+	providerMaven: {capabilityDownloads, credentialAnonymous, func(ctx context.Context, name string, options reportOptions, repositoryPattern string, since time.Time) *reportModel {
+		return buildMavenReport(name, repositoryPattern, since)
+	}},
+	providerGoProxy: {capabilityDownloads, credentialAnonymous, func(ctx context.Context, name string, options reportOptions, repositoryPattern string, since time.Time) *reportModel {
+		return buildGoProxyReport(name, repositoryPattern, since)
+	}},
+	providerHelm: {capabilityDownloads, credentialUsernamePassword, func(ctx context.Context, name string, options reportOptions, repositoryPattern string, since time.Time) *reportModel {
+		return buildHelmReport(name, repositoryPattern, since)
+	}},
+	providerTerraform: {0, credentialToken, func(ctx context.Context, name string, options reportOptions, repositoryPattern string, since time.Time) *reportModel {
+		return buildTerraformReport(name, repositoryPattern, since)
+	}},
+	providerVscode: {capabilityDownloads, credentialAnonymous, func(ctx context.Context, name string, options reportOptions, repositoryPattern string, since time.Time) *reportModel {
+		return buildExtensionReport(name, providerVscode, repositoryPattern, since)
+	}},
+	providerChrome: {capabilityDownloads, credentialAnonymous, func(ctx context.Context, name string, options reportOptions, repositoryPattern string, since time.Time) *reportModel {
+		return buildExtensionReport(name, providerChrome, repositoryPattern, since)
+	}},
+	providerFirefox: {capabilityDownloads, credentialAppKey, func(ctx context.Context, name string, options reportOptions, repositoryPattern string, since time.Time) *reportModel {
+		return buildExtensionReport(name, providerFirefox, repositoryPattern, since)
+	}},
+	providerScrape: {0, credentialAnonymous, func(ctx context.Context, name string, options reportOptions, repositoryPattern string, since time.Time) *reportModel {
+		return buildScrapeReport(name, repositoryPattern, since)
+	}},
+}
+
+// readProviderName returns the provider property of the remote definition
+func readProviderName(name string) string {
+	provider, ok := namedSectionGet(name, config.Remote, config.Provider, "")
+	if !ok || provider == "" || provider == providerGithub {
+		// Mirrors without API access and hosts without Github API are read with git
+		if gitRemote(name) {
+			return providerGit
+		}
+		return providerGithub
+	}
+	for _, p := range providers {
+		if p == provider {
+			return provider
+		}
+	}
+	log.Fatal(fmt.Sprintf("Unknown %s '%s', supported providers: %s", config.Provider.Name(), provider, strings.Join(providers, ", ")))
+	return ""
+}
+
+// skipUnsupportedOptions turns off the report options the provider has no capability for
+func skipUnsupportedOptions(provider string, capabilities providerCapabilities, options *reportOptions) {
+	skip := func(option string, enabled *bool, capability providerCapabilities) {
+		if *enabled && !capabilities.has(capability) {
+			fmt.Fprintln(progressOutput, fmt.Sprintf("The %s provider does not support %s, skipped", provider, option))
+			*enabled = false
+		}
+	}
+	skip("--checksums", &options.checksums, capabilityDownloads)
+	skip("--with-metadata", &options.metadata, capabilityRepositoryApi)
+	skip("--with-ci-status", &options.ciStatus, capabilityRepositoryApi)
+	skip("--licenses", &options.licenses, capabilityRepositoryApi)
+}
+
+// providerRelease is the model of a release shared by all providers besides Github: the version,
+// which stands in for the tag, its date, the download and the notes. A version without date is dated
+// by the first report seeing it.
+type providerRelease struct {
+	version     string
+	created     time.Time
+	firstSeen   bool
+	commit      string
+	notesUrl    string
+	downloadUrl string
+	notes       string
+	// appVersion is the version of the application packaged by a chart
+	appVersion string
+}
+
+// buildProviderReport builds the report of providers without milestones, which only know the
+// versions of their projects
+func buildProviderReport(name, account string, repos []*github.Repository, since time.Time, readReleases func(repository string) []providerRelease) *reportModel {
+	report := &reportModel{
+		name:         name,
+		account:      account,
+		repositories: make([]*repository, 0),
+	}
+	provider := readProviderName(name)
+	for _, repo := range repos {
+		fmt.Fprintln(progressOutput, fmt.Sprintf("Reading versions of %s...", repo.GetName()))
+		releases := providerReleases(name, provider, repo.GetName(), readReleases(repo.GetName()), since)
+		if len(releases) == 0 {
+			continue
+		}
+		owner, _ := namedSectionGet(name, config.Remote, config.Owner, repo.GetName())
+		rep := &repository{
+			name:     repo.GetName(),
+			owner:    owner,
+			releases: releases,
+			private:  repo.GetPrivate(),
+		}
+		classifyReleases(name, rep)
+		highlightReleases(name, rep)
+		snoozeReleases(name, rep)
+		report.repositories = append(report.repositories, rep)
+	}
+
+	report.anomalies = detectAnomalies(name, repos, report)
+	report.stale = detectStale(name, repos)
+	saveState()
+	return report
+}
+
+// readListedRepositories returns the repositories listed by the property, which stands in for the
+// repository listing of the Github API
+func readListedRepositories(name string, key config.Key, repositoryPattern string) []*github.Repository {
+	var pattern *namePattern = nil
+	if repositoryPattern != "" {
+		pattern = mustCompileNamePattern(name, repositoryPattern)
+	}
+
+	value, _ := namedSectionGet(name, config.Remote, key, "")
+	repositories := make([]*github.Repository, 0)
+	for _, repository := range strings.Split(value, ",") {
+		repository = strings.TrimSpace(repository)
+		if repository == "" || (pattern != nil && !pattern.MatchString(repository)) || isBlacklisted(name, repository) {
+			continue
+		}
+		repositories = append(repositories, &github.Repository{Name: github.String(repository)})
+	}
+	if len(repositories) == 0 {
+		log.Fatal(fmt.Sprintf("No repositories to read, please set the %s property", key.Name()))
+	}
+	return repositories
+}
+
+// providerReleases turns the versions matching the release pattern which are newer than since into
+// releases of the report
+func providerReleases(name, provider, repository string, versions []providerRelease, since time.Time) []*release {
+	releasePattern := readReleasePatterns(name, repository)
+	prefixes, classifier := readSubprojects(name, repository), readChannelClassifier(name, repository)
+	releases := make([]*release, 0)
+	for _, version := range versions {
+		if pattern := releasePattern(version.version); pattern != nil && !pattern.MatchString(version.version) {
+			continue
+		}
+		if !since.Before(version.created) {
+			continue
+		}
+
+		rel := &release{
+			name:         version.version,
+			created:      version.created,
+			firstSeen:    version.firstSeen,
+			published:    true,
+			provider:     provider,
+			commit:       version.commit,
+			milestoneUrl: version.notesUrl,
+			downloadUrl:  version.downloadUrl,
+			notes:        version.notes,
+			channel:      classifier.channel(version.version),
+			appVersion:   version.appVersion,
+		}
+		rel.subproject, _ = subprojectOf(prefixes, version.version)
+		releases = append(releases, rel)
+	}
+	return releases
+}
+
+// newProviderClient returns a HTTP client for the APIs of other providers, sharing the cache and the
+// API metrics and the responses of the invocation with the Github client
+func newProviderClient(name string) *http.Client {
+	return &http.Client{Transport: responses.Transport(&apiMetricsTransport{name, withHttpDebugging(tracer.Transport(httpCache))})}
+}
+
+// readProvider sends the request and returns the body of the response, failing on anything but 200
+func readProvider(client *http.Client, request *http.Request) []byte {
+	response, err := client.Do(request)
+	if err != nil {
+		log.Fatal(fmt.Sprintf("Could not read %s: ", request.URL), err)
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode != http.StatusOK {
+		log.Fatal(fmt.Sprintf("Could not read %s: %s", request.URL, response.Status))
+	}
+	body, err := ioutil.ReadAll(response.Body)
+	if err != nil {
+		log.Fatal(fmt.Sprintf("Could not read %s: ", request.URL), err)
+	}
+	return body
+}
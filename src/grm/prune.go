@@ -0,0 +1,123 @@
+package main
+
+import (
+	"github.com/google/go-github/github"
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"sort"
+	"strings"
+	"grm/config"
+)
+
+// deadRepository is a repository referenced by overrides of a remote definition, like
+// release-pattern:<repository> or repository-blacklisted:<repository>, which no longer exists upstream
+type deadRepository struct {
+	name string
+	// renamed is the new name of a renamed or transferred repository, its overrides are moved
+	renamed string
+	// entries are the override keys referring to the repository, like release-pattern:terraform
+	entries []string
+}
+
+// readOverriddenRepositories returns the repositories referenced by overrides of the remote
+// definition with their override keys. Wildcard overrides and overrides of sub-projects, like
+// release-pattern:api/v2, belong to the repository before the slash.
+func readOverriddenRepositories(name string) map[string][]string {
+	repositories := make(map[string][]string)
+	for key := range configuration.NamedSection(name, config.Remote) {
+		tokens := strings.SplitN(key, ":", 2)
+		if len(tokens) != 2 || config.KeyLookup(key) == nil || config.IsWildcard(tokens[1]) {
+			continue
+		}
+		repository := strings.SplitN(tokens[1], "/", 2)[0]
+		repositories[repository] = append(repositories[repository], key)
+	}
+	return repositories
+}
+
+// readDeadRepositories checks the repositories referenced by overrides one by one, repositories
+// missing in the list of the account could be private ones the credentials can't see
+func readDeadRepositories(ctx context.Context, name, account string, client *github.Client) []deadRepository {
+	repositories := readOverriddenRepositories(name)
+	names := make([]string, 0, len(repositories))
+	for repository := range repositories {
+		names = append(names, repository)
+	}
+	sort.Strings(names)
+
+	dead := make([]deadRepository, 0)
+	for _, repository := range names {
+		fmt.Fprintln(progressOutput, fmt.Sprintf("Checking %s...", repository))
+		exists, renamed := readRepositoryExists(ctx, account, repository, client)
+		if exists && renamed == "" {
+			continue
+		}
+		entries := repositories[repository]
+		sort.Strings(entries)
+		dead = append(dead, deadRepository{name: repository, renamed: renamed, entries: entries})
+	}
+	return dead
+}
+
+// readRepositoryExists reports whether the repository exists, and its new name if Github redirected
+// to a renamed or transferred repository
+func readRepositoryExists(ctx context.Context, account, repository string, client *github.Client) (bool, string) {
+	for {
+		repo, response, err := client.Repositories.Get(ctx, account, repository)
+		if rateLimit(response) {
+			continue
+		}
+
+		if response != nil && response.StatusCode == http.StatusNotFound {
+			return false, ""
+		}
+
+		if err != nil {
+			log.Fatal(fmt.Sprintf("Could not retrieve repository %s: ", repository), err)
+		}
+
+		if !strings.EqualFold(repo.GetOwner().GetLogin(), account) {
+			return true, repo.GetFullName()
+		}
+		if !strings.EqualFold(repo.GetName(), repository) {
+			return true, repo.GetName()
+		}
+		return true, ""
+	}
+}
+
+// pruneDeadRepositories removes the overrides of deleted repositories and moves those of renamed
+// repositories within the account. Overrides of transferred repositories are removed, they belong to
+// another remote definition now.
+func pruneDeadRepositories(mutator config.Mutator, name string, dead []deadRepository) {
+	section := configuration.NamedSection(name, config.Remote)
+	for _, repository := range dead {
+		for _, entry := range repository.entries {
+			tokens := strings.SplitN(entry, ":", 2)
+			key := config.KeyLookup(entry)
+			mutator.NamedSectionDelete(name, config.Remote, key, tokens[1])
+			if repository.renamed != "" && !strings.Contains(repository.renamed, "/") {
+				specifier := repository.renamed + strings.TrimPrefix(tokens[1], repository.name)
+				mutator.NamedSectionSet(name, config.Remote, key, specifier, section[entry])
+			}
+		}
+	}
+}
+
+func printDeadRepositories(writer io.Writer, dead []deadRepository) {
+	for _, repository := range dead {
+		switch {
+		case repository.renamed == "":
+			fmt.Fprintln(writer, fmt.Sprintf(" * %s was deleted, removing %s", repository.name, strings.Join(repository.entries, ", ")))
+		case strings.Contains(repository.renamed, "/"):
+			fmt.Fprintln(writer, fmt.Sprintf(" * %s was transferred to %s, removing %s", repository.name, repository.renamed,
+				strings.Join(repository.entries, ", ")))
+		default:
+			fmt.Fprintln(writer, fmt.Sprintf(" * %s was renamed to %s, moving %s", repository.name, repository.renamed,
+				strings.Join(repository.entries, ", ")))
+		}
+	}
+}
@@ -0,0 +1,96 @@
+package main
+
+import (
+	"github.com/google/go-github/github"
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"grm/config"
+)
+
+// The Github search returns at most 1000 results of a query
+const searchResultLimit = 1000
+
+// readRepositoryQuery returns the search query of the remote definition, limited to the remote account
+// unless the query names an owner itself
+func readRepositoryQuery(name, account string) (string, bool) {
+	query, ok := namedSectionGet(name, config.Remote, config.Query, "")
+	query = strings.TrimSpace(query)
+	if !ok || query == "" {
+		return "", false
+	}
+	for _, term := range strings.Fields(query) {
+		if strings.HasPrefix(term, "user:") || strings.HasPrefix(term, "org:") {
+			return query, true
+		}
+	}
+	return fmt.Sprintf("%s user:%s", query, account), true
+}
+
+// searchRepositories evaluates the query, so the tracked repositories follow repositories being created
+// or archived. Reports read the releases from the remote account, repositories of other owners are
+// skipped.
+func searchRepositories(ctx context.Context, query, account, visibility string, client *github.Client) []*github.Repository {
+	repositories := make([]*github.Repository, 0)
+	skipped := 0
+
+	page := 1
+	for {
+		result, response, err := client.Search.Repositories(ctx, query, &github.SearchOptions{
+			ListOptions: github.ListOptions{
+				PerPage: 100,
+				Page:    page,
+			},
+		})
+
+		if rateLimit(response) {
+			continue
+		}
+
+		if err != nil {
+			log.Fatal(fmt.Sprintf("Could not search repositories '%s': ", query), err)
+		}
+
+		if page == 1 && result.GetTotal() > searchResultLimit {
+			fmt.Fprintln(os.Stderr, fmt.Sprintf("The query '%s' matches %d repositories, only the first %d are tracked",
+				query, result.GetTotal(), searchResultLimit))
+		}
+
+		for i := range result.Repositories {
+			repository := &result.Repositories[i]
+			if !strings.EqualFold(repository.GetOwner().GetLogin(), account) {
+				skipped++
+				continue
+			}
+			if visibility == "public" && repository.GetPrivate() {
+				continue
+			}
+			repositories = append(repositories, repository)
+		}
+
+		if hasMorePages(response) {
+			page++
+			continue
+		}
+
+		if skipped > 0 {
+			fmt.Fprintln(os.Stderr, fmt.Sprintf("Skipped %d repositories of the query '%s' not owned by %s", skipped, query, account))
+		}
+		return repositories
+	}
+}
+
+// countRepositoryQuery returns the number of repositories matching the query, as estimate of the
+// tracked repositories
+func countRepositoryQuery(ctx context.Context, query string, client *github.Client) int {
+	result, _, err := client.Search.Repositories(ctx, query, &github.SearchOptions{ListOptions: github.ListOptions{PerPage: 1}})
+	if err != nil {
+		log.Fatal(fmt.Sprintf("Could not search repositories '%s': ", query), err)
+	}
+	if result.GetTotal() > searchResultLimit {
+		return searchResultLimit
+	}
+	return result.GetTotal()
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// credentialLimit is the Github rate limit window of a credential as reported by its last response
+type credentialLimit struct {
+	remaining int
+	reset     time.Time
+}
+
+// Rate limits by credential and resource, remote definitions sharing a token share its limit
+var credentialLimits = struct {
+	sync.Mutex
+	values map[string]credentialLimit
+}{values: make(map[string]credentialLimit)}
+
+// credentialId identifies a credential without keeping the secret
+func credentialId(username, secret string) string {
+	sum := sha256.Sum256([]byte(username + "\x00" + secret))
+	return hex.EncodeToString(sum[:8])
+}
+
+// rateLimitResource tells the separate limit of the search API from the core limit
+func rateLimitResource(req *http.Request) string {
+	if strings.HasPrefix(req.URL.Path, "/search/") || strings.Contains(req.URL.Path, "/api/v3/search/") {
+		return "search"
+	}
+	return "core"
+}
+
+// rateLimitTransport holds back the requests of an exhausted credential until its rate limit window
+// resets. Requests of other credentials continue meanwhile.
+type rateLimitTransport struct {
+	credential string
+	transport  http.RoundTripper
+}
+
+func (t *rateLimitTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	id := t.credential + "/" + rateLimitResource(req)
+	credentialLimits.Lock()
+	limit, ok := credentialLimits.values[id]
+	credentialLimits.Unlock()
+	if ok && limit.remaining == 0 {
+		if wait := time.Until(limit.reset); wait > 0 {
+			select {
+			case <-time.After(wait):
+			case <-req.Context().Done():
+				return nil, req.Context().Err()
+			}
+		}
+	}
+
+	response, err := t.transport.RoundTrip(req)
+	if err != nil {
+		return response, err
+	}
+	remaining, err := strconv.Atoi(response.Header.Get("X-RateLimit-Remaining"))
+	if err != nil {
+		return response, nil
+	}
+	reset, _ := strconv.ParseInt(response.Header.Get("X-RateLimit-Reset"), 10, 64)
+	credentialLimits.Lock()
+	credentialLimits.values[id] = credentialLimit{remaining, time.Unix(reset, 0)}
+	credentialLimits.Unlock()
+	return response, nil
+}
@@ -0,0 +1,143 @@
+package main
+
+import (
+	"github.com/google/go-github/github"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"log"
+	"strings"
+	"sync"
+	"grm/state"
+)
+
+// stateName is the name the state and the configuration know the repository by, also if the report
+// redacts it
+func (r *repository) stateName() string {
+	if r.realName != "" {
+		return r.realName
+	}
+	return r.name
+}
+
+// Key of the secret private repository names are hashed with in the state
+var redactionKeyName = state.Key("redaction", "key")
+
+var redactionMutex sync.Mutex
+
+// redactionKey returns the secret of the installation private repository names are hashed with, it
+// is created on first use
+func redactionKey() []byte {
+	redactionMutex.Lock()
+	defer redactionMutex.Unlock()
+
+	var key []byte
+	if openState().Get(redactionKeyName, &key) && len(key) == 32 {
+		return key
+	}
+	key = make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		log.Fatal("Could not generate the redaction key: ", err)
+	}
+	// Saved right away, reports redacted before the state is saved again must get the same hashes
+	if err := openState().Set(redactionKeyName, key); err != nil {
+		log.Fatal("Could not store the redaction key: ", err)
+	}
+	if err := openState().Save(); err != nil {
+		log.Fatal("Could not store the redaction key: ", err)
+	}
+	return key
+}
+
+// redactedName replaces the name of a private repository with an HMAC of the installation's key, the
+// same repository always gets the same hash so shared reports can still be compared, but names can't
+// be guessed by hashing candidates without the key
+func redactedName(key []byte, account, repository string) string {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(strings.ToLower(account + "/" + repository)))
+	return "private-" + hex.EncodeToString(mac.Sum(nil)[:6])
+}
+
+// redactPrivate hides the names, notes and links of private repositories of the report, e.g. before
+// sharing it outside of the team. The state keeps the real names, reports are redacted after they were
+// recorded.
+func redactPrivate(report *reportModel) {
+	names := make(map[string]string)
+	var key []byte
+	for _, rep := range report.repositories {
+		if !rep.private || rep.realName != "" {
+			continue
+		}
+		if key == nil {
+			key = redactionKey()
+		}
+		names[rep.name] = redactedName(key, report.account, rep.name)
+		rep.realName, rep.name = rep.name, names[rep.name]
+		rep.url = ""
+		if rep.metadata != nil {
+			metadata := *rep.metadata
+			metadata.Description, metadata.Topics = "", nil
+			rep.metadata = &metadata
+		}
+		for i, milestone := range rep.milestones {
+			rep.milestones[i] = redactMilestone(milestone)
+		}
+		for _, rel := range rep.releases {
+			rel.notes, rel.summary = "", ""
+			rel.highlights, rel.references, rel.ciFailures = nil, nil, nil
+			rel.milestoneUrl, rel.downloadUrl = "", ""
+			if rel.milestone != nil {
+				rel.milestone = redactMilestone(rel.milestone)
+			}
+		}
+	}
+	if len(names) == 0 {
+		return
+	}
+
+	redact := func(repository string) string {
+		if name, ok := names[repository]; ok {
+			return name
+		}
+		return repository
+	}
+	for i := range report.licenses {
+		report.licenses[i].repository = redact(report.licenses[i].repository)
+	}
+	for i := range report.anomalies {
+		report.anomalies[i].repository = redact(report.anomalies[i].repository)
+	}
+	for i := range report.stale {
+		report.stale[i].repository = redact(report.stale[i].repository)
+	}
+	for i := range report.maintainers {
+		report.maintainers[i].repository = redact(report.maintainers[i].repository)
+	}
+	for i := range report.checksums {
+		report.checksums[i].repository = redact(report.checksums[i].repository)
+	}
+	for i := range report.announcements {
+		if name, ok := names[report.announcements[i].repository]; ok {
+			report.announcements[i].repository = name
+			report.announcements[i].title, report.announcements[i].url = "", ""
+		}
+	}
+	added := make(map[string][]historyEntry, len(report.added))
+	for repository, entries := range report.added {
+		added[redact(repository)] = entries
+	}
+	report.added = added
+}
+
+// redactMilestone keeps the title and the dates of the milestone but not its links and description
+func redactMilestone(milestone *github.Milestone) *github.Milestone {
+	return &github.Milestone{
+		Number:    milestone.Number,
+		Title:     milestone.Title,
+		State:     milestone.State,
+		CreatedAt: milestone.CreatedAt,
+		ClosedAt:  milestone.ClosedAt,
+		DueOn:     milestone.DueOn,
+	}
+}
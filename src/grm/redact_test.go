@@ -0,0 +1,75 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"path/filepath"
+	"testing"
+	"grm/state"
+)
+
+// useState makes a new state file the state of the test
+func useState(t *testing.T) string {
+	path := filepath.Join(t.TempDir(), "state.json")
+	s, err := state.NewStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	previous := stateStore
+	stateStore = s
+	t.Cleanup(func() { stateStore = previous })
+	return path
+}
+
+func TestRedactedName(t *testing.T) {
+	key, other := make([]byte, 32), make([]byte, 32)
+	other[0] = 1
+
+	name := redactedName(key, "hashicorp", "sentinel")
+	if redactedName(key, "HashiCorp", "Sentinel") != name {
+		t.Error("name depends on the case")
+	}
+	if redactedName(other, "hashicorp", "sentinel") == name {
+		t.Error("name doesn't depend on the key")
+	}
+	checksum := sha256.Sum256([]byte("hashicorp/sentinel"))
+	if name == "private-"+hex.EncodeToString(checksum[:6]) {
+		t.Error("name is the plain hash")
+	}
+}
+
+func TestRedactPrivate(t *testing.T) {
+	path := useState(t)
+	newReport := func() *reportModel {
+		return &reportModel{name: "hashicorp", account: "hashicorp", repositories: []*repository{
+			{name: "terraform"},
+			{name: "sentinel", private: true, url: "https://github.com/hashicorp/sentinel",
+				releases: []*release{{name: "v0.1.0", notes: "Fixes"}}},
+		}}
+	}
+
+	report := newReport()
+	redactPrivate(report)
+	public, private := report.repositories[0], report.repositories[1]
+	if public.name != "terraform" || public.realName != "" {
+		t.Errorf("public repository redacted as %q", public.name)
+	}
+	if private.name != redactedName(redactionKey(), "hashicorp", "sentinel") || private.realName != "sentinel" {
+		t.Errorf("private repository redacted as %q", private.name)
+	}
+	if private.url != "" || private.releases[0].notes != "" {
+		t.Errorf("private repository keeps %q, %q", private.url, private.releases[0].notes)
+	}
+
+	// The key is kept in the state, later runs get the same names
+	reopened, err := state.NewStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	stateStore = reopened
+	again := newReport()
+	redactPrivate(again)
+	if again.repositories[1].name != private.name {
+		t.Errorf("redacted as %q, before as %q", again.repositories[1].name, private.name)
+	}
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+var (
+	// owner/repository#123 or #123, not preceded by characters of a word or url
+	issueReferencePattern = regexp.MustCompile(`(?:^|[\s(\[,;])((?:([\w.-]+)/([\w.-]+))?#(\d+))\b`)
+	issueUrlPattern       = regexp.MustCompile(`https://github\.com/([\w.-]+)/([\w.-]+)/(?:issues|pull)/(\d+)`)
+	cvePattern            = regexp.MustCompile(`(?i)\bCVE-\d{4}-\d{4,}\b`)
+	advisoryPattern       = regexp.MustCompile(`(?i)\bGHSA(?:-[23456789cfghjmpqrvwx]{4}){3}\b`)
+)
+
+type reference struct {
+	Kind string `json:"kind"`
+	Id   string `json:"id"`
+	Url  string `json:"url"`
+}
+
+// extractReferences finds issues, pull requests, CVEs and Github security advisories referenced by the
+// release notes and resolves them to links. Short issue references refer to the repository itself.
+func extractReferences(account, repository, notes string) []reference {
+	references := make([]reference, 0)
+	known := make(map[string]bool)
+	add := func(ref reference) {
+		if !known[ref.Id] {
+			known[ref.Id] = true
+			references = append(references, ref)
+		}
+	}
+
+	for _, match := range issueUrlPattern.FindAllStringSubmatch(notes, -1) {
+		add(issueReference(match[1], match[2], match[3], account, repository))
+	}
+	for _, match := range issueReferencePattern.FindAllStringSubmatch(notes, -1) {
+		owner, repo := match[2], match[3]
+		if owner == "" {
+			owner, repo = account, repository
+		}
+		add(issueReference(owner, repo, match[4], account, repository))
+	}
+	for _, match := range cvePattern.FindAllString(notes, -1) {
+		id := strings.ToUpper(match)
+		add(reference{"cve", id, "https://nvd.nist.gov/vuln/detail/" + id})
+	}
+	for _, match := range advisoryPattern.FindAllString(notes, -1) {
+		id := "GHSA" + strings.ToLower(match[4:])
+		add(reference{"advisory", id, "https://github.com/advisories/" + id})
+	}
+	return references
+}
+
+// issueReference creates the reference to an issue or pull request, Github redirects issue links of pull requests
+func issueReference(owner, repo, number, account, repository string) reference {
+	id := fmt.Sprintf("%s/%s#%s", owner, repo, number)
+	if strings.EqualFold(owner, account) && strings.EqualFold(repo, repository) {
+		id = "#" + number
+	}
+	return reference{"issue", id, fmt.Sprintf("https://github.com/%s/%s/issues/%s", owner, repo, number)}
+}
@@ -0,0 +1,52 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+	"grm/config"
+)
+
+// notifyReload delivers SIGHUP, the daemon modes reload their configuration on it
+func notifyReload() <-chan os.Signal {
+	hangup := make(chan os.Signal, 1)
+	signal.Notify(hangup, syscall.SIGHUP)
+	return hangup
+}
+
+// reloadConfiguration reads the configuration file again, the apply function resolves everything
+// derived from it. A file which can't be read or fails to apply is reported and the running
+// configuration is kept.
+func reloadConfiguration(names []string, apply func() error) bool {
+	c, err := config.LoadConfiguration(*homeDir)
+	if err != nil {
+		log.Println(fmt.Sprintf("Could not reload configuration, keeping the running one: %s", err))
+		return false
+	}
+
+	previous := configuration.Snapshot()
+	configuration.Replace(c)
+	err = checkRemoteDefinitions(names)
+	if err == nil {
+		err = apply()
+	}
+	if err != nil {
+		configuration.Replace(previous)
+		log.Println(fmt.Sprintf("Could not reload configuration, keeping the running one: %s", err))
+		return false
+	}
+	log.Println("Configuration reloaded")
+	return true
+}
+
+// checkRemoteDefinitions fails if a remote definition was removed
+func checkRemoteDefinitions(names []string) error {
+	for _, name := range names {
+		if len(configuration.NamedSection(name, config.Remote)) == 0 {
+			return fmt.Errorf("Unknown remote definition '%s'", name)
+		}
+	}
+	return nil
+}
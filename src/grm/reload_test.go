@@ -0,0 +1,43 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"grm/config"
+)
+
+func TestReloadConfiguration(t *testing.T) {
+	home := t.TempDir()
+	previousHome := homeDir
+	homeDir = &home
+	t.Cleanup(func() { homeDir = previousHome })
+	useConfiguration(t, "[Core]\nconfig-version = 1\n[Remote \"hashicorp\"]\nuser = hashicorp\n")
+
+	tests := []struct {
+		name     string
+		content  string
+		reloaded bool
+		user     string
+	}{
+		{"invalid version", "[Remote \"hashicorp\"]\nuser = terraform\n[Core]\nconfig-version = one\n", false, "hashicorp"},
+		{"newer version", "[Remote \"hashicorp\"]\nuser = terraform\n[Core]\nconfig-version = 99\n", false, "hashicorp"},
+		{"removed remote", "[Core]\nconfig-version = 1\n", false, "hashicorp"},
+		{"changed user", "[Core]\nconfig-version = 1\n[Remote \"hashicorp\"]\nuser = terraform\n", true, "terraform"},
+	}
+	for _, test := range tests {
+		if err := os.MkdirAll(filepath.Join(home, "github-release-monitor"), 0700); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(grmPath("config"), []byte(test.content), 0600); err != nil {
+			t.Fatal(err)
+		}
+		if reloaded := reloadConfiguration([]string{"hashicorp"}, func() error { return nil }); reloaded != test.reloaded {
+			t.Errorf("%s: reloaded %t", test.name, reloaded)
+		}
+		if user, _ := namedSectionGet("hashicorp", config.Remote, config.RemoteUser, ""); user != test.user {
+			t.Errorf("%s: user %q, expected %q", test.name, user, test.user)
+		}
+	}
+}
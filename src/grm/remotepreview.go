@@ -0,0 +1,67 @@
+package main
+
+import (
+	"github.com/google/go-github/github"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// Matching repositories listed by the preview of remote add
+const remotePreviewRepositories = 10
+
+// remotePreview summarizes the account of a new remote definition and its repositories matching the
+// repository pattern
+type remotePreview struct {
+	account string
+	kind    string
+	total   int
+	matches []string
+}
+
+// readRemotePreview verifies the account exists and matches its public repositories against the
+// repository pattern, private repositories are only visible after grm auth
+func readRemotePreview(ctx context.Context, name, account, repositoryPattern string, client *github.Client) (remotePreview, error) {
+	preview := remotePreview{account: account, matches: make([]string, 0)}
+	for {
+		user, response, err := client.Users.Get(ctx, account)
+		if rateLimit(response) {
+			continue
+		}
+		if response != nil && response.StatusCode == http.StatusNotFound {
+			return preview, fmt.Errorf("Github user or organization '%s' does not exist", account)
+		}
+		if err != nil {
+			return preview, err
+		}
+		preview.kind = strings.ToLower(user.GetType())
+		break
+	}
+
+	pattern, err := compileNamePattern(name, repositoryPattern)
+	if err != nil {
+		return preview, fmt.Errorf("invalid repository pattern '%s': %s", repositoryPattern, err)
+	}
+	repositories := readRepositories(ctx, name, account, "", "", client)
+	preview.total = len(repositories)
+	for _, repository := range repositories {
+		if pattern.MatchString(repository.GetName()) {
+			preview.matches = append(preview.matches, repository.GetName())
+		}
+	}
+	return preview, nil
+}
+
+func printRemotePreview(writer io.Writer, preview remotePreview, repositoryPattern string) {
+	fmt.Fprintln(writer, fmt.Sprintf("Github %s %s has %d public repositories, %d match the pattern '%s'",
+		preview.kind, preview.account, preview.total, len(preview.matches), repositoryPattern))
+	for i, name := range preview.matches {
+		if i == remotePreviewRepositories {
+			fmt.Fprintln(writer, fmt.Sprintf(" ... and %d more", len(preview.matches)-remotePreviewRepositories))
+			break
+		}
+		fmt.Fprintln(writer, fmt.Sprintf(" * %s", name))
+	}
+}
@@ -0,0 +1,521 @@
+package main
+
+import (
+	"io"
+	"fmt"
+	"sort"
+	"strings"
+	"encoding/json"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"os"
+	"grm/i18n"
+	"time"
+	"golang.org/x/crypto/ssh/terminal"
+)
+
+// Progress information is written here, machine readable formats keep stdout clean
+var progressOutput io.Writer = os.Stdout
+
+type reportModel struct {
+	name          string
+	account       string
+	repositories  []*repository
+	licenses      []licenseFinding
+	anomalies     []cadenceAnomaly
+	stale         []staleRepository
+	maintainers   []maintainerChange
+	checksums     []checksumChange
+	announcements []announcement
+	added         map[string][]historyEntry
+	minSeverity   string
+	checked       bool
+}
+
+type reportFormatter func(writer io.Writer, report *reportModel) error
+
+var reportFormats = map[string]reportFormatter{
+	"text":           formatText,
+	"terraform":      formatTerraform,
+	"terraform-json": formatTerraformJson,
+	"ansible":        formatAnsible,
+	"html":           formatHtml,
+	"json":           formatJson,
+	"nvchecker":      formatNvchecker,
+	"ics":            formatIcs,
+}
+
+func reportFormatNames() string {
+	names := make([]string, 0, len(reportFormats))
+	for name := range reportFormats {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return strings.Join(names, ", ")
+}
+
+// reported returns whether the release passes the minimum severity and isn't snoozed, escalated
+// releases and releases of unknown severity always pass the minimum severity
+func (r *reportModel) reported(rel *release) bool {
+	if rel.snoozed {
+		return false
+	}
+	if r.minSeverity == "" || rel.severity == "" || rel.escalated {
+		return true
+	}
+	return severityRank(rel.severity) >= severityRank(r.minSeverity)
+}
+
+// releaseUrl links the release, its Github release page or the page of other providers, nothing if the
+// repository is redacted
+func releaseUrl(account string, rep *repository, rel *release) string {
+	if rel.provider != providerGithub || rep.realName != "" {
+		return rel.milestoneUrl
+	}
+	return fmt.Sprintf("https://github.com/%s/%s/releases/tag/%s", account, rep.name, rel.name)
+}
+
+// latestRelease returns the most recent release of the repository
+func (r *repository) latestRelease() *release {
+	var latest *release
+	for _, rel := range r.releases {
+		if latest == nil || rel.created.After(latest.created) {
+			latest = rel
+		}
+	}
+	return latest
+}
+
+// latestReported returns the most recent published release of the repository the report doesn't leave
+// out, e.g. because it is snoozed
+func (r *reportModel) latestReported(rep *repository) *release {
+	var latest *release
+	for _, rel := range rep.releases {
+		if !rel.published || !r.reported(rel) {
+			continue
+		}
+		if latest == nil || rel.created.After(latest.created) {
+			latest = rel
+		}
+	}
+	return latest
+}
+
+func formatText(writer io.Writer, report *reportModel) error {
+	found := 0
+	for _, rep := range report.repositories {
+		if len(rep.releases) > 0 {
+			found++
+		}
+	}
+
+	fmt.Fprintln(writer, locale.T("Found %s repositories", locale.Number(found)))
+	groups := groupByOwner(report.repositories)
+	for _, group := range groups {
+		printed := false
+		for _, rep := range group.repositories {
+			for _, rel := range rep.releases {
+				if !rel.published || !report.reported(rel) {
+					continue
+				}
+				if !printed && len(groups) > 1 {
+					fmt.Fprintln(writer, colorize(writer, group.title(), ansiBold))
+					fmt.Fprintln(writer, "")
+				}
+				printed = true
+				printRelease(writer, report, rep, rel)
+			}
+		}
+	}
+
+	printStale(writer, report.stale)
+	printAnomalies(writer, report.anomalies)
+	printMaintainerChanges(writer, report.maintainers)
+	printChecksumChanges(writer, report.checksums)
+	printAnnouncements(writer, report.announcements)
+
+	if report.checked {
+		printLicenseFindings(writer, report.licenses)
+	}
+	return nil
+}
+
+// printRelease writes a reported release of the text report
+func printRelease(writer io.Writer, report *reportModel, rep *repository, rel *release) {
+	headline := locale.T("New %s release: %s (%s)", releaseLineName(rep.name, rel.subproject, rel.channel), rel.name, locale.Date(rel.created)+", "+locale.Ago(rel.created))
+	if len(rel.highlights) > 0 {
+		headline = colorize(writer, "[!] "+headline, ansiBoldRed)
+	}
+	fmt.Fprintln(writer, headline)
+	if rel.appVersion != "" {
+		fmt.Fprintln(writer, locale.T("App version: %s", rel.appVersion))
+	}
+	if rep.metadata != nil {
+		fmt.Fprintln(writer, locale.T("Repository: %s", rep.metadata.text()))
+	}
+	if len(rel.highlights) > 0 {
+		fmt.Fprintln(writer, colorize(writer, locale.T("Highlights: %s", strings.Join(rel.highlights, ", ")), ansiBoldRed))
+	}
+	if rel.breakingMarker != "" {
+		fmt.Fprintln(writer, locale.T("Severity: %s (release notes mention '%s')", rel.severity, rel.breakingMarker))
+	} else if rel.severity != "" {
+		fmt.Fprintln(writer, locale.T("Severity: %s", rel.severity))
+	}
+	if rel.ciStatus != "" {
+		fmt.Fprintln(writer, colorize(writer, locale.T("CI: %s", ciText(rel)), ciColors[rel.ciStatus]))
+	}
+	if rel.summary != "" {
+		fmt.Fprintln(writer, locale.T("Summary:"))
+		for _, line := range strings.Split(rel.summary, "\n") {
+			fmt.Fprintln(writer, "\t"+line)
+		}
+	}
+	if ack, ok := readAcknowledgement(report.name, rep.stateName(), rel.name); ok {
+		acknowledged := locale.Date(ack.Acknowledged)
+		if ack.Comment != "" {
+			acknowledged += " (" + ack.Comment + ")"
+		}
+		fmt.Fprintln(writer, locale.T("Acknowledged: %s", acknowledged))
+	} else {
+		fmt.Fprintln(writer, colorize(writer, locale.T("Not acknowledged yet: %s",
+			fmt.Sprintf("grm ack %s/%s %s", report.name, rep.name, rel.name)), ansiBold))
+	}
+	if rel.milestoneUrl != "" {
+		fmt.Fprintln(writer, locale.T("Release Notes: %s", rel.milestoneUrl))
+	}
+	if rel.downloadUrl != "" {
+		fmt.Fprintln(writer, locale.T("Download: %s", rel.downloadUrl))
+	}
+	if record, ok := readDownloadRecord(report.name, rep.stateName(), rel.name); ok {
+		if len(record.Verdicts) > 0 {
+			fmt.Fprintln(writer, locale.T("Scans:"))
+			for _, verdict := range record.Verdicts {
+				fmt.Fprintln(writer, "\t"+verdict.String())
+			}
+		}
+		if len(record.Attested) > 0 {
+			fmt.Fprintln(writer, locale.T("Attestations:"))
+			for _, attestation := range record.Attested {
+				fmt.Fprintln(writer, "\t"+attestation.String())
+			}
+		}
+	}
+	fmt.Fprintln(writer, "")
+}
+
+const (
+	ansiBold    = "\x1b[1m"
+	ansiBoldRed = "\x1b[1;31m"
+)
+
+// colorize wraps the text in ANSI escape codes if it is written to a terminal
+func colorize(writer io.Writer, text, code string) string {
+	if isTerminal(writer) {
+		return code + text + "\x1b[0m"
+	}
+	return text
+}
+
+// isTerminal reports whether the writer is a terminal, writers wrapping a terminal tell themselves
+func isTerminal(writer io.Writer) bool {
+	if w, ok := writer.(interface{ Terminal() bool }); ok {
+		return w.Terminal()
+	}
+	file, ok := writer.(*os.File)
+	return ok && terminal.IsTerminal(int(file.Fd()))
+}
+
+type jsonRelease struct {
+	Repository   string      `json:"repository"`
+	Owner        string      `json:"owner,omitempty"`
+	Tag          string      `json:"tag"`
+	Version      string      `json:"version"`
+	Released     time.Time   `json:"released"`
+	Severity     string      `json:"severity,omitempty"`
+	Highlights   []string    `json:"highlights,omitempty"`
+	Summary      string      `json:"summary,omitempty"`
+	MilestoneUrl string      `json:"milestone_url"`
+	DownloadUrl  string      `json:"download_url,omitempty"`
+	Sha256       string      `json:"sha256,omitempty"`
+	References   []reference `json:"references"`
+	Acknowledged *time.Time  `json:"acknowledged,omitempty"`
+	// Metadata of the repository, only with --with-metadata
+	Metadata *repositoryMetadata `json:"metadata,omitempty"`
+	// Subproject is the release line of a monorepo the tag belongs to
+	Subproject string `json:"subproject,omitempty"`
+	Channel    string `json:"channel,omitempty"`
+	// CiStatus of the tagged commit with the failed checks, only with --with-ci-status
+	CiStatus   string   `json:"ci_status,omitempty"`
+	CiFailures []string `json:"ci_failures,omitempty"`
+	// AppVersion is the version of the application packaged by a Helm chart
+	AppVersion string `json:"app_version,omitempty"`
+	// Provider the release was read from, FirstSeen if Released is the first report seeing it
+	Provider  string `json:"provider,omitempty"`
+	FirstSeen bool   `json:"first_seen,omitempty"`
+}
+
+func newJsonRelease(report *reportModel, rep *repository, rel *release) jsonRelease {
+	r := jsonRelease{
+		Repository:   rep.name,
+		Owner:        rep.owner,
+		Tag:          rel.name,
+		Version:      extractVersion(report.name, rep.stateName(), rel.name),
+		Released:     locale.Time(rel.created),
+		Severity:     rel.severity,
+		Highlights:   rel.highlights,
+		Summary:      rel.summary,
+		MilestoneUrl: rel.milestoneUrl,
+		DownloadUrl:  rel.downloadUrl,
+		Sha256:       rel.sha256,
+		References:   rel.references,
+		Metadata:     rep.metadata,
+		Subproject:   rel.subproject,
+		Channel:      rel.channel,
+		CiStatus:     rel.ciStatus,
+		CiFailures:   rel.ciFailures,
+		AppVersion:   rel.appVersion,
+		Provider:     rel.provider,
+		FirstSeen:    rel.firstSeen,
+	}
+	if ack, ok := readAcknowledgement(report.name, rep.stateName(), rel.name); ok {
+		acknowledged := locale.Time(ack.Acknowledged)
+		r.Acknowledged = &acknowledged
+	}
+	return r
+}
+
+type jsonFinding struct {
+	Repository string `json:"repository"`
+	Kind       string `json:"kind"`
+	Message    string `json:"message"`
+}
+
+// jsonReport is the document written by the json format, report diff compares two of them
+type jsonReport struct {
+	Account   string        `json:"account"`
+	Findings  []jsonFinding `json:"findings"`
+	Generated time.Time     `json:"generated"`
+	Releases  []jsonRelease `json:"releases"`
+	Remote    string        `json:"remote"`
+}
+
+// formatJson writes the new releases, grouped by owner, and all findings of the report
+func formatJson(writer io.Writer, report *reportModel) error {
+	encoder := json.NewEncoder(writer)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(newJsonReport(report))
+}
+
+func newJsonReport(report *reportModel) jsonReport {
+	releases := make([]jsonRelease, 0)
+	for _, rep := range ownedRepositories(report.repositories) {
+		for _, rel := range rep.releases {
+			if !rel.published || !report.reported(rel) {
+				continue
+			}
+			releases = append(releases, newJsonRelease(report, rep, rel))
+		}
+	}
+
+	findings := make([]jsonFinding, 0)
+	for _, s := range report.stale {
+		findings = append(findings, jsonFinding{s.repository, "stale",
+			fmt.Sprintf("latest release %s is older than %s", s.latest.Tag, i18n.English.Days(s.threshold))})
+	}
+	for _, anomaly := range report.anomalies {
+		findings = append(findings, jsonFinding{anomaly.repository, anomaly.kind, anomaly.message(i18n.English)})
+	}
+	for _, change := range report.maintainers {
+		findings = append(findings, jsonFinding{change.repository, "maintainers-changed", change.message(i18n.English)})
+	}
+	for _, change := range report.checksums {
+		findings = append(findings, jsonFinding{change.repository, "checksum-changed", change.message(i18n.English)})
+	}
+	for _, a := range report.announcements {
+		findings = append(findings, jsonFinding{a.repository, "announcement", a.message(i18n.English)})
+	}
+	for _, finding := range report.licenses {
+		if finding.changed() {
+			findings = append(findings, jsonFinding{finding.repository, "license-changed",
+				fmt.Sprintf("license changed from %s to %s", finding.previous, finding.license)})
+		}
+		if !finding.allowed {
+			findings = append(findings, jsonFinding{finding.repository, "license-not-allowed",
+				fmt.Sprintf("license %s is not on the allowlist", finding.license)})
+		}
+	}
+
+	return jsonReport{
+		Account:   report.account,
+		Findings:  findings,
+		Generated: locale.Time(time.Now()),
+		Releases:  releases,
+		Remote:    report.name,
+	}
+}
+
+// inventoryEntry describes the latest release of a tool for infrastructure code
+type inventoryEntry struct {
+	Version     string `json:"version"`
+	Tag         string `json:"tag"`
+	Released    string `json:"released"`
+	DownloadUrl string `json:"download_url"`
+	Sha256      string `json:"sha256"`
+	Severity    string `json:"severity"`
+	// Url links the release page of the provider, only nvchecker lists it
+	Url string `json:"-"`
+}
+
+func buildInventory(report *reportModel) (map[string]inventoryEntry, []string) {
+	inventory := make(map[string]inventoryEntry)
+	names := make([]string, 0)
+	for _, rep := range report.repositories {
+		latest := report.latestReported(rep)
+		if latest == nil {
+			continue
+		}
+		inventory[rep.name] = inventoryEntry{
+			Version:     strings.TrimPrefix(extractVersion(report.name, rep.stateName(), latest.name), "v"),
+			Tag:         latest.name,
+			Released:    locale.Time(latest.created).Format("2006-01-02"),
+			DownloadUrl: latest.downloadUrl,
+			Sha256:      latest.sha256,
+			Severity:    latest.severity,
+			Url:         releaseUrl(report.account, rep, latest),
+		}
+		names = append(names, rep.name)
+	}
+	sort.Strings(names)
+	return inventory, names
+}
+
+func formatTerraform(writer io.Writer, report *reportModel) error {
+	inventory, names := buildInventory(report)
+	fmt.Fprintln(writer, "grm_releases = {")
+	for _, name := range names {
+		entry := inventory[name]
+		fmt.Fprintln(writer, fmt.Sprintf("  %s = {", quote(name)))
+		fmt.Fprintln(writer, fmt.Sprintf("    version      = %s", quote(entry.Version)))
+		fmt.Fprintln(writer, fmt.Sprintf("    tag          = %s", quote(entry.Tag)))
+		fmt.Fprintln(writer, fmt.Sprintf("    released     = %s", quote(entry.Released)))
+		fmt.Fprintln(writer, fmt.Sprintf("    download_url = %s", quote(entry.DownloadUrl)))
+		fmt.Fprintln(writer, fmt.Sprintf("    sha256       = %s", quote(entry.Sha256)))
+		fmt.Fprintln(writer, fmt.Sprintf("    severity     = %s", quote(entry.Severity)))
+		fmt.Fprintln(writer, "  }")
+	}
+	fmt.Fprintln(writer, "}")
+	return nil
+}
+
+func formatTerraformJson(writer io.Writer, report *reportModel) error {
+	inventory, _ := buildInventory(report)
+	encoder := json.NewEncoder(writer)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(map[string]interface{}{
+		"grm_releases": inventory,
+	})
+}
+
+func formatAnsible(writer io.Writer, report *reportModel) error {
+	inventory, names := buildInventory(report)
+	fmt.Fprintln(writer, "---")
+	if len(names) == 0 {
+		fmt.Fprintln(writer, "grm_releases: {}")
+		return nil
+	}
+	fmt.Fprintln(writer, "grm_releases:")
+	for _, name := range names {
+		entry := inventory[name]
+		fmt.Fprintln(writer, fmt.Sprintf("  %s:", quote(name)))
+		fmt.Fprintln(writer, fmt.Sprintf("    version: %s", quote(entry.Version)))
+		fmt.Fprintln(writer, fmt.Sprintf("    tag: %s", quote(entry.Tag)))
+		fmt.Fprintln(writer, fmt.Sprintf("    released: %s", quote(entry.Released)))
+		fmt.Fprintln(writer, fmt.Sprintf("    download_url: %s", quote(entry.DownloadUrl)))
+		fmt.Fprintln(writer, fmt.Sprintf("    sha256: %s", quote(entry.Sha256)))
+		fmt.Fprintln(writer, fmt.Sprintf("    severity: %s", quote(entry.Severity)))
+	}
+	return nil
+}
+
+// formatNvchecker writes a newver file in nvchecker's version 2 format
+func formatNvchecker(writer io.Writer, report *reportModel) error {
+	inventory, _ := buildInventory(report)
+	data := make(map[string]map[string]string)
+	for name, entry := range inventory {
+		data[name] = map[string]string{
+			"version": entry.Version,
+			"gitref":  "refs/tags/" + entry.Tag,
+		}
+		if entry.Url != "" {
+			data[name]["url"] = entry.Url
+		}
+	}
+
+	encoder := json.NewEncoder(writer)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(map[string]interface{}{
+		"version": 2,
+		"data":    data,
+	})
+}
+
+// quote produces a double quoted string valid in HCL, JSON and YAML
+func quote(value string) string {
+	data, _ := json.Marshal(value)
+	return string(data)
+}
+
+// checksumUrl downloads the url through the shared cache and calculates its sha256 checksum
+func checksumUrl(url string) (string, error) {
+	response, err := newHttpClient().Get(url)
+	if err != nil {
+		return "", err
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("unexpected response: %s", response.Status)
+	}
+
+	hash := sha256.New()
+	if _, err := io.Copy(hash, response.Body); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// resolveChecksums fills in the sha256 of each repository's latest release, either from a previous
+// download or, if requested, by downloading the release's download url
+func resolveChecksums(report *reportModel, download bool) {
+	for _, rep := range report.repositories {
+		latest := report.latestReported(rep)
+		if latest == nil || latest.downloadUrl == "" {
+			continue
+		}
+		// The checksum belongs to the download url, not to any other asset of the release
+		if record, ok := readDownloadRecord(report.name, rep.stateName(), latest.name); ok {
+			for _, asset := range record.Assets {
+				if asset.Url == latest.downloadUrl {
+					latest.sha256 = asset.Sha256
+				}
+			}
+			if latest.sha256 != "" {
+				continue
+			}
+		}
+		if download {
+			fmt.Fprintln(progressOutput, fmt.Sprintf("Calculating checksum of %s...", latest.downloadUrl))
+			checksum, err := checksumUrl(latest.downloadUrl)
+			if err != nil {
+				fmt.Fprintln(progressOutput, fmt.Sprintf("Could not calculate checksum of %s: %s", latest.downloadUrl, err))
+				continue
+			}
+			latest.sha256 = checksum
+			observed := observeChecksum(report.name, rep.stateName(), latest.name, assetName(latest.downloadUrl), latest.downloadUrl, checksum)
+			if observed != nil {
+				report.checksums = append(report.checksums, *observed)
+			}
+		}
+	}
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+	"time"
+	"golang.org/x/crypto/ssh/terminal"
+)
+
+// Age of the last report reused by report --cached without --max-age
+const defaultReportMaxAge = time.Hour
+
+// recordingWriter writes the report to the terminal or file and keeps a copy to persist it. Colors
+// are kept as if the report was written to the file directly.
+type recordingWriter struct {
+	file   *os.File
+	buffer bytes.Buffer
+}
+
+func (w *recordingWriter) Write(p []byte) (int, error) {
+	w.buffer.Write(p)
+	return w.file.Write(p)
+}
+
+func (w *recordingWriter) Terminal() bool {
+	return terminal.IsTerminal(int(w.file.Fd()))
+}
+
+// reportCacheKey identifies reports generated with the same arguments, reports written to a terminal
+// are colored and kept apart from the others
+func reportCacheKey(arguments []string, terminal bool) string {
+	hash := sha256.Sum256([]byte(fmt.Sprintf("%s\x00%t", strings.Join(arguments, "\x00"), terminal)))
+	return hex.EncodeToString(hash[:])
+}
+
+func reportCachePath(key string) string {
+	return grmPath("reports", key)
+}
+
+// readCachedReport returns the last report generated with the same arguments if it isn't older
+// than the maximum age
+func readCachedReport(key string, maxAge time.Duration) ([]byte, bool) {
+	info, err := os.Stat(reportCachePath(key))
+	if err != nil || time.Since(info.ModTime()) > maxAge {
+		return nil, false
+	}
+	data, err := ioutil.ReadFile(reportCachePath(key))
+	if err != nil {
+		return nil, false
+	}
+	return data, true
+}
+
+// writeCachedReport persists the report, failures only cost the next cached run an API round trip
+func writeCachedReport(key string, data []byte) {
+	if err := os.MkdirAll(grmPath("reports"), 0700); err != nil {
+		fmt.Fprintln(os.Stderr, fmt.Sprintf("Could not persist report: %s", err))
+		return
+	}
+	if err := ioutil.WriteFile(reportCachePath(key), data, 0600); err != nil {
+		fmt.Fprintln(os.Stderr, fmt.Sprintf("Could not persist report: %s", err))
+	}
+}
@@ -0,0 +1,194 @@
+package main
+
+import (
+	"github.com/araddon/dateparse"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"log"
+	"os"
+	"strings"
+	"time"
+	"grm/state"
+)
+
+// Reports recorded per remote definition for report diff --against-state
+const reportSnapshots = 10
+
+// reportDiff is the delta between two json reports of a remote definition
+type reportDiff struct {
+	Remote      string        `json:"remote"`
+	From        time.Time     `json:"from"`
+	To          time.Time     `json:"to"`
+	NewReleases []jsonRelease `json:"new_releases"`
+	// DroppedReleases are no longer reported, e.g. snoozed or older than --since
+	DroppedReleases  []jsonRelease `json:"dropped_releases"`
+	Acknowledged     []jsonRelease `json:"acknowledged"`
+	NewFindings      []jsonFinding `json:"new_findings"`
+	ResolvedFindings []jsonFinding `json:"resolved_findings"`
+}
+
+func (d reportDiff) empty() bool {
+	return len(d.NewReleases) == 0 && len(d.DroppedReleases) == 0 && len(d.Acknowledged) == 0 &&
+		len(d.NewFindings) == 0 && len(d.ResolvedFindings) == 0
+}
+
+func reportSnapshotKey(name string, generated time.Time) string {
+	return state.Key("reports", name, generated.UTC().Format(time.RFC3339))
+}
+
+// recordReportSnapshot keeps the json report in the state, only the latest reports are kept
+func recordReportSnapshot(report *reportModel) {
+	snapshot := newJsonReport(report)
+	openState().Set(reportSnapshotKey(report.name, snapshot.Generated), snapshot)
+
+	keys := openState().Keys(state.Key("reports", report.name) + "/")
+	for i := 0; i < len(keys)-reportSnapshots; i++ {
+		openState().Delete(keys[i])
+	}
+}
+
+// readReportSnapshot returns the latest recorded report of the remote definition generated at or
+// before the given time
+func readReportSnapshot(name string, at time.Time) (jsonReport, bool) {
+	snapshot := jsonReport{}
+	keys := openState().Keys(state.Key("reports", name) + "/")
+	for i := len(keys) - 1; i >= 0; i-- {
+		if keys[i] <= reportSnapshotKey(name, at) {
+			return snapshot, openState().Get(keys[i], &snapshot)
+		}
+	}
+	return snapshot, false
+}
+
+// readJsonReport reads a report written with --format json, - reads it from stdin
+func readJsonReport(path string) jsonReport {
+	var data []byte
+	var err error
+	if path == "-" {
+		data, err = ioutil.ReadAll(os.Stdin)
+	} else {
+		data, err = ioutil.ReadFile(path)
+	}
+	if err != nil {
+		log.Fatal(fmt.Sprintf("Could not read report '%s': ", path), err)
+	}
+
+	report := jsonReport{}
+	if err := json.Unmarshal(data, &report); err != nil {
+		log.Fatal(fmt.Sprintf("Could not parse report '%s', reports have to be written with --format json: ", path), err)
+	}
+	return report
+}
+
+// parseSnapshotTime parses the --against-state timestamp in the time zone of the reports
+func parseSnapshotTime(value string) time.Time {
+	at, err := dateparse.ParseIn(value, locale.Time(time.Now()).Location())
+	if err != nil {
+		log.Fatal(fmt.Sprintf("Could not parse timestamp '%s': ", value), err)
+	}
+	return at
+}
+
+// diffReports compares releases by repository and tag and findings by repository, kind and message
+func diffReports(old, current jsonReport) reportDiff {
+	diff := reportDiff{
+		Remote:           current.Remote,
+		From:             old.Generated,
+		To:               current.Generated,
+		NewReleases:      make([]jsonRelease, 0),
+		DroppedReleases:  make([]jsonRelease, 0),
+		Acknowledged:     make([]jsonRelease, 0),
+		NewFindings:      make([]jsonFinding, 0),
+		ResolvedFindings: make([]jsonFinding, 0),
+	}
+
+	releaseKey := func(release jsonRelease) string {
+		return release.Repository + "\x00" + release.Tag
+	}
+	oldReleases := make(map[string]jsonRelease, len(old.Releases))
+	for _, release := range old.Releases {
+		oldReleases[releaseKey(release)] = release
+	}
+	newReleases := make(map[string]bool, len(current.Releases))
+	for _, release := range current.Releases {
+		newReleases[releaseKey(release)] = true
+		previous, ok := oldReleases[releaseKey(release)]
+		switch {
+		case !ok:
+			diff.NewReleases = append(diff.NewReleases, release)
+		case previous.Acknowledged == nil && release.Acknowledged != nil:
+			diff.Acknowledged = append(diff.Acknowledged, release)
+		}
+	}
+	for _, release := range old.Releases {
+		if !newReleases[releaseKey(release)] {
+			diff.DroppedReleases = append(diff.DroppedReleases, release)
+		}
+	}
+
+	findingKey := func(finding jsonFinding) string {
+		return strings.Join([]string{finding.Repository, finding.Kind, finding.Message}, "\x00")
+	}
+	oldFindings := make(map[string]bool, len(old.Findings))
+	for _, finding := range old.Findings {
+		oldFindings[findingKey(finding)] = true
+	}
+	newFindings := make(map[string]bool, len(current.Findings))
+	for _, finding := range current.Findings {
+		newFindings[findingKey(finding)] = true
+		if !oldFindings[findingKey(finding)] {
+			diff.NewFindings = append(diff.NewFindings, finding)
+		}
+	}
+	for _, finding := range old.Findings {
+		if !newFindings[findingKey(finding)] {
+			diff.ResolvedFindings = append(diff.ResolvedFindings, finding)
+		}
+	}
+	return diff
+}
+
+func formatReportDiffText(writer io.Writer, diff reportDiff) error {
+	fmt.Fprintln(writer, fmt.Sprintf("Changes of %s between %s and %s", diff.Remote,
+		diff.From.Format("2006-01-02 15:04 MST"), diff.To.Format("2006-01-02 15:04 MST")))
+	if diff.empty() {
+		fmt.Fprintln(writer, "No changes")
+		return nil
+	}
+
+	releases := func(title string, releases []jsonRelease, color string) {
+		if len(releases) == 0 {
+			return
+		}
+		fmt.Fprintln(writer, "")
+		fmt.Fprintln(writer, colorize(writer, title, color))
+		for _, release := range releases {
+			fmt.Fprintln(writer, fmt.Sprintf(" * %s %s (%s)", release.Repository, release.Tag, release.Released.Format("2006-01-02")))
+		}
+	}
+	findings := func(title string, findings []jsonFinding, color string) {
+		if len(findings) == 0 {
+			return
+		}
+		fmt.Fprintln(writer, "")
+		fmt.Fprintln(writer, colorize(writer, title, color))
+		for _, finding := range findings {
+			fmt.Fprintln(writer, fmt.Sprintf(" * %s: %s", finding.Repository, finding.Message))
+		}
+	}
+
+	releases("New releases:", diff.NewReleases, ansiGreen)
+	releases("Acknowledged releases:", diff.Acknowledged, ansiBold)
+	releases("No longer reported:", diff.DroppedReleases, ansiYellow)
+	findings("New findings:", diff.NewFindings, ansiBoldRed)
+	findings("Resolved findings:", diff.ResolvedFindings, ansiGreen)
+	return nil
+}
+
+func formatReportDiffJson(writer io.Writer, diff reportDiff) error {
+	encoder := json.NewEncoder(writer)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(diff)
+}
@@ -0,0 +1,286 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// Releases per page if only --page is given
+const defaultReportPageSize = 50
+
+// releaseFilter decides whether a release is kept in the report
+type releaseFilter func(report *reportModel, rep *repository, rel *release) bool
+
+// releaseFields are the fields of --filter expressions
+var releaseFields = map[string]func(report *reportModel, rep *repository, rel *release) string{
+	"repo":  func(report *reportModel, rep *repository, rel *release) string { return rep.name },
+	"owner": func(report *reportModel, rep *repository, rel *release) string { return rep.owner },
+	"tag":   func(report *reportModel, rep *repository, rel *release) string { return rel.name },
+	"version": func(report *reportModel, rep *repository, rel *release) string {
+		return extractVersion(report.name, rep.stateName(), rel.name)
+	},
+	"severity":   func(report *reportModel, rep *repository, rel *release) string { return rel.severity },
+	"channel":    func(report *reportModel, rep *repository, rel *release) string { return rel.channel },
+	"subproject": func(report *reportModel, rep *repository, rel *release) string { return rel.subproject },
+	"provider":   func(report *reportModel, rep *repository, rel *release) string { return rel.provider },
+	"ci":         func(report *reportModel, rep *repository, rel *release) string { return rel.ciStatus },
+	"prerelease": func(report *reportModel, rep *repository, rel *release) string {
+		return strconv.FormatBool(rel.channel != "" && rel.channel != channelStable)
+	},
+	"highlighted": func(report *reportModel, rep *repository, rel *release) string {
+		return strconv.FormatBool(len(rel.highlights) > 0)
+	},
+	"acknowledged": func(report *reportModel, rep *repository, rel *release) string {
+		_, ok := readAcknowledgement(report.name, rep.stateName(), rel.name)
+		return strconv.FormatBool(ok)
+	},
+}
+
+func releaseFieldNames() string {
+	names := make([]string, 0, len(releaseFields))
+	for name := range releaseFields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return strings.Join(names, ", ")
+}
+
+// parseReleaseFilter parses --filter expressions like repo=~terraform && prerelease==false. Fields
+// are compared with ==, !=, =~ and !~ (regular expressions), comparisons are combined with &&, || and
+// ! and grouped with parentheses. Values with spaces, parentheses or other operator characters are
+// quoted, like tag=~'(rc|beta)'.
+func parseReleaseFilter(expression string) (releaseFilter, error) {
+	tokens, err := tokenizeFilter(expression)
+	if err != nil {
+		return nil, err
+	}
+	parser := &filterParser{tokens: tokens}
+	filter, err := parser.or()
+	if err != nil {
+		return nil, err
+	}
+	if parser.position < len(parser.tokens) {
+		return nil, fmt.Errorf("unexpected '%s'", parser.tokens[parser.position].text)
+	}
+	return filter, nil
+}
+
+type filterToken struct {
+	text string
+	// quoted values are never operators
+	quoted bool
+}
+
+var filterOperators = []string{"&&", "||", "==", "!=", "=~", "!~", "!", "(", ")"}
+
+func tokenizeFilter(expression string) ([]filterToken, error) {
+	tokens := make([]filterToken, 0)
+	for i := 0; i < len(expression); {
+		c := expression[i]
+		if unicode.IsSpace(rune(c)) {
+			i++
+			continue
+		}
+		if c == '\'' || c == '"' {
+			end := strings.IndexByte(expression[i+1:], c)
+			if end < 0 {
+				return nil, fmt.Errorf("unterminated quote at position %d", i+1)
+			}
+			tokens = append(tokens, filterToken{expression[i+1 : i+1+end], true})
+			i += end + 2
+			continue
+		}
+		operator := ""
+		for _, o := range filterOperators {
+			if strings.HasPrefix(expression[i:], o) {
+				operator = o
+				break
+			}
+		}
+		if operator != "" {
+			tokens = append(tokens, filterToken{operator, false})
+			i += len(operator)
+			continue
+		}
+		// Unquoted words end at whitespace, quotes and operator characters
+		start := i
+		for i < len(expression) && !unicode.IsSpace(rune(expression[i])) && !strings.ContainsRune("&|=!()'\"", rune(expression[i])) {
+			i++
+		}
+		if i == start {
+			return nil, fmt.Errorf("unexpected '%c' at position %d", c, i+1)
+		}
+		tokens = append(tokens, filterToken{expression[start:i], false})
+	}
+	return tokens, nil
+}
+
+type filterParser struct {
+	tokens   []filterToken
+	position int
+}
+
+// accept consumes the next token if it is the given operator
+func (p *filterParser) accept(operator string) bool {
+	if p.position < len(p.tokens) && !p.tokens[p.position].quoted && p.tokens[p.position].text == operator {
+		p.position++
+		return true
+	}
+	return false
+}
+
+func (p *filterParser) next() (filterToken, error) {
+	if p.position >= len(p.tokens) {
+		return filterToken{}, fmt.Errorf("unexpected end of expression")
+	}
+	p.position++
+	return p.tokens[p.position-1], nil
+}
+
+func (p *filterParser) or() (releaseFilter, error) {
+	left, err := p.and()
+	if err != nil {
+		return nil, err
+	}
+	for p.accept("||") {
+		right, err := p.and()
+		if err != nil {
+			return nil, err
+		}
+		l := left
+		left = func(report *reportModel, rep *repository, rel *release) bool {
+			return l(report, rep, rel) || right(report, rep, rel)
+		}
+	}
+	return left, nil
+}
+
+func (p *filterParser) and() (releaseFilter, error) {
+	left, err := p.unary()
+	if err != nil {
+		return nil, err
+	}
+	for p.accept("&&") {
+		right, err := p.unary()
+		if err != nil {
+			return nil, err
+		}
+		l := left
+		left = func(report *reportModel, rep *repository, rel *release) bool {
+			return l(report, rep, rel) && right(report, rep, rel)
+		}
+	}
+	return left, nil
+}
+
+func (p *filterParser) unary() (releaseFilter, error) {
+	if p.accept("!") {
+		filter, err := p.unary()
+		if err != nil {
+			return nil, err
+		}
+		return func(report *reportModel, rep *repository, rel *release) bool {
+			return !filter(report, rep, rel)
+		}, nil
+	}
+	if p.accept("(") {
+		filter, err := p.or()
+		if err != nil {
+			return nil, err
+		}
+		if !p.accept(")") {
+			return nil, fmt.Errorf("missing ')'")
+		}
+		return filter, nil
+	}
+	return p.comparison()
+}
+
+func (p *filterParser) comparison() (releaseFilter, error) {
+	field, err := p.next()
+	if err != nil {
+		return nil, err
+	}
+	value, ok := releaseFields[field.text]
+	if !ok || field.quoted {
+		return nil, fmt.Errorf("unknown field '%s', supported fields: %s", field.text, releaseFieldNames())
+	}
+	operator, err := p.next()
+	if err != nil {
+		return nil, err
+	}
+	operand, err := p.next()
+	if err != nil {
+		return nil, err
+	}
+	if operator.quoted {
+		return nil, fmt.Errorf("expected ==, !=, =~ or !~ after %s, got '%s'", field.text, operator.text)
+	}
+	for _, o := range filterOperators {
+		if !operand.quoted && operand.text == o {
+			return nil, fmt.Errorf("expected a value after %s%s, got '%s'", field.text, operator.text, operand.text)
+		}
+	}
+
+	switch operator.text {
+	case "==", "!=":
+		equal := operator.text == "=="
+		return func(report *reportModel, rep *repository, rel *release) bool {
+			return (value(report, rep, rel) == operand.text) == equal
+		}, nil
+	case "=~", "!~":
+		pattern, err := regexp.Compile(operand.text)
+		if err != nil {
+			return nil, fmt.Errorf("invalid regular expression '%s': %s", operand.text, err)
+		}
+		matches := operator.text == "=~"
+		return func(report *reportModel, rep *repository, rel *release) bool {
+			return pattern.MatchString(value(report, rep, rel)) == matches
+		}, nil
+	}
+	return nil, fmt.Errorf("expected ==, !=, =~ or !~ after %s, got '%s'", field.text, operator.text)
+}
+
+// filterReport drops the releases not matching the filter
+func filterReport(report *reportModel, filter releaseFilter) {
+	for _, rep := range report.repositories {
+		releases := make([]*release, 0, len(rep.releases))
+		for _, rel := range rep.releases {
+			if filter(report, rep, rel) {
+				releases = append(releases, rel)
+			}
+		}
+		rep.releases = releases
+	}
+}
+
+// paginateReport keeps the reported releases of the page in the order of the report and returns the
+// number of reported releases. Findings are only part of the first page.
+func paginateReport(report *reportModel, page, pageSize int) int {
+	first, last := (page-1)*pageSize, page*pageSize
+	index := 0
+	for _, rep := range ownedRepositories(report.repositories) {
+		releases := make([]*release, 0, len(rep.releases))
+		for _, rel := range rep.releases {
+			if rel.published && report.reported(rel) {
+				if index < first || index >= last {
+					index++
+					continue
+				}
+				index++
+			}
+			releases = append(releases, rel)
+		}
+		rep.releases = releases
+	}
+
+	if page > 1 {
+		report.stale, report.anomalies, report.maintainers, report.announcements = nil, nil, nil, nil
+		report.licenses, report.checksums = nil, nil
+	}
+	return index
+}
@@ -0,0 +1,110 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func newFilterTestReport() *reportModel {
+	return &reportModel{name: "hashicorp", repositories: []*repository{
+		{name: "terraform", owner: "hashicorp", releases: []*release{
+			{name: "v0.11.8", severity: "minor", channel: channelStable},
+			{name: "v0.12.0-rc1", severity: "breaking", channel: "beta", highlights: []string{"HCL2"}},
+		}},
+		{name: "vault", owner: "hashicorp", releases: []*release{
+			{name: "v1.0.0", severity: "major", channel: channelStable, ciStatus: "success"},
+		}},
+		{name: "consul-template", owner: "hashicorp", releases: []*release{
+			{name: "v0.19.5 (hotfix)", severity: "patch", subproject: "cli"},
+		}},
+	}}
+}
+
+// matchingReleases returns repository@tag of the releases the filter keeps
+func matchingReleases(report *reportModel, filter releaseFilter) string {
+	matches := []string{}
+	for _, rep := range report.repositories {
+		for _, rel := range rep.releases {
+			if filter(report, rep, rel) {
+				matches = append(matches, rep.name+"@"+rel.name)
+			}
+		}
+	}
+	return strings.Join(matches, " ")
+}
+
+func TestParseReleaseFilter(t *testing.T) {
+	tests := []struct {
+		expression string
+		matches    string
+	}{
+		{"repo==vault", "vault@v1.0.0"},
+		{"repo != vault", "terraform@v0.11.8 terraform@v0.12.0-rc1 consul-template@v0.19.5 (hotfix)"},
+		{"repo=~^terra", "terraform@v0.11.8 terraform@v0.12.0-rc1"},
+		{"tag!~rc", "terraform@v0.11.8 vault@v1.0.0 consul-template@v0.19.5 (hotfix)"},
+		{"prerelease==true", "terraform@v0.12.0-rc1"},
+		{"highlighted==true || ci==success", "terraform@v0.12.0-rc1 vault@v1.0.0"},
+		{"repo==terraform && prerelease==false", "terraform@v0.11.8"},
+		{"severity==major || severity==breaking && repo==vault", "vault@v1.0.0"},
+		{"(severity==major || severity==breaking) && owner==hashicorp", "terraform@v0.12.0-rc1 vault@v1.0.0"},
+		{"!(repo==terraform)", "vault@v1.0.0 consul-template@v0.19.5 (hotfix)"},
+		{"!!repo==vault", "vault@v1.0.0"},
+		{"tag=='v0.19.5 (hotfix)'", "consul-template@v0.19.5 (hotfix)"},
+		{`tag=~"(rc|beta)"`, "terraform@v0.12.0-rc1"},
+		{"subproject==cli", "consul-template@v0.19.5 (hotfix)"},
+		{"tag=='&&'", ""},
+	}
+	report := newFilterTestReport()
+	for _, test := range tests {
+		filter, err := parseReleaseFilter(test.expression)
+		if err != nil {
+			t.Errorf("%s: %s", test.expression, err)
+			continue
+		}
+		if matches := matchingReleases(report, filter); matches != test.matches {
+			t.Errorf("%s: matches %q, expected %q", test.expression, matches, test.matches)
+		}
+	}
+}
+
+func TestParseReleaseFilterErrors(t *testing.T) {
+	tests := []struct {
+		expression string
+		err        string
+	}{
+		{"", "unexpected end of expression"},
+		{"repo", "unexpected end of expression"},
+		{"repo==", "unexpected end of expression"},
+		{"name==vault", "unknown field 'name', supported fields: acknowledged, channel, ci, "},
+		{"'repo'==vault", "unknown field 'repo'"},
+		{"repo 'is' vault", "expected ==, !=, =~ or !~ after repo, got 'is'"},
+		{"repo vault x", "expected ==, !=, =~ or !~ after repo, got 'vault'"},
+		{"repo==&&", "expected a value after repo==, got '&&'"},
+		{"repo=='vault", "unterminated quote at position 7"},
+		{"(repo==vault", "missing ')'"},
+		{"repo==vault)", "unexpected ')'"},
+		{"repo==vault tag==v1", "unexpected 'tag'"},
+		{"tag=~(", "expected a value after tag=~, got '('"},
+		{"tag=~'('", "invalid regular expression '('"},
+		{"repo==vault & tag==v1", "unexpected '&' at position 13"},
+	}
+	for _, test := range tests {
+		_, err := parseReleaseFilter(test.expression)
+		if err == nil || !strings.HasPrefix(err.Error(), test.err) {
+			t.Errorf("%q: error %v, expected %q", test.expression, err, test.err)
+		}
+	}
+}
+
+func TestFilterReport(t *testing.T) {
+	report := newFilterTestReport()
+	filter, err := parseReleaseFilter("prerelease==false")
+	if err != nil {
+		t.Fatal(err)
+	}
+	filterReport(report, filter)
+	if matches := matchingReleases(report, func(*reportModel, *repository, *release) bool { return true }); matches !=
+		"terraform@v0.11.8 vault@v1.0.0 consul-template@v0.19.5 (hotfix)" {
+		t.Errorf("kept %q", matches)
+	}
+}
@@ -0,0 +1,125 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"grm/schema"
+)
+
+// reportSchema is the contract of the json report format. Properties are only added, changes of
+// existing properties require a new $id.
+const reportSchema = `{
+  "$schema": "https://json-schema.org/draft/2020-12/schema",
+  "$id": "grm-report-v1",
+  "title": "GRM report",
+  "description": "New releases and findings of a remote definition, written by grm report --format json",
+  "type": "object",
+  "required": ["account", "findings", "generated", "releases", "remote"],
+  "additionalProperties": false,
+  "properties": {
+    "account": {"type": "string", "description": "The scanned account"},
+    "generated": {"type": "string", "format": "date-time"},
+    "remote": {"type": "string", "description": "The name of the remote definition"},
+    "releases": {
+      "type": "array",
+      "items": {
+        "type": "object",
+        "required": ["repository", "tag", "version", "released", "milestone_url", "references"],
+        "additionalProperties": false,
+        "properties": {
+          "repository": {"type": "string"},
+          "owner": {"type": "string", "description": "The owner property of the repository"},
+          "tag": {"type": "string"},
+          "version": {"type": "string", "description": "The version extracted from the tag"},
+          "released": {"type": "string", "format": "date-time"},
+          "severity": {"enum": ["patch", "minor", "major", "breaking"]},
+          "highlights": {"type": "array", "items": {"type": "string"}},
+          "summary": {"type": "string"},
+          "milestone_url": {"type": "string"},
+          "download_url": {"type": "string"},
+          "sha256": {"type": "string"},
+          "references": {
+            "type": ["array", "null"],
+            "items": {
+              "type": "object",
+              "required": ["kind", "id", "url"],
+              "additionalProperties": false,
+              "properties": {
+                "kind": {"type": "string"},
+                "id": {"type": "string"},
+                "url": {"type": "string"}
+              }
+            }
+          },
+          "acknowledged": {"type": "string", "format": "date-time"},
+          "metadata": {
+            "type": "object",
+            "required": ["stars", "open_issues"],
+            "additionalProperties": false,
+            "properties": {
+              "description": {"type": "string"},
+              "stars": {"type": "integer"},
+              "open_issues": {"type": "integer"},
+              "license": {"type": "string"},
+              "topics": {"type": "array", "items": {"type": "string"}}
+            }
+          },
+          "subproject": {"type": "string"},
+          "channel": {"type": "string"},
+          "ci_status": {"type": "string"},
+          "ci_failures": {"type": "array", "items": {"type": "string"}},
+          "app_version": {"type": "string", "description": "The application version packaged by a Helm chart"},
+          "provider": {"type": "string"},
+          "first_seen": {"type": "boolean", "description": "Released is the time of the first report seeing the release"}
+        }
+      }
+    },
+    "findings": {
+      "type": "array",
+      "items": {
+        "type": "object",
+        "required": ["repository", "kind", "message"],
+        "additionalProperties": false,
+        "properties": {
+          "repository": {"type": "string"},
+          "kind": {"enum": ["stale", "silent", "burst", "maintainers-changed", "checksum-changed", "announcement", "license-changed", "license-not-allowed"]},
+          "message": {"type": "string"}
+        }
+      }
+    }
+  }
+}
+`
+
+// schemas are the published JSON schemas by name, printed by grm schema
+var schemas = map[string]string{
+	"report": reportSchema,
+}
+
+func schemaNames() string {
+	names := make([]string, 0, len(schemas))
+	for name := range schemas {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return strings.Join(names, ", ")
+}
+
+// validateOutput checks a json document written by GRM against its schema, a violation is a bug of
+// GRM and fails the command
+func validateOutput(name string, document []byte) {
+	s, err := schema.Parse([]byte(schemas[name]))
+	if err != nil {
+		log.Fatal(fmt.Sprintf("Could not parse the %s schema: ", name), err)
+	}
+	violations, err := s.Validate(document)
+	if err != nil {
+		log.Fatal(fmt.Sprintf("Could not parse the %s output: ", name), err)
+	}
+	if len(violations) > 0 {
+		log.Fatal(fmt.Sprintf("The %s output violates its schema, please report this as a bug:\n  %s", name,
+			strings.Join(violations, "\n  ")))
+	}
+}
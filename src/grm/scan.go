@@ -0,0 +1,124 @@
+package main
+
+import (
+	"os/exec"
+	"runtime"
+	"strings"
+	"bytes"
+	"time"
+	"io/ioutil"
+	"fmt"
+	"grm/config"
+)
+
+// Scanners run against every downloaded asset, a nonzero exit code fails the scan
+var scannerKeys = []config.Key{config.VirusScanCmd, config.SbomCmd}
+
+type scanVerdict struct {
+	Asset   string    `json:"asset"`
+	Scanner string    `json:"scanner"`
+	Passed  bool      `json:"passed"`
+	Output  string    `json:"output,omitempty"`
+	Scanned time.Time `json:"scanned"`
+}
+
+func (v scanVerdict) String() string {
+	verdict := "passed"
+	if !v.Passed {
+		verdict = "FAILED"
+	}
+	if v.Output != "" {
+		return fmt.Sprintf("%s %s: %s (%s)", v.Scanner, verdict, v.Asset, v.Output)
+	}
+	return fmt.Sprintf("%s %s: %s", v.Scanner, verdict, v.Asset)
+}
+
+func scanAssets(name, repository string, assets []downloadedAsset) []scanVerdict {
+	verdicts := make([]scanVerdict, 0)
+	for _, key := range scannerKeys {
+		command, ok := namedSectionGet(name, config.Remote, key, repository)
+		if !ok || strings.TrimSpace(command) == "" {
+			continue
+		}
+
+		scanner := strings.TrimSuffix(key.Name(), "-cmd")
+		for _, asset := range assets {
+			fmt.Println(fmt.Sprintf("Running %s on %s...", scanner, asset.Name))
+			verdict := runScanner(scanner, command, asset, key == config.SbomCmd)
+
+			// The output of SBOM generators is the actual artifact, keep it next to the asset
+			if key == config.SbomCmd && verdict.Passed && verdict.Output != "" {
+				sbom := asset.Path + ".sbom"
+				if err := ioutil.WriteFile(sbom, []byte(verdict.Output), 0644); err == nil {
+					verdict.Output = "written to " + sbom
+				}
+			}
+			verdicts = append(verdicts, verdict)
+		}
+	}
+	return verdicts
+}
+
+// runScanner executes the scanner command through the shell, the asset's path replaces the {file}
+// placeholder or is appended to the command
+func runScanner(scanner, command string, asset downloadedAsset, keepOutput bool) scanVerdict {
+	var cmd *exec.Cmd
+	if runtime.GOOS == "windows" {
+		if strings.Contains(command, "{file}") {
+			command = strings.Replace(command, "{file}", "\""+asset.Path+"\"", -1)
+		} else {
+			command = command + " \"" + asset.Path + "\""
+		}
+		cmd = shellCommand(command)
+	} else {
+		if strings.Contains(command, "{file}") {
+			command = strings.Replace(command, "{file}", "\"$1\"", -1)
+		} else {
+			command = command + " \"$1\""
+		}
+		cmd = shellCommand(command, asset.Path)
+	}
+
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	err := cmd.Run()
+
+	verdict := scanVerdict{
+		Asset:   asset.Name,
+		Scanner: scanner,
+		Passed:  err == nil,
+		Scanned: time.Now().UTC(),
+	}
+
+	if keepOutput && err == nil {
+		verdict.Output = stdout.String()
+	} else {
+		output := strings.TrimSpace(stdout.String() + "\n" + stderr.String())
+		if lines := strings.Split(output, "\n"); len(lines) > 0 {
+			// The last line usually contains the summary
+			verdict.Output = strings.TrimSpace(lines[len(lines)-1])
+		}
+		if verdict.Output == "" && err != nil {
+			verdict.Output = err.Error()
+		}
+	}
+	return verdict
+}
+
+// shellCommand runs the command through the platform's shell, on Unix the arguments are available as $1, $2, ...
+func shellCommand(command string, args ...string) *exec.Cmd {
+	if runtime.GOOS == "windows" {
+		return exec.Command("cmd", "/C", command)
+	}
+	return exec.Command("sh", append([]string{"-c", command, "sh"}, args...)...)
+}
+
+func scansPassed(verdicts []scanVerdict) bool {
+	for _, verdict := range verdicts {
+		if !verdict.Passed {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,270 @@
+package main
+
+import (
+	"github.com/google/go-github/github"
+	"fmt"
+	"html"
+	"log"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+	"grm/config"
+)
+
+// Versions found on scraped pages without a scrape-pattern, like 1.2 or 2.10.3
+const defaultScrapePattern = `\d+(\.\d+)+`
+
+// buildScrapeReport extracts the versions of the projects listed by the projects property from the
+// pages of the scrape-url property, a last resort for upstreams without any API. A page has no
+// dates, versions are dated by the release history, new versions by now.
+func buildScrapeReport(name, repositoryPattern string, since time.Time) *reportModel {
+	client := newProviderClient(name)
+	repos := readListedRepositories(name, config.Projects, repositoryPattern)
+	return buildProviderReport(name, "", repos, since, func(project string) []providerRelease {
+		return scrapeVersions(client, name, project)
+	})
+}
+
+func scrapeVersions(client *http.Client, name, project string) []providerRelease {
+	pageUrl, ok := namedSectionGet(name, config.Remote, config.ScrapeUrl, project)
+	if !ok || pageUrl == "" {
+		log.Fatal(fmt.Sprintf("No %s defined for project %s", config.ScrapeUrl.Name(), project))
+	}
+	pageUrl = strings.Replace(pageUrl, "{repository}", project, -1)
+
+	pattern := defaultScrapePattern
+	if p, ok := namedSectionGet(name, config.Remote, config.ScrapePattern, project); ok && p != "" {
+		pattern = p
+	}
+	versionPattern, err := regexp.Compile(pattern)
+	if err != nil {
+		log.Fatal(fmt.Sprintf("Cannot compile regex: %s", pattern))
+	}
+
+	request, err := http.NewRequest("GET", pageUrl, nil)
+	if err != nil {
+		log.Fatal(fmt.Sprintf("Could not create request for %s: ", pageUrl), err)
+	}
+	page := readProvider(client, request)
+
+	texts := []string{string(page)}
+	if selector, ok := namedSectionGet(name, config.Remote, config.ScrapeSelector, project); ok && selector != "" {
+		if texts, err = selectHtml(page, selector); err != nil {
+			log.Fatal(fmt.Sprintf("Could not select '%s' on %s: ", selector, pageUrl), err)
+		}
+	}
+
+	known := make(map[string]time.Time)
+	for _, entry := range readHistory(name, project) {
+		known[entry.Tag] = entry.Released
+	}
+	downloadUrl, _ := namedSectionGet(name, config.Remote, config.DownloadUrl, project)
+
+	now := time.Now().UTC()
+	tags := make([]providerRelease, 0)
+	seen := make(map[string]bool)
+	for _, text := range texts {
+		// The first group of the pattern is the version, the whole match without groups
+		for _, match := range versionPattern.FindAllStringSubmatch(text, -1) {
+			version := match[0]
+			if len(match) > 1 {
+				version = match[1]
+			}
+			if version == "" || seen[version] {
+				continue
+			}
+			seen[version] = true
+
+			tag := providerRelease{version: version, created: now, firstSeen: true, notesUrl: pageUrl}
+			if released, ok := known[version]; ok {
+				tag.created = released
+			} else if downloadUrl != "" {
+				tag.downloadUrl = buildDownloadUrl("", project, downloadUrl, &github.Milestone{Title: github.String(version)})
+			}
+			tags = append(tags, tag)
+		}
+	}
+	if len(tags) == 0 {
+		log.Fatal(fmt.Sprintf("No versions of project %s found on %s", project, pageUrl))
+	}
+	return tags
+}
+
+// htmlSelector is a small subset of CSS selectors: compound selectors of a tag name, .class, #id,
+// [attribute] and [attribute=value] combined by descendant, with an optional @attribute suffix which
+// selects the value of the attribute instead of the text of the element, like "a.download@href"
+type htmlSelector struct {
+	steps     []htmlSelectorStep
+	attribute string
+}
+
+type htmlSelectorStep struct {
+	tag        string
+	classes    []string
+	attributes map[string]*string
+}
+
+var selectorStepPattern = regexp.MustCompile(`^([a-zA-Z][a-zA-Z0-9-]*|\*)?((?:\.[\w-]+|#[\w-]+|\[[\w-]+(?:=(?:"[^"]*"|'[^']*'|[^\]]*))?\])*)$`)
+var selectorPartPattern = regexp.MustCompile(`\.([\w-]+)|#([\w-]+)|\[([\w-]+)(?:=("[^"]*"|'[^']*'|[^\]]*))?\]`)
+
+func parseHtmlSelector(selector string) (htmlSelector, error) {
+	parsed := htmlSelector{}
+	if at := strings.LastIndex(selector, "@"); at >= 0 {
+		selector, parsed.attribute = selector[:at], strings.ToLower(strings.TrimSpace(selector[at+1:]))
+	}
+	for _, compound := range strings.Fields(selector) {
+		match := selectorStepPattern.FindStringSubmatch(compound)
+		if match == nil {
+			return parsed, fmt.Errorf("unsupported selector '%s'", compound)
+		}
+		step := htmlSelectorStep{tag: strings.ToLower(match[1]), attributes: make(map[string]*string)}
+		for _, part := range selectorPartPattern.FindAllStringSubmatch(match[2], -1) {
+			switch {
+			case part[1] != "":
+				step.classes = append(step.classes, part[1])
+			case part[2] != "":
+				id := part[2]
+				step.attributes["id"] = &id
+			case strings.HasPrefix(part[0], "[") && strings.Contains(part[0], "="):
+				value := strings.Trim(part[4], "\"'")
+				step.attributes[strings.ToLower(part[3])] = &value
+			default:
+				step.attributes[strings.ToLower(part[3])] = nil
+			}
+		}
+		parsed.steps = append(parsed.steps, step)
+	}
+	if len(parsed.steps) == 0 {
+		return parsed, fmt.Errorf("empty selector")
+	}
+	return parsed, nil
+}
+
+func (s htmlSelectorStep) matches(element htmlElement) bool {
+	if s.tag != "" && s.tag != "*" && s.tag != element.tag {
+		return false
+	}
+	for _, class := range s.classes {
+		found := false
+		for _, c := range strings.Fields(element.attributes["class"]) {
+			found = found || c == class
+		}
+		if !found {
+			return false
+		}
+	}
+	for name, value := range s.attributes {
+		actual, ok := element.attributes[name]
+		if !ok || (value != nil && actual != *value) {
+			return false
+		}
+	}
+	return true
+}
+
+// matches returns whether the last element of the path matches the last step and its ancestors the
+// other steps in order
+func (s htmlSelector) matches(path []htmlElement) bool {
+	last := len(s.steps) - 1
+	if len(path) == 0 || !s.steps[last].matches(path[len(path)-1]) {
+		return false
+	}
+	step := last - 1
+	for i := len(path) - 2; i >= 0 && step >= 0; i-- {
+		if s.steps[step].matches(path[i]) {
+			step--
+		}
+	}
+	return step < 0
+}
+
+// htmlElement is an open element of the scanned page
+type htmlElement struct {
+	tag        string
+	attributes map[string]string
+	// text collects the text of a selected element, nil for elements which are not selected
+	text *strings.Builder
+}
+
+var (
+	htmlTagPattern       = regexp.MustCompile(`(?s)<!--.*?-->|<[!?][^>]*>|<(/?)([a-zA-Z][a-zA-Z0-9:-]*)((?:"[^"]*"|'[^']*'|[^'">])*)>`)
+	htmlAttributePattern = regexp.MustCompile(`([^\s"'>/=]+)(?:\s*=\s*("[^"]*"|'[^']*'|[^\s"'>]+))?`)
+	// Blocks which are no markup, their content is skipped
+	htmlRawBlocks = regexp.MustCompile(`(?is)<(script|style|template)\b.*?</(script|style|template)\s*>`)
+)
+
+// Elements without end tag
+var htmlVoidElements = map[string]bool{
+	"area": true, "base": true, "br": true, "col": true, "embed": true, "hr": true, "img": true, "input": true,
+	"link": true, "meta": true, "source": true, "track": true, "wbr": true,
+}
+
+// selectHtml returns the texts, or attribute values, of the elements of the page matching the
+// selector. Pages are scanned leniently, an end tag closes the elements opened after its own start
+// tag and stray end tags are ignored.
+func selectHtml(page []byte, selector string) ([]string, error) {
+	parsed, err := parseHtmlSelector(selector)
+	if err != nil {
+		return nil, err
+	}
+
+	selected := make([]string, 0)
+	path := make([]htmlElement, 0)
+	closeTo := func(depth int) {
+		for len(path) > depth {
+			if element := path[len(path)-1]; element.text != nil {
+				selected = append(selected, strings.TrimSpace(element.text.String()))
+			}
+			path = path[:len(path)-1]
+		}
+	}
+	addText := func(text string) {
+		for _, element := range path {
+			if element.text != nil {
+				element.text.WriteString(html.UnescapeString(text))
+			}
+		}
+	}
+
+	content := string(htmlRawBlocks.ReplaceAll(page, nil))
+	position := 0
+	for _, match := range htmlTagPattern.FindAllStringSubmatchIndex(content, -1) {
+		addText(content[position:match[0]])
+		position = match[1]
+		if match[4] < 0 {
+			// Comments, doctype and processing instructions
+			continue
+		}
+
+		tag := strings.ToLower(content[match[4]:match[5]])
+		if content[match[2]:match[3]] == "/" {
+			for depth := len(path) - 1; depth >= 0; depth-- {
+				if path[depth].tag == tag {
+					closeTo(depth)
+					break
+				}
+			}
+			continue
+		}
+
+		element := htmlElement{tag: tag, attributes: make(map[string]string)}
+		for _, attribute := range htmlAttributePattern.FindAllStringSubmatch(content[match[6]:match[7]], -1) {
+			element.attributes[strings.ToLower(attribute[1])] = html.UnescapeString(strings.Trim(attribute[2], "\"'"))
+		}
+		path = append(path, element)
+		if parsed.matches(path) {
+			if parsed.attribute == "" {
+				path[len(path)-1].text = &strings.Builder{}
+			} else if value, ok := element.attributes[parsed.attribute]; ok {
+				selected = append(selected, value)
+			}
+		}
+		if htmlVoidElements[tag] || strings.HasSuffix(content[match[6]:match[7]], "/") {
+			closeTo(len(path) - 1)
+		}
+	}
+	addText(content[position:])
+	closeTo(0)
+	return selected, nil
+}
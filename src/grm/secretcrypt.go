@@ -0,0 +1,395 @@
+package main
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"os/exec"
+	"strings"
+	"sync"
+	"grm/config"
+)
+
+// Encryption schemes of stored passwords. machine encrypts with AES and a key derived from the machine
+// id and the remote definition, the configuration can't be moved to another machine. key does the same
+// with a key provided from outside GRM, like a Kubernetes secret. age encrypts to the recipients of a
+// recipients file, any machine with one of the identities, including hardware keys through age
+// plugins, can decrypt. ssh-agent derives the key from a signature of the SSH agent, the passwords can
+// only be decrypted while the SSH key is loaded.
+const (
+	encryptionMachine  = "machine"
+	encryptionKey      = "key"
+	encryptionAge      = "age"
+	encryptionSshAgent = "ssh-agent"
+)
+
+// Passwords encrypted with a key of the remote definition are stored as machine:<key salt>:<password>.
+// Passwords without prefix were encrypted with the machine key shared by all remote definitions.
+const machineSecretPrefix = "machine:"
+
+// Passwords encrypted with a key derived from the encryption-key are stored as key:<key salt>:<password>
+const keySecretPrefix = "key:"
+
+// Passwords encrypted with age are stored with this prefix and without salt
+const ageSecretPrefix = "age:"
+
+// Passwords encrypted with the SSH agent are stored as ssh-agent:<challenge>:<password>, the challenge
+// is signed to derive the key
+const sshAgentSecretPrefix = "ssh-agent:"
+
+// Signed challenges are prefixed to not sign anything else with the SSH key
+const sshAgentChallengeNamespace = "grm-secret-key:"
+
+// storedSecret is an encrypted password of a remote definition and the key of its salt
+type storedSecret struct {
+	password, salt config.Key
+}
+
+// Passwords stored by grm auth
+var storedSecrets = []storedSecret{{config.Password, config.Salt}, {config.XmppPassword, config.XmppSalt}}
+
+// Decrypted age and SSH agent secrets, each decryption may ask for the PIN or a touch of a hardware key
+var decryptedSecrets = struct {
+	sync.Mutex
+	values map[string]string
+}{values: make(map[string]string)}
+
+func readEncryption() string {
+	value, ok := sectionGet(config.Core, config.Encryption, "")
+	if !ok || value == "" {
+		return encryptionMachine
+	}
+	switch value = strings.ToLower(value); value {
+	case encryptionMachine, encryptionKey, encryptionAge, encryptionSshAgent:
+		return value
+	}
+	log.Fatal(fmt.Sprintf("Unknown %s '%s', supported are: %s, %s, %s, %s", config.Encryption.Name(), value,
+		encryptionMachine, encryptionKey, encryptionAge, encryptionSshAgent))
+	return ""
+}
+
+// encryptSecret encrypts a password of the remote definition with the configured encryption, returns
+// the encrypted password and its salt. Every password gets a key of its own.
+func encryptSecret(name, value string) (string, string) {
+	encryption := readEncryption()
+	if err := checkCryptoPolicy(encryption); err != nil {
+		log.Fatal("Could not encrypt password: ", err)
+	}
+
+	switch encryption {
+	case encryptionKey:
+		return sealRemoteSecret(keySecretPrefix, readEncryptionKey(), name, value)
+	case encryptionAge:
+		return ageEncryptSecret(value)
+	case encryptionSshAgent:
+		return sshAgentEncryptSecret(value)
+	}
+	return sealRemoteSecret(machineSecretPrefix, generateMachineKey(), name, value)
+}
+
+// decryptSecret decrypts a password of the remote definition stored by encryptSecret, with the
+// encryption it was stored with
+func decryptSecret(name, value, salt string) string {
+	if err := checkCryptoPolicy(secretScheme(value)); err != nil {
+		log.Fatal(fmt.Sprintf("Could not decrypt the password of remote definition %s: ", name), err)
+	}
+
+	if strings.HasPrefix(value, keySecretPrefix) {
+		return openRemoteSecret(keySecretPrefix, readEncryptionKey(), name, value, salt)
+	}
+	if strings.HasPrefix(value, machineSecretPrefix) {
+		return openRemoteSecret(machineSecretPrefix, generateMachineKey(), name, value, salt)
+	}
+	if !strings.HasPrefix(value, ageSecretPrefix) && !strings.HasPrefix(value, sshAgentSecretPrefix) {
+		return decrypt(value, salt, generateMachineKey())
+	}
+
+	decryptedSecrets.Lock()
+	defer decryptedSecrets.Unlock()
+	if decrypted, ok := decryptedSecrets.values[value]; ok {
+		return decrypted
+	}
+
+	var decrypted string
+	if strings.HasPrefix(value, ageSecretPrefix) {
+		decrypted = ageDecryptSecret(value)
+	} else {
+		decrypted = sshAgentDecryptSecret(value, salt)
+	}
+	decryptedSecrets.values[value] = decrypted
+	return decrypted
+}
+
+// sealRemoteSecret encrypts the password with a key derived from the base key, the remote definition
+// and a random key salt
+func sealRemoteSecret(prefix string, base []byte, name, value string) (string, string) {
+	keySalt := make([]byte, 16)
+	if _, err := rand.Read(keySalt); err != nil {
+		log.Fatal("Could not generate a unique password salt: ", err)
+	}
+	encrypted, salt := encrypt(value, deriveRemoteKey(base, name, keySalt))
+	return prefix + base64.StdEncoding.EncodeToString(keySalt) + ":" + encrypted, salt
+}
+
+func openRemoteSecret(prefix string, base []byte, name, value, salt string) string {
+	parts := strings.SplitN(strings.TrimPrefix(value, prefix), ":", 2)
+	keySalt, err := base64.StdEncoding.DecodeString(parts[0])
+	if err != nil || len(parts) != 2 {
+		log.Fatal("Could not decode password, invalid key salt")
+	}
+	return decrypt(parts[1], salt, deriveRemoteKey(base, name, keySalt))
+}
+
+// deriveRemoteKey derives the key of a password of the remote definition from the base key, a
+// password copied to another remote definition can't be decrypted
+func deriveRemoteKey(base []byte, name string, keySalt []byte) []byte {
+	mac := hmac.New(sha256.New, base)
+	mac.Write([]byte("grm-remote:" + name + ":"))
+	mac.Write(keySalt)
+	return mac.Sum(nil)
+}
+
+// secretScheme names the encryption a stored password was encrypted with
+func secretScheme(value string) string {
+	switch {
+	case strings.HasPrefix(value, machineSecretPrefix):
+		return encryptionMachine
+	case strings.HasPrefix(value, keySecretPrefix):
+		return encryptionKey
+	case strings.HasPrefix(value, ageSecretPrefix):
+		return encryptionAge
+	case strings.HasPrefix(value, sshAgentSecretPrefix):
+		return encryptionSshAgent
+	}
+	return encryptionMachine + " (shared key)"
+}
+
+// readEncryptionKey reads the 256 bit key of the key encryption, hex or base64 encoded
+func readEncryptionKey() []byte {
+	value, ok := sectionGet(config.Core, config.EncryptionKey, "")
+	if !ok || value == "" {
+		log.Fatal(fmt.Sprintf("Could not read the encryption key, key encryption requires the global %s", config.EncryptionKey.Name()))
+	}
+	value = strings.TrimSpace(value)
+	key, err := hex.DecodeString(value)
+	if err != nil {
+		key, err = base64.StdEncoding.DecodeString(value)
+	}
+	if err != nil || len(key) != 32 {
+		log.Fatal(fmt.Sprintf("Could not read the encryption key, %s must be 32 bytes, hex or base64 encoded", config.EncryptionKey.Name()))
+	}
+	return key
+}
+
+func ageEncryptSecret(value string) (string, string) {
+	recipients, ok := sectionGet(config.Core, config.AgeRecipients, "")
+	if !ok || recipients == "" {
+		log.Fatal(fmt.Sprintf("Could not encrypt password, age encryption requires the global %s", config.AgeRecipients.Name()))
+	}
+	encrypted, err := runAge(value, "--encrypt", "--recipients-file", recipients)
+	if err != nil {
+		log.Fatal("Could not encrypt password with age: ", err)
+	}
+	return ageSecretPrefix + base64.StdEncoding.EncodeToString(encrypted), ""
+}
+
+func ageDecryptSecret(value string) string {
+	data, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(value, ageSecretPrefix))
+	if err != nil {
+		log.Fatal("Could not decode password: ", err)
+	}
+	identity, ok := sectionGet(config.Core, config.AgeIdentity, "")
+	if !ok || identity == "" {
+		log.Fatal(fmt.Sprintf("Could not decrypt password, age encryption requires the global %s", config.AgeIdentity.Name()))
+	}
+	decrypted, err := runAge(string(data), "--decrypt", "--identity", identity)
+	if err != nil {
+		log.Fatal("Could not decrypt password with age: ", err)
+	}
+	return string(decrypted)
+}
+
+func sshAgentEncryptSecret(value string) (string, string) {
+	agent, err := connectSshAgent()
+	if err != nil {
+		log.Fatal("Could not encrypt password with the SSH agent: ", err)
+	}
+	defer agent.Close()
+
+	keys, err := readSshAgentKeys(agent)
+	if err != nil {
+		log.Fatal("Could not encrypt password with the SSH agent: ", err)
+	}
+	challenge := make([]byte, 32)
+	if _, err := rand.Read(challenge); err != nil {
+		log.Fatal("Could not generate a unique password challenge: ", err)
+	}
+
+	key, err := deterministicSshAgentKey(agent, keys, challenge)
+	if err != nil {
+		log.Fatal("Could not encrypt password with the SSH agent: ", err)
+	}
+
+	encrypted, salt := encrypt(value, key)
+	return sshAgentSecretPrefix + base64.StdEncoding.EncodeToString(challenge) + ":" + encrypted, salt
+}
+
+// sshAgentDecryptSecret tries the agent's keys until one decrypts the password
+func sshAgentDecryptSecret(value, salt string) string {
+	parts := strings.SplitN(strings.TrimPrefix(value, sshAgentSecretPrefix), ":", 2)
+	challenge, err := base64.StdEncoding.DecodeString(parts[0])
+	if err != nil || len(parts) != 2 {
+		log.Fatal("Could not decode password, invalid SSH agent challenge")
+	}
+
+	agent, err := connectSshAgent()
+	if err != nil {
+		log.Fatal("Could not decrypt password with the SSH agent: ", err)
+	}
+	defer agent.Close()
+
+	keys, err := agent.keys()
+	if err != nil {
+		log.Fatal("Could not decrypt password with the SSH agent: ", err)
+	}
+	for _, k := range keys {
+		key, err := deriveSshAgentKey(agent, k, challenge)
+		if err != nil {
+			continue
+		}
+		if decrypted, err := openSecret(parts[1], salt, key); err == nil {
+			return decrypted
+		}
+	}
+	log.Fatal("Could not decrypt password, it was encrypted with an SSH key not loaded into the SSH agent")
+	return ""
+}
+
+// readSshAgentKeys returns the keys of the agent to encrypt with, the configured ssh-agent-key or all keys
+func readSshAgentKeys(agent *sshAgent) ([]sshAgentKey, error) {
+	keys, err := agent.keys()
+	if err != nil {
+		return nil, err
+	}
+	if len(keys) == 0 {
+		return nil, errors.New("the SSH agent holds no keys, please add one with ssh-add")
+	}
+
+	selected, ok := sectionGet(config.Core, config.SshAgentKey, "")
+	if !ok || selected == "" {
+		return keys, nil
+	}
+	for _, key := range keys {
+		if key.fingerprint() == selected || key.comment == selected {
+			return []sshAgentKey{key}, nil
+		}
+	}
+	return nil, fmt.Errorf("the SSH agent holds no key %s", selected)
+}
+
+// deterministicSshAgentKey derives the key from the first of the keys signing the challenge again with
+// the same signature, ECDSA and FIDO keys are skipped because their signatures differ every time
+func deterministicSshAgentKey(agent *sshAgent, keys []sshAgentKey, challenge []byte) ([]byte, error) {
+	for _, k := range keys {
+		key, err := deriveSshAgentKey(agent, k, challenge)
+		if err != nil {
+			continue
+		}
+		if again, err := deriveSshAgentKey(agent, k, challenge); err == nil && bytes.Equal(key, again) {
+			return key, nil
+		}
+	}
+	return nil, errors.New("the signatures of the keys of the SSH agent are not deterministic, please add an Ed25519 or RSA key")
+}
+
+// deriveSshAgentKey derives an AES key from the signature of the challenge
+func deriveSshAgentKey(agent *sshAgent, key sshAgentKey, challenge []byte) ([]byte, error) {
+	signature, err := agent.sign(key, append([]byte(sshAgentChallengeNamespace), challenge...))
+	if err != nil {
+		return nil, err
+	}
+	hash := sha256.Sum256(signature)
+	return hash[:], nil
+}
+
+// openSecret decrypts a password encrypted by encrypt, unlike decrypt a wrong key is no fatal error
+func openSecret(value, salt string, key []byte) (string, error) {
+	data, err := base64.StdEncoding.DecodeString(value)
+	if err != nil {
+		return "", err
+	}
+	nonce, err := base64.StdEncoding.DecodeString(salt)
+	if err != nil {
+		return "", err
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return "", err
+	}
+	aesgcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return "", err
+	}
+	if len(nonce) != aesgcm.NonceSize() {
+		return "", errors.New("invalid password salt")
+	}
+	decrypted, err := aesgcm.Open(nil, nonce, data, nil)
+	return string(decrypted), err
+}
+
+// isEncryptedWithoutSalt returns true for passwords which need no salt to be decrypted
+func isEncryptedWithoutSalt(value string) bool {
+	return strings.HasPrefix(value, ageSecretPrefix)
+}
+
+// runAge pipes the input through the age command, plugins of hardware keys talk to the terminal
+func runAge(input string, args ...string) ([]byte, error) {
+	if _, err := exec.LookPath("age"); err != nil {
+		return nil, fmt.Errorf("age encryption requires 'age' to be installed")
+	}
+	var output, stderr bytes.Buffer
+	cmd := exec.Command("age", args...)
+	cmd.Stdin = strings.NewReader(input)
+	cmd.Stdout = &output
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("%s: %s", err, strings.TrimSpace(stderr.String()))
+	}
+	return output.Bytes(), nil
+}
+
+// setEncryptedSecret stores an encrypted password with its salt, age encrypted passwords have none
+func setEncryptedSecret(mutator config.Mutator, name string, passwordKey, saltKey config.Key, value string) {
+	encrypted, salt := encryptSecret(name, value)
+	mutator.NamedSectionSet(name, config.Remote, passwordKey, "", encrypted)
+	if salt != "" {
+		mutator.NamedSectionSet(name, config.Remote, saltKey, "", salt)
+	} else {
+		mutator.NamedSectionDelete(name, config.Remote, saltKey, "")
+	}
+}
+
+// reencryptSecrets encrypts the stored passwords of the remote definition again with the configured
+// encryption and new keys, e.g. after switching from machine to age or ssh-agent. Other remote
+// definitions are not touched.
+func reencryptSecrets(name string) {
+	applyChanges(func(mutator config.Mutator) {
+		for _, s := range storedSecrets {
+			password, ok := namedSectionGet(name, config.Remote, s.password, "")
+			if !ok || password == "" {
+				continue
+			}
+			salt, _ := namedSectionGet(name, config.Remote, s.salt, "")
+			setEncryptedSecret(mutator, name, s.password, s.salt, decryptSecret(name, password, salt))
+		}
+	})
+	fmt.Println(fmt.Sprintf("Re-encrypted the credentials of remote definition %s with %s encryption", name, readEncryption()))
+}
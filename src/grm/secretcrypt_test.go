@@ -0,0 +1,161 @@
+package main
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// Stand-in for age, grm only pipes the passwords through it. It checks the recipients and identity
+// files are passed on and refuses data it didn't seal.
+const fakeAge = `#!/bin/sh
+[ -f "$3" ] || { echo "$3: no such file" >&2; exit 1; }
+case "$1" in
+--encrypt) printf 'sealed:'; cat ;;
+--decrypt)
+	data=$(cat)
+	case "$data" in
+	sealed:*) printf '%s' "${data#sealed:}" ;;
+	*) echo "no identity matched any of the recipients" >&2; exit 1 ;;
+	esac ;;
+esac
+`
+
+func TestAgeEncryption(t *testing.T) {
+	dir := t.TempDir()
+	if err := ioutil.WriteFile(filepath.Join(dir, "age"), []byte(fakeAge), 0700); err != nil {
+		t.Fatal(err)
+	}
+	for _, file := range []string{"recipients", "identity"} {
+		if err := ioutil.WriteFile(filepath.Join(dir, file), []byte("age1..."), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	t.Setenv("PATH", dir+string(os.PathListSeparator)+os.Getenv("PATH"))
+	useConfiguration(t, fmt.Sprintf("[Core]\nencryption = age\nage-recipients = %s\nage-identity = %s\n",
+		filepath.Join(dir, "recipients"), filepath.Join(dir, "identity")))
+
+	tests := []string{"s3cret", "", "with spaces and ✓", strings.Repeat("long", 100)}
+	for _, password := range tests {
+		encrypted, salt := encryptSecret("hashicorp", password)
+		if !strings.HasPrefix(encrypted, ageSecretPrefix) || salt != "" {
+			t.Errorf("%q: encrypted to %q with salt %q", password, encrypted, salt)
+		}
+		if !isEncryptedWithoutSalt(encrypted) || secretScheme(encrypted) != encryptionAge {
+			t.Errorf("%q: scheme %s", password, secretScheme(encrypted))
+		}
+		if decrypted := decryptSecret("hashicorp", encrypted, ""); decrypted != password {
+			t.Errorf("%q: decrypted %q", password, decrypted)
+		}
+	}
+
+	// Decrypted passwords are kept, age isn't run again
+	encrypted, _ := encryptSecret("hashicorp", "cached")
+	if decryptSecret("hashicorp", encrypted, "") != "cached" {
+		t.Fatal("not decrypted")
+	}
+	t.Setenv("PATH", t.TempDir())
+	if decrypted := decryptSecret("hashicorp", encrypted, ""); decrypted != "cached" {
+		t.Errorf("decrypted %q from the cache", decrypted)
+	}
+	if _, err := runAge("sealed:x", "--decrypt", "--identity", filepath.Join(dir, "identity")); err == nil ||
+		!strings.Contains(err.Error(), "requires 'age' to be installed") {
+		t.Errorf("error %v without age", err)
+	}
+}
+
+func TestRemoteSecretKeys(t *testing.T) {
+	useConfiguration(t, "")
+	base := []byte(strings.Repeat("k", 32))
+	tests := []struct {
+		name     string
+		password string
+	}{
+		{"hashicorp", "s3cret"},
+		{"hashicorp-enterprise", ""},
+		{"with spaces", "with spaces and ✓"},
+	}
+	for _, test := range tests {
+		encrypted, salt := sealRemoteSecret(machineSecretPrefix, base, test.name, test.password)
+		if secretScheme(encrypted) != encryptionMachine || isEncryptedWithoutSalt(encrypted) {
+			t.Errorf("%s: scheme %s", test.name, secretScheme(encrypted))
+		}
+		if decrypted := openRemoteSecret(machineSecretPrefix, base, test.name, encrypted, salt); decrypted != test.password {
+			t.Errorf("%s: decrypted %q", test.name, decrypted)
+		}
+
+		// The key depends on the remote definition and the key salt, a password copied to another
+		// remote definition can't be decrypted
+		parts := strings.SplitN(strings.TrimPrefix(encrypted, machineSecretPrefix), ":", 2)
+		keySalt, err := base64.StdEncoding.DecodeString(parts[0])
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := openSecret(parts[1], salt, deriveRemoteKey(base, test.name, keySalt)); err != nil {
+			t.Errorf("%s: %s", test.name, err)
+		}
+		if _, err := openSecret(parts[1], salt, deriveRemoteKey(base, test.name+"-copy", keySalt)); err == nil {
+			t.Errorf("%s: decrypted with the key of another remote definition", test.name)
+		}
+		if _, err := openSecret(parts[1], salt, deriveRemoteKey(base, test.name, make([]byte, 16))); err == nil {
+			t.Errorf("%s: decrypted with another key salt", test.name)
+		}
+		if other, _ := sealRemoteSecret(machineSecretPrefix, base, test.name, test.password); other == encrypted {
+			t.Errorf("%s: same encrypted password twice", test.name)
+		}
+	}
+
+	// Machine encryption is the default, passwords of older releases use the shared machine key
+	encrypted, salt := encryptSecret("hashicorp", "s3cret")
+	if !strings.HasPrefix(encrypted, machineSecretPrefix) || decryptSecret("hashicorp", encrypted, salt) != "s3cret" {
+		t.Errorf("machine encryption: %q", encrypted)
+	}
+	shared, salt := encrypt("s3cret", generateMachineKey())
+	if secretScheme(shared) != "machine (shared key)" || decryptSecret("hashicorp", shared, salt) != "s3cret" {
+		t.Errorf("shared machine key: %q", shared)
+	}
+}
+
+func TestKeyEncryption(t *testing.T) {
+	key := make([]byte, 32)
+	for i := range key {
+		key[i] = byte(i)
+	}
+	tests := []struct {
+		name  string
+		value string
+	}{
+		{"hex", hex.EncodeToString(key)},
+		{"base64", base64.StdEncoding.EncodeToString(key)},
+		{"surrounding spaces", " " + hex.EncodeToString(key) + " "},
+	}
+	for _, test := range tests {
+		useConfiguration(t, fmt.Sprintf("[Core]\nencryption = key\nencryption-key = %s\n", test.value))
+		if !bytes.Equal(readEncryptionKey(), key) {
+			t.Errorf("%s: read key %x", test.name, readEncryptionKey())
+		}
+		encrypted, salt := encryptSecret("hashicorp", "s3cret")
+		if !strings.HasPrefix(encrypted, keySecretPrefix) || secretScheme(encrypted) != encryptionKey {
+			t.Errorf("%s: encrypted to %q", test.name, encrypted)
+		}
+		if decrypted := decryptSecret("hashicorp", encrypted, salt); decrypted != "s3cret" {
+			t.Errorf("%s: decrypted %q", test.name, decrypted)
+		}
+
+		// Without the key the password can't be decrypted, not even with the machine key
+		parts := strings.SplitN(strings.TrimPrefix(encrypted, keySecretPrefix), ":", 2)
+		keySalt, err := base64.StdEncoding.DecodeString(parts[0])
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := openSecret(parts[1], salt, deriveRemoteKey(generateMachineKey(), "hashicorp", keySalt)); err == nil {
+			t.Errorf("%s: decrypted with the machine key", test.name)
+		}
+	}
+}
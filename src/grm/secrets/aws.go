@@ -0,0 +1,48 @@
+package secrets
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"grm/cloud"
+)
+
+// AwsSecretsManager reads a secret of AWS Secrets Manager by its name or ARN, like
+// aws-secretsmanager:grm/github#token. The region of an ARN takes precedence over the configured one.
+// Credentials follow the default credential chain of the AWS SDKs.
+func AwsSecretsManager(reference string) (string, error) {
+	return cached("aws-secretsmanager", reference, func(id string) (string, error) {
+		credentials, err := cloud.ReadAwsCredentials()
+		if err != nil {
+			return "", err
+		}
+		// arn:aws:secretsmanager:<region>:<account>:secret:<name>
+		if arn := strings.Split(id, ":"); len(arn) > 3 && arn[0] == "arn" {
+			credentials.Region = arn[3]
+		}
+
+		endpoint := os.Getenv("AWS_SECRETSMANAGER_ENDPOINT")
+		if endpoint == "" {
+			endpoint = fmt.Sprintf("https://secretsmanager.%s.amazonaws.com", credentials.Region)
+		}
+		body, _ := json.Marshal(map[string]string{"SecretId": id})
+		req, err := http.NewRequest(http.MethodPost, strings.TrimSuffix(endpoint, "/")+"/", bytes.NewReader(body))
+		if err != nil {
+			return "", err
+		}
+		req.Header.Set("Content-Type", "application/x-amz-json-1.1")
+		req.Header.Set("X-Amz-Target", "secretsmanager.GetSecretValue")
+		credentials.SignV4(req, body, "secretsmanager")
+
+		response := struct {
+			SecretString string `json:"SecretString"`
+		}{}
+		if err := readJson(req, &response); err != nil {
+			return "", err
+		}
+		return response.SecretString, nil
+	})
+}
@@ -0,0 +1,50 @@
+package secrets
+
+import (
+	"encoding/base64"
+	"net/http"
+	"os"
+	"strings"
+	"grm/cloud"
+)
+
+const gcpSecretManagerApi = "https://secretmanager.googleapis.com"
+
+// GcpSecretManager reads a secret version of GCP Secret Manager, like
+// gcp-secretmanager:projects/acme/secrets/github-token, by default the latest version. Credentials
+// follow the application default credentials of the Google SDKs.
+func GcpSecretManager(reference string) (string, error) {
+	return cached("gcp-secretmanager", reference, func(name string) (string, error) {
+		if !strings.Contains(name, "/versions/") {
+			name += "/versions/latest"
+		}
+		token, err := cloud.GoogleAccessToken()
+		if err != nil {
+			return "", err
+		}
+
+		api := os.Getenv("GCP_SECRETMANAGER_ENDPOINT")
+		if api == "" {
+			api = gcpSecretManagerApi
+		}
+		req, err := http.NewRequest(http.MethodGet, strings.TrimSuffix(api, "/")+"/v1/"+name+":access", nil)
+		if err != nil {
+			return "", err
+		}
+		req.Header.Set("Authorization", "Bearer "+token)
+
+		response := struct {
+			Payload struct {
+				Data string `json:"data"`
+			} `json:"payload"`
+		}{}
+		if err := readJson(req, &response); err != nil {
+			return "", err
+		}
+		data, err := base64.StdEncoding.DecodeString(response.Payload.Data)
+		if err != nil {
+			return "", err
+		}
+		return string(data), nil
+	})
+}
@@ -0,0 +1,92 @@
+// Package secrets reads credentials from central secret stores: HashiCorp Vault, AWS Secrets Manager
+// and GCP Secret Manager. Configuration values reference secrets like vault:secret/data/grm#token.
+package secrets
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+var client = &http.Client{Timeout: 30 * time.Second}
+
+// Secrets are read once per run, reports read the same properties many times
+var resolved = struct {
+	sync.Mutex
+	values map[string]string
+}{values: make(map[string]string)}
+
+// cached resolves the reference with the store once and splits off the field selected by #field
+func cached(store string, reference string, read func(path string) (string, error)) (string, error) {
+	resolved.Lock()
+	defer resolved.Unlock()
+
+	key := store + ":" + reference
+	if value, ok := resolved.values[key]; ok {
+		return value, nil
+	}
+
+	path, field := splitField(reference)
+	value, err := read(path)
+	if err != nil {
+		return "", fmt.Errorf("could not read secret %s from %s: %s", path, store, err)
+	}
+	if field != "" {
+		if value, err = selectField(value, field); err != nil {
+			return "", fmt.Errorf("could not read secret %s from %s: %s", path, store, err)
+		}
+	}
+	resolved.values[key] = value
+	return value, nil
+}
+
+// splitField splits a reference like secret/data/grm#token into the path and the field
+func splitField(reference string) (string, string) {
+	if i := strings.LastIndex(reference, "#"); i >= 0 {
+		return reference[:i], reference[i+1:]
+	}
+	return reference, ""
+}
+
+// selectField reads a field of a secret holding a JSON object
+func selectField(value, field string) (string, error) {
+	fields := make(map[string]interface{})
+	if err := json.Unmarshal([]byte(value), &fields); err != nil {
+		return "", fmt.Errorf("field %s selected, but the secret is no JSON object", field)
+	}
+	return fieldValue(fields, field)
+}
+
+func fieldValue(fields map[string]interface{}, field string) (string, error) {
+	value, ok := fields[field]
+	if !ok {
+		return "", fmt.Errorf("field %s not found", field)
+	}
+	if s, ok := value.(string); ok {
+		return s, nil
+	}
+	data, _ := json.Marshal(value)
+	return string(data), nil
+}
+
+// readJson executes the request and decodes the JSON response
+func readJson(req *http.Request, value interface{}) error {
+	response, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+
+	data, err := ioutil.ReadAll(response.Body)
+	if err != nil {
+		return err
+	}
+	if response.StatusCode != http.StatusOK {
+		return fmt.Errorf("%s responded with %s %s", req.URL.Host, response.Status, strings.TrimSpace(string(data)))
+	}
+	return json.Unmarshal(data, value)
+}
@@ -0,0 +1,109 @@
+package secrets
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Service account token of Kubernetes pods, used for the Kubernetes auth method of Vault
+const kubernetesServiceAccountToken = "/var/run/secrets/kubernetes.io/serviceaccount/token"
+
+type vaultResponse struct {
+	Data map[string]interface{} `json:"data"`
+	Auth struct {
+		ClientToken string `json:"client_token"`
+	} `json:"auth"`
+}
+
+// Vault reads a secret of a HashiCorp Vault KV secrets engine, like vault:secret/data/grm#token for
+// version 2 or vault:kv/grm#token for version 1, the field defaults to value. The server is read from
+// VAULT_ADDR, the token from VAULT_TOKEN or ~/.vault-token. With VAULT_ROLE the pod logs in with the
+// Kubernetes auth method, mounted at VAULT_AUTH_PATH, default: kubernetes.
+func Vault(reference string) (string, error) {
+	if !strings.Contains(reference, "#") {
+		reference += "#value"
+	}
+	return cached("vault", reference, func(path string) (string, error) {
+		address := strings.TrimSuffix(os.Getenv("VAULT_ADDR"), "/")
+		if address == "" {
+			return "", fmt.Errorf("VAULT_ADDR is not set")
+		}
+		token, err := vaultToken(address)
+		if err != nil {
+			return "", err
+		}
+
+		req, err := vaultRequest(http.MethodGet, address+"/v1/"+strings.TrimPrefix(path, "/"), token, nil)
+		if err != nil {
+			return "", err
+		}
+		response := vaultResponse{}
+		if err := readJson(req, &response); err != nil {
+			return "", err
+		}
+
+		// KV version 2 nests the secret with its metadata
+		data := response.Data
+		if nested, ok := data["data"].(map[string]interface{}); ok {
+			if _, ok := data["metadata"]; ok {
+				data = nested
+			}
+		}
+		encoded, _ := json.Marshal(data)
+		return string(encoded), nil
+	})
+}
+
+func vaultToken(address string) (string, error) {
+	if token := os.Getenv("VAULT_TOKEN"); token != "" {
+		return token, nil
+	}
+
+	if role := os.Getenv("VAULT_ROLE"); role != "" {
+		jwt, err := ioutil.ReadFile(kubernetesServiceAccountToken)
+		if err != nil {
+			return "", err
+		}
+		mount := os.Getenv("VAULT_AUTH_PATH")
+		if mount == "" {
+			mount = "kubernetes"
+		}
+		body, _ := json.Marshal(map[string]string{"role": role, "jwt": strings.TrimSpace(string(jwt))})
+		req, err := vaultRequest(http.MethodPost, fmt.Sprintf("%s/v1/auth/%s/login", address, mount), "", body)
+		if err != nil {
+			return "", err
+		}
+		response := vaultResponse{}
+		if err := readJson(req, &response); err != nil {
+			return "", err
+		}
+		return response.Auth.ClientToken, nil
+	}
+
+	if home, err := os.UserHomeDir(); err == nil {
+		if token, err := ioutil.ReadFile(filepath.Join(home, ".vault-token")); err == nil {
+			return strings.TrimSpace(string(token)), nil
+		}
+	}
+	return "", fmt.Errorf("no Vault token found in VAULT_TOKEN, VAULT_ROLE or ~/.vault-token")
+}
+
+func vaultRequest(method, url, token string, body []byte) (*http.Request, error) {
+	req, err := http.NewRequest(method, url, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	if token != "" {
+		req.Header.Set("X-Vault-Token", token)
+	}
+	if namespace := os.Getenv("VAULT_NAMESPACE"); namespace != "" {
+		req.Header.Set("X-Vault-Namespace", namespace)
+	}
+	return req, nil
+}
@@ -0,0 +1,287 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"encoding/json"
+	"strings"
+	"sync"
+	"time"
+	"fmt"
+	"log"
+	"io/ioutil"
+	grmpb "grm/proto"
+)
+
+// releaseServer periodically builds the reports of the remote definitions and serves them over HTTP
+type releaseServer struct {
+	names    []string
+	options  reportOptions
+	interval time.Duration
+	fresh    time.Duration
+	stale    time.Duration
+	redact   bool
+
+	apiUsers []apiUser
+	trigger  chan bool
+	events   *eventBroker
+	guard    pollGuard
+
+	mutex   sync.RWMutex
+	reports map[string]*reportModel
+	updated time.Time
+}
+
+func newReleaseServer(names []string, options reportOptions, interval, fresh, stale time.Duration, apiUsers []apiUser, redact bool) *releaseServer {
+	return &releaseServer{
+		names:    names,
+		options:  options,
+		interval: interval,
+		fresh:    fresh,
+		stale:    stale,
+		redact:   redact,
+		apiUsers: apiUsers,
+		trigger:  make(chan bool, 1),
+		events:   newEventBroker(),
+		reports:  make(map[string]*reportModel),
+	}
+}
+
+func (s *releaseServer) refresh() {
+	if !s.guard.start() {
+		return
+	}
+	defer s.guard.done()
+
+	// Every refresh reads the current data
+	responses.Reset()
+	ctx, span := tracer.Start(context.Background(), "refresh")
+	reports := make(map[string]*reportModel)
+	for _, name := range s.names {
+		if s.guard.stopped() {
+			break
+		}
+		reports[name] = buildReport(ctx, name, s.options)
+		if s.redact {
+			redactPrivate(reports[name])
+		}
+	}
+	span.End()
+	flushTraces()
+
+	s.mutex.Lock()
+	s.reports = reports
+	s.updated = time.Now()
+	s.mutex.Unlock()
+	health.recordPoll()
+
+	for _, name := range s.names {
+		for _, event := range releaseEvents(reports[name]) {
+			if s.redact || !privateRepository(reports[name], event.Repository) {
+				s.events.publish(event)
+			}
+		}
+	}
+}
+
+// privateRepository reports whether the repository of the report is private, those are only streamed
+// if they are redacted
+func privateRepository(report *reportModel, name string) bool {
+	for _, rep := range report.repositories {
+		if rep.name == name {
+			return rep.private
+		}
+	}
+	return false
+}
+
+// reload replaces the configuration and the API users while no request is served, the reports are
+// refreshed with it right after
+func (s *releaseServer) reload() {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	reloadConfiguration(s.names, func() error {
+		users, err := readApiUsers()
+		if err != nil {
+			return err
+		}
+		s.apiUsers = users
+		return nil
+	})
+}
+
+// run refreshes the reports in the configured interval or when triggered through the API, it never returns
+func (s *releaseServer) run() {
+	ticker := time.NewTicker(s.interval)
+	hangup := notifyReload()
+	for {
+		select {
+		case <-ticker.C:
+		case <-s.trigger:
+		case <-hangup:
+			s.reload()
+		}
+		s.refresh()
+		if *verbose {
+			log.Println("Refreshed reports")
+		}
+	}
+}
+
+func (s *releaseServer) handler() http.Handler {
+	mux := http.NewServeMux()
+	// Badges are embedded in READMEs, shields.io fetches them without token
+	mux.HandleFunc("/badge/", s.handleBadge)
+	mux.Handle("/events", s.authorize(http.HandlerFunc(s.handleEvents)))
+	registerHealth(mux)
+	mux.Handle("/api/", s.authorize(http.HandlerFunc(s.handleApi)))
+	mux.Handle("/"+grmpb.ServiceName+"/", s.authorizeGrpc(grmpb.NewGrmHandler(grpcServer{s})))
+	return mux
+}
+
+// findRepository searches the reports for the repository of the given Github account, the caller
+// holds the read lock
+func (s *releaseServer) findRepository(account, name string) (*reportModel, *repository) {
+	for _, report := range s.reports {
+		if !strings.EqualFold(report.account, account) {
+			continue
+		}
+		for _, rep := range report.repositories {
+			if strings.EqualFold(rep.name, name) {
+				return report, rep
+			}
+		}
+	}
+	return nil, nil
+}
+
+// shieldsBadge is the endpoint badge schema of shields.io
+type shieldsBadge struct {
+	SchemaVersion int    `json:"schemaVersion"`
+	Label         string `json:"label"`
+	Message       string `json:"message"`
+	Color         string `json:"color"`
+	IsError       bool   `json:"isError,omitempty"`
+	CacheSeconds  int    `json:"cacheSeconds,omitempty"`
+}
+
+// handleBadge serves /badge/<account>/<repository>, colored by the age of the latest release
+func (s *releaseServer) handleBadge(writer http.ResponseWriter, request *http.Request) {
+	tokens := strings.Split(strings.Trim(strings.TrimPrefix(request.URL.Path, "/badge/"), "/"), "/")
+	if len(tokens) != 2 {
+		http.NotFound(writer, request)
+		return
+	}
+
+	badge := shieldsBadge{
+		SchemaVersion: 1,
+		Label:         tokens[1],
+		CacheSeconds:  int(s.interval.Seconds()),
+	}
+	status := http.StatusOK
+
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	report, rep := s.findRepository(tokens[0], tokens[1])
+	var latest *release
+	// Badges are served without token, private repositories are left out even if redacted
+	if rep != nil && !rep.private {
+		latest = rep.latestRelease()
+	}
+
+	if latest == nil {
+		badge.Message = "unknown"
+		badge.Color = "lightgrey"
+		badge.IsError = true
+		status = http.StatusNotFound
+	} else {
+		badge.Message = latest.name
+		if version := extractVersion(report.name, rep.stateName(), latest.name); version != latest.name {
+			badge.Message = "v" + strings.TrimPrefix(version, "v")
+		}
+		badge.Color = freshnessColor(time.Since(latest.created), s.fresh, s.stale)
+	}
+
+	writer.Header().Set("Content-Type", "application/json")
+	writer.WriteHeader(status)
+	json.NewEncoder(writer).Encode(badge)
+}
+
+// handleEvents streams release events found by refreshes as server-sent events
+func (s *releaseServer) handleEvents(writer http.ResponseWriter, request *http.Request) {
+	flusher, ok := writer.(http.Flusher)
+	if !ok {
+		http.Error(writer, "streaming not supported", http.StatusInternalServerError)
+		return
+	}
+
+	subscriber := s.events.subscribe()
+	defer s.events.unsubscribe(subscriber)
+
+	writer.Header().Set("Content-Type", "text/event-stream")
+	writer.Header().Set("Cache-Control", "no-cache")
+	writer.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	// Comments keep proxies from closing idle connections
+	keepAlive := time.NewTicker(30 * time.Second)
+	defer keepAlive.Stop()
+
+	for {
+		select {
+		case event, ok := <-subscriber:
+			if !ok {
+				return
+			}
+			data, err := json.Marshal(event)
+			if err != nil {
+				return
+			}
+			fmt.Fprintf(writer, "event: %s\ndata: %s\n\n", event.Type, data)
+		case <-keepAlive.C:
+			fmt.Fprint(writer, ": keep-alive\n\n")
+		case <-request.Context().Done():
+			return
+		}
+		flusher.Flush()
+	}
+}
+
+func freshnessColor(age, fresh, stale time.Duration) string {
+	switch {
+	case age <= fresh:
+		return "green"
+	case age <= stale:
+		return "orange"
+	}
+	return "red"
+}
+
+func serve(server *releaseServer, address string, drainTimeout time.Duration) {
+	progressOutput = ioutil.Discard
+
+	listener := &http.Server{Addr: address, Handler: server.handler(), Protocols: grpcProtocols()}
+	// Event streams never end by themselves, they are closed to let the shutdown complete
+	listener.RegisterOnShutdown(server.events.close)
+	terminate := notifyShutdown()
+	go func() {
+		defer recoverCrash()
+		<-terminate
+		shutdown(&server.guard, drainTimeout, listener)
+	}()
+
+	health.watchHealth(server.interval)
+	fmt.Println("Building initial reports...")
+	server.refresh()
+	go func() {
+		defer recoverCrash()
+		server.run()
+	}()
+
+	fmt.Println(fmt.Sprintf("Listening on %s", address))
+	if err := listener.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+		log.Fatal(fmt.Sprintf("Could not listen on %s: ", address), err)
+	}
+	// The shutdown exits
+	select {}
+}
@@ -0,0 +1,67 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestBadgeWithoutToken(t *testing.T) {
+	useConfiguration(t, "[Core]\nconfig-version = 1\n[Remote \"hashicorp\"]\nuser = hashicorp\n")
+	created := time.Now().Add(-time.Hour)
+	report := &reportModel{name: "hashicorp", account: "hashicorp", repositories: []*repository{
+		{name: "terraform", releases: []*release{{name: "v0.11.8", created: created}}},
+		{name: "sentinel", private: true, releases: []*release{{name: "v0.1.0", created: created}}},
+	}}
+
+	tests := []struct {
+		name    string
+		apiUser []apiUser
+		redact  bool
+		path    string
+		status  int
+		message string
+	}{
+		{"public", nil, false, "/badge/hashicorp/terraform", http.StatusOK, "v0.11.8"},
+		{"public with API users", []apiUser{{"ops", "secret", roleViewer}}, false, "/badge/hashicorp/terraform", http.StatusOK, "v0.11.8"},
+		{"private", nil, false, "/badge/hashicorp/sentinel", http.StatusNotFound, "unknown"},
+		{"private with API users", []apiUser{{"ops", "secret", roleViewer}}, false, "/badge/hashicorp/sentinel", http.StatusNotFound, "unknown"},
+		{"private redacted", nil, true, "/badge/hashicorp/sentinel", http.StatusNotFound, "unknown"},
+		{"unknown", nil, false, "/badge/hashicorp/vault", http.StatusNotFound, "unknown"},
+	}
+	for _, test := range tests {
+		server := newReleaseServer([]string{"hashicorp"}, reportOptions{}, time.Hour, 24*time.Hour, 72*time.Hour, test.apiUser, test.redact)
+		server.reports["hashicorp"] = report
+
+		response := httptest.NewRecorder()
+		server.handler().ServeHTTP(response, httptest.NewRequest(http.MethodGet, test.path, nil))
+		badge := shieldsBadge{}
+		json.NewDecoder(response.Body).Decode(&badge)
+		if response.Code != test.status || badge.Message != test.message {
+			t.Errorf("%s: status %d, message %q, expected %d, %q", test.name, response.Code, badge.Message, test.status, test.message)
+		}
+	}
+}
+
+func TestTokenRequired(t *testing.T) {
+	useConfiguration(t, "[Core]\nconfig-version = 1\n[Remote \"hashicorp\"]\nuser = hashicorp\n")
+	// Badges are public, the event stream and the API carry release details and need a token
+	tests := []struct {
+		path   string
+		status int
+	}{
+		{"/badge/hashicorp/terraform", http.StatusNotFound},
+		{"/events", http.StatusUnauthorized},
+		{"/api/remotes", http.StatusUnauthorized},
+	}
+	for _, test := range tests {
+		server := newReleaseServer([]string{"hashicorp"}, reportOptions{}, time.Hour, 24*time.Hour, 72*time.Hour, []apiUser{{"ops", "secret", roleViewer}}, false)
+		response := httptest.NewRecorder()
+		server.handler().ServeHTTP(response, httptest.NewRequest(http.MethodGet, test.path, nil))
+		if response.Code != test.status {
+			t.Errorf("%s: status %d, expected %d", test.path, response.Code, test.status)
+		}
+	}
+}
@@ -0,0 +1,120 @@
+package main
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Severities of a release, ordered from least to most disruptive
+var severities = []string{"patch", "minor", "major", "breaking"}
+
+// Markers in release notes announcing changes requiring action from users
+var breakingChangePattern = regexp.MustCompile(`(?i)\bbreaking\b|\bmigration\b|\bmigrate\b|\bincompatib|\bremoved support\b`)
+
+var semverPattern = regexp.MustCompile(`^[vV]?(\d+)(?:\.(\d+))?(?:\.(\d+))?(?:-([0-9A-Za-z.-]+))?(?:\+[0-9A-Za-z.-]+)?$`)
+
+type semver struct {
+	major      int
+	minor      int
+	patch      int
+	prerelease string
+}
+
+func parseSemver(value string) (semver, bool) {
+	substrings := semverPattern.FindStringSubmatch(strings.TrimSpace(value))
+	if substrings == nil {
+		return semver{}, false
+	}
+	version := semver{prerelease: substrings[4]}
+	version.major, _ = strconv.Atoi(substrings[1])
+	version.minor, _ = strconv.Atoi(substrings[2])
+	version.patch, _ = strconv.Atoi(substrings[3])
+	return version, true
+}
+
+// less orders versions by precedence, prereleases come before their release
+func (v semver) less(other semver) bool {
+	if v.major != other.major {
+		return v.major < other.major
+	}
+	if v.minor != other.minor {
+		return v.minor < other.minor
+	}
+	if v.patch != other.patch {
+		return v.patch < other.patch
+	}
+	if v.prerelease == "" || other.prerelease == "" {
+		return v.prerelease != "" && other.prerelease == ""
+	}
+	return v.prerelease < other.prerelease
+}
+
+// bump classifies the change from previous to v as major, minor or patch
+func (v semver) bump(previous semver) string {
+	switch {
+	case v.major != previous.major:
+		return "major"
+	case v.minor != previous.minor:
+		return "minor"
+	}
+	return "patch"
+}
+
+func severityRank(severity string) int {
+	for i, s := range severities {
+		if s == severity {
+			return i
+		}
+	}
+	return -1
+}
+
+// classifyReleases determines the semver bump of every new release compared to its predecessor and raises
+// the severity to breaking if the release notes contain breaking change markers
+func classifyReleases(name string, rep *repository) {
+	// Sub-projects are versioned independently, releases are only compared within their sub-project
+	prefixes := readSubprojects(name, rep.name)
+	versions := make(map[string]semver)
+	subprojects := make(map[string]string)
+	for _, entry := range readHistory(name, rep.name) {
+		if v, ok := parseSemver(extractVersion(name, rep.name, entry.Tag)); ok {
+			versions[entry.Tag] = v
+			subprojects[entry.Tag], _ = subprojectOf(prefixes, entry.Tag)
+		}
+	}
+	for _, rel := range rep.releases {
+		if v, ok := parseSemver(extractVersion(name, rep.name, rel.name)); ok {
+			versions[rel.name] = v
+			subprojects[rel.name] = rel.subproject
+		}
+	}
+
+	for _, rel := range rep.releases {
+		if !rel.published {
+			continue
+		}
+
+		if current, ok := versions[rel.name]; ok {
+			var previous *semver
+			for tag, v := range versions {
+				if tag == rel.name || !v.less(current) || v.prerelease != "" || subprojects[tag] != rel.subproject {
+					continue
+				}
+				if previous == nil || previous.less(v) {
+					candidate := v
+					previous = &candidate
+				}
+			}
+			rel.severity = "major"
+			if previous != nil {
+				rel.severity = current.bump(*previous)
+			}
+		}
+
+		if marker := breakingChangePattern.FindString(rel.notes); marker != "" {
+			rel.severity = "breaking"
+			rel.breakingMarker = marker
+		}
+	}
+}
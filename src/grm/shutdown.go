@@ -0,0 +1,100 @@
+package main
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
+)
+
+// Time the running poll gets to finish on SIGTERM, e.g. within the termination grace period of a
+// Kubernetes pod (default: 30s)
+const defaultDrainTimeout = "25s"
+
+// pollGuard lets a shutdown wait for the running poll of a daemon and keeps further polls from starting
+type pollGuard struct {
+	mutex    sync.Mutex
+	stopping int32
+}
+
+// start begins a poll, ended with done. Once the daemon shuts down it returns false or blocks until
+// the exit.
+func (g *pollGuard) start() bool {
+	g.mutex.Lock()
+	if g.stopped() {
+		g.mutex.Unlock()
+		return false
+	}
+	return true
+}
+
+func (g *pollGuard) done() {
+	g.mutex.Unlock()
+}
+
+// stopped tells a running poll to skip the remaining remote definitions
+func (g *pollGuard) stopped() bool {
+	return atomic.LoadInt32(&g.stopping) == 1
+}
+
+// drain waits for the running poll until the deadline and reports whether it finished
+func (g *pollGuard) drain(deadline time.Time) bool {
+	atomic.StoreInt32(&g.stopping, 1)
+	finished := make(chan bool)
+	go func() {
+		g.mutex.Lock()
+		close(finished)
+	}()
+	select {
+	case <-finished:
+		return true
+	case <-time.After(time.Until(deadline)):
+		return false
+	}
+}
+
+// notifyShutdown delivers SIGTERM and SIGINT
+func notifyShutdown() <-chan os.Signal {
+	terminate := make(chan os.Signal, 1)
+	signal.Notify(terminate, syscall.SIGTERM, syscall.SIGINT)
+	return terminate
+}
+
+// shutdown stops the servers and waits for the running poll and the served requests until the drain
+// timeout, then writes the state and the traces and exits. A poll cut off by the timeout still gets
+// its state written up to then, the exit status tells it wasn't complete.
+func shutdown(guard *pollGuard, drainTimeout time.Duration, servers ...*http.Server) {
+	log.Println("Shutting down, waiting for the running poll")
+	deadline := time.Now().Add(drainTimeout)
+
+	ctx, cancel := context.WithDeadline(context.Background(), deadline)
+	defer cancel()
+	stopped := make(chan bool)
+	go func() {
+		for _, server := range servers {
+			server.Shutdown(ctx)
+		}
+		close(stopped)
+	}()
+
+	drained := guard.drain(deadline)
+	select {
+	case <-stopped:
+	case <-ctx.Done():
+	}
+	if !drained {
+		log.Println("Drain timeout exceeded, the running poll was cut off")
+	}
+
+	saveState()
+	flushTraces()
+	if !drained {
+		os.Exit(1)
+	}
+	os.Exit(0)
+}
@@ -0,0 +1,154 @@
+package main
+
+import (
+	"os/exec"
+	"fmt"
+	"strings"
+	"os"
+	"bytes"
+	"errors"
+)
+
+// Namespace used for ssh signatures, prevents reusing signatures made for other purposes
+const sshSignatureNamespace = "grm-export"
+
+const supportedSignatureMethods = "ssh, minisign, gpg"
+
+type signatureMethod struct {
+	tool      string
+	extension string
+	sign      func(key, file string) *exec.Cmd
+	verify    func(key, file, signature string) *exec.Cmd
+}
+
+var signatureMethods = map[string]signatureMethod{
+	"ssh": {
+		tool:      "ssh-keygen",
+		extension: ".sig",
+		sign: func(key, file string) *exec.Cmd {
+			return exec.Command("ssh-keygen", "-Y", "sign", "-f", key, "-n", sshSignatureNamespace, file)
+		},
+		verify: nil, // needs principal lookup, see verifySsh
+	},
+	"minisign": {
+		tool:      "minisign",
+		extension: ".minisig",
+		sign: func(key, file string) *exec.Cmd {
+			return exec.Command("minisign", "-S", "-s", key, "-m", file, "-x", file+".minisig")
+		},
+		verify: func(key, file, signature string) *exec.Cmd {
+			return exec.Command("minisign", "-V", "-p", key, "-m", file, "-x", signature)
+		},
+	},
+	"gpg": {
+		tool:      "gpg",
+		extension: ".asc",
+		sign: func(key, file string) *exec.Cmd {
+			args := []string{"--batch", "--yes", "--armor", "--detach-sign", "--output", file + ".asc"}
+			if key != "" {
+				args = append(args, "--local-user", key)
+			}
+			return exec.Command("gpg", append(args, file)...)
+		},
+		verify: func(key, file, signature string) *exec.Cmd {
+			args := []string{"--batch"}
+			if key != "" {
+				args = append(args, "--no-default-keyring", "--keyring", key)
+			}
+			return exec.Command("gpg", append(args, "--verify", signature, file)...)
+		},
+	},
+}
+
+func lookupSignatureMethod(method string) (signatureMethod, error) {
+	m, ok := signatureMethods[method]
+	if !ok {
+		return m, fmt.Errorf("unknown signature method '%s', supported are: %s", method, supportedSignatureMethods)
+	}
+	if err := checkSignaturePolicy(method); err != nil {
+		return m, err
+	}
+	if _, err := exec.LookPath(m.tool); err != nil {
+		return m, fmt.Errorf("signature method '%s' requires '%s' to be installed", method, m.tool)
+	}
+	return m, nil
+}
+
+// signFile creates a detached signature next to the given file and returns the signature's path
+func signFile(method, key, file string) (string, error) {
+	method = strings.ToLower(method)
+	m, err := lookupSignatureMethod(method)
+	if err != nil {
+		return "", err
+	}
+	if key == "" && method != "gpg" {
+		return "", fmt.Errorf("signature method '%s' requires a signing key", method)
+	}
+
+	if err := runSignatureTool(m.sign(key, file)); err != nil {
+		return "", err
+	}
+	return file + m.extension, nil
+}
+
+// verifyFile checks the detached signature of a file, the signature defaults to the
+// method specific file next to the verified file
+func verifyFile(method, key, file, signature string) error {
+	method = strings.ToLower(method)
+	m, err := lookupSignatureMethod(method)
+	if err != nil {
+		return err
+	}
+
+	if signature == "" {
+		signature = file + m.extension
+	}
+	if _, err := os.Stat(signature); err != nil {
+		return fmt.Errorf("signature file '%s' not found", signature)
+	}
+
+	if method == "ssh" {
+		return verifySsh(key, file, signature)
+	}
+	return runSignatureTool(m.verify(key, file, signature))
+}
+
+func verifySsh(allowedSigners, file, signature string) error {
+	if allowedSigners == "" {
+		return errors.New("signature method 'ssh' requires an allowed signers file")
+	}
+
+	find := exec.Command("ssh-keygen", "-Y", "find-principals", "-f", allowedSigners, "-s", signature)
+	output, err := find.Output()
+	if err != nil {
+		return errors.New("signature was not made by any of the allowed signers")
+	}
+	principals := strings.Fields(string(output))
+	if len(principals) == 0 {
+		return errors.New("signature was not made by any of the allowed signers")
+	}
+
+	data, err := os.Open(file)
+	if err != nil {
+		return err
+	}
+	defer data.Close()
+
+	verify := exec.Command("ssh-keygen", "-Y", "verify", "-f", allowedSigners, "-I", principals[0],
+		"-n", sshSignatureNamespace, "-s", signature)
+	verify.Stdin = data
+	return runSignatureTool(verify)
+}
+
+func runSignatureTool(cmd *exec.Cmd) error {
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		message := strings.TrimSpace(stderr.String())
+		if message == "" {
+			message = err.Error()
+		}
+		return fmt.Errorf("%s failed: %s", cmd.Args[0], message)
+	}
+	return nil
+}
@@ -0,0 +1,99 @@
+package main
+
+import (
+	"io/ioutil"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// newSshKey creates an ed25519 key pair and an allowed signers file trusting it
+func newSshKey(t *testing.T, dir, name string) (key, allowedSigners string) {
+	key = filepath.Join(dir, name)
+	if output, err := exec.Command("ssh-keygen", "-q", "-t", "ed25519", "-N", "", "-C", name, "-f", key).CombinedOutput(); err != nil {
+		t.Fatalf("ssh-keygen failed: %s", output)
+	}
+	public, err := ioutil.ReadFile(key + ".pub")
+	if err != nil {
+		t.Fatal(err)
+	}
+	allowedSigners = filepath.Join(dir, name+".allowed_signers")
+	if err := ioutil.WriteFile(allowedSigners, []byte(name+"@example.com "+string(public)), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return key, allowedSigners
+}
+
+func TestVerifySshSignature(t *testing.T) {
+	if _, err := exec.LookPath("ssh-keygen"); err != nil {
+		t.Skip("ssh-keygen is not installed")
+	}
+	useConfiguration(t, "")
+	dir := t.TempDir()
+	key, allowedSigners := newSshKey(t, dir, "signer")
+	_, otherSigners := newSshKey(t, dir, "other")
+
+	file := filepath.Join(dir, "export.json")
+	if err := ioutil.WriteFile(file, []byte(`{"repositories":[]}`), 0600); err != nil {
+		t.Fatal(err)
+	}
+	signature, err := signFile("SSH", key, file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if signature != file+".sig" {
+		t.Errorf("signature written to %s", signature)
+	}
+	tampered := filepath.Join(dir, "tampered.json")
+	if err := ioutil.WriteFile(tampered, []byte(`{"repositories":[{}]}`), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name      string
+		method    string
+		key       string
+		file      string
+		signature string
+		err       string
+	}{
+		{"valid", "ssh", allowedSigners, file, "", ""},
+		{"explicit signature", "ssh", allowedSigners, file, signature, ""},
+		{"tampered file", "ssh", allowedSigners, tampered, signature, "ssh-keygen failed"},
+		{"unknown signer", "ssh", otherSigners, file, "", "not made by any of the allowed signers"},
+		{"no allowed signers", "ssh", "", file, "", "requires an allowed signers file"},
+		{"missing signature", "ssh", allowedSigners, tampered, "", "not found"},
+		{"unknown method", "pgp", allowedSigners, file, "", "unknown signature method 'pgp'"},
+	}
+	for _, test := range tests {
+		err := verifyFile(test.method, test.key, test.file, test.signature)
+		switch {
+		case test.err == "" && err != nil:
+			t.Errorf("%s: %s", test.name, err)
+		case test.err != "" && (err == nil || !strings.Contains(err.Error(), test.err)):
+			t.Errorf("%s: error %v, expected %q", test.name, err, test.err)
+		}
+	}
+}
+
+func TestSignFileErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		config string
+		method string
+		key    string
+		err    string
+	}{
+		{"unknown method", "", "x509", "key", "unknown signature method 'x509'"},
+		{"no key", "", "ssh", "", "requires a signing key"},
+		{"minisign under fips", "[Core]\ncrypto-policy = fips\n", "minisign", "key", "not allowed by crypto-policy fips"},
+	}
+	for _, test := range tests {
+		useConfiguration(t, test.config)
+		_, err := signFile(test.method, test.key, filepath.Join(t.TempDir(), "export.json"))
+		if err == nil || !strings.Contains(err.Error(), test.err) {
+			t.Errorf("%s: error %v, expected %q", test.name, err, test.err)
+		}
+	}
+}
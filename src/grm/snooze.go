@@ -0,0 +1,80 @@
+package main
+
+import (
+	"strings"
+	"time"
+	"grm/state"
+)
+
+// snooze hides the releases of a repository, or only of one release line, until a date
+type snooze struct {
+	Until time.Time `json:"until"`
+	// Line restricts the snooze to versions like 1.2 or 1.2.x, empty for all releases
+	Line string `json:"line,omitempty"`
+}
+
+func snoozeKey(name, repository string) string {
+	return state.Key("snooze", name, repository)
+}
+
+func readSnoozes(name, repository string) []snooze {
+	snoozes := make([]snooze, 0)
+	openState().Get(snoozeKey(name, repository), &snoozes)
+	return snoozes
+}
+
+// writeSnooze replaces the snooze of the same release line, expired snoozes are dropped
+func writeSnooze(name, repository string, s snooze) {
+	snoozes := []snooze{s}
+	for _, existing := range readSnoozes(name, repository) {
+		if existing.Line != s.Line && existing.Until.After(time.Now()) {
+			snoozes = append(snoozes, existing)
+		}
+	}
+	openState().Set(snoozeKey(name, repository), snoozes)
+}
+
+// removeSnooze removes the snooze of the release line and returns whether one existed
+func removeSnooze(name, repository, line string) bool {
+	snoozes := make([]snooze, 0)
+	removed := false
+	for _, existing := range readSnoozes(name, repository) {
+		if existing.Line == line {
+			removed = true
+			continue
+		}
+		snoozes = append(snoozes, existing)
+	}
+	if len(snoozes) == 0 {
+		openState().Delete(snoozeKey(name, repository))
+	} else {
+		openState().Set(snoozeKey(name, repository), snoozes)
+	}
+	return removed
+}
+
+// matches returns whether the version belongs to the snoozed release line
+func (s snooze) matches(version string) bool {
+	if s.Line == "" {
+		return true
+	}
+	version = strings.TrimPrefix(version, "v")
+	line := strings.TrimSuffix(strings.TrimPrefix(s.Line, "v"), ".x")
+	return version == line || strings.HasPrefix(version, line+".")
+}
+
+// snoozeReleases marks the releases of snoozed repositories or release lines, which are then left out
+// of reports and notifications
+func snoozeReleases(name string, rep *repository) {
+	now := time.Now()
+	for _, s := range readSnoozes(name, rep.name) {
+		if !s.Until.After(now) {
+			continue
+		}
+		for _, rel := range rep.releases {
+			if s.matches(extractVersion(name, rep.name, rel.name)) {
+				rel.snoozed = true
+			}
+		}
+	}
+}
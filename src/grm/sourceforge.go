@@ -0,0 +1,98 @@
+package main
+
+import (
+	"encoding/xml"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"path"
+	"sort"
+	"strings"
+	"time"
+	"grm/config"
+)
+
+// SourceForge site, a variable to point tests at other servers
+var sourceforgeUrl = "https://sourceforge.net"
+
+// Number of recent files read from the file release feed of a project
+const sourceforgeFeedLimit = 500
+
+// sourceforgeFeed is the RSS feed of the file releases of a project, one item per file
+type sourceforgeFeed struct {
+	Items []struct {
+		Title   string `xml:"title"`
+		Link    string `xml:"link"`
+		PubDate string `xml:"pubDate"`
+	} `xml:"channel>item"`
+}
+
+// buildSourceforgeReport reads the file releases of the SourceForge projects listed by the projects
+// property. Every folder with files is a release named by its path, like 5.4.8 or gnuplot/5.4.8, so
+// release patterns and sub-projects apply to the folders.
+func buildSourceforgeReport(name, repositoryPattern string, since time.Time) *reportModel {
+	client := newProviderClient(name)
+	repos := readListedRepositories(name, config.Projects, repositoryPattern)
+	return buildProviderReport(name, "", repos, since, func(project string) []providerRelease {
+		return readSourceforgeReleases(client, project)
+	})
+}
+
+// readSourceforgeReleases groups the files of the feed by folder, a release is dated by its oldest
+// file and downloads its first file
+func readSourceforgeReleases(client *http.Client, project string) []providerRelease {
+	feedUrl := fmt.Sprintf("%s/projects/%s/rss?path=/&limit=%d", sourceforgeUrl, url.PathEscape(project), sourceforgeFeedLimit)
+	request, err := http.NewRequest("GET", feedUrl, nil)
+	if err != nil {
+		log.Fatal(fmt.Sprintf("Could not create request for %s: ", feedUrl), err)
+	}
+	feed := sourceforgeFeed{}
+	if err := xml.Unmarshal(readProvider(client, request), &feed); err != nil {
+		log.Fatal(fmt.Sprintf("Could not parse file releases of SourceForge project %s: ", project), err)
+	}
+
+	releases := make(map[string]*providerRelease)
+	for _, item := range feed.Items {
+		folder := strings.Trim(path.Dir(item.Title), "/")
+		if folder == "" || folder == "." {
+			continue
+		}
+		published, err := parseFeedDate(item.PubDate)
+		if err != nil {
+			continue
+		}
+		release, ok := releases[folder]
+		if !ok {
+			release = &providerRelease{
+				version:     folder,
+				created:     published,
+				notesUrl:    fmt.Sprintf("%s/projects/%s/files/%s/", sourceforgeUrl, project, folder),
+				downloadUrl: item.Link,
+			}
+			releases[folder] = release
+		}
+		if published.Before(release.created) {
+			release.created = published
+		}
+	}
+
+	tags := make([]providerRelease, 0, len(releases))
+	for _, release := range releases {
+		tags = append(tags, *release)
+	}
+	sort.Slice(tags, func(i, j int) bool {
+		return tags[i].created.Before(tags[j].created)
+	})
+	return tags
+}
+
+// parseFeedDate parses the RFC 1123 dates of RSS feeds, SourceForge writes UT as zone
+func parseFeedDate(value string) (time.Time, error) {
+	for _, layout := range []string{time.RFC1123Z, time.RFC1123} {
+		if t, err := time.Parse(layout, strings.Replace(value, " UT", " UTC", 1)); err == nil {
+			return t.UTC(), nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid date '%s'", value)
+}
@@ -0,0 +1,196 @@
+package main
+
+import (
+	"github.com/google/go-github/github"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+	"grm/config"
+)
+
+// Instance of git.sr.ht used without sourcehut-url property
+const defaultSourcehutUrl = "https://git.sr.ht"
+
+// sourcehutClient reads the legacy REST API of git.sr.ht, authenticated with the token property
+type sourcehutClient struct {
+	base   string
+	token  string
+	client *http.Client
+}
+
+// sourcehutPage is a page of API results, next is the cursor of the following page or null
+type sourcehutPage struct {
+	Next    json.RawMessage `json:"next"`
+	Results json.RawMessage `json:"results"`
+}
+
+type sourcehutRepository struct {
+	Name       string `json:"name"`
+	Visibility string `json:"visibility"`
+}
+
+type sourcehutRef struct {
+	Name      string `json:"name"`
+	Target    string `json:"target"`
+	Artifacts []struct {
+		Filename string `json:"filename"`
+		Url      string `json:"url"`
+	} `json:"artifacts"`
+}
+
+type sourcehutCommit struct {
+	Id        string    `json:"id"`
+	Timestamp time.Time `json:"timestamp"`
+}
+
+func newSourcehutClient(name string) *sourcehutClient {
+	base := defaultSourcehutUrl
+	if u, ok := namedSectionGet(name, config.Remote, config.SourcehutUrl, ""); ok && u != "" {
+		base = strings.TrimSuffix(u, "/")
+	}
+	_, token, _ := readCredentials(name)
+	return &sourcehutClient{
+		base:   base,
+		token:  token,
+		client: newProviderClient(name),
+	}
+}
+
+// buildSourcehutReport reads the refs of the repositories of a SourceHut user. Tags are the releases,
+// refs pages link the release notes and the first artifact attached to a tag is its download.
+func buildSourcehutReport(name, repositoryPattern string, private bool, since time.Time) *reportModel {
+	client := newSourcehutClient(name)
+	account, ok := namedSectionGet(name, config.Remote, config.RemoteUser, "")
+	if !ok || account == "" {
+		log.Fatal(fmt.Sprintf("No %s defined for the SourceHut remote %s", config.RemoteUser.Name(), name))
+	}
+	if !strings.HasPrefix(account, "~") {
+		account = "~" + account
+	}
+
+	fmt.Fprint(progressOutput, "Reading repositories... ")
+	repos := client.repositories(name, account, repositoryPattern, private)
+	fmt.Fprintln(progressOutput, "done.")
+
+	return buildProviderReport(name, account, repos, since, func(repository string) []providerRelease {
+		return client.tags(name, account, repository)
+	})
+}
+
+// repositories lists the repositories of the user matching the pattern, unlisted and private ones
+// only if private repositories are shown
+func (c *sourcehutClient) repositories(name, account, repositoryPattern string, private bool) []*github.Repository {
+	var pattern *namePattern = nil
+	if repositoryPattern != "" {
+		pattern = mustCompileNamePattern(name, repositoryPattern)
+	}
+
+	repositories := make([]*github.Repository, 0)
+	for _, page := range c.pages(fmt.Sprintf("/api/%s/repos", account)) {
+		results := make([]sourcehutRepository, 0)
+		if err := json.Unmarshal(page, &results); err != nil {
+			log.Fatal(fmt.Sprintf("Could not parse repositories of %s: ", account), err)
+		}
+		for _, repo := range results {
+			if repo.Visibility != "public" && !private {
+				continue
+			}
+			if (pattern == nil || pattern.MatchString(repo.Name)) && !isBlacklisted(name, repo.Name) {
+				repositories = append(repositories, &github.Repository{
+					Name:    github.String(repo.Name),
+					Private: github.Bool(repo.Visibility != "public"),
+				})
+			}
+		}
+	}
+	return repositories
+}
+
+// tags returns the tags of the repository dated by their commit, tags of the release history keep
+// their date without looking up the commit again
+func (c *sourcehutClient) tags(name, account, repository string) []providerRelease {
+	known := make(map[string]time.Time)
+	for _, entry := range readHistory(name, repository) {
+		known[entry.Tag] = entry.Released
+	}
+
+	tags := make([]providerRelease, 0)
+	for _, page := range c.pages(fmt.Sprintf("/api/%s/repos/%s/refs", account, url.PathEscape(repository))) {
+		refs := make([]sourcehutRef, 0)
+		if err := json.Unmarshal(page, &refs); err != nil {
+			log.Fatal(fmt.Sprintf("Could not parse refs of repository %s: ", repository), err)
+		}
+		for _, ref := range refs {
+			if !strings.HasPrefix(ref.Name, "refs/tags/") {
+				continue
+			}
+			tag := providerRelease{
+				version:  strings.TrimPrefix(ref.Name, "refs/tags/"),
+				commit:   ref.Target,
+				notesUrl: fmt.Sprintf("%s/%s/%s/refs/%s", c.base, account, repository, strings.TrimPrefix(ref.Name, "refs/tags/")),
+			}
+			if len(ref.Artifacts) > 0 {
+				tag.downloadUrl = ref.Artifacts[0].Url
+			}
+			tags = append(tags, tag)
+		}
+	}
+
+	for i := range tags {
+		if released, ok := known[tags[i].version]; ok {
+			tags[i].created = released
+			continue
+		}
+		commit := c.commit(account, repository, tags[i].version)
+		tags[i].commit, tags[i].created = commit.Id, commit.Timestamp
+	}
+	return tags
+}
+
+// commit returns the commit a ref points to, annotated tags are resolved by the log
+func (c *sourcehutClient) commit(account, repository, ref string) sourcehutCommit {
+	page := sourcehutPage{}
+	c.get(fmt.Sprintf("/api/%s/repos/%s/log/%s", account, url.PathEscape(repository), url.PathEscape(ref)), &page)
+	commits := make([]sourcehutCommit, 0)
+	if err := json.Unmarshal(page.Results, &commits); err != nil || len(commits) == 0 {
+		log.Fatal(fmt.Sprintf("Could not read commit of %s in repository %s", ref, repository))
+	}
+	return commits[0]
+}
+
+// pages follows the cursor of a paginated resource and returns the results of all pages
+func (c *sourcehutClient) pages(path string) []json.RawMessage {
+	results := make([]json.RawMessage, 0)
+	start := ""
+	for {
+		target := path
+		if start != "" {
+			target += "?start=" + url.QueryEscape(start)
+		}
+		page := sourcehutPage{}
+		c.get(target, &page)
+		results = append(results, page.Results)
+
+		start = strings.Trim(string(page.Next), `"`)
+		if start == "" || start == "null" {
+			return results
+		}
+	}
+}
+
+func (c *sourcehutClient) get(path string, result interface{}) {
+	request, err := http.NewRequest("GET", c.base+path, nil)
+	if err != nil {
+		log.Fatal(fmt.Sprintf("Could not create request for %s: ", path), err)
+	}
+	if c.token != "" {
+		request.Header.Set("Authorization", "token "+c.token)
+	}
+	if err := json.Unmarshal(readProvider(c.client, request), result); err != nil {
+		log.Fatal(fmt.Sprintf("Could not parse %s from SourceHut: ", path), err)
+	}
+}
@@ -0,0 +1,146 @@
+package main
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+)
+
+// Messages of the SSH agent protocol, see draft-miller-ssh-agent
+const (
+	sshAgentFailure           = 5
+	sshAgentRequestIdentities = 11
+	sshAgentIdentitiesAnswer  = 12
+	sshAgentSignRequest       = 13
+	sshAgentSignResponse      = 14
+)
+
+// sshAgentKey is a public key held by the SSH agent
+type sshAgentKey struct {
+	blob    []byte
+	comment string
+}
+
+// fingerprint formats the key like ssh-keygen -l does, e.g. SHA256:uNiVztksCsDhcc0u9e8BujQXVUpKZIDTMczCvj3tD2s
+func (k sshAgentKey) fingerprint() string {
+	hash := sha256.Sum256(k.blob)
+	return "SHA256:" + base64.RawStdEncoding.EncodeToString(hash[:])
+}
+
+// sshAgent talks to the agent listening on SSH_AUTH_SOCK, hardware-backed keys stay in the agent
+type sshAgent struct {
+	conn net.Conn
+}
+
+func connectSshAgent() (*sshAgent, error) {
+	socket := os.Getenv("SSH_AUTH_SOCK")
+	if socket == "" {
+		return nil, errors.New("no SSH agent running, SSH_AUTH_SOCK is not set")
+	}
+	conn, err := net.Dial("unix", socket)
+	if err != nil {
+		return nil, err
+	}
+	return &sshAgent{conn: conn}, nil
+}
+
+func (a *sshAgent) Close() error {
+	return a.conn.Close()
+}
+
+// keys lists the public keys held by the agent
+func (a *sshAgent) keys() ([]sshAgentKey, error) {
+	reply, err := a.call([]byte{sshAgentRequestIdentities})
+	if err != nil {
+		return nil, err
+	}
+	if reply[0] != sshAgentIdentitiesAnswer {
+		return nil, fmt.Errorf("unexpected SSH agent response %d to the identities request", reply[0])
+	}
+
+	reader := bytes.NewReader(reply[1:])
+	var count uint32
+	if err := binary.Read(reader, binary.BigEndian, &count); err != nil {
+		return nil, err
+	}
+	keys := make([]sshAgentKey, 0, count)
+	for i := uint32(0); i < count; i++ {
+		blob, err := readSshString(reader)
+		if err != nil {
+			return nil, err
+		}
+		comment, err := readSshString(reader)
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, sshAgentKey{blob: blob, comment: string(comment)})
+	}
+	return keys, nil
+}
+
+// sign asks the agent to sign the data with the key, returns the signature blob
+func (a *sshAgent) sign(key sshAgentKey, data []byte) ([]byte, error) {
+	request := []byte{sshAgentSignRequest}
+	request = appendSshString(request, key.blob)
+	request = appendSshString(request, data)
+	request = append(request, 0, 0, 0, 0)
+
+	reply, err := a.call(request)
+	if err != nil {
+		return nil, err
+	}
+	switch reply[0] {
+	case sshAgentSignResponse:
+		return readSshString(bytes.NewReader(reply[1:]))
+	case sshAgentFailure:
+		return nil, fmt.Errorf("SSH agent refused to sign with key %s", key.fingerprint())
+	}
+	return nil, fmt.Errorf("unexpected SSH agent response %d to the sign request", reply[0])
+}
+
+// call sends a request framed by its length and reads the framed reply
+func (a *sshAgent) call(request []byte) ([]byte, error) {
+	frame := make([]byte, 4, 4+len(request))
+	binary.BigEndian.PutUint32(frame, uint32(len(request)))
+	if _, err := a.conn.Write(append(frame, request...)); err != nil {
+		return nil, err
+	}
+
+	var length uint32
+	if err := binary.Read(a.conn, binary.BigEndian, &length); err != nil {
+		return nil, err
+	}
+	if length == 0 || length > 256*1024 {
+		return nil, fmt.Errorf("invalid SSH agent response length %d", length)
+	}
+	reply := make([]byte, length)
+	if _, err := io.ReadFull(a.conn, reply); err != nil {
+		return nil, err
+	}
+	return reply, nil
+}
+
+func appendSshString(buffer, value []byte) []byte {
+	length := make([]byte, 4)
+	binary.BigEndian.PutUint32(length, uint32(len(value)))
+	return append(append(buffer, length...), value...)
+}
+
+func readSshString(reader *bytes.Reader) ([]byte, error) {
+	var length uint32
+	if err := binary.Read(reader, binary.BigEndian, &length); err != nil {
+		return nil, err
+	}
+	if int64(length) > int64(reader.Len()) {
+		return nil, errors.New("truncated SSH agent response")
+	}
+	value := make([]byte, length)
+	_, err := io.ReadFull(reader, value)
+	return value, err
+}
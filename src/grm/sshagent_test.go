@@ -0,0 +1,204 @@
+package main
+
+import (
+	"bytes"
+	"encoding/base64"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// startSshAgent runs an SSH agent holding new ed25519 keys with the given comments, the key files are
+// returned by comment
+func startSshAgent(t *testing.T, comments ...string) map[string]string {
+	for _, tool := range []string{"ssh-agent", "ssh-add", "ssh-keygen"} {
+		if _, err := exec.LookPath(tool); err != nil {
+			t.Skipf("%s is not installed", tool)
+		}
+	}
+	dir := t.TempDir()
+	socket := filepath.Join(dir, "agent.sock")
+	agent := exec.Command("ssh-agent", "-D", "-a", socket)
+	if err := agent.Start(); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		agent.Process.Kill()
+		agent.Wait()
+	})
+	for i := 0; i < 50; i++ {
+		if _, err := os.Stat(socket); err == nil {
+			break
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+	t.Setenv("SSH_AUTH_SOCK", socket)
+
+	keys := make(map[string]string)
+	for _, comment := range comments {
+		key, _ := newSshKey(t, dir, comment)
+		if output, err := exec.Command("ssh-add", key).CombinedOutput(); err != nil {
+			t.Fatalf("ssh-add failed: %s", output)
+		}
+		keys[comment] = key
+	}
+	return keys
+}
+
+// sshKeyFingerprint returns the fingerprint ssh-keygen shows for the key
+func sshKeyFingerprint(t *testing.T, key string) string {
+	output, err := exec.Command("ssh-keygen", "-l", "-f", key+".pub").Output()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return strings.Fields(string(output))[1]
+}
+
+func TestSshAgentEncryption(t *testing.T) {
+	keys := startSshAgent(t, "first", "second")
+
+	tests := []struct {
+		name     string
+		selected string
+		signer   string
+	}{
+		{"first key of the agent", "", "first"},
+		{"key selected by comment", "second", "second"},
+		{"key selected by fingerprint", sshKeyFingerprint(t, keys["first"]), "first"},
+	}
+	for _, test := range tests {
+		useConfiguration(t, "[Core]\nencryption = ssh-agent\nssh-agent-key = "+test.selected+"\n")
+		encrypted, salt := encryptSecret("hashicorp", "s3cret "+test.name)
+		if !strings.HasPrefix(encrypted, sshAgentSecretPrefix) || secretScheme(encrypted) != encryptionSshAgent {
+			t.Errorf("%s: encrypted to %q", test.name, encrypted)
+			continue
+		}
+		if decrypted := decryptSecret("hashicorp", encrypted, salt); decrypted != "s3cret "+test.name {
+			t.Errorf("%s: decrypted %q", test.name, decrypted)
+		}
+
+		// Only the signature of the selected key derives the password key
+		parts := strings.SplitN(strings.TrimPrefix(encrypted, sshAgentSecretPrefix), ":", 2)
+		challenge, err := base64.StdEncoding.DecodeString(parts[0])
+		if err != nil {
+			t.Fatal(err)
+		}
+		agent, err := connectSshAgent()
+		if err != nil {
+			t.Fatal(err)
+		}
+		agentKeys, err := agent.keys()
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, key := range agentKeys {
+			derived, err := deriveSshAgentKey(agent, key, challenge)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, err := openSecret(parts[1], salt, derived); (err == nil) != (key.comment == test.signer) {
+				t.Errorf("%s: key %s opens the password: %t", test.name, key.comment, err == nil)
+			}
+		}
+		agent.Close()
+	}
+}
+
+func TestSshAgentEncryptionSkipsEcdsaKeys(t *testing.T) {
+	startSshAgent(t)
+	dir := t.TempDir()
+	// The ECDSA key is loaded first, its signatures differ every time
+	ecdsa := filepath.Join(dir, "ecdsa")
+	if output, err := exec.Command("ssh-keygen", "-q", "-t", "ecdsa", "-N", "", "-C", "ecdsa", "-f", ecdsa).CombinedOutput(); err != nil {
+		t.Fatalf("ssh-keygen failed: %s", output)
+	}
+	ed25519, _ := newSshKey(t, dir, "ed25519")
+	for _, key := range []string{ecdsa, ed25519} {
+		if output, err := exec.Command("ssh-add", key).CombinedOutput(); err != nil {
+			t.Fatalf("ssh-add failed: %s", output)
+		}
+	}
+
+	useConfiguration(t, "[Core]\nencryption = ssh-agent\n")
+	encrypted, salt := encryptSecret("hashicorp", "s3cret")
+	if decrypted := decryptSecret("hashicorp", encrypted, salt); decrypted != "s3cret" {
+		t.Errorf("decrypted %q", decrypted)
+	}
+
+	agent, err := connectSshAgent()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer agent.Close()
+	keys, err := agent.keys()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(keys) != 2 || keys[0].comment != "ecdsa" {
+		t.Fatalf("agent keys %v", keys)
+	}
+	if _, err := deterministicSshAgentKey(agent, keys[:1], []byte("challenge")); err == nil {
+		t.Error("ECDSA key used")
+	}
+}
+
+func TestReadSshAgentKeys(t *testing.T) {
+	keys := startSshAgent(t, "first", "second")
+	agent, err := connectSshAgent()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer agent.Close()
+
+	tests := []struct {
+		selected string
+		comments []string
+		err      string
+	}{
+		{"", []string{"first", "second"}, ""},
+		{"second", []string{"second"}, ""},
+		{sshKeyFingerprint(t, keys["second"]), []string{"second"}, ""},
+		{"third", nil, "the SSH agent holds no key third"},
+	}
+	for _, test := range tests {
+		useConfiguration(t, "[Core]\nssh-agent-key = "+test.selected+"\n")
+		selected, err := readSshAgentKeys(agent)
+		if test.err != "" {
+			if err == nil || err.Error() != test.err {
+				t.Errorf("%q: error %v, expected %q", test.selected, err, test.err)
+			}
+			continue
+		}
+		comments := []string{}
+		for _, key := range selected {
+			comments = append(comments, key.comment)
+		}
+		if err != nil || strings.Join(comments, ",") != strings.Join(test.comments, ",") {
+			t.Errorf("%q: keys %v (%v), expected %v", test.selected, comments, err, test.comments)
+		}
+	}
+}
+
+func TestReadSshString(t *testing.T) {
+	tests := []struct {
+		name  string
+		data  []byte
+		value string
+		err   bool
+	}{
+		{"string", appendSshString(nil, []byte("ssh-ed25519")), "ssh-ed25519", false},
+		{"empty", appendSshString(nil, nil), "", false},
+		{"followed by more", appendSshString(appendSshString(nil, []byte("a")), []byte("b")), "a", false},
+		{"truncated length", []byte{0, 0}, "", true},
+		{"length beyond the end", []byte{0, 0, 0, 5, 'a'}, "", true},
+	}
+	for _, test := range tests {
+		value, err := readSshString(bytes.NewReader(test.data))
+		if (err != nil) != test.err || string(value) != test.value {
+			t.Errorf("%s: read %q (%v)", test.name, value, err)
+		}
+	}
+}
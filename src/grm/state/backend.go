@@ -0,0 +1,75 @@
+package state
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// HttpClient sends the requests of the S3 and Google Cloud Storage backends, grm replaces it with its
+// client logging the requests with --debug-http
+var HttpClient = &http.Client{Timeout: 30 * time.Second}
+
+// Backend loads and saves the serialized store. Load returns nil data if nothing was saved yet, and
+// the revision of the data. Save only writes if the saved data still has the revision, an empty
+// revision if nothing was saved yet, otherwise it returns ErrConflict. It returns the new revision.
+type Backend interface {
+	Load() (data []byte, revision string, err error)
+	Save(data []byte, revision string) (string, error)
+	String() string
+}
+
+// ErrConflict is returned by Save if the state was saved by someone else since it was loaded
+var ErrConflict = errors.New("the state was changed concurrently")
+
+// contentRevision is the revision of backends without revisions of their own, a hash of the data
+func contentRevision(data []byte) string {
+	if data == nil {
+		return ""
+	}
+	hash := sha256.Sum256(data)
+	return hex.EncodeToString(hash[:])
+}
+
+// Remote returns whether the backend keeps the state outside the local filesystem
+func Remote(backend Backend) bool {
+	_, local := backend.(*fileBackend)
+	return !local
+}
+
+// ParseBackend creates a backend from its URL:
+//
+//	file:///var/lib/grm/state.json
+//	redis://:password@localhost:6379/0?key=grm-state
+//	s3://bucket/path/state.json?region=eu-west-1&endpoint=https://minio:9000
+//	gs://bucket/path/state.json
+func ParseBackend(rawurl string) (Backend, error) {
+	u, err := url.Parse(rawurl)
+	if err != nil {
+		return nil, err
+	}
+
+	object := strings.TrimPrefix(u.Path, "/")
+	switch u.Scheme {
+	case "", "file":
+		return NewFileBackend(u.Path), nil
+	case "redis", "rediss":
+		return newRedisBackend(u)
+	case "s3":
+		if u.Host == "" || object == "" {
+			return nil, fmt.Errorf("S3 state backend '%s' requires a bucket and an object key", rawurl)
+		}
+		return newS3Backend(u.Host, object, u.Query().Get("region"), u.Query().Get("endpoint"))
+	case "gs":
+		if u.Host == "" || object == "" {
+			return nil, fmt.Errorf("GCS state backend '%s' requires a bucket and an object name", rawurl)
+		}
+		return newGcsBackend(u.Host, object), nil
+	}
+	return nil, fmt.Errorf("unsupported state backend '%s', supported: file, redis, s3, gs", u.Scheme)
+}
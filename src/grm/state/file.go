@@ -0,0 +1,59 @@
+package state
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+)
+
+type fileBackend struct {
+	path string
+}
+
+func NewFileBackend(path string) Backend {
+	return &fileBackend{path}
+}
+
+func (f *fileBackend) Load() ([]byte, string, error) {
+	data, err := ioutil.ReadFile(f.path)
+	if os.IsNotExist(err) {
+		return nil, "", nil
+	}
+	return data, contentRevision(data), err
+}
+
+// Save compares the file with the revision right before replacing it, a process writing the file in
+// between isn't detected. Local files are usually only written by one grm at a time.
+func (f *fileBackend) Save(data []byte, revision string) (string, error) {
+	if err := os.MkdirAll(filepath.Dir(f.path), os.ModePerm); err != nil {
+		return "", err
+	}
+
+	// Write to a temporary file first to never leave a half written state behind
+	temp, err := ioutil.TempFile(filepath.Dir(f.path), filepath.Base(f.path)+".tmp")
+	if err != nil {
+		return "", err
+	}
+	defer os.Remove(temp.Name())
+	_, err = temp.Write(data)
+	if closeErr := temp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return "", err
+	}
+
+	if _, current, err := f.Load(); err != nil {
+		return "", err
+	} else if current != revision {
+		return "", ErrConflict
+	}
+	if err := os.Rename(temp.Name(), f.path); err != nil {
+		return "", err
+	}
+	return contentRevision(data), nil
+}
+
+func (f *fileBackend) String() string {
+	return f.path
+}
@@ -0,0 +1,81 @@
+package state
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"grm/cloud"
+)
+
+var gcsApi = "https://storage.googleapis.com"
+
+// gcsBackend stores the state as an object in a Google Cloud Storage bucket
+type gcsBackend struct {
+	bucket string
+	object string
+}
+
+func newGcsBackend(bucket, object string) *gcsBackend {
+	return &gcsBackend{bucket, object}
+}
+
+// Load returns the generation of the object as revision
+func (g *gcsBackend) Load() ([]byte, string, error) {
+	u := fmt.Sprintf("%s/storage/v1/b/%s/o/%s?alt=media", gcsApi, url.PathEscape(g.bucket), url.PathEscape(g.object))
+	req, err := g.request(http.MethodGet, u, nil)
+	if err != nil {
+		return nil, "", err
+	}
+	data, header, err := loadObject(req)
+	if data == nil || err != nil {
+		return nil, "", err
+	}
+	return data, header.Get("X-Goog-Generation"), nil
+}
+
+// Save is a conditional write: GCS only replaces the object if its generation still matches, the
+// generation 0 only creates it if it doesn't exist yet
+func (g *gcsBackend) Save(data []byte, revision string) (string, error) {
+	generation := revision
+	if generation == "" {
+		generation = "0"
+	}
+	u := fmt.Sprintf("%s/upload/storage/v1/b/%s/o?uploadType=media&name=%s&ifGenerationMatch=%s", gcsApi,
+		url.PathEscape(g.bucket), url.QueryEscape(g.object), url.QueryEscape(generation))
+	req, err := g.request(http.MethodPost, u, data)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	_, body, err := saveObject(req)
+	if err != nil {
+		return "", err
+	}
+
+	object := struct {
+		Generation string `json:"generation"`
+	}{}
+	if err := json.Unmarshal(body, &object); err != nil {
+		return "", fmt.Errorf("could not read the generation of %s: %s", g, err)
+	}
+	return object.Generation, nil
+}
+
+func (g *gcsBackend) String() string {
+	return fmt.Sprintf("gs://%s/%s", g.bucket, g.object)
+}
+
+func (g *gcsBackend) request(method, u string, data []byte) (*http.Request, error) {
+	token, err := cloud.GoogleAccessToken()
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequest(method, u, bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	return req, nil
+}
@@ -0,0 +1,44 @@
+package state
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+)
+
+func (o *objectServer) ServeGcs(writer http.ResponseWriter, request *http.Request) {
+	switch {
+	case request.Method == http.MethodGet && request.URL.Path != "/storage/v1/b/bucket/o/grm/state.json":
+		http.Error(writer, "unexpected object "+request.URL.Path, http.StatusBadRequest)
+	case request.Method == http.MethodGet && o.data == nil:
+		http.NotFound(writer, request)
+	case request.Method == http.MethodGet:
+		writer.Header().Set("X-Goog-Generation", strconv.Itoa(o.revision))
+		writer.Write(o.data)
+	case request.URL.Query().Get("name") != "grm/state.json":
+		http.Error(writer, "unexpected object "+request.URL.Query().Get("name"), http.StatusBadRequest)
+	default:
+		generation, err := strconv.Atoi(request.URL.Query().Get("ifGenerationMatch"))
+		if err != nil {
+			http.Error(writer, "missing ifGenerationMatch", http.StatusBadRequest)
+			return
+		}
+		if o.save(writer, request, generation) {
+			fmt.Fprintf(writer, `{"name":"grm/state.json","generation":"%d"}`, o.revision)
+		}
+	}
+}
+
+func TestGcsBackend(t *testing.T) {
+	t.Setenv("GOOGLE_OAUTH_ACCESS_TOKEN", "token")
+	object := &objectServer{}
+	server := httptest.NewServer(http.HandlerFunc(object.ServeGcs))
+	defer server.Close()
+	previous := gcsApi
+	gcsApi = server.URL
+	defer func() { gcsApi = previous }()
+
+	checkObjectBackend(t, newGcsBackend("bucket", "grm/state.json"))
+}
@@ -0,0 +1,227 @@
+package state
+
+import (
+	"bufio"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	redisDefaultKey = "grm/state"
+	redisTimeout    = 10 * time.Second
+)
+
+// redisBackend stores the state as a single string value, speaking the RESP protocol directly
+type redisBackend struct {
+	address  string
+	tls      bool
+	password string
+	database int
+	key      string
+}
+
+func newRedisBackend(u *url.URL) (*redisBackend, error) {
+	backend := &redisBackend{
+		address: u.Host,
+		tls:     u.Scheme == "rediss",
+		key:     u.Query().Get("key"),
+	}
+	if !strings.Contains(backend.address, ":") {
+		backend.address += ":6379"
+	}
+	if backend.key == "" {
+		backend.key = redisDefaultKey
+	}
+	if u.User != nil {
+		backend.password, _ = u.User.Password()
+	}
+	if db := strings.TrimPrefix(u.Path, "/"); db != "" {
+		database, err := strconv.Atoi(db)
+		if err != nil {
+			return nil, fmt.Errorf("invalid redis database '%s'", db)
+		}
+		backend.database = database
+	}
+	return backend, nil
+}
+
+// Load returns a hash of the value as revision
+func (r *redisBackend) Load() ([]byte, string, error) {
+	var data []byte
+	err := r.session(func(conn *redisConn) error {
+		value, err := conn.command("GET", r.key)
+		data = value
+		return err
+	})
+	return data, contentRevision(data), err
+}
+
+// Save compares the value with the revision in a transaction watching the key, it fails if another
+// client sets the key in between
+func (r *redisBackend) Save(data []byte, revision string) (string, error) {
+	err := r.session(func(conn *redisConn) error {
+		if _, err := conn.command("WATCH", r.key); err != nil {
+			return err
+		}
+		current, err := conn.command("GET", r.key)
+		if err != nil {
+			return err
+		}
+		if contentRevision(current) != revision {
+			return ErrConflict
+		}
+
+		if _, err := conn.command("MULTI"); err != nil {
+			return err
+		}
+		if _, err := conn.command("SET", r.key, string(data)); err != nil {
+			return err
+		}
+		return conn.exec()
+	})
+	if err != nil {
+		return "", err
+	}
+	return contentRevision(data), nil
+}
+
+func (r *redisBackend) String() string {
+	return fmt.Sprintf("redis://%s/%d?key=%s", r.address, r.database, r.key)
+}
+
+func (r *redisBackend) session(function func(conn *redisConn) error) error {
+	var (
+		c   net.Conn
+		err error
+	)
+	dialer := &net.Dialer{Timeout: redisTimeout}
+	if r.tls {
+		host, _, _ := net.SplitHostPort(r.address)
+		c, err = tls.DialWithDialer(dialer, "tcp", r.address, &tls.Config{ServerName: host})
+	} else {
+		c, err = dialer.Dial("tcp", r.address)
+	}
+	if err != nil {
+		return err
+	}
+	defer c.Close()
+	c.SetDeadline(time.Now().Add(redisTimeout))
+
+	conn := &redisConn{c, bufio.NewReader(c)}
+	if r.password != "" {
+		if _, err := conn.command("AUTH", r.password); err != nil {
+			return err
+		}
+	}
+	if r.database != 0 {
+		if _, err := conn.command("SELECT", strconv.Itoa(r.database)); err != nil {
+			return err
+		}
+	}
+	return function(conn)
+}
+
+type redisConn struct {
+	conn   net.Conn
+	reader *bufio.Reader
+}
+
+// command sends a command and returns the bulk string reply, nil for a nil reply
+func (c *redisConn) command(args ...string) ([]byte, error) {
+	if err := c.send(args...); err != nil {
+		return nil, err
+	}
+	line, err := c.readLine()
+	if err != nil {
+		return nil, err
+	}
+	return c.reply(line)
+}
+
+// exec executes the queued commands of a transaction, ErrConflict if a watched key was changed
+func (c *redisConn) exec() error {
+	if err := c.send("EXEC"); err != nil {
+		return err
+	}
+	line, err := c.readLine()
+	if err != nil {
+		return err
+	}
+	if line == "*-1" {
+		return ErrConflict
+	}
+	if line[0] != '*' {
+		_, err := c.reply(line)
+		if err == nil {
+			err = fmt.Errorf("unexpected redis reply '%s'", line)
+		}
+		return err
+	}
+
+	count, err := strconv.Atoi(line[1:])
+	if err != nil {
+		return err
+	}
+	for i := 0; i < count; i++ {
+		line, err := c.readLine()
+		if err == nil {
+			_, err = c.reply(line)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (c *redisConn) send(args ...string) error {
+	request := fmt.Sprintf("*%d\r\n", len(args))
+	for _, arg := range args {
+		request += fmt.Sprintf("$%d\r\n%s\r\n", len(arg), arg)
+	}
+	_, err := io.WriteString(c.conn, request)
+	return err
+}
+
+func (c *redisConn) readLine() (string, error) {
+	line, err := c.reader.ReadString('\n')
+	if err != nil {
+		return "", err
+	}
+	line = strings.TrimSuffix(line, "\r\n")
+	if line == "" {
+		return "", errors.New("empty redis reply")
+	}
+	return line, nil
+}
+
+// reply reads the reply starting with the line, arrays aren't supported
+func (c *redisConn) reply(line string) ([]byte, error) {
+	switch line[0] {
+	case '+', ':':
+		return []byte(line[1:]), nil
+	case '-':
+		return nil, fmt.Errorf("redis: %s", line[1:])
+	case '$':
+		length, err := strconv.Atoi(line[1:])
+		if err != nil {
+			return nil, err
+		}
+		if length < 0 {
+			return nil, nil
+		}
+		data := make([]byte, length+2)
+		if _, err := io.ReadFull(c.reader, data); err != nil {
+			return nil, err
+		}
+		return data[:length], nil
+	}
+	return nil, fmt.Errorf("unexpected redis reply '%s'", line)
+}
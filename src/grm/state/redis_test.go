@@ -0,0 +1,231 @@
+package state
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestRedisCommand(t *testing.T) {
+	tests := []struct {
+		name  string
+		reply string
+		value []byte
+		err   string
+	}{
+		{"simple string", "+OK\r\n", []byte("OK"), ""},
+		{"integer", ":42\r\n", []byte("42"), ""},
+		{"bulk string", "$5\r\nstate\r\n", []byte("state"), ""},
+		{"bulk string with CRLF", "$6\r\na\r\nb\r\n\r\n", []byte("a\r\nb\r\n"), ""},
+		{"empty bulk string", "$0\r\n\r\n", []byte{}, ""},
+		{"nil bulk string", "$-1\r\n", nil, ""},
+		{"error", "-WRONGPASS invalid username-password pair\r\n", nil, "redis: WRONGPASS invalid username-password pair"},
+		{"empty reply", "\r\n", nil, "empty redis reply"},
+		{"array", "*1\r\n$1\r\na\r\n", nil, "unexpected redis reply '*1'"},
+		{"invalid length", "$x\r\n", nil, "strconv.Atoi: parsing \"x\": invalid syntax"},
+		{"truncated bulk string", "$10\r\nstate", nil, "unexpected EOF"},
+		{"connection closed", "", nil, "EOF"},
+	}
+	for _, test := range tests {
+		client, server := net.Pipe()
+		requests := make(chan string, 1)
+		go func(reply string) {
+			defer server.Close()
+			request := make([]byte, len("*2\r\n$3\r\nGET\r\n$9\r\ngrm/state\r\n"))
+			io.ReadFull(server, request)
+			requests <- string(request)
+			io.WriteString(server, reply)
+		}(test.reply)
+
+		conn := &redisConn{client, bufio.NewReader(client)}
+		value, err := conn.command("GET", "grm/state")
+		client.Close()
+		if request := <-requests; request != "*2\r\n$3\r\nGET\r\n$9\r\ngrm/state\r\n" {
+			t.Errorf("%s: request %q", test.name, request)
+		}
+		switch {
+		case test.err != "" && (err == nil || err.Error() != test.err):
+			t.Errorf("%s: error %v, expected %q", test.name, err, test.err)
+		case test.err == "" && err != nil:
+			t.Errorf("%s: %s", test.name, err)
+		case test.err == "" && (string(value) != string(test.value) || (value == nil) != (test.value == nil)):
+			t.Errorf("%s: value %q, expected %q", test.name, value, test.value)
+		}
+	}
+}
+
+func TestRedisExec(t *testing.T) {
+	tests := []struct {
+		name  string
+		reply string
+		err   error
+	}{
+		{"executed", "*1\r\n+OK\r\n", nil},
+		{"watched key changed", "*-1\r\n", ErrConflict},
+		{"aborted", "-EXECABORT Transaction discarded because of previous errors.\r\n",
+			errors.New("redis: EXECABORT Transaction discarded because of previous errors.")},
+		{"failed command", "*1\r\n-OOM command not allowed\r\n", errors.New("redis: OOM command not allowed")},
+	}
+	for _, test := range tests {
+		client, server := net.Pipe()
+		go func(reply string) {
+			defer server.Close()
+			io.ReadFull(server, make([]byte, len("*1\r\n$4\r\nEXEC\r\n")))
+			io.WriteString(server, reply)
+		}(test.reply)
+
+		err := (&redisConn{client, bufio.NewReader(client)}).exec()
+		client.Close()
+		if fmt.Sprint(err) != fmt.Sprint(test.err) {
+			t.Errorf("%s: error %v, expected %v", test.name, err, test.err)
+		}
+	}
+}
+
+func TestNewRedisBackend(t *testing.T) {
+	tests := []struct {
+		url     string
+		backend redisBackend
+		err     string
+	}{
+		{"redis://localhost", redisBackend{address: "localhost:6379", key: redisDefaultKey}, ""},
+		{"rediss://:s3cret@cache.example.com:6380/2?key=grm-state",
+			redisBackend{address: "cache.example.com:6380", tls: true, password: "s3cret", database: 2, key: "grm-state"}, ""},
+		{"redis://localhost/one", redisBackend{}, "invalid redis database 'one'"},
+	}
+	for _, test := range tests {
+		u, err := url.Parse(test.url)
+		if err != nil {
+			t.Fatal(err)
+		}
+		backend, err := newRedisBackend(u)
+		if test.err != "" {
+			if err == nil || err.Error() != test.err {
+				t.Errorf("%s: error %v, expected %q", test.url, err, test.err)
+			}
+			continue
+		}
+		if err != nil || *backend != test.backend {
+			t.Errorf("%s: backend %+v (%v), expected %+v", test.url, backend, err, test.backend)
+		}
+	}
+}
+
+// serveRedis answers the commands of one connection like a redis server with the password s3cret,
+// transactions always succeed
+func serveRedis(conn net.Conn, values map[string]string, commands chan<- string) {
+	defer conn.Close()
+	reader := bufio.NewReader(conn)
+	queued := []func(){}
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			return
+		}
+		count, _ := strconv.Atoi(strings.TrimSpace(line[1:]))
+		args := make([]string, count)
+		for i := range args {
+			line, _ = reader.ReadString('\n')
+			length, _ := strconv.Atoi(strings.TrimSpace(line[1:]))
+			data := make([]byte, length+2)
+			io.ReadFull(reader, data)
+			args[i] = string(data[:length])
+		}
+		commands <- args[0]
+
+		switch args[0] {
+		case "AUTH":
+			if args[1] != "s3cret" {
+				io.WriteString(conn, "-WRONGPASS invalid username-password pair\r\n")
+				return
+			}
+			io.WriteString(conn, "+OK\r\n")
+		case "SELECT", "WATCH":
+			io.WriteString(conn, "+OK\r\n")
+		case "MULTI":
+			queued = []func(){}
+			io.WriteString(conn, "+OK\r\n")
+		case "SET":
+			queued = append(queued, func() { values[args[1]] = args[2] })
+			io.WriteString(conn, "+QUEUED\r\n")
+		case "EXEC":
+			fmt.Fprintf(conn, "*%d\r\n", len(queued))
+			for _, command := range queued {
+				command()
+				io.WriteString(conn, "+OK\r\n")
+			}
+		case "GET":
+			value, ok := values[args[1]]
+			if !ok {
+				io.WriteString(conn, "$-1\r\n")
+				continue
+			}
+			fmt.Fprintf(conn, "$%d\r\n%s\r\n", len(value), value)
+		}
+	}
+}
+
+func TestRedisBackend(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+	values := make(map[string]string)
+	commands := make(chan string, 100)
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			serveRedis(conn, values, commands)
+		}
+	}()
+
+	received := func() string {
+		names := []string{}
+		for len(commands) > 0 {
+			names = append(names, <-commands)
+		}
+		return strings.Join(names, " ")
+	}
+	u, _ := url.Parse(fmt.Sprintf("redis://:s3cret@%s/3", listener.Addr()))
+	backend, err := newRedisBackend(u)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if data, revision, err := backend.Load(); err != nil || data != nil || revision != "" {
+		t.Errorf("loaded %q, revision %q (%v) before saving", data, revision, err)
+	}
+	revision, err := backend.Save([]byte("{\"releases\":{}}\r\n"), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if data, loaded, err := backend.Load(); err != nil || string(data) != "{\"releases\":{}}\r\n" || loaded != revision {
+		t.Errorf("loaded %q, revision %q (%v), expected %q", data, loaded, err, revision)
+	}
+	if commands := received(); commands != "AUTH SELECT GET AUTH SELECT WATCH GET MULTI SET EXEC AUTH SELECT GET" {
+		t.Errorf("commands %s", commands)
+	}
+
+	// Saved by someone else since the revision was loaded
+	if _, err := backend.Save([]byte("{}"), ""); err != ErrConflict {
+		t.Errorf("saved over another state, %v", err)
+	}
+	if commands := received(); commands != "AUTH SELECT WATCH GET" {
+		t.Errorf("commands %s of a conflicting save", commands)
+	}
+
+	backend.password = "wrong"
+	if _, _, err := backend.Load(); err == nil || !strings.Contains(err.Error(), "WRONGPASS") {
+		t.Errorf("error %v with a wrong password", err)
+	}
+}
@@ -0,0 +1,123 @@
+package state
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"grm/cloud"
+)
+
+// s3Backend stores the state as an object in an S3 bucket or an S3 compatible storage like MinIO
+type s3Backend struct {
+	objectUrl   string
+	credentials cloud.AwsCredentials
+}
+
+func newS3Backend(bucket, object, region, endpoint string) (*s3Backend, error) {
+	credentials, err := cloud.ReadAwsCredentials()
+	if err != nil {
+		return nil, err
+	}
+	if region != "" {
+		credentials.Region = region
+	}
+
+	// Path style addressing works with all buckets names and S3 compatible storages
+	if endpoint == "" {
+		endpoint = fmt.Sprintf("https://s3.%s.amazonaws.com", credentials.Region)
+	}
+	objectUrl := fmt.Sprintf("%s/%s/%s", strings.TrimSuffix(endpoint, "/"), bucket, escapeObject(object))
+	return &s3Backend{objectUrl, credentials}, nil
+}
+
+// Load returns the ETag of the object as revision
+func (s *s3Backend) Load() ([]byte, string, error) {
+	req, err := http.NewRequest(http.MethodGet, s.objectUrl, nil)
+	if err != nil {
+		return nil, "", err
+	}
+	s.credentials.SignV4(req, nil, "s3")
+	data, header, err := loadObject(req)
+	if data == nil || err != nil {
+		return nil, "", err
+	}
+	return data, header.Get("ETag"), nil
+}
+
+// Save is a conditional write: S3 only replaces the object if its ETag still matches, or only creates
+// it if it doesn't exist yet
+func (s *s3Backend) Save(data []byte, revision string) (string, error) {
+	req, err := http.NewRequest(http.MethodPut, s.objectUrl, bytes.NewReader(data))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if revision == "" {
+		req.Header.Set("If-None-Match", "*")
+	} else {
+		req.Header.Set("If-Match", revision)
+	}
+	s.credentials.SignV4(req, data, "s3")
+	header, _, err := saveObject(req)
+	if err != nil {
+		return "", err
+	}
+	return header.Get("ETag"), nil
+}
+
+func (s *s3Backend) String() string {
+	return s.objectUrl
+}
+
+func escapeObject(object string) string {
+	elements := strings.Split(object, "/")
+	for i, element := range elements {
+		elements[i] = url.PathEscape(element)
+	}
+	return strings.Join(elements, "/")
+}
+
+// loadObject executes an object download, a missing object is not an error
+func loadObject(req *http.Request) ([]byte, http.Header, error) {
+	response, err := HttpClient.Do(req)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode == http.StatusNotFound {
+		return nil, nil, nil
+	}
+	data, err := ioutil.ReadAll(response.Body)
+	if err != nil {
+		return nil, nil, err
+	}
+	if response.StatusCode != http.StatusOK {
+		return nil, nil, fmt.Errorf("could not read %s: %s %s", req.URL, response.Status, strings.TrimSpace(string(data)))
+	}
+	return data, response.Header, nil
+}
+
+// saveObject executes a conditional object upload, a failed precondition is returned as ErrConflict.
+// S3 answers 409 if another conditional write of the object is in progress.
+func saveObject(req *http.Request) (http.Header, []byte, error) {
+	response, err := HttpClient.Do(req)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer response.Body.Close()
+
+	data, err := ioutil.ReadAll(response.Body)
+	switch {
+	case response.StatusCode == http.StatusPreconditionFailed || response.StatusCode == http.StatusConflict:
+		return nil, nil, ErrConflict
+	case response.StatusCode/100 != 2:
+		return nil, nil, fmt.Errorf("could not write %s: %s %s", req.URL, response.Status, strings.TrimSpace(string(data)))
+	case err != nil:
+		return nil, nil, err
+	}
+	return response.Header, data, nil
+}
@@ -0,0 +1,89 @@
+package state
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// objectServer keeps one object like S3 or GCS, with a revision counted up by every write
+type objectServer struct {
+	data     []byte
+	revision int
+}
+
+// save writes the object if the precondition holds, the current revision or 0 for a missing object
+func (o *objectServer) save(writer http.ResponseWriter, request *http.Request, precondition int) bool {
+	if precondition != o.revision {
+		http.Error(writer, "PreconditionFailed", http.StatusPreconditionFailed)
+		return false
+	}
+	o.data, _ = ioutil.ReadAll(request.Body)
+	o.revision++
+	return true
+}
+
+func (o *objectServer) ServeS3(writer http.ResponseWriter, request *http.Request) {
+	etag := fmt.Sprintf("\"%d\"", o.revision)
+	switch {
+	case request.Method == http.MethodGet && o.data == nil:
+		http.NotFound(writer, request)
+	case request.Method == http.MethodGet:
+		writer.Header().Set("ETag", etag)
+		writer.Write(o.data)
+	case request.Header.Get("If-None-Match") == "*":
+		if o.save(writer, request, 0) {
+			writer.Header().Set("ETag", fmt.Sprintf("\"%d\"", o.revision))
+		}
+	case request.Header.Get("If-Match") == etag:
+		if o.save(writer, request, o.revision) {
+			writer.Header().Set("ETag", fmt.Sprintf("\"%d\"", o.revision))
+		}
+	default:
+		http.Error(writer, "PreconditionFailed", http.StatusPreconditionFailed)
+	}
+}
+
+func TestS3Backend(t *testing.T) {
+	t.Setenv("AWS_ACCESS_KEY_ID", "AKIDEXAMPLE")
+	t.Setenv("AWS_SECRET_ACCESS_KEY", "secret")
+	object := &objectServer{}
+	server := httptest.NewServer(http.HandlerFunc(object.ServeS3))
+	defer server.Close()
+
+	backend, err := newS3Backend("bucket", "grm/state.json", "eu-west-1", server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkObjectBackend(t, backend)
+}
+
+// checkObjectBackend saves and loads the state, saves of outdated revisions fail
+func checkObjectBackend(t *testing.T, backend Backend) {
+	if data, revision, err := backend.Load(); err != nil || data != nil || revision != "" {
+		t.Errorf("loaded %q, revision %q (%v) before saving", data, revision, err)
+	}
+	first, err := backend.Save([]byte("{}"), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := backend.Save([]byte("{}"), ""); err != ErrConflict {
+		t.Errorf("created the state twice, %v", err)
+	}
+
+	second, err := backend.Save([]byte("{\"seen\":{}}"), first)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := backend.Save([]byte("{}"), first); err != ErrConflict {
+		t.Errorf("saved over revision %s with revision %s, %v", second, first, err)
+	}
+	if data, revision, err := backend.Load(); err != nil || string(data) != "{\"seen\":{}}" || revision != second {
+		t.Errorf("loaded %q, revision %q (%v), expected revision %q", data, revision, err, second)
+	}
+	if second == first {
+		t.Errorf("revision %q unchanged", second)
+	}
+}
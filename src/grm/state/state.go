@@ -0,0 +1,154 @@
+package state
+
+import (
+	"encoding/json"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Store persists information between runs, like previously seen releases or
+// licenses. Values are stored as JSON documents under slash separated keys.
+// The whole store is loaded from and saved to its backend as one document.
+// If someone else saved the document in between, the store is reloaded and
+// its own changes are applied again.
+type Store struct {
+	backend  Backend
+	values   map[string]json.RawMessage
+	revision string
+	// changes are the keys set or deleted (nil) since the store was saved
+	changes map[string]json.RawMessage
+	mutex   sync.Mutex
+}
+
+// saveAttempts limits the retries of a save conflicting with concurrent saves
+const saveAttempts = 5
+
+// NewStore opens a store kept in a local file
+func NewStore(path string) (*Store, error) {
+	return Open(NewFileBackend(path))
+}
+
+// Open loads the store from the backend
+func Open(backend Backend) (*Store, error) {
+	store := &Store{
+		backend: backend,
+		changes: make(map[string]json.RawMessage),
+	}
+	if err := store.load(); err != nil {
+		return nil, err
+	}
+	return store, nil
+}
+
+// load reads the values and their revision from the backend
+func (s *Store) load() error {
+	data, revision, err := s.backend.Load()
+	if err != nil {
+		return err
+	}
+	values := make(map[string]json.RawMessage)
+	if data != nil {
+		if err := json.Unmarshal(data, &values); err != nil {
+			return err
+		}
+	}
+	s.values, s.revision = values, revision
+	return nil
+}
+
+func (s *Store) Backend() Backend {
+	return s.backend
+}
+
+// Key joins the given elements into a store key
+func Key(elements ...string) string {
+	return strings.Join(elements, "/")
+}
+
+func (s *Store) Get(key string, value interface{}) bool {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	data, ok := s.values[key]
+	if !ok {
+		return false
+	}
+	return json.Unmarshal(data, value) == nil
+}
+
+func (s *Store) Set(key string, value interface{}) error {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.values[key] = data
+	s.changes[key] = data
+	return nil
+}
+
+func (s *Store) Delete(key string) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if _, ok := s.values[key]; ok {
+		delete(s.values, key)
+		s.changes[key] = nil
+	}
+}
+
+// Keys returns all keys starting with the given prefix in sorted order
+func (s *Store) Keys(prefix string) []string {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	keys := make([]string, 0)
+	for key := range s.values {
+		if strings.HasPrefix(key, prefix) {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// Save writes the store back to its backend, if anything has changed. Changes saved by someone
+// else since the store was loaded are kept, values both changed are overwritten.
+func (s *Store) Save() error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if len(s.changes) == 0 {
+		return nil
+	}
+
+	for attempt := 1; ; attempt++ {
+		data, err := json.MarshalIndent(s.values, "", "  ")
+		if err != nil {
+			return err
+		}
+		revision, err := s.backend.Save(data, s.revision)
+		if err == nil {
+			s.revision = revision
+			s.changes = make(map[string]json.RawMessage)
+			return nil
+		}
+		if err != ErrConflict || attempt == saveAttempts {
+			return err
+		}
+
+		if err := s.load(); err != nil {
+			return err
+		}
+		for key, value := range s.changes {
+			if value == nil {
+				delete(s.values, key)
+			} else {
+				s.values[key] = value
+			}
+		}
+	}
+}
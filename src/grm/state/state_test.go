@@ -0,0 +1,97 @@
+package state
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestSaveConcurrentChanges(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state.json")
+	initial, err := NewStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	initial.Set("acknowledged/terraform", "v0.11.7")
+	initial.Set("acknowledged/vault", "v0.10.0")
+	if err := initial.Save(); err != nil {
+		t.Fatal(err)
+	}
+
+	// Both stores are loaded before either one is saved, like grm serve and grm run
+	serve, err := NewStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	run, err := NewStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	serve.Set("acknowledged/terraform", "v0.11.8")
+	serve.Delete("acknowledged/vault")
+	run.Set("seen/consul", "v1.2.2")
+	run.Set("acknowledged/terraform", "v0.11.7")
+	if err := serve.Save(); err != nil {
+		t.Fatal(err)
+	}
+	if err := run.Save(); err != nil {
+		t.Fatal(err)
+	}
+
+	saved, err := NewStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		key   string
+		value string
+	}{
+		// The later save wins values changed by both
+		{"acknowledged/terraform", "v0.11.7"},
+		{"acknowledged/vault", ""},
+		{"seen/consul", "v1.2.2"},
+	}
+	for _, test := range tests {
+		value := ""
+		saved.Get(test.key, &value)
+		if value != test.value {
+			t.Errorf("%s: saved %q, expected %q", test.key, value, test.value)
+		}
+		run.Get(test.key, &value)
+		if value != test.value {
+			t.Errorf("%s: %q after saving, expected %q", test.key, value, test.value)
+		}
+	}
+}
+
+// conflictingBackend fails every save like a backend written concurrently all the time
+type conflictingBackend struct {
+	saves int
+}
+
+func (b *conflictingBackend) Load() ([]byte, string, error) {
+	return []byte("{}"), "1", nil
+}
+
+func (b *conflictingBackend) Save(data []byte, revision string) (string, error) {
+	b.saves++
+	return "", ErrConflict
+}
+
+func (b *conflictingBackend) String() string {
+	return "conflicting"
+}
+
+func TestSaveGivesUp(t *testing.T) {
+	backend := &conflictingBackend{}
+	store, err := Open(backend)
+	if err != nil {
+		t.Fatal(err)
+	}
+	store.Set("seen/consul", "v1.2.2")
+	if err := store.Save(); err != ErrConflict {
+		t.Errorf("error %v", err)
+	}
+	if backend.saves != saveAttempts {
+		t.Errorf("%d attempts, expected %d", backend.saves, saveAttempts)
+	}
+}
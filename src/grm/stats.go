@@ -0,0 +1,133 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"text/tabwriter"
+	"time"
+)
+
+// Number of busiest repositories listed per remote definition
+const statsBusiestRepositories = 5
+
+type monthStats struct {
+	Month    string `json:"month"`
+	Releases int    `json:"releases"`
+}
+
+type repositoryStats struct {
+	Repository string `json:"repository"`
+	Releases   int    `json:"releases"`
+}
+
+// remoteStats summarizes the release frequency of the repositories of a remote definition
+type remoteStats struct {
+	Remote       string            `json:"remote"`
+	Releases     int               `json:"releases"`
+	Months       []monthStats      `json:"months"`
+	AverageDays  float64           `json:"average_days_between_releases"`
+	Busiest      []repositoryStats `json:"busiest_repositories"`
+	Repositories int               `json:"repositories"`
+}
+
+// buildStats summarizes the release history of the last months, counted by release date
+func buildStats(records []historyRecord, months int, now time.Time) []remoteStats {
+	now = locale.Time(now)
+	start := time.Date(now.Year(), now.Month()-time.Month(months-1), 1, 0, 0, 0, 0, now.Location())
+
+	byRemote := make(map[string][]historyRecord)
+	names := make([]string, 0)
+	for _, record := range records {
+		if locale.Time(record.Released).Before(start) {
+			continue
+		}
+		if _, ok := byRemote[record.remote]; !ok {
+			names = append(names, record.remote)
+		}
+		byRemote[record.remote] = append(byRemote[record.remote], record)
+	}
+	sort.Strings(names)
+
+	stats := make([]remoteStats, 0, len(names))
+	for _, name := range names {
+		stats = append(stats, summarizeRemote(name, byRemote[name], start, months))
+	}
+	return stats
+}
+
+func summarizeRemote(name string, records []historyRecord, start time.Time, months int) remoteStats {
+	s := remoteStats{Remote: name, Releases: len(records)}
+
+	counts := make(map[string]int)
+	released := make(map[string][]time.Time)
+	for _, record := range records {
+		counts[locale.Time(record.Released).Format("2006-01")]++
+		released[record.repository] = append(released[record.repository], record.Released)
+	}
+	for i := 0; i < months; i++ {
+		month := start.AddDate(0, i, 0).Format("2006-01")
+		s.Months = append(s.Months, monthStats{month, counts[month]})
+	}
+
+	var total time.Duration
+	intervals := 0
+	s.Busiest = make([]repositoryStats, 0, len(released))
+	for repository, times := range released {
+		sort.Slice(times, func(i, j int) bool { return times[i].Before(times[j]) })
+		for i := 1; i < len(times); i++ {
+			total += times[i].Sub(times[i-1])
+			intervals++
+		}
+		s.Busiest = append(s.Busiest, repositoryStats{repository, len(times)})
+	}
+	if intervals > 0 {
+		s.AverageDays = total.Hours() / 24 / float64(intervals)
+	}
+
+	s.Repositories = len(s.Busiest)
+	sort.Slice(s.Busiest, func(i, j int) bool {
+		if s.Busiest[i].Releases != s.Busiest[j].Releases {
+			return s.Busiest[i].Releases > s.Busiest[j].Releases
+		}
+		return s.Busiest[i].Repository < s.Busiest[j].Repository
+	})
+	if len(s.Busiest) > statsBusiestRepositories {
+		s.Busiest = s.Busiest[:statsBusiestRepositories]
+	}
+	return s
+}
+
+func formatStatsText(writer io.Writer, stats []remoteStats) error {
+	for _, s := range stats {
+		fmt.Fprintln(writer, fmt.Sprintf("Remote: %s", s.Remote))
+		fmt.Fprintln(writer, fmt.Sprintf("Releases: %d in %d repositories", s.Releases, s.Repositories))
+		if s.AverageDays > 0 {
+			fmt.Fprintln(writer, fmt.Sprintf("Average days between releases: %.1f", s.AverageDays))
+		}
+		fmt.Fprintln(writer, "")
+
+		table := tabwriter.NewWriter(writer, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(table, "Month\tReleases")
+		for _, month := range s.Months {
+			fmt.Fprintln(table, fmt.Sprintf("%s\t%d", month.Month, month.Releases))
+		}
+		fmt.Fprintln(table, "")
+		fmt.Fprintln(table, "Busiest repository\tReleases")
+		for _, busiest := range s.Busiest {
+			fmt.Fprintln(table, fmt.Sprintf("%s\t%d", busiest.Repository, busiest.Releases))
+		}
+		if err := table.Flush(); err != nil {
+			return err
+		}
+		fmt.Fprintln(writer, "")
+	}
+	return nil
+}
+
+func formatStatsJson(writer io.Writer, stats []remoteStats) error {
+	encoder := json.NewEncoder(writer)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(stats)
+}
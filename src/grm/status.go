@@ -0,0 +1,65 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"text/tabwriter"
+	"time"
+)
+
+// pendingReleases returns the releases found by reports after the first report of their repository
+// which are neither acknowledged nor snoozed. Releases recorded by the first report of a repository
+// only seed its history and are never pending.
+func pendingReleases(records []historyRecord) []historyRecord {
+	baselines := make(map[string]time.Time)
+	for _, record := range records {
+		key := record.remote + "/" + record.repository
+		if baseline, ok := baselines[key]; !record.Seen.IsZero() && (!ok || record.Seen.Before(baseline)) {
+			baselines[key] = record.Seen
+		}
+	}
+
+	now := time.Now()
+	pending := make([]historyRecord, 0)
+	for _, record := range records {
+		if !record.Seen.After(baselines[record.remote+"/"+record.repository]) {
+			continue
+		}
+		if _, ok := readAcknowledgement(record.remote, record.repository, record.Tag); ok {
+			continue
+		}
+		if snoozedRecord(record, now) {
+			continue
+		}
+		pending = append(pending, record)
+	}
+	return pending
+}
+
+func snoozedRecord(record historyRecord, now time.Time) bool {
+	for _, s := range readSnoozes(record.remote, record.repository) {
+		if s.Until.After(now) && s.matches(extractVersion(record.remote, record.repository, record.Tag)) {
+			return true
+		}
+	}
+	return false
+}
+
+// formatStatusShort prints a single token for shell prompts and status bars, nothing without pending
+// releases
+func formatStatusShort(writer io.Writer, pending []historyRecord, symbol string) {
+	if len(pending) == 0 {
+		return
+	}
+	fmt.Fprintln(writer, fmt.Sprintf("%d%s", len(pending), symbol))
+}
+
+func formatStatusText(writer io.Writer, pending []historyRecord) error {
+	table := tabwriter.NewWriter(writer, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(table, "Remote\tRepository\tRelease\tReleased\tSeen")
+	for _, record := range pending {
+		fmt.Fprintln(table, fmt.Sprintf("%s\t%s\t%s\t%s\t%s", record.remote, record.repository, record.Tag,
+			locale.Date(record.Released), locale.Date(record.Seen)))
+	}
+	return table.Flush()
+}
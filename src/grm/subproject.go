@@ -0,0 +1,85 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"grm/config"
+)
+
+// readSubprojects returns the tag prefixes of the sub-projects released from the repository, like
+// api/ and cli/ of a monorepo tagging api/v1.2.0 and cli/v0.9.0
+func readSubprojects(name, repository string) []string {
+	prefixes := make([]string, 0)
+	value, ok := namedSectionGet(name, config.Remote, config.Subprojects, repository)
+	if !ok {
+		return prefixes
+	}
+	for _, prefix := range strings.Split(value, ",") {
+		if prefix = strings.TrimSpace(prefix); prefix != "" {
+			prefixes = append(prefixes, prefix)
+		}
+	}
+	// The longest prefix wins for nested sub-projects like api/ and api/v2/
+	sort.Slice(prefixes, func(i, j int) bool {
+		return len(prefixes[i]) > len(prefixes[j])
+	})
+	return prefixes
+}
+
+// subprojectOf returns the sub-project of the tag and its tag prefix, both are empty for tags of the
+// repository itself
+func subprojectOf(prefixes []string, tag string) (string, string) {
+	for _, prefix := range prefixes {
+		if strings.HasPrefix(tag, prefix) {
+			return strings.TrimRight(prefix, "/-_@"), prefix
+		}
+	}
+	return "", ""
+}
+
+// subprojectName names a release line in reports and state, repository/sub-project for sub-projects
+func subprojectName(repository, subproject string) string {
+	if subproject == "" {
+		return repository
+	}
+	return repository + "/" + subproject
+}
+
+// subprojectGet reads a property of a sub-project. Sub-project overrides are set like repository
+// overrides with --repository=<repository>/<sub-project> and fall back to those of the repository.
+func subprojectGet(name string, key config.Key, repository, subproject string) (string, bool) {
+	if subproject != "" {
+		overrides, err := configuration.NamedSectionGetOverrides(name, config.Remote, key)
+		if err != nil {
+			log.Fatal(fmt.Sprintf("Could not read the configuration of %s: ", name), err)
+		}
+		if value, ok := overrides[key.Name()+":"+subprojectName(repository, subproject)]; ok {
+			return value, true
+		}
+	}
+	return namedSectionGet(name, config.Remote, key, repository)
+}
+
+// groupHistory splits a release history into the release lines of the repository by sub-project and
+// channel, keyed by the name of the line. Each line keeps its own baseline for the cadence.
+func groupHistory(prefixes []string, classifier channelClassifier, repository string, entries []historyEntry) map[string][]historyEntry {
+	lines := make(map[string][]historyEntry)
+	for _, entry := range entries {
+		subproject, _ := subprojectOf(prefixes, entry.Tag)
+		line := releaseLineName(repository, subproject, classifier.channel(entry.Tag))
+		lines[line] = append(lines[line], entry)
+	}
+	return lines
+}
+
+// historyLines returns the names of the grouped release lines in order, the repository first
+func historyLines(lines map[string][]historyEntry) []string {
+	names := make([]string, 0, len(lines))
+	for line := range lines {
+		names = append(names, line)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"grm/config"
+	"grm/state"
+)
+
+// Release notes up to this number of lines are short enough to be shown as they are
+const summaryMinimumLines = 3
+
+// summarizer condenses long release notes into a short summary
+type summarizer interface {
+	summarize(notes string) (string, error)
+}
+
+// commandSummarizer pipes the release notes into a command, e.g. a script calling a language model API,
+// and uses its output as the summary
+type commandSummarizer struct {
+	command string
+}
+
+func (s commandSummarizer) summarize(notes string) (string, error) {
+	var stdout, stderr bytes.Buffer
+	cmd := shellCommand(s.command)
+	cmd.Stdin = strings.NewReader(notes)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("%s: %s", err, strings.TrimSpace(stderr.String()))
+	}
+	return strings.TrimSpace(stdout.String()), nil
+}
+
+func newSummarizer(name, repository string) summarizer {
+	if command, ok := namedSectionGet(name, config.Remote, config.SummaryCmd, repository); ok && command != "" {
+		return commandSummarizer{command}
+	}
+	return nil
+}
+
+// summarizeReleases summarizes the notes of all new releases with long release notes. Summaries are
+// remembered by the checksum of the notes, so unchanged notes are never summarized twice.
+func summarizeReleases(name string, rep *repository) {
+	summarizer := newSummarizer(name, rep.name)
+	if summarizer == nil {
+		return
+	}
+
+	for _, rel := range rep.releases {
+		notes := strings.TrimSpace(rel.notes)
+		if !rel.published || len(strings.Split(notes, "\n")) <= summaryMinimumLines {
+			continue
+		}
+
+		checksum := sha256.Sum256([]byte(notes))
+		key := state.Key("summary", hex.EncodeToString(checksum[:]))
+		if openState().Get(key, &rel.summary) {
+			continue
+		}
+
+		summary, err := summarizer.summarize(notes)
+		if err != nil {
+			fmt.Fprintln(progressOutput, fmt.Sprintf("Could not summarize release notes of %s %s: %s", rep.name, rel.name, err))
+			continue
+		}
+		rel.summary = summary
+		openState().Set(key, summary)
+	}
+}
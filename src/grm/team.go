@@ -0,0 +1,85 @@
+package main
+
+import (
+	"github.com/google/go-github/github"
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"grm/config"
+)
+
+// readTeamRepositories returns the names of the repositories of the organization the team of the
+// remote definition has access to, false if no team is configured
+func readTeamRepositories(ctx context.Context, name, account string, client *github.Client) (map[string]bool, bool) {
+	slug, ok := namedSectionGet(name, config.Remote, config.Team, "")
+	slug = strings.TrimSpace(slug)
+	if !ok || slug == "" {
+		return nil, false
+	}
+
+	team := findTeam(ctx, account, slug, client)
+	repositories := make(map[string]bool)
+
+	page := 1
+	for {
+		r, response, err := client.Organizations.ListTeamRepos(ctx, team.GetID(), &github.ListOptions{
+			PerPage: 100,
+			Page:    page,
+		})
+
+		if rateLimit(response) {
+			continue
+		}
+
+		if err != nil {
+			log.Fatal(fmt.Sprintf("Could not retrieve repositories of team '%s': ", slug), err)
+		}
+
+		for _, repository := range r {
+			if strings.EqualFold(repository.GetOwner().GetLogin(), account) {
+				repositories[repository.GetName()] = true
+			}
+		}
+
+		if hasMorePages(response) {
+			page++
+			continue
+		}
+
+		return repositories, true
+	}
+}
+
+// findTeam looks up the team of the organization by slug or name
+func findTeam(ctx context.Context, organization, slug string, client *github.Client) *github.Team {
+	page := 1
+	for {
+		teams, response, err := client.Organizations.ListTeams(ctx, organization, &github.ListOptions{
+			PerPage: 100,
+			Page:    page,
+		})
+
+		if rateLimit(response) {
+			continue
+		}
+
+		if err != nil {
+			log.Fatal(fmt.Sprintf("Could not retrieve teams of '%s', teams are only supported for organizations: ", organization), err)
+		}
+
+		for _, team := range teams {
+			if team.GetSlug() == slug || strings.EqualFold(team.GetName(), slug) {
+				return team
+			}
+		}
+
+		if hasMorePages(response) {
+			page++
+			continue
+		}
+
+		log.Fatal(fmt.Sprintf("Unknown team '%s' of organization '%s'", slug, organization))
+		return nil
+	}
+}
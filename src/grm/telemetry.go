@@ -0,0 +1,113 @@
+package main
+
+import (
+	"net/http"
+	"strconv"
+	"fmt"
+	"log"
+	"time"
+	"grm/metrics"
+	"grm/config"
+	"grm/tracing"
+	"os"
+)
+
+// Spans of the current run, nil if tracing is disabled
+var tracer *tracing.Tracer
+
+// newTracer creates a tracer if an OTLP endpoint is configured, either by the global otlp-endpoint
+// property or the standard OTEL_EXPORTER_OTLP_ENDPOINT environment variable
+func newTracer() *tracing.Tracer {
+	endpoint := os.Getenv("OTEL_EXPORTER_OTLP_ENDPOINT")
+	if e, ok := sectionGet(config.Core, config.OtlpEndpoint, ""); ok && e != "" {
+		endpoint = e
+	}
+	if endpoint == "" {
+		return nil
+	}
+	headers := tracing.ParseHeaders(os.Getenv("OTEL_EXPORTER_OTLP_HEADERS"))
+	return tracing.NewTracer(endpoint, headers, "grm", buildVersion)
+}
+
+// flushTraces exports the finished spans, failures are only logged
+func flushTraces() {
+	if err := tracer.Flush(); err != nil {
+		log.Println("Could not export traces: ", err)
+	}
+}
+
+// Metrics of the current run, pushed to a Pushgateway or statsd after the report
+var runMetrics = newRunMetrics()
+
+func newRunMetrics() *metrics.Registry {
+	registry := metrics.NewRegistry()
+	registry.Define("grm_new_releases", metrics.Gauge, "Number of new releases with a matching milestone")
+	registry.Define("grm_repositories", metrics.Gauge, "Number of repositories with releases in the report")
+	registry.Define("grm_api_requests_total", metrics.Counter, "Number of Github API requests")
+	registry.Define("grm_api_errors_total", metrics.Counter, "Number of failed Github API requests")
+	registry.Define("grm_api_rate_limit_remaining", metrics.Gauge, "Remaining Github API requests in the current rate limit window")
+	registry.Define("grm_run_duration_seconds", metrics.Gauge, "Duration of the report run")
+	registry.Define("grm_last_run_timestamp_seconds", metrics.Gauge, "Unix timestamp of the last report run")
+	return registry
+}
+
+// apiMetricsTransport counts Github API requests, failures and the remaining rate limit per remote definition
+type apiMetricsTransport struct {
+	name      string
+	transport http.RoundTripper
+}
+
+func (t *apiMetricsTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	labels := map[string]string{"remote": t.name}
+	runMetrics.Add("grm_api_requests_total", labels, 1)
+	countApiRequest(t.name)
+
+	response, err := t.transport.RoundTrip(req)
+	// Not found responses are expected, e.g. for repositories without releases or licenses
+	if err != nil || (response.StatusCode >= 400 && response.StatusCode != http.StatusNotFound) {
+		runMetrics.Add("grm_api_errors_total", labels, 1)
+		health.recordApiError()
+	}
+	if err == nil {
+		if remaining, err := strconv.Atoi(response.Header.Get("X-RateLimit-Remaining")); err == nil {
+			runMetrics.Set("grm_api_rate_limit_remaining", labels, float64(remaining))
+		}
+	}
+	return response, err
+}
+
+func recordReportMetrics(report *reportModel, started time.Time) {
+	labels := map[string]string{"remote": report.name}
+
+	repositories, releases := 0, 0
+	for _, rep := range report.repositories {
+		if len(rep.releases) > 0 {
+			repositories++
+		}
+		for _, rel := range rep.releases {
+			if rel.published {
+				releases++
+			}
+		}
+	}
+
+	runMetrics.Set("grm_new_releases", labels, float64(releases))
+	runMetrics.Set("grm_repositories", labels, float64(repositories))
+	runMetrics.Set("grm_run_duration_seconds", labels, time.Since(started).Seconds())
+	runMetrics.Set("grm_last_run_timestamp_seconds", labels, float64(time.Now().Unix()))
+}
+
+// pushMetrics pushes the run metrics to the configured Pushgateway and statsd endpoints. Failures are
+// only logged, a monitoring outage must not fail the report.
+func pushMetrics(name string) {
+	if url, ok := sectionGet(config.Core, config.PushgatewayUrl, ""); ok && url != "" {
+		if err := runMetrics.PushGateway(url, "grm", map[string]string{"remote": name}); err != nil {
+			log.Println(fmt.Sprintf("Could not push metrics to %s: %s", url, err))
+		}
+	}
+	if address, ok := sectionGet(config.Core, config.StatsdAddress, ""); ok && address != "" {
+		if err := runMetrics.Statsd(address); err != nil {
+			log.Println(fmt.Sprintf("Could not send metrics to %s: %s", address, err))
+		}
+	}
+}
@@ -0,0 +1,108 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+	"grm/config"
+)
+
+// Terraform Registry, a variable to point tests at other servers
+var terraformRegistryUrl = "https://registry.terraform.io"
+
+// terraformVersion is the registry entry of a provider or module version
+type terraformVersion struct {
+	Version     string    `json:"version"`
+	PublishedAt time.Time `json:"published_at"`
+	Source      string    `json:"source"`
+	Tag         string    `json:"tag"`
+}
+
+// buildTerraformReport reads the versions of the Terraform providers and modules listed by the
+// projects property, providers as namespace/type like hashicorp/aws and modules as
+// namespace/name/provider like terraform-aws-modules/vpc/aws. Versions stand in for tags. Private
+// registries are read with the token of the auth command.
+func buildTerraformReport(name, repositoryPattern string, since time.Time) *reportModel {
+	client := newProviderClient(name)
+	_, token, _ := readCredentials(name)
+	repos := readListedRepositories(name, config.Projects, repositoryPattern)
+	return buildProviderReport(name, "", repos, since, func(address string) []providerRelease {
+		return readTerraformVersions(client, token, name, address, since)
+	})
+}
+
+// readTerraformVersions lists the versions of the provider or module. The listing has no dates,
+// versions of the release history keep their date, newer versions are read one by one.
+func readTerraformVersions(client *http.Client, token, name, address string, since time.Time) []providerRelease {
+	kind := "providers"
+	switch strings.Count(address, "/") {
+	case 1:
+	case 2:
+		kind = "modules"
+	default:
+		log.Fatal(fmt.Sprintf("Invalid Terraform address '%s', expected namespace/type or namespace/name/provider", address))
+	}
+
+	known := make(map[string]time.Time)
+	for _, entry := range readHistory(name, address) {
+		known[entry.Tag] = entry.Released
+	}
+
+	tags := make([]providerRelease, 0)
+	for _, version := range listTerraformVersions(client, token, kind, address) {
+		if released, ok := known[version]; ok && !since.Before(released) {
+			continue
+		}
+		detail := terraformVersion{}
+		readTerraformRegistry(client, token, fmt.Sprintf("/v1/%s/%s/%s", kind, address, version), &detail)
+
+		tag := providerRelease{
+			version:  version,
+			created:  detail.PublishedAt.UTC(),
+			notesUrl: fmt.Sprintf("%s/%s/%s/%s", terraformRegistryUrl, kind, address, version),
+		}
+		// Registry versions are published from tags of a Github repository
+		if strings.HasPrefix(detail.Source, "https://github.com/") && detail.Tag != "" {
+			tag.downloadUrl = fmt.Sprintf("%s/releases/tag/%s", detail.Source, detail.Tag)
+		}
+		tags = append(tags, tag)
+	}
+	return tags
+}
+
+func listTerraformVersions(client *http.Client, token, kind, address string) []string {
+	listing := struct {
+		// Providers list their versions directly, modules per module
+		Versions []terraformVersion `json:"versions"`
+		Modules  []struct {
+			Versions []terraformVersion `json:"versions"`
+		} `json:"modules"`
+	}{}
+	readTerraformRegistry(client, token, fmt.Sprintf("/v1/%s/%s/versions", kind, address), &listing)
+
+	versions := listing.Versions
+	for _, module := range listing.Modules {
+		versions = append(versions, module.Versions...)
+	}
+	names := make([]string, 0, len(versions))
+	for _, version := range versions {
+		names = append(names, version.Version)
+	}
+	return names
+}
+
+func readTerraformRegistry(client *http.Client, token, path string, value interface{}) {
+	request, err := http.NewRequest("GET", terraformRegistryUrl+path, nil)
+	if err != nil {
+		log.Fatal(fmt.Sprintf("Could not create request for %s: ", path), err)
+	}
+	if token != "" {
+		request.Header.Set("Authorization", "Bearer "+token)
+	}
+	if err := json.Unmarshal(readProvider(client, request), value); err != nil {
+		log.Fatal(fmt.Sprintf("Could not parse %s: ", request.URL), err)
+	}
+}
@@ -0,0 +1,132 @@
+package main
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+	"grm/notify"
+	"grm/state"
+)
+
+// sink is a notification sink together with the throttling configured by the parameters of its URL:
+//
+//	min-severity=major  only releases of at least this severity, unknown severities don't pass
+//	advisories          only releases referencing a CVE or a Github security advisory
+//	rate=10/h           at most 10 messages per hour, further messages are dropped
+type sink struct {
+	*notify.Sink
+	minSeverity string
+	advisories  bool
+	limit       int
+	period      time.Duration
+}
+
+func newSink(s *notify.Sink, location string) (*sink, error) {
+	u, err := url.Parse(location)
+	if err != nil {
+		return nil, err
+	}
+	query := u.Query()
+	result := &sink{Sink: s, minSeverity: query.Get("min-severity")}
+	if result.minSeverity != "" && severityRank(result.minSeverity) < 0 {
+		return nil, fmt.Errorf("unknown min-severity '%s', supported: %s", result.minSeverity, strings.Join(severities, ", "))
+	}
+	if _, ok := query["advisories"]; ok {
+		result.advisories = query.Get("advisories") != "false"
+	}
+	if rate := query.Get("rate"); rate != "" {
+		result.limit, result.period, err = parseRate(rate)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return result, nil
+}
+
+// Units of rate periods, unlike ages m are minutes
+var rateUnits = map[string]time.Duration{
+	"s": time.Second,
+	"m": time.Minute,
+	"h": time.Hour,
+	"d": 24 * time.Hour,
+	"w": 7 * 24 * time.Hour,
+}
+
+// parseRate parses rates like 10/h, 50/d or 3/30m
+func parseRate(value string) (int, time.Duration, error) {
+	invalid := fmt.Errorf("invalid rate '%s', expected messages/period like 10/h", value)
+	tokens := strings.SplitN(value, "/", 2)
+	if len(tokens) != 2 {
+		return 0, 0, invalid
+	}
+	limit, err := strconv.Atoi(tokens[0])
+	if err != nil || limit < 1 {
+		return 0, 0, invalid
+	}
+
+	period := tokens[1]
+	if len(period) == 0 {
+		return 0, 0, invalid
+	}
+	unit, ok := rateUnits[period[len(period)-1:]]
+	if !ok {
+		return 0, 0, invalid
+	}
+	count := 1
+	if len(period) > 1 {
+		count, err = strconv.Atoi(period[:len(period)-1])
+		if err != nil || count < 1 {
+			return 0, 0, invalid
+		}
+	}
+	return limit, time.Duration(count) * unit, nil
+}
+
+// accepts returns whether the event passes the severity threshold and the advisory filter of the sink,
+// warnings always pass
+func (s *sink) accepts(event releaseEvent) bool {
+	if event.Warning != nil {
+		return true
+	}
+	if s.minSeverity != "" && severityRank(event.Severity) < severityRank(s.minSeverity) {
+		return false
+	}
+	if s.advisories {
+		for _, ref := range event.References {
+			if ref.Kind == "cve" || ref.Kind == "advisory" {
+				return true
+			}
+		}
+		return false
+	}
+	return true
+}
+
+func notifiedKey(remote string, s *sink) string {
+	return state.Key("notified", remote, s.String())
+}
+
+// allow enforces the rate limit of the sink. The times of the messages sent are kept in the state
+// store, so the limit holds across runs of the report and watch commands.
+func (s *sink) allow(remote string, now time.Time) bool {
+	if s.limit == 0 {
+		return true
+	}
+
+	sent := make([]time.Time, 0)
+	openState().Get(notifiedKey(remote, s), &sent)
+	recent := make([]time.Time, 0, len(sent)+1)
+	for _, t := range sent {
+		if now.Sub(t) < s.period {
+			recent = append(recent, t)
+		}
+	}
+	if len(recent) >= s.limit {
+		return false
+	}
+
+	openState().Set(notifiedKey(remote, s), append(recent, now.UTC()))
+	return true
+}
@@ -0,0 +1,265 @@
+package tracing
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"bytes"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// OTLP status code and span kinds
+const (
+	statusError = 2
+
+	kindInternal = 1
+	kindClient   = 3
+)
+
+type spanKey struct{}
+
+// Tracer records spans and exports them to an OTLP/HTTP collector using the JSON encoding.
+// A nil Tracer is valid and records nothing, so callers don't need to check whether tracing is enabled.
+type Tracer struct {
+	endpoint string
+	headers  map[string]string
+	service  string
+	version  string
+
+	mutex sync.Mutex
+	spans []*Span
+}
+
+type Span struct {
+	tracer     *Tracer
+	traceId    string
+	spanId     string
+	parentId   string
+	name       string
+	kind       int
+	start      time.Time
+	end        time.Time
+	attributes map[string]string
+	status     int
+	message    string
+}
+
+// NewTracer creates a tracer exporting to the collector endpoint, e.g. http://localhost:4318
+func NewTracer(endpoint string, headers map[string]string, service, version string) *Tracer {
+	return &Tracer{
+		endpoint: strings.TrimSuffix(endpoint, "/"),
+		headers:  headers,
+		service:  service,
+		version:  version,
+	}
+}
+
+// ParseHeaders parses headers in the OTEL_EXPORTER_OTLP_HEADERS format: key1=value1,key2=value2
+func ParseHeaders(value string) map[string]string {
+	headers := make(map[string]string)
+	for _, pair := range strings.Split(value, ",") {
+		tokens := strings.SplitN(pair, "=", 2)
+		if len(tokens) == 2 && strings.TrimSpace(tokens[0]) != "" {
+			headers[strings.TrimSpace(tokens[0])] = strings.TrimSpace(tokens[1])
+		}
+	}
+	return headers
+}
+
+// Start starts a span as child of the span in ctx, or as a new trace
+func (t *Tracer) Start(ctx context.Context, name string) (context.Context, *Span) {
+	return t.start(ctx, name, kindInternal)
+}
+
+func (t *Tracer) start(ctx context.Context, name string, kind int) (context.Context, *Span) {
+	if t == nil {
+		return ctx, nil
+	}
+
+	span := &Span{
+		tracer:     t,
+		spanId:     randomId(8),
+		name:       name,
+		kind:       kind,
+		start:      time.Now(),
+		attributes: make(map[string]string),
+	}
+	if parent, ok := ctx.Value(spanKey{}).(*Span); ok && parent != nil {
+		span.traceId = parent.traceId
+		span.parentId = parent.spanId
+	} else {
+		span.traceId = randomId(16)
+	}
+	return context.WithValue(ctx, spanKey{}, span), span
+}
+
+func (s *Span) SetAttribute(key string, value interface{}) {
+	if s == nil {
+		return
+	}
+	s.tracer.mutex.Lock()
+	defer s.tracer.mutex.Unlock()
+	s.attributes[key] = fmt.Sprint(value)
+}
+
+func (s *Span) SetError(err error) {
+	if s == nil || err == nil {
+		return
+	}
+	s.status = statusError
+	s.message = err.Error()
+}
+
+// End finishes the span and queues it for export
+func (s *Span) End() {
+	if s == nil {
+		return
+	}
+	s.tracer.mutex.Lock()
+	defer s.tracer.mutex.Unlock()
+	s.end = time.Now()
+	s.tracer.spans = append(s.tracer.spans, s)
+}
+
+// Transport creates a client span for every request, as child of the span in the request's context
+func (t *Tracer) Transport(transport http.RoundTripper) http.RoundTripper {
+	if t == nil {
+		return transport
+	}
+	return &tracingTransport{t, transport}
+}
+
+type tracingTransport struct {
+	tracer    *Tracer
+	transport http.RoundTripper
+}
+
+func (t *tracingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	_, span := t.tracer.start(req.Context(), fmt.Sprintf("%s %s", req.Method, req.URL.Path), kindClient)
+	defer span.End()
+
+	span.SetAttribute("http.method", req.Method)
+	span.SetAttribute("http.url", req.URL.String())
+
+	response, err := t.transport.RoundTrip(req)
+	if err != nil {
+		span.SetError(err)
+		return response, err
+	}
+
+	span.SetAttribute("http.status_code", response.StatusCode)
+	if remaining := response.Header.Get("X-RateLimit-Remaining"); remaining != "" {
+		span.SetAttribute("github.rate_limit.remaining", remaining)
+	}
+	if response.StatusCode >= 500 {
+		span.status = statusError
+	}
+	return response, err
+}
+
+// Flush exports all finished spans to the collector
+func (t *Tracer) Flush() error {
+	if t == nil {
+		return nil
+	}
+
+	t.mutex.Lock()
+	spans := t.spans
+	t.spans = nil
+	t.mutex.Unlock()
+
+	if len(spans) == 0 {
+		return nil
+	}
+
+	data, err := json.Marshal(t.encode(spans))
+	if err != nil {
+		return err
+	}
+
+	request, err := http.NewRequest("POST", t.endpoint+"/v1/traces", bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	request.Header.Set("Content-Type", "application/json")
+	for key, value := range t.headers {
+		request.Header.Set(key, value)
+	}
+
+	client := &http.Client{Timeout: 30 * time.Second}
+	response, err := client.Do(request)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode/100 != 2 {
+		return fmt.Errorf("unexpected response: %s", response.Status)
+	}
+	return nil
+}
+
+type attribute struct {
+	Key   string            `json:"key"`
+	Value map[string]string `json:"value"`
+}
+
+func attributes(values map[string]string) []attribute {
+	result := make([]attribute, 0, len(values))
+	for key, value := range values {
+		result = append(result, attribute{key, map[string]string{"stringValue": value}})
+	}
+	return result
+}
+
+// encode builds an ExportTraceServiceRequest in the OTLP JSON encoding
+func (t *Tracer) encode(spans []*Span) map[string]interface{} {
+	encoded := make([]map[string]interface{}, 0, len(spans))
+	for _, span := range spans {
+		s := map[string]interface{}{
+			"traceId":           span.traceId,
+			"spanId":            span.spanId,
+			"name":              span.name,
+			"kind":              span.kind,
+			"startTimeUnixNano": strconv.FormatInt(span.start.UnixNano(), 10),
+			"endTimeUnixNano":   strconv.FormatInt(span.end.UnixNano(), 10),
+			"attributes":        attributes(span.attributes),
+			"status":            map[string]interface{}{"code": span.status, "message": span.message},
+		}
+		if span.parentId != "" {
+			s["parentSpanId"] = span.parentId
+		}
+		encoded = append(encoded, s)
+	}
+
+	return map[string]interface{}{
+		"resourceSpans": []interface{}{
+			map[string]interface{}{
+				"resource": map[string]interface{}{
+					"attributes": attributes(map[string]string{
+						"service.name":    t.service,
+						"service.version": t.version,
+					}),
+				},
+				"scopeSpans": []interface{}{
+					map[string]interface{}{
+						"scope": map[string]string{"name": t.service},
+						"spans": encoded,
+					},
+				},
+			},
+		},
+	}
+}
+
+func randomId(size int) string {
+	id := make([]byte, size)
+	rand.Read(id)
+	return hex.EncodeToString(id)
+}